@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"api-monitor/internal/config"
+	"api-monitor/internal/output"
+	"api-monitor/internal/preflight"
+)
+
+func runCheckConfig(args []string) int {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout for each dependency probe")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(args)
+
+	cfg := config.Load()
+	report := preflight.Run(context.Background(), cfg, *timeout)
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		output.Println("🔎 API Monitor readiness report")
+		output.Println()
+		for _, check := range report.Checks {
+			icon := "✅"
+			switch check.Status {
+			case preflight.StatusWarn:
+				icon = "⚠️ "
+			case preflight.StatusFail:
+				icon = "❌"
+			}
+			output.Printf("%s %-18s %s\n", icon, check.Name, check.Detail)
+		}
+		output.Println()
+	}
+
+	if !report.Ready() {
+		if !*asJSON {
+			output.Println("Not ready: fix the failures above before starting the monitor.")
+		}
+		return 1
+	}
+	if !*asJSON {
+		output.Println("Ready to start.")
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	"api-monitor/internal/importer"
+	"api-monitor/internal/output"
+	"api-monitor/internal/storage"
+)
+
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "Export format: "+strings.Join(importer.Formats(), ", "))
+	file := flags.String("f", "", "CSV export to import")
+	url := flags.String("url", "", "Endpoint URL the history belongs to (when the export has no URL column)")
+	timezone := flags.String("tz", "UTC", "IANA timezone of timestamps without an offset")
+	dryRun := flags.Bool("dry-run", false, "Parse and summarize without writing to the database")
+	flags.Parse(args)
+
+	if *format == "" || *file == "" {
+		output.Fprintln(os.Stderr, "Usage: apimon import -format <format> -f <export.csv> [-url <endpoint>]")
+		return 2
+	}
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ invalid timezone: %v\n", err)
+		return 2
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	imported, err := importer.Read(f, importer.Options{Format: *format, URL: *url, Location: location})
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	// Group by endpoint so each one's previous import is replaced as a unit
+	results := make(map[string][]checker.CheckResult)
+	incidents := make(map[string][]storage.Incident)
+	var urls []string
+	for _, result := range imported.Results {
+		if _, seen := results[result.URL]; !seen {
+			urls = append(urls, result.URL)
+		}
+		results[result.URL] = append(results[result.URL], result)
+	}
+	for _, incident := range imported.Incidents {
+		incidents[incident.URL] = append(incidents[incident.URL], incident)
+	}
+
+	output.Printf("📥 Read %d results and %d incidents from %s", len(imported.Results), len(imported.Incidents), *file)
+	if imported.Skipped > 0 {
+		output.Printf(" (skipped %d unreadable rows)", imported.Skipped)
+	}
+	output.Println()
+	for _, u := range urls {
+		first, last := results[u][0].CheckedAt, results[u][len(results[u])-1].CheckedAt
+		output.Printf("   %s: %d results, %d incidents, %s → %s\n", u, len(results[u]), len(incidents[u]),
+			first.Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+	if *dryRun {
+		output.Println("Dry run: nothing written.")
+		return 0
+	}
+
+	cfg := config.Load()
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	signer, err := cfg.ResultSigner()
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ Invalid RESULT_SIGNING_KEY: %v\n", err)
+		return 1
+	}
+	if signer != nil {
+		store.SetSigner(signer)
+	}
+
+	for _, u := range urls {
+		if err := store.ReplaceImported(u, *format, results[u], incidents[u]); err != nil {
+			output.Fprintf(os.Stderr, "❌ Failed to import %s: %v\n", u, err)
+			return 1
+		}
+	}
+	output.Println("✅ Import complete. Importing the same export again replaces it.")
+	return 0
+}
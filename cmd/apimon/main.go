@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"api-monitor/internal/output"
+)
+
+// command is an apimon subcommand
+type command struct {
+	name        string
+	description string
+	run         func(args []string) int
+}
+
+var commands = []command{
+	{"check-config", "Validate configuration and probe dependencies before starting", runCheckConfig},
+	{"validate", "Check a declarative endpoints file for errors, duplicates and unreachable hosts", runValidate},
+	{"import", "Backfill history from UptimeRobot, Pingdom or StatusCake CSV exports", runImport},
+	{"seed", "Generate synthetic endpoints and history for demos and load tests", runSeed},
+	{"verify", "Check the signatures of stored results to show they weren't modified", runVerify},
+	{"signing-key", "Generate an ed25519 key pair for result signing", runSigningKey},
+}
+
+func main() {
+	log.SetOutput(output.Writer(os.Stderr))
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+
+	output.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	output.Fprintln(os.Stderr, "Usage: apimon <command> [flags]")
+	output.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		output.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	"api-monitor/internal/output"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// Seeded history is tagged so it can be told apart from real checks
+const (
+	seedTrigger = "seed"
+	seedTag     = "seed"
+)
+
+var (
+	seedServices = []string{"payments", "orders", "search", "auth", "catalog", "inventory", "shipping", "users",
+		"notifications", "billing", "checkout", "recommendations", "reviews", "media", "analytics", "gateway"}
+	seedPaths = []string{"/health", "/v1/status", "/api/ping", "/readyz"}
+	seedTeams = []string{"core", "commerce", "platform", "growth"}
+)
+
+// seedProfile is the behaviour of one synthetic endpoint
+type seedProfile struct {
+	endpoint scheduler.Endpoint
+	base     time.Duration // typical latency at quiet times
+	jitter   float64       // spread of the latency noise
+	diurnal  float64       // how much busy hours slow it down
+}
+
+// seedOutage is a period in which an endpoint fails or is degraded
+type seedOutage struct {
+	start, end time.Time
+	degraded   bool   // slow but up
+	mode       string // "status", "timeout" or "refused"
+}
+
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := flags.Int("endpoints", 50, "Synthetic endpoints to generate")
+	days := flags.Int("days", 7, "Days of history to generate per endpoint")
+	interval := flags.Duration("interval", 5*time.Minute, "Time between generated checks")
+	outages := flags.Float64("outages", 0.1, "Average outages per endpoint per day")
+	randSeed := flags.Int64("seed", 1, "Random seed; the same seed generates the same data")
+	register := flags.Bool("register", true, "Save the endpoints (disabled, tagged \"seed\") so dashboards list them")
+	flags.Parse(args)
+
+	if *count < 1 || *days < 1 || *interval < time.Second || *outages < 0 {
+		output.Fprintln(os.Stderr, "Usage: apimon seed [-endpoints 50] [-days 7] [-interval 5m] [-outages 0.1] [-seed 1]")
+		return 2
+	}
+
+	cfg := config.Load()
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	rng := rand.New(rand.NewSource(*randSeed))
+	to := time.Now().UTC().Truncate(*interval)
+	from := to.Add(-time.Duration(*days) * 24 * time.Hour)
+	perEndpoint := int(to.Sub(from) / *interval)
+	output.Printf("🌱 Seeding %d endpoints with %d days of history (%d checks each)\n", *count, *days, perEndpoint)
+
+	// Sketches are saved one window at a time, so wide windows keep big seeds fast
+	sketchWindow := cfg.SketchWindow
+	if sketchWindow < time.Hour {
+		sketchWindow = time.Hour
+	}
+
+	started := time.Now()
+	var totalResults, totalIncidents int
+	for i := 0; i < *count; i++ {
+		profile := newSeedProfile(rng, i, *interval)
+		periods := seedOutages(rng, from, to, *outages)
+		results, incidents := generateHistory(rng, profile, periods, from, to, *interval)
+
+		// Replace whatever an earlier run generated for this URL, sketches included
+		url := profile.endpoint.URL
+		if _, err := store.DeleteResults(url, time.Time{}); err != nil {
+			output.Fprintf(os.Stderr, "❌ Failed to clear %s: %v\n", url, err)
+			return 1
+		}
+		if err := store.ReplaceImported(url, seedTrigger, results, incidents); err != nil {
+			output.Fprintf(os.Stderr, "❌ Failed to seed %s: %v\n", url, err)
+			return 1
+		}
+		sketches := storage.NewSketchRecorder(sketchWindow)
+		for _, result := range results {
+			sketches.Add(result)
+		}
+		if err := sketches.Flush(store, to.Add(sketchWindow)); err != nil {
+			output.Fprintf(os.Stderr, "⚠️  Failed to save latency sketches for %s: %v\n", url, err)
+		}
+		if *register {
+			data, err := json.Marshal(profile.endpoint)
+			if err == nil {
+				err = store.SaveEndpoint(storage.EndpointRecord{ID: profile.endpoint.ID, URL: url, Config: data})
+			}
+			if err != nil {
+				output.Fprintf(os.Stderr, "❌ Failed to register %s: %v\n", url, err)
+				return 1
+			}
+		}
+
+		totalResults += len(results)
+		totalIncidents += len(incidents)
+		if (i+1)%10 == 0 || i+1 == *count {
+			output.Printf("   %d/%d endpoints, %d results\n", i+1, *count, totalResults)
+		}
+	}
+
+	output.Printf("✅ Seeded %d results and %d incidents in %v\n", totalResults, totalIncidents, time.Since(started).Round(time.Second))
+	if *register {
+		output.Println("   Endpoints are registered disabled with the \"seed\" tag; running the seed again replaces their history.")
+	}
+	return 0
+}
+
+// newSeedProfile creates the i-th synthetic endpoint
+func newSeedProfile(rng *rand.Rand, i int, interval time.Duration) seedProfile {
+	service := seedServices[i%len(seedServices)]
+	env := "prod"
+	if rng.Float64() < 0.25 {
+		env = "staging"
+	}
+	url := fmt.Sprintf("https://%s-%02d.%s.example.com%s", service, i/len(seedServices)+1, env, seedPaths[rng.Intn(len(seedPaths))])
+
+	return seedProfile{
+		endpoint: scheduler.Endpoint{
+			ID:       scheduler.EndpointID(url),
+			URL:      url,
+			Interval: interval,
+			Tags:     []string{seedTag, env, seedTeams[rng.Intn(len(seedTeams))]},
+			Disabled: true,
+		},
+		// Latencies between roughly 30ms and 600ms, skewed towards fast
+		base:    time.Duration(30+math.Pow(rng.Float64(), 2)*570) * time.Millisecond,
+		jitter:  0.1 + rng.Float64()*0.3,
+		diurnal: 0.1 + rng.Float64()*0.6,
+	}
+}
+
+// seedOutages places outages and slow periods at random between from and to
+func seedOutages(rng *rand.Rand, from, to time.Time, perDay float64) []seedOutage {
+	days := to.Sub(from).Hours() / 24
+	n := poisson(rng, perDay*days)
+	modes := []string{"status", "status", "timeout", "refused"}
+
+	periods := make([]seedOutage, 0, n)
+	for i := 0; i < n; i++ {
+		start := from.Add(time.Duration(rng.Int63n(int64(to.Sub(from)))))
+		outage := seedOutage{mode: modes[rng.Intn(len(modes))]}
+		if rng.Float64() < 0.4 {
+			outage.degraded = true
+			outage.end = start.Add(time.Duration(30+rng.Intn(180)) * time.Minute)
+		} else {
+			outage.end = start.Add(time.Duration(2+rng.Intn(60)) * time.Minute)
+		}
+		outage.start = start
+		periods = append(periods, outage)
+	}
+	return periods
+}
+
+// generateHistory produces the check results of one endpoint and the
+// incidents of its outages
+func generateHistory(rng *rand.Rand, profile seedProfile, periods []seedOutage, from, to time.Time, interval time.Duration) ([]checker.CheckResult, []storage.Incident) {
+	url := profile.endpoint.URL
+	results := make([]checker.CheckResult, 0, int(to.Sub(from)/interval))
+	var incidents []storage.Incident
+	var open *storage.Incident
+
+	for at := from; at.Before(to); at = at.Add(interval) {
+		var outage *seedOutage
+		for i := range periods {
+			if !at.Before(periods[i].start) && at.Before(periods[i].end) {
+				outage = &periods[i]
+				break
+			}
+		}
+
+		result := checker.CheckResult{URL: url, CheckedAt: at, Trigger: seedTrigger, StatusCode: 200, IsHealthy: true}
+		latency := seedLatency(rng, profile, at)
+		switch {
+		case outage != nil && outage.degraded:
+			latency *= time.Duration(3 + rng.Intn(4))
+		case outage != nil:
+			seedFailure(&result, outage.mode, latency)
+		case rng.Float64() < 0.001:
+			// A lone blip that isn't worth an incident
+			result.StatusCode = 500
+			result.IsHealthy = false
+		}
+		if result.ResponseTime == 0 {
+			result.ResponseTime = latency
+			result.TTFB = time.Duration(float64(latency) * (0.6 + rng.Float64()*0.3))
+			result.SetMetadata(checker.MetaProtocol, "HTTP/2.0")
+		}
+		checker.AssessQuality(&result)
+		results = append(results, result)
+
+		down := outage != nil && !outage.degraded
+		if down && open == nil {
+			open = &storage.Incident{URL: url, StartedAt: at, Cause: seedCause(outage.mode), Source: seedTrigger}
+		} else if !down && open != nil {
+			ended := at
+			open.EndedAt = &ended
+			incidents = append(incidents, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		// Still down at the end of the generated range
+		incidents = append(incidents, *open)
+	}
+	return results, incidents
+}
+
+// seedLatency returns a latency following the endpoint's daily and weekly
+// load pattern with random noise and rare spikes
+func seedLatency(rng *rand.Rand, profile seedProfile, at time.Time) time.Duration {
+	hour := float64(at.Hour()) + float64(at.Minute())/60
+	load := 1 + profile.diurnal*math.Max(0, math.Sin((hour-8)/24*2*math.Pi))
+	if weekday := at.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		load = 1 + (load-1)*0.4
+	}
+	latency := float64(profile.base) * load * math.Exp(rng.NormFloat64()*profile.jitter)
+	if rng.Float64() < 0.005 {
+		latency *= 3 + rng.Float64()*5
+	}
+	return time.Duration(latency)
+}
+
+// seedFailure turns a result into a failed check of the given kind
+func seedFailure(result *checker.CheckResult, mode string, latency time.Duration) {
+	result.IsHealthy = false
+	switch mode {
+	case "timeout":
+		result.StatusCode = 0
+		result.ResponseTime = 5 * time.Second
+		result.Error = "context deadline exceeded (Client.Timeout exceeded while awaiting headers)"
+		result.SetMetadata(checker.MetaErrorClass, checker.ErrorClassTimeout)
+	case "refused":
+		result.StatusCode = 0
+		result.ResponseTime = time.Millisecond + latency/100
+		result.Error = "dial tcp: connect: connection refused"
+		result.SetMetadata(checker.MetaErrorClass, checker.ErrorClassRefused)
+	default:
+		result.StatusCode = 503
+	}
+}
+
+// seedCause describes an outage in its incident
+func seedCause(mode string) string {
+	switch mode {
+	case "timeout":
+		return "requests timing out"
+	case "refused":
+		return "connection refused"
+	default:
+		return "HTTP 503 Service Unavailable"
+	}
+}
+
+// poisson draws from a Poisson distribution with the given mean
+func poisson(rng *rand.Rand, mean float64) int {
+	limit := math.Exp(-mean)
+	n, p := 0, rng.Float64()
+	for p > limit {
+		n++
+		p *= rng.Float64()
+	}
+	return n
+}
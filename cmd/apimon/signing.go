@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"api-monitor/internal/config"
+	"api-monitor/internal/output"
+	"api-monitor/internal/signing"
+	"api-monitor/internal/storage"
+)
+
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	url := flags.String("url", "", "Endpoint URL to verify (all when empty)")
+	since := flags.Duration("since", 30*24*time.Hour, "Verify results checked within this long")
+	from := flags.String("from", "", "Start of the range (RFC 3339), overrides -since")
+	to := flags.String("to", "", "End of the range (RFC 3339), now when empty")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(args)
+
+	end := time.Now()
+	start := end.Add(-*since)
+	var err error
+	if *to != "" {
+		if end, err = time.Parse(time.RFC3339, *to); err != nil {
+			output.Fprintf(os.Stderr, "❌ invalid -to: %v\n", err)
+			return 2
+		}
+		start = end.Add(-*since)
+	}
+	if *from != "" {
+		if start, err = time.Parse(time.RFC3339, *from); err != nil {
+			output.Fprintf(os.Stderr, "❌ invalid -from: %v\n", err)
+			return 2
+		}
+	}
+
+	cfg := config.Load()
+	key, err := cfg.ResultVerifier()
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ No verification key: %v\n", err)
+		return 2
+	}
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	report, err := store.VerifyResults(key, *url, start, end)
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ Verification failed: %v\n", err)
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		output.Printf("🔏 Verified %d results from %s to %s with %s key %s\n", report.Checked,
+			start.Format(time.RFC3339), end.Format(time.RFC3339), key.Algorithm(), key.ID())
+		output.Printf("   %d valid, %d unsigned, %d signed with another key, %d invalid\n",
+			report.Valid, report.Unsigned, report.OtherKey, report.Invalid)
+		for _, failure := range report.Failures {
+			output.Printf("❌ #%d %s at %s: %s\n", failure.ID, failure.URL, failure.CheckedAt.Format(time.RFC3339), failure.Reason)
+		}
+		if shown := len(report.Failures); shown < report.Invalid+report.OtherKey {
+			output.Printf("   ... and %d more\n", report.Invalid+report.OtherKey-shown)
+		}
+	}
+
+	if report.Invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runSigningKey(args []string) int {
+	flags := flag.NewFlagSet("signing-key", flag.ExitOnError)
+	flags.Parse(args)
+
+	private, public, err := signing.GenerateEd25519()
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	output.Println("# Keep the signing key secret; share the verify key with auditors")
+	output.Printf("RESULT_SIGNING_KEY=%s\n", private)
+	output.Printf("RESULT_VERIFY_KEY=%s\n", public)
+	return 0
+}
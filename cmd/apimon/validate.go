@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"api-monitor/internal/manifest"
+	"api-monitor/internal/output"
+)
+
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	file := flags.String("f", "endpoints.yaml", "Configuration file to validate (- for stdin)")
+	offline := flags.Bool("offline", false, "Skip the host reachability checks")
+	timeout := flags.Duration("timeout", 5*time.Second, "Timeout for each reachability check")
+	asJSON := flags.Bool("json", false, "Print the report as JSON")
+	flags.Parse(args)
+
+	var data []byte
+	var err error
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+	if err != nil {
+		output.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	var report manifest.Report
+	parsed, err := manifest.Parse(data)
+	if err != nil {
+		report = manifest.Report{Problems: []manifest.Problem{{
+			Index:    -1,
+			Severity: manifest.SeverityError,
+			Message:  err.Error(),
+		}}}
+	} else {
+		report = manifest.Validate(context.Background(), parsed, manifest.Options{
+			CheckHosts: !*offline,
+			Timeout:    *timeout,
+		})
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		output.Printf("🔎 Validating %s (%d endpoints)\n\n", *file, report.Endpoints)
+		for _, problem := range report.Problems {
+			icon := "❌"
+			if problem.Severity == manifest.SeverityWarning {
+				icon = "⚠️ "
+			}
+			output.Printf("%s %s\n", icon, problem)
+		}
+		if len(report.Problems) > 0 {
+			output.Println()
+		}
+		if report.Valid {
+			output.Println("✅ Configuration is valid.")
+		} else {
+			output.Println("Configuration has errors.")
+		}
+	}
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
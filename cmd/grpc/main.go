@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	monitorgrpc "api-monitor/internal/grpc"
+	"api-monitor/internal/health"
+	"api-monitor/internal/output"
+	"api-monitor/internal/storage"
+	"api-monitor/internal/tlsutil"
+)
+
+// Serves the MonitorManager gRPC service on GRPC_PORT. It monitors the
+// endpoints added through gRPC, plus any already stored in the database.
+func main() {
+	log.SetOutput(output.Writer(os.Stderr))
+	cfg := config.Load()
+	output.SetStyle(cfg.OutputStyle) // also from CONFIG_FILE or the profile
+	for _, loadErr := range cfg.LoadErrors {
+		log.Printf("Configuration problem: %s", loadErr)
+	}
+
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Database unavailable, endpoints and results will not be persisted: %v", err)
+		store = nil
+	} else {
+		defer store.Close()
+		signer, err := cfg.ResultSigner()
+		if err != nil {
+			log.Fatalf("Invalid RESULT_SIGNING_KEY: %v", err)
+		}
+		if signer != nil {
+			store.SetSigner(signer)
+		}
+	}
+
+	tlsSetup, err := tlsutil.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+
+	// SIGINT or SIGTERM shuts down gracefully; a second one exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	server := monitorgrpc.NewMonitorServer(store, checker.StaticLabels(cfg.Labels), cfg.SecretResolver.Middleware())
+	prober := health.NewProber(cfg.HealthLivenessGrace, server.HealthChecks(cfg.HealthRequireDatabase)...)
+	server.SetHealth(prober)
+	server.SetGateway(cfg.GatewayPort)
+	server.SetToken(cfg.AdminToken)
+	if cfg.AdminToken == "" {
+		log.Printf("⚠️ ADMIN_TOKEN is unset: anyone who can reach GRPC_PORT or GATEWAY_PORT can add and remove endpoints")
+	}
+	if cfg.HealthPort > 0 {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", prober.LivenessHandler)
+		mux.HandleFunc("/readyz", prober.ReadinessHandler)
+		go func() {
+			log.Printf("Serving /healthz and /readyz on port %d", cfg.HealthPort)
+			if err := http.ListenAndServe(fmt.Sprintf(":%d", cfg.HealthPort), mux); err != nil {
+				log.Printf("Health probe server failed: %v", err)
+			}
+		}()
+	}
+	if tlsSetup != nil {
+		err = server.StartGRPCServer(ctx, cfg.GRPCPort, tlsSetup.Config, cfg.ShutdownTimeout)
+	} else {
+		err = server.StartGRPCServer(ctx, cfg.GRPCPort, nil, cfg.ShutdownTimeout)
+	}
+	if err != nil {
+		log.Fatalf("gRPC server failed: %v", err)
+	}
+}
@@ -2,12 +2,17 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"api-monitor/internal/output"
 )
 
 // ChatCompletionRequest represents the OpenAI API request format
@@ -47,6 +52,9 @@ type Insight struct {
 	Confidence float64 `json:"confidence"`
 }
 
+// recorder serves recorded provider responses in proxy mode, nil otherwise
+var recorder *Recorder
+
 // MockAI generates realistic monitoring insights
 type MockAI struct{}
 
@@ -139,6 +147,12 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 		"capabilities": []string{"monitoring_insights", "pattern_analysis", "recommendations"},
 		"timestamp":    time.Now().Unix(),
 	}
+	if recorder != nil {
+		response["type"] = "record_replay_proxy"
+		response["upstream"] = recorder.upstream
+		response["offline"] = recorder.offline
+		response["recordings"] = recorder.Count()
+	}
 	
 	json.NewEncoder(w).Encode(response)
 }
@@ -177,6 +191,28 @@ func chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// In proxy mode answer with the real provider's recorded response
+	if recorder != nil {
+		data, replayed, err := recorder.Complete(req, r.Header.Get("Authorization"))
+		switch {
+		case err == nil:
+			if replayed {
+				w.Header().Set("X-Mock-AI", "replayed")
+			} else {
+				w.Header().Set("X-Mock-AI", "recorded")
+			}
+			w.Write(data)
+			return
+		case errors.Is(err, errNotRecorded):
+			log.Printf("No recording for prompt %s, falling back to mock insights", promptKey(req)[:12])
+			w.Header().Set("X-Mock-AI", "generated")
+		default:
+			log.Printf("Proxying to %s failed: %v", recorder.upstream, err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	
 	// Generate AI insights
 	ai := &MockAI{}
 	insights := ai.generateInsights(prompt)
@@ -232,22 +268,43 @@ func testHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	log.SetOutput(output.Writer(os.Stderr))
+	port := flag.Int("port", 8000, "Port to listen on")
+	upstream := flag.String("proxy", os.Getenv("MOCK_AI_UPSTREAM"), "Base URL of a real provider to record responses from, e.g. https://api.openai.com")
+	recordings := flag.String("recordings", "testdata/mock-ai", "Directory holding recorded responses")
+	offline := flag.Bool("offline", false, "Only replay recordings; never contact the provider")
+	flag.Parse()
+	
+	if *upstream != "" || *offline {
+		var err error
+		recorder, err = NewRecorder(*upstream, os.Getenv("MOCK_AI_UPSTREAM_KEY"), *recordings, *offline)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
+	
 	http.HandleFunc("/health", healthHandler)
 	http.HandleFunc("/v1/chat/completions", chatCompletionsHandler)
 	http.HandleFunc("/demo/test", testHandler)
 	
-	port := 8000
-	fmt.Printf("🚀 Mock GPT-OSS AI Server starting...\n")
-	fmt.Printf("🤖 Simulating OpenAI GPT-OSS-20B for monitoring insights\n")
-	fmt.Printf("🌐 Server running on http://localhost:%d\n", port)
-	fmt.Printf("\n📡 Available endpoints:\n")
-	fmt.Printf("   - GET  /health              - Health check\n")
-	fmt.Printf("   - POST /v1/chat/completions - OpenAI-compatible API\n")
-	fmt.Printf("   - GET  /demo/test           - Test sample insights\n")
-	fmt.Printf("\n🧪 Test the server:\n")
-	fmt.Printf("   curl http://localhost:%d/health\n", port)
-	fmt.Printf("   curl http://localhost:%d/demo/test\n", port)
-	fmt.Printf("\n✅ Ready for API Monitor integration!\n\n")
-	
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	output.Printf("🚀 Mock GPT-OSS AI Server starting...\n")
+	switch {
+	case recorder != nil && recorder.offline:
+		output.Printf("📼 Replaying %d recordings from %s (offline)\n", recorder.Count(), *recordings)
+	case recorder != nil:
+		output.Printf("📼 Recording responses from %s into %s (%d recorded)\n", recorder.upstream, *recordings, recorder.Count())
+	default:
+		output.Printf("🤖 Simulating OpenAI GPT-OSS-20B for monitoring insights\n")
+	}
+	output.Printf("🌐 Server running on http://localhost:%d\n", *port)
+	output.Printf("\n📡 Available endpoints:\n")
+	output.Printf("   - GET  /health              - Health check\n")
+	output.Printf("   - POST /v1/chat/completions - OpenAI-compatible API\n")
+	output.Printf("   - GET  /demo/test           - Test sample insights\n")
+	output.Printf("\n🧪 Test the server:\n")
+	output.Printf("   curl http://localhost:%d/health\n", *port)
+	output.Printf("   curl http://localhost:%d/demo/test\n", *port)
+	output.Printf("\n✅ Ready for API Monitor integration!\n\n")
+	
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
 }
\ No newline at end of file
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxUpstreamResponse caps how much of a provider response is recorded
+const maxUpstreamResponse = 4 << 20
+
+// Recording is one provider response stored on disk
+type Recording struct {
+	Key        string          `json:"key"`
+	Model      string          `json:"model"`
+	Messages   []Message       `json:"messages"`
+	Response   json.RawMessage `json:"response"`
+	RecordedAt time.Time       `json:"recordedAt"`
+}
+
+// Recorder forwards chat completions to a real provider once, stores the
+// responses keyed by prompt hash and replays them afterwards
+type Recorder struct {
+	upstream string
+	apiKey   string
+	dir      string
+	offline  bool
+	client   *http.Client
+
+	// one lock per key so concurrent identical prompts hit the provider once
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewRecorder stores recordings in dir, creating it if needed. With offline
+// set the upstream is never contacted and only recordings are served.
+func NewRecorder(upstream, apiKey, dir string, offline bool) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	return &Recorder{
+		upstream: strings.TrimRight(upstream, "/"),
+		apiKey:   apiKey,
+		dir:      dir,
+		offline:  offline,
+		client:   &http.Client{Timeout: 2 * time.Minute},
+		locks:    make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// errNotRecorded means a prompt has no recording and can't be forwarded
+var errNotRecorded = errors.New("prompt not recorded")
+
+// promptKey hashes the model and messages of a request. Sampling settings are
+// left out so tuning them doesn't invalidate recordings.
+func promptKey(req ChatCompletionRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Model))
+	for _, msg := range req.Messages {
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (rec *Recorder) path(key string) string {
+	return filepath.Join(rec.dir, key+".json")
+}
+
+func (rec *Recorder) lock(key string) *sync.Mutex {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	l, ok := rec.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		rec.locks[key] = l
+	}
+	return l
+}
+
+// Complete returns the recorded response to a request, forwarding it to the
+// upstream and recording the answer on a miss. replayed reports whether the
+// response came from disk.
+func (rec *Recorder) Complete(req ChatCompletionRequest, auth string) (response []byte, replayed bool, err error) {
+	key := promptKey(req)
+	l := rec.lock(key)
+	l.Lock()
+	defer l.Unlock()
+
+	if recording, err := rec.load(key); err == nil {
+		return recording.Response, true, nil
+	} else if !os.IsNotExist(err) {
+		return nil, false, err
+	}
+
+	if rec.offline || rec.upstream == "" {
+		return nil, false, errNotRecorded
+	}
+
+	response, err = rec.forward(req, auth)
+	if err != nil {
+		return nil, false, err
+	}
+	recording := Recording{Key: key, Model: req.Model, Messages: req.Messages, Response: response, RecordedAt: time.Now()}
+	if err := rec.save(recording); err != nil {
+		log.Printf("Failed to save recording %s: %v", key, err)
+	}
+	return response, false, nil
+}
+
+func (rec *Recorder) load(key string) (Recording, error) {
+	var recording Recording
+	data, err := os.ReadFile(rec.path(key))
+	if err != nil {
+		return recording, err
+	}
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return recording, fmt.Errorf("corrupt recording %s: %w", key, err)
+	}
+	return recording, nil
+}
+
+// save writes through a temporary file so a crash never leaves half a recording
+func (rec *Recorder) save(recording Recording) error {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := rec.path(recording.Key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, rec.path(recording.Key))
+}
+
+// forward sends the request to the upstream provider. The configured API key
+// wins over the one the caller sent.
+func (rec *Recorder) forward(req ChatCompletionRequest, auth string) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq, err := http.NewRequest("POST", rec.upstream+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("Content-Type", "application/json")
+	if rec.apiKey != "" {
+		upstreamReq.Header.Set("Authorization", "Bearer "+rec.apiKey)
+	} else if auth != "" {
+		upstreamReq.Header.Set("Authorization", auth)
+	}
+
+	resp, err := rec.client.Do(upstreamReq)
+	if err != nil {
+		return nil, fmt.Errorf("upstream request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	// Errors are passed on but never recorded, so the next run retries
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("upstream returned invalid JSON")
+	}
+	return data, nil
+}
+
+// Count returns how many recordings are on disk
+func (rec *Recorder) Count() int {
+	matches, _ := filepath.Glob(filepath.Join(rec.dir, "*.json"))
+	return len(matches)
+}
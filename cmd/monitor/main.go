@@ -1,38 +1,53 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	"api-monitor/internal/output"
 	"api-monitor/internal/storage"
 )
 
 func main() {
+	log.SetOutput(output.Writer(os.Stderr))
 	// Command line flags
 	useDB := flag.Bool("db", false, "Use database storage")
 	flag.Parse()
 
-	fmt.Println("🚀 Starting API Monitor...")
+	output.Println("🚀 Starting API Monitor...")
+
+	// SIGINT or SIGTERM stops after the round of checks in progress is saved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	
 	// Create checker with 5 second timeout
 	httpChecker := checker.NewHTTPChecker(5 * time.Second)
 	
 	// Setup database if requested
-	var store *storage.PostgresStore
+	// Database settings (DATABASE_DRIVER, DATABASE_URL or DB_*) are shared with the web server
+	var store storage.Store
 	if *useDB {
-		fmt.Println("📊 Connecting to database...")
-		connectionString := "host=localhost port=5432 user=monitor password=password dbname=api_monitor sslmode=disable"
+		output.Println("📊 Connecting to database...")
+		cfg := config.Load()
+		output.SetStyle(cfg.OutputStyle) // also from CONFIG_FILE or the profile
+		for _, loadErr := range cfg.LoadErrors {
+			log.Printf("Configuration problem: %s", loadErr)
+		}
 		
 		var err error
-		store, err = storage.NewPostgresStore(connectionString)
+		store, err = storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
 		if err != nil {
 			log.Fatalf("Failed to connect to database: %v", err)
 		}
 		defer store.Close()
-		fmt.Println("✅ Database connected!")
+		output.Println("✅ Database connected!")
 	}
 	
 	// URLs to monitor (we'll start with public APIs)
@@ -43,7 +58,7 @@ func main() {
 		"https://httpbin.org/delay/2", // Reduced to 2 seconds
 	}
 	
-	fmt.Printf("📡 Monitoring %d endpoints...\n\n", len(urls))
+	output.Printf("📡 Monitoring %d endpoints...\n\n", len(urls))
 	
 	// Run checks every 15 seconds
 	for {
@@ -54,11 +69,11 @@ func main() {
 			if err := store.SaveResults(results); err != nil {
 				log.Printf("Failed to save results: %v", err)
 			} else {
-				fmt.Println("💾 Results saved to database")
+				output.Println("💾 Results saved to database")
 			}
 		}
 		
-		fmt.Printf("=== Check Results at %s ===\n", time.Now().Format("15:04:05"))
+		output.Printf("=== Check Results at %s ===\n", time.Now().Format("15:04:05"))
 		
 		for _, result := range results {
 			status := "HEALTHY"
@@ -66,17 +81,22 @@ func main() {
 				status = "UNHEALTHY"
 			}
 			
-			fmt.Printf("%s %s\n", status, result.URL)
-			fmt.Printf("   Status: %d | Response Time: %v\n", 
+			output.Printf("%s %s\n", status, result.URL)
+			output.Printf("   Status: %d | Response Time: %v\n",
 				result.StatusCode, result.ResponseTime.Round(time.Millisecond))
 			
 			if result.Error != "" {
-				fmt.Printf("   Error: %s\n", result.Error)
+				output.Printf("   Error: %s\n", result.Error)
 			}
-			fmt.Println()
+			output.Println()
 		}
 		
-		fmt.Println("💤 Waiting 15 seconds...\n")
-		time.Sleep(15 * time.Second)
+		output.Print("💤 Waiting 15 seconds...\n\n")
+		select {
+		case <-ctx.Done():
+			output.Println("👋 Stopping API Monitor")
+			return
+		case <-time.After(15 * time.Second):
+		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/output"
+	"api-monitor/internal/stats"
+	"api-monitor/internal/storage"
+)
+
+// urlList collects -url flags, which may be repeated or comma-separated
+type urlList []string
+
+func (l *urlList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *urlList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// resolveURLs expands glob patterns against the URLs that have stored results
+// carrying labels
+func resolveURLs(store storage.Store, patterns []string, labels map[string]string) ([]string, error) {
+	var known []string
+	seen := make(map[string]bool)
+	var urls []string
+
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			if !seen[pattern] {
+				seen[pattern] = true
+				urls = append(urls, pattern)
+			}
+			continue
+		}
+
+		if known == nil {
+			var err error
+			if known, err = store.ListURLs(labels); err != nil {
+				return nil, err
+			}
+		}
+		for _, url := range known {
+			if matched, _ := path.Match(pattern, url); matched && !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+
+	return urls, nil
+}
+
+// formatLabels renders labels as sorted name=value pairs
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// formatMetadata renders metadata as sorted key=value pairs
+func formatMetadata(metadata map[string]interface{}) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// summarize computes latency statistics over usable results and counts
+// healthy and suspect results
+func summarize(results []checker.CheckResult, mode string, trim float64) (stats.Summary, int, int) {
+	var healthyCount, suspectCount int
+	latencies := make([]time.Duration, 0, len(results))
+
+	for _, result := range results {
+		if result.Usable() {
+			latencies = append(latencies, result.ResponseTime)
+		} else {
+			suspectCount++
+		}
+		if result.IsHealthy {
+			healthyCount++
+		}
+	}
+
+	return stats.Summarize(latencies, mode, trim), healthyCount, suspectCount
+}
+
+// compare queries several URLs concurrently and prints one row per URL.
+// filter supplies everything but the URL.
+func compare(store storage.Store, urls []string, filter storage.ResultQuery, mode string, trim float64) {
+	output.Printf("🔍 Comparing %d URLs (last %d results each)\n\n", len(urls), filter.Limit)
+
+	type row struct {
+		results []checker.CheckResult
+		err     error
+	}
+	rows := make([]row, len(urls))
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			query := filter
+			query.URL = url
+			results, err := store.QueryResults(query)
+			rows[i] = row{results: results, err: err}
+		}(i, url)
+	}
+	wg.Wait()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	output.Fprintf(w, "URL\tCHECKS\tUPTIME\t%s\tP95\tMAX\tLAST\n", strings.ToUpper(stats.Summarize(nil, mode, trim).Label()))
+	for i, url := range urls {
+		if rows[i].err != nil {
+			log.Printf("Failed to query results for %s: %v", url, rows[i].err)
+			output.Fprintf(w, "%s\t-\t-\t-\t-\t-\terror\n", url)
+			continue
+		}
+		results := rows[i].results
+		if len(results) == 0 {
+			output.Fprintf(w, "%s\t0\t-\t-\t-\t-\tno data\n", url)
+			continue
+		}
+
+		summary, healthyCount, _ := summarize(results, mode, trim)
+		uptime := float64(healthyCount) / float64(len(results)) * 100
+		last := "✅"
+		if !results[0].IsHealthy {
+			last = "❌"
+		}
+		output.Fprintf(w, "%s\t%d\t%.1f%%\t%dms\t%dms\t%dms\t%s\n", url, len(results), uptime,
+			summary.Central.Milliseconds(), summary.P95.Milliseconds(), summary.Max.Milliseconds(), last)
+	}
+	w.Flush()
+}
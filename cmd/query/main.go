@@ -2,43 +2,86 @@ package main
 
 import (
 	"flag"
-	"fmt"
 	"log"
+	"os"
 
+	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	"api-monitor/internal/output"
+	"api-monitor/internal/stats"
 	"api-monitor/internal/storage"
 )
 
 func main() {
-	url := flag.String("url", "", "URL to query results for")
+	log.SetOutput(output.Writer(os.Stderr))
+	var patterns urlList
+	flag.Var(&patterns, "url", "URL to query results for; repeat, comma-separate or use a glob such as 'https://api.example.com/*' to compare several")
 	limit := flag.Int("limit", 10, "Number of recent results to fetch")
+	statsMode := flag.String("stats", stats.ModeMean, "Latency summary: mean, trimmed_mean or median")
+	trim := flag.Float64("trim", 5, "Percent of samples dropped from each end for trimmed_mean")
+	var labelArgs urlList // same repeat/comma syntax as -url
+	flag.Var(&labelArgs, "label", "Only results carrying this label, e.g. team=payments; repeat or comma-separate to require several")
+	var metaArgs urlList
+	flag.Var(&metaArgs, "meta", "Only results with this metadata value, e.g. error_class=timeout or protocol=HTTP/2.0")
 	flag.Parse()
 
-	if *url == "" {
+	if len(patterns) == 0 {
 		log.Fatal("Please provide a URL with -url flag")
 	}
+	if err := stats.ValidateMode(*statsMode); err != nil {
+		log.Fatal(err)
+	}
+	labels, err := checker.ParseLabels(labelArgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	metadata, err := checker.ParseLabels(metaArgs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter := storage.ResultQuery{Labels: labels, Metadata: metadata, Limit: *limit}
 
-	fmt.Printf("🔍 Querying results for: %s\n\n", *url)
-
-	// Connect to database
-	connectionString := "host=localhost port=5432 user=monitor password=password dbname=api_monitor sslmode=disable"
-	store, err := storage.NewPostgresStore(connectionString)
+	// Connect to the database the web server writes to
+	cfg := config.Load()
+	output.SetStyle(cfg.OutputStyle) // also from CONFIG_FILE or the profile
+	for _, loadErr := range cfg.LoadErrors {
+		log.Printf("Configuration problem: %s", loadErr)
+	}
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer store.Close()
 
+	urls, err := resolveURLs(store, patterns, labels)
+	if err != nil {
+		log.Fatalf("Failed to list monitored URLs: %v", err)
+	}
+	if len(urls) == 0 {
+		output.Println("No monitored URLs match the given patterns")
+		return
+	}
+	if len(urls) > 1 {
+		compare(store, urls, filter, *statsMode, *trim)
+		return
+	}
+	url := urls[0]
+
+	output.Printf("🔍 Querying results for: %s\n\n", url)
+
 	// Get recent results
-	results, err := store.GetRecentResults(*url, *limit)
+	filter.URL = url
+	results, err := store.QueryResults(filter)
 	if err != nil {
 		log.Fatalf("Failed to query results: %v", err)
 	}
 
 	if len(results) == 0 {
-		fmt.Println("No results found for this URL")
+		output.Println("No results found for this URL")
 		return
 	}
 
-	fmt.Printf("📊 Found %d recent results:\n\n", len(results))
+	output.Printf("📊 Found %d recent results:\n\n", len(results))
 
 	for i, result := range results {
 		status := "✅ HEALTHY"
@@ -46,32 +89,38 @@ func main() {
 			status = "❌ UNHEALTHY"
 		}
 
-		fmt.Printf("%d. %s\n", i+1, status)
-		fmt.Printf("   Time: %s\n", result.CheckedAt.Format("2006-01-02 15:04:05"))
-		fmt.Printf("   Status: %d | Response Time: %v\n", 
-			result.StatusCode, result.ResponseTime)
+		output.Printf("%d. %s\n", i+1, status)
+		output.Printf("   Time: %s\n", result.CheckedAt.Format("2006-01-02 15:04:05"))
+		output.Printf("   Status: %d | Response Time: %v | TTFB: %v\n",
+			result.StatusCode, result.ResponseTime, result.TTFB)
+		output.Printf("   DNS: %v | Connect: %v | TLS: %v | Download: %v\n",
+			result.DNSLookup, result.TCPConnect, result.TLSHandshake, result.BodyDownload)
 
+		if len(result.Labels) > 0 {
+			output.Printf("   Labels: %s\n", formatLabels(result.Labels))
+		}
+		if len(result.Metadata) > 0 {
+			output.Printf("   Metadata: %s\n", formatMetadata(result.Metadata))
+		}
 		if result.Error != "" {
-			fmt.Printf("   Error: %s\n", result.Error)
+			output.Printf("   Error: %s\n", result.Error)
 		}
-		fmt.Println()
-	}
-
-	// Calculate some basic statistics
-	var totalResponseTime int64
-	var healthyCount int
-	
-	for _, result := range results {
-		totalResponseTime += result.ResponseTime.Milliseconds()
-		if result.IsHealthy {
-			healthyCount++
+		if !result.Usable() {
+			output.Printf("   ⚠️ Suspect timing: %s\n", result.QualityIssue)
 		}
+		output.Println()
 	}
 
-	avgResponseTime := totalResponseTime / int64(len(results))
+	// Calculate some basic statistics
+	summary, healthyCount, suspectCount := summarize(results, *statsMode, *trim)
 	uptime := (float64(healthyCount) / float64(len(results))) * 100
 
-	fmt.Printf("📈 Statistics:\n")
-	fmt.Printf("   Average Response Time: %dms\n", avgResponseTime)
-	fmt.Printf("   Uptime: %.1f%% (%d/%d checks)\n", uptime, healthyCount, len(results))
+	output.Printf("📈 Statistics:\n")
+	output.Printf("   Response Time (%s): %dms\n", summary.Label(), summary.Central.Milliseconds())
+	output.Printf("   Median: %dms | p95: %dms | Max: %dms\n",
+		summary.Median.Milliseconds(), summary.P95.Milliseconds(), summary.Max.Milliseconds())
+	if suspectCount > 0 {
+		output.Printf("   Excluded %d result(s) with suspect timings\n", suspectCount)
+	}
+	output.Printf("   Uptime: %.1f%% (%d/%d checks)\n", uptime, healthyCount, len(results))
 }
\ No newline at end of file
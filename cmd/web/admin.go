@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/auth"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// requireAdmin protects destructive endpoints with ADMIN_TOKEN on top of the
+// dashboard login. Without a token they are disabled.
+func (ws *WebServer) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return ws.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if ws.config.AdminToken == "" {
+			http.Error(w, "Admin API disabled, set ADMIN_TOKEN to enable it", http.StatusForbidden)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(ws.config.AdminToken)) != 1 {
+			log.Printf("Rejected admin request %s %s from %s", r.Method, r.URL.Path, clientIP(r))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// actor names who sent an admin request: the dashboard user, the API key,
+// or the admin token without either
+func (ws *WebServer) actor(r *http.Request) string {
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		if session, ok := ws.sessions.Get(cookie.Value); ok {
+			return session.Username
+		}
+	}
+	if key, ok := ws.requestAPIKey(r); ok {
+		return "api key " + key.Name
+	}
+	return "admin token"
+}
+
+// audit records an administrative action in the log and, with a database,
+// in the audit_log table
+func (ws *WebServer) audit(r *http.Request, action string, details interface{}) {
+	actor := ws.actor(r)
+	data, _ := json.Marshal(details)
+	log.Printf("📝 Audit: %s by %s from %s: %s", action, actor, clientIP(r), data)
+
+	if ws.store == nil {
+		return
+	}
+	err := ws.store.SaveAudit(storage.AuditEntry{
+		Action:     action,
+		Actor:      actor,
+		RemoteAddr: clientIP(r),
+		Details:    data,
+		At:         time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to save audit entry for %s: %v", action, err)
+	}
+}
+
+// handleResults deletes the stored history of a URL, e.g. of a decommissioned
+// service or for a GDPR erasure request:
+// DELETE /api/results?url=...&before=2024-01-01T00:00:00Z&reason=...
+// Endpoints that are still monitored need force=true.
+func (ws *WebServer) handleResults(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "DELETE" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	url := strings.TrimSpace(query.Get("url"))
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	var before time.Time
+	if value := query.Get("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "before must be an RFC 3339 time such as 2024-01-01T00:00:00Z", http.StatusBadRequest)
+			return
+		}
+		before = parsed
+	}
+	force, _ := strconv.ParseBool(query.Get("force"))
+	if _, monitored := ws.scheduler.Get(scheduler.EndpointID(url)); monitored && !force {
+		http.Error(w, "URL is still monitored, remove the endpoint first or pass force=true", http.StatusConflict)
+		return
+	}
+
+	summary, err := ws.store.DeleteResults(url, before)
+	if err != nil {
+		log.Printf("Failed to delete results of %s: %v", url, err)
+		http.Error(w, "Failed to delete results", http.StatusInternalServerError)
+		return
+	}
+
+	details := map[string]interface{}{"url": url, "deleted": summary}
+	if !before.IsZero() {
+		details["before"] = before
+	}
+	if reason := query.Get("reason"); reason != "" {
+		details["reason"] = reason
+	}
+	ws.audit(r, "results.delete", details)
+	json.NewEncoder(w).Encode(details)
+}
+
+// handleAuditLog lists recent administrative actions (limit, default 100)
+func (ws *WebServer) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	entries, err := ws.store.GetAuditLog(limit)
+	if err != nil {
+		log.Printf("Failed to load audit log: %v", err)
+		http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
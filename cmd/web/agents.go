@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/agents"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+)
+
+// maxAgentResultsBytes caps the size of a batch of results sent by an agent
+const maxAgentResultsBytes = 5 << 20
+
+// AgentAssignment tells an agent what to check and how often to report in
+type AgentAssignment struct {
+	Agent             agents.Agent         `json:"agent"`
+	HeartbeatInterval time.Duration        `json:"heartbeatInterval"`
+	Endpoints         []scheduler.Endpoint `json:"endpoints"`
+}
+
+// AgentResultsResponse counts the results of a batch that were recorded and
+// those dropped because their endpoint isn't assigned to the agent
+type AgentResultsResponse struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+}
+
+// requireAgentToken protects the agent API with AGENT_TOKEN, which every
+// agent presents. Without a token the agent API is disabled.
+func (ws *WebServer) requireAgentToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ws.agents == nil {
+			http.Error(w, "Agent API disabled, set AGENT_TOKEN to enable it", http.StatusForbidden)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(ws.config.AgentToken)) != 1 {
+			log.Printf("Rejected agent request %s %s from %s", r.Method, r.URL.Path, clientIP(r))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleAgents lists the registered agents with their health and assigned
+// endpoints
+func (ws *WebServer) handleAgents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.agents == nil {
+		json.NewEncoder(w).Encode([]agents.Agent{})
+		return
+	}
+	json.NewEncoder(w).Encode(ws.agents.List(time.Now()))
+}
+
+// handleAgentActions routes the agent API: POST /api/agents/register,
+// POST /api/agents/{id}/heartbeat, POST /api/agents/{id}/results and
+// DELETE /api/agents/{id}
+func (ws *WebServer) handleAgentActions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/agents/"), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "register" && r.Method == "POST":
+		ws.registerAgent(w, r)
+	case len(parts) == 1 && r.Method == "DELETE":
+		if !ws.agents.Deregister(parts[0]) {
+			http.Error(w, "Agent not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Agent %s deregistered", parts[0])
+		ws.assignAgents()
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "heartbeat" && r.Method == "POST":
+		ws.agentHeartbeat(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "results" && r.Method == "POST":
+		ws.agentResults(w, r, parts[0])
+	case len(parts) > 2 || (len(parts) == 2 && parts[1] != "heartbeat" && parts[1] != "results"):
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// registerAgent adds an agent and answers with its first assignment
+func (ws *WebServer) registerAgent(w http.ResponseWriter, r *http.Request) {
+	var registration agents.Registration
+	if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	agent, err := ws.agents.Register(registration, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("Agent %s (%s) registered from %s", agent.Name, agent.ID, clientIP(r))
+
+	ws.assignAgents()
+	json.NewEncoder(w).Encode(ws.agentAssignment(agent.ID))
+}
+
+// agentHeartbeat records a heartbeat and answers with the agent's current
+// assignment. Unknown agents get 404 and should register again.
+func (ws *WebServer) agentHeartbeat(w http.ResponseWriter, r *http.Request, id string) {
+	var heartbeat agents.Heartbeat
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&heartbeat); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	agent, ok := ws.agents.Heartbeat(id, heartbeat, time.Now())
+	if !ok {
+		http.Error(w, "Agent not registered", http.StatusNotFound)
+		return
+	}
+	if len(agent.Endpoints) == 0 {
+		ws.assignAgents()
+	}
+	json.NewEncoder(w).Encode(ws.agentAssignment(id))
+}
+
+// agentResults records the results an agent checked like local ones,
+// labelled with the agent's name and region
+func (ws *WebServer) agentResults(w http.ResponseWriter, r *http.Request, id string) {
+	agent, ok := ws.agents.Get(id)
+	if !ok {
+		http.Error(w, "Agent not registered", http.StatusNotFound)
+		return
+	}
+	var results []checker.CheckResult
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAgentResultsBytes)).Decode(&results); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	var response AgentResultsResponse
+	for _, result := range results {
+		endpointID := scheduler.EndpointID(result.URL)
+		if !ws.agents.Assigned(endpointID, id) {
+			response.Rejected++
+			continue
+		}
+		result.Labels = agentLabels(agent, result.Labels)
+		if result.CheckedAt.IsZero() {
+			result.CheckedAt = time.Now()
+		}
+		if ws.scheduler.Record(endpointID, result) {
+			response.Accepted++
+		} else {
+			response.Rejected++
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// agentLabels adds the agent's name and region to a result's labels
+// without overriding labels the agent set itself
+func agentLabels(agent agents.Agent, labels map[string]string) map[string]string {
+	merged := map[string]string{"agent": agent.Name}
+	if agent.Region != "" {
+		merged["region"] = agent.Region
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	return merged
+}
+
+// agentAssignment lists the endpoints an agent should check. Paused and
+// disabled endpoints are left out so agents don't need to track pausing.
+func (ws *WebServer) agentAssignment(id string) AgentAssignment {
+	agent, _ := ws.agents.Get(id)
+	assignment := AgentAssignment{Agent: agent, HeartbeatInterval: ws.agents.Interval(), Endpoints: []scheduler.Endpoint{}}
+	for _, endpointID := range agent.Endpoints {
+		endpoint, ok := ws.scheduler.Get(endpointID)
+		if ok && !ws.scheduler.IsPaused(endpoint) {
+			assignment.Endpoints = append(assignment.Endpoints, endpoint)
+		}
+	}
+	return assignment
+}
+
+// assignAgents hands endpoints to online agents by their placement. With no
+// agents online the endpoints stay unassigned and are checked here.
+func (ws *WebServer) assignAgents() {
+	placements := make(map[string]agents.Placement)
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.Disabled {
+			continue
+		}
+		placement := agents.Placement{}
+		if endpoint.Agents != nil {
+			placement = *endpoint.Agents
+		}
+		placements[endpoint.ID] = placement
+	}
+	if assigned := ws.agents.Assign(placements); assigned > 0 {
+		log.Printf("Assigned %d endpoints to agents", assigned)
+	}
+}
+
+// watchAgents marks agents that stop sending heartbeats offline and moves
+// their endpoints to the remaining agents, or back to this server
+func (ws *WebServer) watchAgents() {
+	ticker := time.NewTicker(ws.agents.Interval())
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		offline := ws.agents.Sweep(now)
+		for _, agent := range offline {
+			log.Printf("⚠️ Agent %s (%s) missed %d heartbeats, marking it offline and reassigning its %d endpoints",
+				agent.Name, agent.ID, agent.MissedHeartbeats, len(agent.Endpoints))
+		}
+		// Also picks up endpoints added since the last heartbeat
+		ws.assignAgents()
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// allTags is the AI_SCHEDULES scope covering every endpoint
+const allTags = "*"
+
+// TagAnalysis is the latest scheduled AI analysis of one tag
+type TagAnalysis struct {
+	Tag         string        `json:"tag"`
+	Interval    time.Duration `json:"interval"`
+	Insights    []ai.Insight  `json:"insights"`
+	Endpoints   int           `json:"endpoints"`
+	GeneratedAt time.Time     `json:"generatedAt,omitempty"`
+	NextRun     time.Time     `json:"nextRun"`
+}
+
+// scopeResults returns the latest results of active endpoints with the tag,
+// and the subset that may be sent to the AI model. Paused endpoints are
+// excluded so they don't count against uptime or insights.
+func (ws *WebServer) scopeResults(tag string) (results, eligible []checker.CheckResult) {
+	for _, current := range ws.snapshot() {
+		if !current.hasResult || current.paused || !hasTag(current.endpoint.Tags, tag) {
+			continue
+		}
+		results = append(results, current.result)
+		if !current.endpoint.AIExcluded {
+			eligible = append(eligible, current.result)
+		}
+	}
+	return results, eligible
+}
+
+func hasTag(tags []string, tag string) bool {
+	if tag == allTags {
+		return true
+	}
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// startAISchedules restores the last persisted runs and starts one analysis
+// loop per configured tag
+func (ws *WebServer) startAISchedules() {
+	last := make(map[string]storage.AnalysisRecord)
+	if ws.store != nil {
+		records, err := ws.store.LatestAnalyses()
+		if err != nil {
+			log.Printf("Failed to load previous AI analyses: %v", err)
+		}
+		for _, record := range records {
+			last[record.Scope] = record
+		}
+	}
+
+	for tag, interval := range ws.config.AISchedules {
+		analysis := &TagAnalysis{Tag: tag, Interval: interval, Insights: []ai.Insight{}, NextRun: time.Now()}
+		if record, ok := last[tag]; ok {
+			if err := json.Unmarshal(record.Insights, &analysis.Insights); err == nil {
+				ws.rememberInsights(analysis.Insights, nil)
+				analysis.Endpoints = record.Endpoints
+				analysis.GeneratedAt = record.GeneratedAt
+				analysis.NextRun = record.GeneratedAt.Add(interval)
+			}
+		}
+
+		ws.analysesMutex.Lock()
+		ws.analyses[tag] = analysis
+		ws.analysesMutex.Unlock()
+
+		go ws.runAISchedule(tag, interval, time.Until(analysis.NextRun))
+	}
+}
+
+// runAISchedule analyzes one tag every interval, starting after delay
+func (ws *WebServer) runAISchedule(tag string, interval, delay time.Duration) {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	ws.analyzeTag(tag, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ws.analyzeTag(tag, interval)
+	}
+}
+
+// analyzeTag runs one analysis and persists it
+func (ws *WebServer) analyzeTag(tag string, interval time.Duration) {
+	results, eligible := ws.scopeResults(tag)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	insights := ws.analyze(ctx, results, eligible)
+	cancel()
+	if insights == nil {
+		insights = []ai.Insight{}
+	}
+
+	now := time.Now()
+	ws.analysesMutex.Lock()
+	ws.analyses[tag] = &TagAnalysis{
+		Tag:         tag,
+		Interval:    interval,
+		Insights:    insights,
+		Endpoints:   len(results),
+		GeneratedAt: now,
+		NextRun:     now.Add(interval),
+	}
+	ws.analysesMutex.Unlock()
+	log.Printf("🤖 Scheduled analysis of tag %q: %d insights over %d endpoints (%d sent to the model)",
+		tag, len(insights), len(results), len(eligible))
+	ws.publishInsights(tag, insights, now)
+
+	if ws.store == nil {
+		return
+	}
+	data, err := json.Marshal(insights)
+	if err != nil {
+		return
+	}
+	if err := ws.store.SaveAnalysis(storage.AnalysisRecord{Scope: tag, Insights: data, Endpoints: len(results), GeneratedAt: now}); err != nil {
+		log.Printf("Failed to save AI analysis for tag %q: %v", tag, err)
+	}
+}
+
+// tagAnalyses returns the scheduled analyses, optionally for one tag, ordered by tag
+func (ws *WebServer) tagAnalyses(tag string) []TagAnalysis {
+	ws.analysesMutex.RLock()
+	defer ws.analysesMutex.RUnlock()
+
+	analyses := []TagAnalysis{}
+	for _, analysis := range ws.analyses {
+		if tag == "" || analysis.Tag == tag {
+			analyses = append(analyses, *analysis)
+		}
+	}
+	sort.Slice(analyses, func(i, j int) bool { return analyses[i].Tag < analyses[j].Tag })
+	return analyses
+}
+
+// scheduledInsights flattens the latest scheduled analyses into one list
+func (ws *WebServer) scheduledInsights(tag string) []ai.Insight {
+	insights := []ai.Insight{}
+	for _, analysis := range ws.tagAnalyses(tag) {
+		insights = append(insights, analysis.Insights...)
+	}
+	return insights
+}
+
+// handleInsightSchedule reports when each tag was last analyzed and runs next
+func (ws *WebServer) handleInsightSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.tagAnalyses(r.URL.Query().Get("tag")))
+}
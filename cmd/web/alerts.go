@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/events"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// publishAlert forwards alerts to event stream subscribers
+func (ws *WebServer) publishAlert(ctx context.Context, alert alerting.Alert) error {
+	ws.events.Publish(events.Event{
+		Type:       events.TypeAlert,
+		EndpointID: alert.EndpointID,
+		URL:        alert.URL,
+		Current:    alert.State,
+		Alert:      &alert,
+	})
+	return nil
+}
+
+// handleAlerts lists the alerts that are currently firing, endpoints that are
+// down first
+func (ws *WebServer) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	active := []alerting.Alert{}
+	if ws.downAlerts != nil {
+		active = append(active, ws.downAlerts.Active()...)
+	}
+	if ws.staleAlerts != nil {
+		active = append(active, ws.staleAlerts.Active()...)
+	}
+	if ws.slowAlerts != nil {
+		active = append(active, ws.slowAlerts.Active()...)
+	}
+	active = append(active, ws.burnRates.Active()...)
+	active = append(active, ws.metricRules.Active()...)
+	json.NewEncoder(w).Encode(active)
+}
+
+// handleAlertRules manages alert rules on extracted metrics. GET, PUT and
+// DELETE take the rule's ?id= (GET without one lists every rule).
+func (ws *WebServer) handleAlertRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			json.NewEncoder(w).Encode(ws.metricRules.Rules())
+			return
+		}
+		rule, ok := ws.metricRules.Rule(id)
+		if !ok {
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(rule)
+
+	case "POST":
+		var rule alerting.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if rule.EndpointID != "" {
+			if _, ok := ws.scheduler.Get(rule.EndpointID); !ok {
+				http.Error(w, "Endpoint not found", http.StatusBadRequest)
+				return
+			}
+		}
+		rule, err := ws.metricRules.AddRule(rule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+
+	case "PUT":
+		var rule alerting.Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if id := r.URL.Query().Get("id"); id != "" {
+			rule.ID = id
+		}
+		if _, ok := ws.metricRules.Rule(rule.ID); !ok {
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+		if rule.EndpointID != "" {
+			if _, ok := ws.scheduler.Get(rule.EndpointID); !ok {
+				http.Error(w, "Endpoint not found", http.StatusBadRequest)
+				return
+			}
+		}
+		rule, err := ws.metricRules.UpdateRule(rule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(rule)
+
+	case "DELETE":
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		if !ws.metricRules.RemoveRule(id) {
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// rulePreviewLimit caps the metric points replayed per endpoint
+const rulePreviewLimit = 100000
+
+// RulePreview lists the periods a rule would have fired in
+type RulePreview struct {
+	Rule       alerting.Rule            `json:"rule"`
+	From       time.Time                `json:"from"`
+	To         time.Time                `json:"to"`
+	Endpoints  int                      `json:"endpoints"` // endpoints with history of the metric
+	Samples    int                      `json:"samples"`
+	Periods    []alerting.PreviewPeriod `json:"periods"`
+	FiringTime time.Duration            `json:"firingTime"`
+}
+
+// handleRulePreview replays stored metric history through a rule without
+// adding it, so thresholds can be tuned against real data. The body is the
+// rule; ?from= and ?to= pick the history (default last 7 days).
+func (ws *WebServer) handleRulePreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	var rule alerting.Rule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to, err := parseTimeRange(r, 7*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	endpoints := ws.scheduler.List()
+	if rule.EndpointID != "" {
+		endpoint, ok := ws.scheduler.Get(rule.EndpointID)
+		if !ok {
+			http.Error(w, "Endpoint not found", http.StatusBadRequest)
+			return
+		}
+		endpoints = []scheduler.Endpoint{endpoint}
+	}
+
+	preview := RulePreview{Rule: rule, From: from, To: to, Periods: []alerting.PreviewPeriod{}}
+	for _, endpoint := range endpoints {
+		samples, err := ws.metricSamples(endpoint.URL, rule.Metric, from, to)
+		if err != nil {
+			log.Printf("Failed to load metrics for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to load metrics", http.StatusInternalServerError)
+			return
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		periods, err := alerting.PreviewRule(rule, endpoint.ID, endpoint.URL, samples)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		preview.Endpoints++
+		preview.Samples += len(samples)
+		for _, period := range periods {
+			end := to
+			if period.ResolvedAt != nil {
+				end = *period.ResolvedAt
+			}
+			preview.FiringTime += end.Sub(period.FiredAt)
+		}
+		preview.Periods = append(preview.Periods, periods...)
+	}
+	sort.Slice(preview.Periods, func(i, j int) bool { return preview.Periods[i].FiredAt.Before(preview.Periods[j].FiredAt) })
+	json.NewEncoder(w).Encode(preview)
+}
+
+// metricSamples loads the stored history of a metric for previews; latency
+// comes from the newest rulePreviewLimit stored results
+func (ws *WebServer) metricSamples(url, metric string, from, to time.Time) ([]alerting.Sample, error) {
+	var samples []alerting.Sample
+	if metric == alerting.MetricLatency {
+		results, err := ws.store.QueryResults(storage.ResultQuery{URL: url, Limit: rulePreviewLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if value, ok := alerting.MetricValue(result, metric); ok && !result.CheckedAt.Before(from) && !result.CheckedAt.After(to) {
+				samples = append(samples, alerting.Sample{At: result.CheckedAt, Value: value})
+			}
+		}
+		return samples, nil
+	}
+
+	points, err := ws.store.GetMetrics(url, metric, from, to, rulePreviewLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range points {
+		samples = append(samples, alerting.Sample{At: point.CheckedAt, Value: point.Value})
+	}
+	return samples, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/auth"
+	"api-monitor/internal/storage"
+)
+
+// apiKeyTouchInterval limits how often a key's last use is written
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyRequest creates an API key
+type APIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope,omitempty"` // read or write (the default)
+}
+
+// APIKeyCreated returns a new API key. The key is only ever shown here.
+type APIKeyCreated struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+// requestAPIKey returns the stored API key a request authenticates with
+func (ws *WebServer) requestAPIKey(r *http.Request) (storage.APIKey, bool) {
+	presented, ok := auth.RequestAPIKey(r)
+	if !ok || ws.store == nil {
+		return storage.APIKey{}, false
+	}
+	key, found, err := ws.store.GetAPIKey(auth.HashAPIKey(presented))
+	if err != nil {
+		log.Printf("Failed to look up API key: %v", err)
+		return storage.APIKey{}, false
+	}
+	if !found {
+		log.Printf("Rejected unknown API key from %s", clientIP(r))
+		return storage.APIKey{}, false
+	}
+
+	now := time.Now()
+	if last, seen := ws.apiKeysUsed.Load(key.ID); !seen || now.Sub(last.(time.Time)) >= apiKeyTouchInterval {
+		ws.apiKeysUsed.Store(key.ID, now)
+		if err := ws.store.TouchAPIKey(key.ID, now.UTC()); err != nil {
+			log.Printf("Failed to record use of API key %s: %v", key.ID, err)
+		}
+	}
+	return key, true
+}
+
+// handleAPIKeys manages API keys: GET lists them, POST creates one and
+// returns the key once, DELETE ?id= revokes one. Only a dashboard login can
+// manage keys, so a leaked key can't mint more.
+func (ws *WebServer) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if _, ok := auth.RequestAPIKey(r); ok {
+		http.Error(w, "API keys can't manage API keys, log in to the dashboard instead", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		keys, err := ws.store.ListAPIKeys()
+		if err != nil {
+			log.Printf("Failed to list API keys: %v", err)
+			http.Error(w, "Failed to list API keys", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(keys)
+
+	case "POST":
+		ws.createAPIKey(w, r)
+
+	case "DELETE":
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+		deleted, err := ws.store.DeleteAPIKey(id)
+		if err != nil {
+			log.Printf("Failed to delete API key %s: %v", id, err)
+			http.Error(w, "Failed to delete API key", http.StatusInternalServerError)
+			return
+		}
+		if !deleted {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		ws.apiKeysUsed.Delete(id)
+		ws.audit(r, "apikey.delete", map[string]string{"id": id})
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ws *WebServer) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !ws.config.AuthEnabled {
+		http.Error(w, "API keys are only checked with AUTH_ENABLED=true, enable it first", http.StatusForbidden)
+		return
+	}
+	var req APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = auth.ScopeWrite
+	}
+	if err := auth.ValidateScope(req.Scope); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key, id, err := auth.GenerateAPIKey()
+	if err != nil {
+		http.Error(w, "Failed to generate API key", http.StatusInternalServerError)
+		return
+	}
+	record := storage.APIKey{
+		ID:        id,
+		Name:      req.Name,
+		Scope:     req.Scope,
+		Hash:      auth.HashAPIKey(key),
+		CreatedBy: ws.actor(r),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := ws.store.SaveAPIKey(record); err != nil {
+		log.Printf("Failed to save API key: %v", err)
+		http.Error(w, "Failed to save API key", http.StatusInternalServerError)
+		return
+	}
+	ws.audit(r, "apikey.create", record)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(APIKeyCreated{APIKey: record, Key: key})
+}
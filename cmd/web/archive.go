@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/archive"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// archiveTimeout bounds uploading or downloading one archive
+const archiveTimeout = 10 * time.Minute
+
+// ArchiveResponse reports an archived endpoint
+type ArchiveResponse struct {
+	Name     string               `json:"name"` // pass to /api/archives/restore
+	Location string               `json:"location"`
+	Archived archive.Summary      `json:"archived"`
+	Deleted  storage.PurgeSummary `json:"deleted"`
+}
+
+// RestoreRequest names the archive to restore
+type RestoreRequest struct {
+	Name string `json:"name"`
+}
+
+// RestoreResponse reports a restored archive
+type RestoreResponse struct {
+	Name      string          `json:"name"`
+	URL       string          `json:"url"`
+	Restored  archive.Summary `json:"restored"`
+	Monitored bool            `json:"monitored"` // the endpoint is scheduled again
+}
+
+// archiveLocation opens ARCHIVE_LOCATION, false when archiving is disabled
+func (ws *WebServer) archiveLocation(w http.ResponseWriter) (archive.Location, bool) {
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	if ws.config.ArchiveLocation == "" {
+		http.Error(w, "Archiving disabled, set ARCHIVE_LOCATION to enable it", http.StatusForbidden)
+		return nil, false
+	}
+	location, err := archive.Open(ws.config.ArchiveLocation, ws.config.ArchiveRegion, ws.config.ArchiveEndpoint, &http.Client{Timeout: archiveTimeout})
+	if err != nil {
+		log.Printf("Invalid ARCHIVE_LOCATION: %v", err)
+		http.Error(w, "Invalid ARCHIVE_LOCATION", http.StatusInternalServerError)
+		return nil, false
+	}
+	return location, true
+}
+
+// handleArchive stops monitoring an endpoint, exports its full history to
+// ARCHIVE_LOCATION and purges what was exported from the database. Nothing
+// is purged unless the archive was stored, and checks still in flight when
+// monitoring stopped land after the exported period and are kept.
+func (ws *WebServer) handleArchive(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	location, ok := ws.archiveLocation(w)
+	if !ok {
+		return
+	}
+
+	config, err := json.Marshal(endpoint)
+	if err != nil {
+		http.Error(w, "Failed to archive endpoint", http.StatusInternalServerError)
+		return
+	}
+	record := storage.EndpointRecord{ID: endpoint.ID, URL: endpoint.URL, Config: config}
+	// Stopped first, so no result is saved while the history is exported
+	ws.removeEndpoint(endpoint)
+	restore := func() {
+		if err := ws.scheduler.Add(endpoint); err != nil {
+			log.Printf("Failed to monitor %s again: %v", endpoint.URL, err)
+			return
+		}
+		if err := ws.persistEndpoint(endpoint); err != nil {
+			log.Printf("Failed to persist endpoint %s: %v", endpoint.URL, err)
+		}
+	}
+
+	now := time.Now()
+	data, header, summary, err := archive.Export(ws.store.WithContext(r.Context()), endpoint.URL, &record, now)
+	if err != nil {
+		restore()
+		log.Printf("Failed to export history of %s: %v", endpoint.URL, err)
+		http.Error(w, "Failed to export history", http.StatusInternalServerError)
+		return
+	}
+	name := archive.Name(endpoint.ID, now)
+	ctx, cancel := context.WithTimeout(r.Context(), archiveTimeout)
+	defer cancel()
+	if err := location.Put(ctx, name, data); err != nil {
+		restore()
+		log.Printf("Failed to store archive %s of %s: %v", name, endpoint.URL, err)
+		http.Error(w, "Failed to store archive", http.StatusBadGateway)
+		return
+	}
+
+	// Only what was exported is purged: results up to the last exported one
+	before := header.ExportedAt
+	if header.To != nil {
+		before = header.To.Add(time.Microsecond)
+	}
+	deleted, err := ws.store.DeleteResults(endpoint.URL, before)
+	if err != nil {
+		// The archive is complete, so the purge can simply be retried
+		log.Printf("Archived %s but failed to purge its history: %v", endpoint.URL, err)
+		http.Error(w, "Archived, but failed to purge the history; retry with DELETE /api/results", http.StatusInternalServerError)
+		return
+	}
+
+	response := ArchiveResponse{Name: name, Location: location.String(), Archived: summary, Deleted: deleted}
+	ws.audit(r, "endpoint.archive", map[string]interface{}{"url": endpoint.URL, "archive": name, "archived": summary})
+	log.Printf("📦 Archived %s to %s/%s (%d results, %d incidents, %d bytes)",
+		endpoint.URL, location, name, summary.Results, summary.Incidents, len(data))
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleArchiveRestore loads an archive back into the database and schedules
+// its endpoint again unless it is monitored already:
+// POST /api/archives/restore {"name": "..."}. Archives overlapping any
+// stored history (results, metrics, sketches, rollups or incidents) are
+// refused, so restoring twice does not duplicate it; a failed restore is
+// undone and can be retried.
+func (ws *WebServer) handleArchiveRestore(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "Invalid JSON: 'name' is required", http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(req.Name, "..") {
+		http.Error(w, "Invalid archive name", http.StatusBadRequest)
+		return
+	}
+	location, ok := ws.archiveLocation(w)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), archiveTimeout)
+	defer cancel()
+	data, err := location.Get(ctx, req.Name)
+	if err != nil {
+		log.Printf("Failed to load archive %s: %v", req.Name, err)
+		http.Error(w, "Failed to load archive", http.StatusBadGateway)
+		return
+	}
+	header, err := archive.ReadHeader(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to, err := archive.Span(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.IsZero() {
+		exists, err := ws.store.HasHistory(header.URL, from, to)
+		if err != nil {
+			log.Printf("Failed to check stored history of %s: %v", header.URL, err)
+			http.Error(w, "Failed to restore archive", http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			http.Error(w, "The database already holds history of this URL over the archived period", http.StatusConflict)
+			return
+		}
+	}
+
+	_, summary, err := archive.Restore(ws.store.WithContext(r.Context()), data)
+	if err != nil {
+		log.Printf("Failed to restore archive %s: %v", req.Name, err)
+		http.Error(w, "Failed to restore archive", http.StatusInternalServerError)
+		return
+	}
+	response := RestoreResponse{Name: req.Name, URL: header.URL, Restored: summary}
+	for _, endpoint := range ws.scheduler.List() {
+		response.Monitored = response.Monitored || endpoint.URL == header.URL
+	}
+	if header.Endpoint != nil && !response.Monitored {
+		response.Monitored = ws.rescheduleArchived(*header.Endpoint)
+	}
+
+	ws.audit(r, "endpoint.restore", map[string]interface{}{"url": header.URL, "archive": req.Name, "restored": summary})
+	json.NewEncoder(w).Encode(response)
+}
+
+// rescheduleArchived monitors an archived endpoint definition again, like
+// loadEndpoints does for stored ones
+func (ws *WebServer) rescheduleArchived(record storage.EndpointRecord) bool {
+	var endpoint scheduler.Endpoint
+	if err := json.Unmarshal(record.Config, &endpoint); err != nil {
+		log.Printf("Not rescheduling archived endpoint %s: %v", record.URL, err)
+		return false
+	}
+	endpoint.ID = record.ID
+	endpoint.URL = record.URL
+	if endpoint.Interval <= 0 {
+		endpoint.Interval = ws.currentConfig().CheckInterval
+	}
+	if owned, _ := ws.grpcOwned(endpoint.ID); owned {
+		log.Printf("Not rescheduling archived endpoint %s: monitored by the gRPC server", record.URL)
+		return false
+	}
+	if err := ws.scheduler.Add(endpoint); err != nil {
+		log.Printf("Not rescheduling archived endpoint %s: %v", record.URL, err)
+		return false
+	}
+	if err := ws.persistEndpoint(endpoint); err != nil {
+		log.Printf("Failed to persist endpoint %s: %v", endpoint.URL, err)
+	}
+	return true
+}
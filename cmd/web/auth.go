@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/auth"
+)
+
+// requireAuth protects a handler behind the dashboard login or an API key
+// when auth is enabled. Read-scoped keys may only send GET requests.
+func (ws *WebServer) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ws.config.AuthEnabled {
+			next(w, r)
+			return
+		}
+
+		if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+			if _, ok := ws.sessions.Get(cookie.Value); ok {
+				next(w, r)
+				return
+			}
+		}
+		if key, ok := ws.requestAPIKey(r); ok {
+			if !auth.ScopeAllows(key.Scope, r.Method) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "API key is read-only"})
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		// API callers get a 401, browsers are sent to the login page
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authentication required"})
+			return
+		}
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	}
+}
+
+func (ws *WebServer) handleLogin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		http.ServeFile(w, r, "web/login.html")
+
+	case "POST":
+		username := strings.TrimSpace(r.FormValue("username"))
+		password := r.FormValue("password")
+		ip := clientIP(r)
+
+		// Lock out both the account and the client address after repeated failures
+		for _, key := range []string{"user:" + username, "ip:" + ip} {
+			if locked, remaining := ws.loginLimiter.Locked(key); locked {
+				log.Printf("Login rejected for %q from %s: locked out", username, ip)
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(remaining.Seconds())+1))
+				http.Redirect(w, r, "/login?error=locked", http.StatusSeeOther)
+				return
+			}
+		}
+
+		if ws.config.AuthPassword == "" || !auth.CheckPassword(ws.config.AuthUsername, ws.config.AuthPassword, username, password) {
+			for _, key := range []string{"user:" + username, "ip:" + ip} {
+				if ws.loginLimiter.Failure(key) {
+					log.Printf("Locking out %s after %d failed logins", key, ws.config.LoginMaxAttempts)
+				}
+			}
+			http.Redirect(w, r, "/login?error=invalid", http.StatusSeeOther)
+			return
+		}
+
+		ws.loginLimiter.Success("user:" + username)
+		ws.loginLimiter.Success("ip:" + ip)
+
+		session, err := ws.sessions.Create(username)
+		if err != nil {
+			http.Error(w, "Failed to create session", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     auth.SessionCookieName,
+			Value:    session.Token,
+			Path:     "/",
+			Expires:  session.ExpiresAt,
+			MaxAge:   int(ws.sessions.TTL().Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil || ws.config.TLSEnabled(),
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		log.Printf("User %s logged in from %s", username, ip)
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ws *WebServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if cookie, err := r.Cookie(auth.SessionCookieName); err == nil {
+		ws.sessions.Delete(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || ws.config.TLSEnabled(),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// clientIP extracts the remote address without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// Limits of debug captures
+const (
+	defaultCaptureDuration = 15 * time.Minute
+	maxCaptureDuration     = time.Hour
+	defaultCaptureLimit    = 50
+	maxCaptureLimit        = 500
+)
+
+// CaptureRequest starts a debug capture of an endpoint
+type CaptureRequest struct {
+	Duration string `json:"duration"` // e.g. "15m", at most 1h
+}
+
+// CaptureStatus tells whether an endpoint's checks are being captured
+type CaptureStatus struct {
+	EndpointID string     `json:"endpointId"`
+	Active     bool       `json:"active"`
+	Until      *time.Time `json:"until,omitempty"`
+}
+
+// capturing reports whether checks of an endpoint should be captured now
+func (ws *WebServer) capturing(endpointID string) bool {
+	ws.capturesMutex.Lock()
+	defer ws.capturesMutex.Unlock()
+	until, ok := ws.captures[endpointID]
+	if ok && !time.Now().Before(until) {
+		delete(ws.captures, endpointID)
+		return false
+	}
+	return ok
+}
+
+// captureStatus returns the capture window of an endpoint
+func (ws *WebServer) captureStatus(endpointID string) CaptureStatus {
+	status := CaptureStatus{EndpointID: endpointID}
+	if ws.capturing(endpointID) {
+		ws.capturesMutex.Lock()
+		until := ws.captures[endpointID]
+		ws.capturesMutex.Unlock()
+		status.Active, status.Until = true, &until
+	}
+	return status
+}
+
+// saveCapture stores the exchange of a captured check and reports whether
+// a row was written
+func (ws *WebServer) saveCapture(endpoint scheduler.Endpoint, result checker.CheckResult) bool {
+	err := ws.store.SaveCapture(storage.Capture{
+		EndpointID: endpoint.ID,
+		URL:        endpoint.URL,
+		CheckedAt:  result.CheckedAt,
+		StatusCode: result.StatusCode,
+		Error:      result.Error,
+		Exchange:   *result.Capture,
+	})
+	if err != nil {
+		log.Printf("Failed to save capture for %s: %v", endpoint.URL, err)
+		return false
+	}
+	return true
+}
+
+// handleCapture starts (POST), shows (GET) or stops (DELETE) the debug
+// capture of an endpoint, which stores the full request and response of
+// every check this instance makes until it ends
+func (ws *WebServer) handleCapture(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(ws.captureStatus(endpoint.ID))
+
+	case "POST":
+		if ws.store == nil {
+			http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if endpoint.Type != "" && endpoint.Type != checker.TypeHTTP {
+			http.Error(w, "Only HTTP checks can be captured", http.StatusBadRequest)
+			return
+		}
+		var req CaptureRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+		}
+		duration := defaultCaptureDuration
+		if req.Duration != "" {
+			parsed, err := time.ParseDuration(req.Duration)
+			if err != nil || parsed <= 0 || parsed > maxCaptureDuration {
+				http.Error(w, "duration must be a positive duration of at most 1h, such as 15m", http.StatusBadRequest)
+				return
+			}
+			duration = parsed
+		}
+
+		// Starting a capture is when old ones are cleaned up
+		if ws.config.CaptureRetention > 0 {
+			if _, err := ws.store.DeleteCaptures("", time.Now().Add(-ws.config.CaptureRetention)); err != nil {
+				log.Printf("Failed to delete expired captures: %v", err)
+			}
+		}
+
+		until := time.Now().Add(duration).UTC()
+		ws.capturesMutex.Lock()
+		ws.captures[endpoint.ID] = until
+		ws.capturesMutex.Unlock()
+		ws.audit(r, "capture.start", map[string]string{"endpointId": endpoint.ID, "url": endpoint.URL, "until": until.Format(time.RFC3339)})
+		log.Printf("🔍 Capturing checks of %s until %s", endpoint.URL, until.Format(time.RFC3339))
+		json.NewEncoder(w).Encode(ws.captureStatus(endpoint.ID))
+
+	case "DELETE":
+		ws.capturesMutex.Lock()
+		delete(ws.captures, endpoint.ID)
+		ws.capturesMutex.Unlock()
+		ws.audit(r, "capture.stop", map[string]string{"endpointId": endpoint.ID, "url": endpoint.URL})
+		json.NewEncoder(w).Encode(ws.captureStatus(endpoint.ID))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCaptures lists the captured exchanges of an endpoint, newest first
+// (?from=, ?to=, default the last 24 hours, and ?limit=), or deletes them
+func (ws *WebServer) handleCaptures(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	switch r.Method {
+	case "GET":
+		from, to, err := parseTimeRange(r, 24*time.Hour)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		limit := defaultCaptureLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			limit = min(limit, maxCaptureLimit)
+		}
+		captures, err := store.GetCaptures(endpoint.ID, from, to, limit)
+		if err != nil {
+			log.Printf("Failed to load captures for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to load captures", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(captures)
+
+	case "DELETE":
+		deleted, err := store.DeleteCaptures(endpoint.ID, time.Now().Add(time.Hour))
+		if err != nil {
+			log.Printf("Failed to delete captures for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to delete captures", http.StatusInternalServerError)
+			return
+		}
+		ws.audit(r, "captures.delete", map[string]interface{}{"endpointId": endpoint.ID, "url": endpoint.URL, "deleted": deleted})
+		json.NewEncoder(w).Encode(map[string]int64{"deleted": deleted})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"api-monitor/internal/config"
+)
+
+// ConfigResponse is the configuration the running instance loaded
+type ConfigResponse struct {
+	Settings   []config.Setting `json:"settings"`
+	LoadErrors []string         `json:"loadErrors"`
+}
+
+// handleConfig lists every setting with its effective value and where it
+// came from (env, file, config, profile or default), with secrets masked.
+// ?source= limits the list to one source, e.g. to see everything left at
+// its default.
+func (ws *WebServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	switch source {
+	case "", config.SourceEnv, config.SourceFile, config.SourceConfig, config.SourceProfile, config.SourceDefault:
+	default:
+		http.Error(w, "source must be env, file, config, profile or default", http.StatusBadRequest)
+		return
+	}
+
+	cfg := ws.currentConfig()
+	response := ConfigResponse{Settings: []config.Setting{}, LoadErrors: cfg.LoadErrors}
+	if response.LoadErrors == nil {
+		response.LoadErrors = []string{}
+	}
+	for _, setting := range cfg.Settings {
+		if source == "" || setting.Source == source {
+			response.Settings = append(response.Settings, setting)
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
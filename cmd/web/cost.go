@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/cost"
+	"api-monitor/internal/scheduler"
+)
+
+// EndpointCost is the work an endpoint costs the monitor. Usage is counted
+// in memory since Since (the server start); PerDay extrapolates it.
+type EndpointCost struct {
+	EndpointID string    `json:"endpointId"`
+	URL        string    `json:"url"`
+	Since      time.Time `json:"since"`
+
+	// ScheduledChecksPerDay follows from the interval or cron schedule; zero
+	// for paused or disabled endpoints
+	ScheduledChecksPerDay float64 `json:"scheduledChecksPerDay"`
+
+	Usage  cost.Usage `json:"usage"`
+	PerDay CostRates  `json:"perDay"`
+
+	// StoredResults counts the endpoint's rows in the results table
+	StoredResults int `json:"storedResults"`
+}
+
+// CostRates is usage per day
+type CostRates struct {
+	Checks        float64 `json:"checks"`
+	BytesSent     float64 `json:"bytesSent"`
+	BytesReceived float64 `json:"bytesReceived"`
+	RowsWritten   float64 `json:"rowsWritten"`
+	AITokens      float64 `json:"aiTokens"`
+}
+
+// handleEndpointCost reports how much work an endpoint causes: checks,
+// bytes transferred, database rows written and its share of AI tokens
+func (ws *WebServer) handleEndpointCost(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	report := EndpointCost{
+		EndpointID: endpoint.ID,
+		URL:        endpoint.URL,
+		Since:      ws.costs.Since(),
+		Usage:      ws.costs.Get(endpoint.URL),
+	}
+	if !endpoint.Disabled && !ws.scheduler.IsPaused(endpoint) {
+		report.ScheduledChecksPerDay = scheduledChecksPerDay(endpoint, now)
+	}
+
+	// Rates need a little history to mean anything
+	if elapsed := now.Sub(report.Since); elapsed >= time.Minute {
+		days := elapsed.Hours() / 24
+		report.PerDay = CostRates{
+			Checks:        float64(report.Usage.Checks) / days,
+			BytesSent:     float64(report.Usage.BytesSent) / days,
+			BytesReceived: float64(report.Usage.BytesReceived) / days,
+			RowsWritten:   float64(report.Usage.RowsWritten) / days,
+			AITokens:      float64(report.Usage.AITokens) / days,
+		}
+	}
+
+	if ws.store != nil {
+		checks, _, err := ws.store.CountChecks(endpoint.URL, time.Time{}, now)
+		if err != nil {
+			log.Printf("Failed to count stored results of %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to count stored results", http.StatusInternalServerError)
+			return
+		}
+		report.StoredResults = checks
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// scheduledChecksPerDay counts an endpoint's checks over the next day
+func scheduledChecksPerDay(endpoint scheduler.Endpoint, now time.Time) float64 {
+	if endpoint.Cron == "" {
+		if endpoint.Interval <= 0 {
+			return 0
+		}
+		return float64(24*time.Hour) / float64(endpoint.Interval)
+	}
+	schedule, err := scheduler.ParseCron(endpoint.Cron, endpoint.Timezone)
+	if err != nil || schedule == nil {
+		return 0
+	}
+	checks := 0
+	end := now.Add(24 * time.Hour)
+	for next := schedule.Next(now); !next.IsZero() && next.Before(end); next = schedule.Next(next) {
+		checks++
+	}
+	return float64(checks)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"api-monitor/internal/scheduler"
+)
+
+// DebugState is a snapshot of the monitor's internals for diagnosing production issues
+type DebugState struct {
+	Goroutines     int               `json:"goroutines"`
+	HeapAllocBytes uint64            `json:"heapAllocBytes"`
+	HeapObjects    uint64            `json:"heapObjects"`
+	NumGC          uint32            `json:"numGC"`
+	EndpointStates map[string]int    `json:"endpointStates"` // healthy, unhealthy, paused, pending
+	Scheduler      scheduler.Metrics `json:"scheduler"`
+	PausedTags     []string          `json:"pausedTags"`
+	FleetPaused    bool              `json:"fleetPaused"`
+	DatabaseOK     bool              `json:"databaseOk"`
+	AIEnabled      bool              `json:"aiEnabled"`
+	StartedAt      time.Time         `json:"startedAt"`
+	Uptime         string            `json:"uptime"`
+}
+
+// startedAt records when the process started, for /debug/state
+var startedAt = time.Now()
+
+// registerDebugHandlers adds pprof and /debug/state behind authentication
+func (ws *WebServer) registerDebugHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/state", ws.requireAuth(ws.handleDebugState))
+	mux.HandleFunc("/debug/pprof/", ws.requireAuth(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", ws.requireAuth(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", ws.requireAuth(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", ws.requireAuth(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", ws.requireAuth(pprof.Trace))
+}
+
+func (ws *WebServer) handleDebugState(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	// Use cached results only; a debug request must not trigger checks
+	states := map[string]int{"healthy": 0, "unhealthy": 0, "paused": 0, "pending": 0}
+	for _, endpoint := range ws.scheduler.List() {
+		result, hasResult := ws.latestResult(endpoint)
+		switch {
+		case ws.scheduler.IsPaused(endpoint):
+			states["paused"]++
+		case !hasResult:
+			states["pending"]++
+		case result.IsHealthy:
+			states["healthy"]++
+		default:
+			states["unhealthy"]++
+		}
+	}
+
+	state := DebugState{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		NumGC:          mem.NumGC,
+		EndpointStates: states,
+		Scheduler:      ws.scheduler.Metrics(),
+		PausedTags:     ws.scheduler.PausedTags(),
+		FleetPaused:    ws.scheduler.Paused(),
+		DatabaseOK:     ws.store != nil,
+		AIEnabled:      ws.aiClient != nil,
+		StartedAt:      startedAt,
+		Uptime:         time.Since(startedAt).Round(time.Second).String(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
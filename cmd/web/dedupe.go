@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"api-monitor/internal/dedupe"
+	"api-monitor/internal/events"
+	"api-monitor/internal/scheduler"
+)
+
+// MergeRequest folds duplicate endpoints into the one being kept
+type MergeRequest struct {
+	Keep  string   `json:"keep"`  // endpoint ID that survives
+	Merge []string `json:"merge"` // endpoint IDs to remove after moving their history
+}
+
+// MergeResponse reports what a merge changed
+type MergeResponse struct {
+	Kept         scheduler.Endpoint `json:"kept"`
+	Removed      []string           `json:"removed"`
+	ResultsMoved int64              `json:"resultsMoved"`
+}
+
+// handleDuplicates lists groups of endpoints that monitor the same target
+func (ws *WebServer) handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	groups := dedupe.Find(ws.scheduler.List(), ws.latestResult)
+	if groups == nil {
+		groups = []dedupe.Group{}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"groups": groups})
+}
+
+// handleMerge moves the history of duplicate endpoints onto one and removes the rest
+func (ws *WebServer) handleMerge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	kept, exists := ws.scheduler.Get(req.Keep)
+	if !exists {
+		http.Error(w, "Endpoint to keep not found", http.StatusNotFound)
+		return
+	}
+	if len(req.Merge) == 0 {
+		http.Error(w, "At least one endpoint to merge is required", http.StatusBadRequest)
+		return
+	}
+
+	var merged []scheduler.Endpoint
+	for _, id := range req.Merge {
+		if id == kept.ID {
+			http.Error(w, "Cannot merge an endpoint into itself", http.StatusBadRequest)
+			return
+		}
+		endpoint, exists := ws.scheduler.Get(id)
+		if !exists {
+			http.Error(w, fmt.Sprintf("Endpoint %s not found", id), http.StatusNotFound)
+			return
+		}
+		merged = append(merged, endpoint)
+	}
+
+	response := MergeResponse{Kept: kept, Removed: []string{}}
+	if ws.store != nil {
+		sources := make([]string, len(merged))
+		for i, endpoint := range merged {
+			sources[i] = endpoint.URL
+		}
+		moved, err := ws.store.MergeResults(kept.URL, sources)
+		if err != nil {
+			log.Printf("Failed to merge history into %s: %v", kept.URL, err)
+			http.Error(w, "Failed to merge history", http.StatusInternalServerError)
+			return
+		}
+		response.ResultsMoved = moved
+	}
+
+	for _, endpoint := range merged {
+		ws.removeEndpoint(endpoint)
+		response.Removed = append(response.Removed, endpoint.ID)
+	}
+
+	log.Printf("Merged %d duplicate endpoint(s) into %s (%d results moved)",
+		len(response.Removed), kept.URL, response.ResultsMoved)
+	json.NewEncoder(w).Encode(response)
+}
+
+// removeEndpoint stops monitoring an endpoint and drops its in-memory state
+// and stored definition, unless the gRPC server owns that definition
+func (ws *WebServer) removeEndpoint(endpoint scheduler.Endpoint) bool {
+	if !ws.scheduler.Remove(endpoint.ID) {
+		return false
+	}
+	ws.sampler.Forget(endpoint.URL)
+	ws.coalescer.Forget(endpoint.URL)
+	ws.transitions.Forget(endpoint.ID)
+	ws.metricRules.ForgetEndpoint(endpoint.ID)
+	ws.burnRates.ForgetEndpoint(endpoint.ID)
+	ws.capturesMutex.Lock()
+	delete(ws.captures, endpoint.ID)
+	ws.capturesMutex.Unlock()
+	if ws.staleAlerts != nil {
+		ws.staleAlerts.ForgetEndpoint(endpoint.ID)
+	}
+	if ws.downAlerts != nil {
+		ws.downAlerts.ForgetEndpoint(endpoint.ID)
+	}
+	if ws.slowAlerts != nil {
+		ws.slowAlerts.ForgetEndpoint(endpoint.ID)
+	}
+	if ws.anomalies != nil {
+		ws.anomalies.Forget(endpoint.URL)
+	}
+	ws.cache.Delete(context.Background(), "status:"+endpoint.ID)
+	ws.live.Publish(events.Event{Type: events.TypeEndpointRemoved, EndpointID: endpoint.ID, URL: endpoint.URL})
+	if owned, err := ws.grpcOwned(endpoint.ID); err != nil {
+		log.Printf("Failed to look up stored endpoint %s: %v", endpoint.URL, err)
+	} else if !owned && ws.store != nil {
+		if _, err := ws.store.DeleteEndpoint(endpoint.ID); err != nil {
+			log.Printf("Failed to delete stored endpoint %s: %v", endpoint.URL, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/storage"
+)
+
+// digestWeek is the period a digest covers; it is compared with the one before
+const digestWeek = 7 * 24 * time.Hour
+
+// maxDigests caps how many past digests GET /api/digests returns
+const maxDigests = 52
+
+// WeeklyDigest is a generated digest with the figures it was written from
+type WeeklyDigest struct {
+	ID   int64     `json:"id,omitempty"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	ai.Digest
+	Endpoints   []ai.DigestEndpoint `json:"endpoints"`
+	Incidents   []ai.DigestIncident `json:"incidents"`
+	Delivered   []string            `json:"delivered"` // channels that received it
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// startDigests writes the weekly digest on the DIGEST_CRON schedule
+func (ws *WebServer) startDigests() {
+	if ws.config.DigestCron == "" {
+		return
+	}
+	if ws.store == nil {
+		log.Printf("DIGEST_CRON is set but the database is unavailable, no weekly digests will be written")
+		return
+	}
+	schedule, err := scheduler.ParseCron(ws.config.DigestCron, ws.config.DigestTimezone)
+	if err != nil {
+		log.Printf("Weekly digest disabled: %v", err)
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(time.Until(schedule.Next(time.Now())))
+			if _, err := ws.generateDigest(); err != nil {
+				log.Printf("Failed to write the weekly digest: %v", err)
+			}
+		}
+	}()
+}
+
+// generateDigest writes the digest of the week ending now, sends it to the
+// reporting channels and stores it. Endpoints excluded from AI analysis
+// aren't sent to the model; without a model, or when every tier fails, the
+// rule-based digest covers the whole fleet.
+func (ws *WebServer) generateDigest() (WeeklyDigest, error) {
+	to := time.Now().UTC()
+	from := to.Add(-digestWeek)
+	input, eligible, err := ws.digestInput(from, to)
+	if err != nil {
+		return WeeklyDigest{}, err
+	}
+
+	digest := WeeklyDigest{From: from, To: to, Endpoints: input.Endpoints, Incidents: input.Incidents, GeneratedAt: to}
+	if ws.aiClient == nil || len(eligible.Endpoints) == 0 {
+		digest.Digest = ai.FallbackDigest(input)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		digest.Digest, err = ws.aiClient.WeeklyDigest(ctx, eligible)
+		cancel()
+		if err != nil {
+			log.Printf("%v", err)
+			digest.Digest = ai.FallbackDigest(input)
+		}
+	}
+
+	delivered, err := ws.alerts.SendReport(digestReport(digest), ws.config.DigestChannels)
+	if err != nil {
+		log.Printf("Failed to deliver the weekly digest: %v", err)
+	}
+	digest.Delivered = delivered
+
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return digest, err
+	}
+	digest.ID, err = ws.store.SaveDigest(storage.DigestRecord{From: from, To: to, Digest: data, GeneratedAt: to})
+	if err != nil {
+		return digest, fmt.Errorf("saving digest: %w", err)
+	}
+	log.Printf("📰 Weekly digest written by %s: %d regression(s), %d improvement(s), sent to %d channel(s)",
+		digest.Tier, len(digest.Regressions), len(digest.Improvements), len(delivered))
+	return digest, nil
+}
+
+// digestInput gathers the week's figures of every endpoint next to the
+// previous week's, and the incidents, for the whole fleet and for the
+// endpoints that may be sent to the AI model
+func (ws *WebServer) digestInput(from, to time.Time) (input, eligible ai.DigestInput, err error) {
+	input = ai.DigestInput{From: from, To: to, Endpoints: []ai.DigestEndpoint{}, Incidents: []ai.DigestIncident{}}
+	eligible = ai.DigestInput{From: from, To: to}
+	previousFrom := from.Add(-digestWeek)
+	maintenance, err := ws.store.GetMaintenance(previousFrom, to)
+	if err != nil {
+		return input, eligible, fmt.Errorf("loading maintenance windows: %w", err)
+	}
+
+	for _, endpoint := range ws.scheduler.List() {
+		target := sla.Target{URL: endpoint.URL, Tags: endpoint.Tags, MinGap: ws.monitoringGap(endpoint)}
+		week, err := sla.BuildRange(ws.store, target, from, to, maintenance)
+		if err != nil {
+			return input, eligible, fmt.Errorf("%s: %w", endpoint.URL, err)
+		}
+		previous, err := sla.BuildRange(ws.store, target, previousFrom, from, maintenance)
+		if err != nil {
+			return input, eligible, fmt.Errorf("%s: %w", endpoint.URL, err)
+		}
+		row := ai.DigestEndpoint{
+			URL:                   endpoint.URL,
+			Checks:                week.Checks,
+			FailedChecks:          week.FailedChecks,
+			UptimePercent:         week.UptimePercent,
+			PreviousUptimePercent: previous.UptimePercent,
+			Outages:               week.Outages,
+			Downtime:              week.Downtime,
+		}
+		if row.P95, err = ws.storedP95(endpoint.URL, from, to); err != nil {
+			return input, eligible, fmt.Errorf("%s: %w", endpoint.URL, err)
+		}
+		if row.PreviousP95, err = ws.storedP95(endpoint.URL, previousFrom, from); err != nil {
+			return input, eligible, fmt.Errorf("%s: %w", endpoint.URL, err)
+		}
+
+		incidents, err := ws.store.GetIncidents(endpoint.URL, from, to)
+		if err != nil {
+			return input, eligible, fmt.Errorf("%s: %w", endpoint.URL, err)
+		}
+		var rows []ai.DigestIncident
+		for _, incident := range incidents {
+			end := to
+			if incident.EndedAt != nil {
+				end = *incident.EndedAt
+			}
+			rows = append(rows, ai.DigestIncident{
+				URL:       incident.URL,
+				StartedAt: incident.StartedAt,
+				Duration:  end.Sub(incident.StartedAt),
+				Ongoing:   incident.EndedAt == nil,
+				Cause:     incident.Cause,
+			})
+		}
+
+		input.Endpoints = append(input.Endpoints, row)
+		input.Incidents = append(input.Incidents, rows...)
+		if !endpoint.AIExcluded {
+			eligible.Endpoints = append(eligible.Endpoints, row)
+			eligible.Incidents = append(eligible.Incidents, rows...)
+		}
+	}
+	return input, eligible, nil
+}
+
+// storedP95 returns the p95 latency of the persisted sketches of [from, to),
+// zero without any samples
+func (ws *WebServer) storedP95(url string, from, to time.Time) (time.Duration, error) {
+	sketch, err := ws.store.LoadSketch(url, from, to)
+	if err != nil || sketch.Count == 0 {
+		return 0, err
+	}
+	return sketch.Quantile(0.95), nil
+}
+
+// digestReport formats a digest for the reporting channels
+func digestReport(digest WeeklyDigest) alerting.Report {
+	items := func(list []ai.DigestItem) []string {
+		var lines []string
+		for _, item := range list {
+			line := item.Title
+			if item.Detail != "" {
+				line += ": " + item.Detail
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	return alerting.Report{
+		Kind:    "weekly_digest",
+		Title:   fmt.Sprintf("Weekly digest, %s to %s", digest.From.Format("Jan 2"), digest.To.Format("Jan 2")),
+		Summary: digest.Summary,
+		Sections: []alerting.ReportSection{
+			{Title: "Notable regressions", Items: items(digest.Regressions)},
+			{Title: "Improvements", Items: items(digest.Improvements)},
+			{Title: "Recommended focus areas", Items: digest.FocusAreas},
+		},
+		At:   digest.GeneratedAt,
+		Data: digest,
+	}
+}
+
+// handleDigests lists the latest weekly digests, newest first (GET, ?limit=
+// up to 52, default 10). POST writes and sends one for the week ending now,
+// which needs the admin token.
+func (ws *WebServer) handleDigests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		limit := 10
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+				return
+			}
+			limit = min(parsed, maxDigests)
+		}
+		records, err := ws.store.WithContext(r.Context()).ListDigests(limit)
+		if err != nil {
+			log.Printf("Failed to load digests: %v", err)
+			http.Error(w, "Failed to load digests", http.StatusInternalServerError)
+			return
+		}
+		digests := make([]WeeklyDigest, 0, len(records))
+		for _, record := range records {
+			var digest WeeklyDigest
+			if err := json.Unmarshal(record.Digest, &digest); err != nil {
+				log.Printf("Skipping unreadable digest %d: %v", record.ID, err)
+				continue
+			}
+			digest.ID = record.ID
+			digests = append(digests, digest)
+		}
+		json.NewEncoder(w).Encode(digests)
+
+	case "POST":
+		ws.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+			digest, err := ws.generateDigest()
+			if err != nil {
+				log.Printf("Failed to write the weekly digest: %v", err)
+				http.Error(w, "Failed to write the weekly digest", http.StatusInternalServerError)
+				return
+			}
+			ws.audit(r, "digest.generate", map[string]interface{}{"id": digest.ID, "delivered": digest.Delivered})
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(digest)
+		})(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// insightMemory is how many recently served insights can be asked about.
+// An insight is only written to the database once someone asks about it.
+const insightMemory = 500
+
+// followUpHistory is how many recent results per affected endpoint are added
+// to a follow-up's context
+const followUpHistory = 10
+
+// servedInsight is an insight with the results it was generated from
+type servedInsight struct {
+	insight  ai.Insight
+	snapshot []checker.CheckResult
+	thread   []ai.Exchange
+	stored   bool
+}
+
+// FollowUpRequest asks a question about an insight
+type FollowUpRequest struct {
+	Question string `json:"question"`
+}
+
+// InsightThread is an insight and the follow-up questions asked about it
+type InsightThread struct {
+	Insight   ai.Insight    `json:"insight"`
+	FollowUps []ai.Exchange `json:"followUps"`
+}
+
+// rememberInsights gives each insight an ID and keeps it, with the results it
+// was generated from, so it can be asked about later
+func (ws *WebServer) rememberInsights(insights []ai.Insight, snapshot []checker.CheckResult) []ai.Insight {
+	ws.insightsMutex.Lock()
+	defer ws.insightsMutex.Unlock()
+
+	for i := range insights {
+		if insights[i].ID == "" {
+			insights[i].ID = newInsightID()
+		}
+		if _, ok := ws.servedInsights[insights[i].ID]; ok {
+			continue
+		}
+		ws.servedInsights[insights[i].ID] = &servedInsight{insight: insights[i], snapshot: snapshot}
+		ws.insightOrder = append(ws.insightOrder, insights[i].ID)
+	}
+
+	// Forget the oldest insights nobody asked about
+	for len(ws.insightOrder) > insightMemory {
+		delete(ws.servedInsights, ws.insightOrder[0])
+		ws.insightOrder = ws.insightOrder[1:]
+	}
+	return insights
+}
+
+// newInsightID returns a random 16 character ID
+func newInsightID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// findInsight returns a served insight from memory or the database
+func (ws *WebServer) findInsight(id string) (*servedInsight, bool) {
+	ws.insightsMutex.Lock()
+	served, ok := ws.servedInsights[id]
+	ws.insightsMutex.Unlock()
+	if ok {
+		return served, true
+	}
+	if ws.store == nil {
+		return nil, false
+	}
+
+	record, ok, err := ws.store.GetInsight(id)
+	if err != nil {
+		log.Printf("Failed to load insight %s: %v", id, err)
+	}
+	if !ok {
+		return nil, false
+	}
+	served = &servedInsight{stored: true}
+	if err := json.Unmarshal(record.Insight, &served.insight); err != nil {
+		return nil, false
+	}
+	json.Unmarshal(record.Snapshot, &served.snapshot)
+	followUps, err := ws.store.GetFollowUps(id)
+	if err != nil {
+		log.Printf("Failed to load follow-ups of insight %s: %v", id, err)
+	}
+	for _, followUp := range followUps {
+		served.thread = append(served.thread, ai.Exchange{
+			Question: followUp.Question,
+			Answer:   followUp.Answer,
+			Tier:     followUp.Tier,
+			AskedAt:  followUp.AskedAt,
+		})
+	}
+
+	ws.insightsMutex.Lock()
+	defer ws.insightsMutex.Unlock()
+	if existing, ok := ws.servedInsights[id]; ok {
+		return existing, true
+	}
+	ws.servedInsights[id] = served
+	ws.insightOrder = append(ws.insightOrder, id)
+	return served, true
+}
+
+// handleInsightActions serves /api/insights/{id}/followup
+func (ws *WebServer) handleInsightActions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/insights/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "followup" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	served, ok := ws.findInsight(parts[0])
+	if !ok {
+		http.Error(w, "Insight not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		ws.insightsMutex.Lock()
+		thread := InsightThread{Insight: served.insight, FollowUps: append([]ai.Exchange{}, served.thread...)}
+		ws.insightsMutex.Unlock()
+		json.NewEncoder(w).Encode(thread)
+
+	case "POST":
+		var req FollowUpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Question) == "" {
+			http.Error(w, "Invalid JSON: 'question' is required", http.StatusBadRequest)
+			return
+		}
+		if ws.aiClient == nil {
+			http.Error(w, "AI is disabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		ws.insightsMutex.Lock()
+		followUp := ai.FollowUpContext{
+			Insight:  served.insight,
+			Snapshot: served.snapshot,
+			Thread:   append([]ai.Exchange{}, served.thread...),
+		}
+		ws.insightsMutex.Unlock()
+		followUp.History = ws.insightHistory(served.insight)
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+		defer cancel()
+		exchange, err := ws.aiClient.FollowUp(ctx, followUp, strings.TrimSpace(req.Question))
+		if err != nil {
+			log.Printf("Insight follow-up failed: %v", err)
+			http.Error(w, "AI is unavailable, try again later", http.StatusBadGateway)
+			return
+		}
+
+		ws.insightsMutex.Lock()
+		served.thread = append(served.thread, exchange)
+		ws.insightsMutex.Unlock()
+		ws.persistFollowUp(served, exchange)
+
+		json.NewEncoder(w).Encode(exchange)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// insightHistory returns recent stored results of the endpoints an insight affects
+func (ws *WebServer) insightHistory(insight ai.Insight) []checker.CheckResult {
+	if ws.store == nil {
+		return nil
+	}
+	var history []checker.CheckResult
+	for _, url := range insight.AffectedEndpoints {
+		results, err := ws.store.GetRecentResults(url, followUpHistory)
+		if err != nil {
+			log.Printf("Failed to load history of %s: %v", url, err)
+			continue
+		}
+		history = append(history, results...)
+	}
+	return history
+}
+
+// persistFollowUp stores the insight on its first follow-up, then the exchange
+func (ws *WebServer) persistFollowUp(served *servedInsight, exchange ai.Exchange) {
+	if ws.store == nil {
+		return
+	}
+
+	ws.insightsMutex.Lock()
+	stored := served.stored
+	served.stored = true
+	ws.insightsMutex.Unlock()
+
+	id := served.insight.ID
+	if !stored {
+		insight, _ := json.Marshal(served.insight)
+		snapshot, _ := json.Marshal(served.snapshot)
+		if err := ws.store.SaveInsight(storage.InsightRecord{ID: id, Insight: insight, Snapshot: snapshot, CreatedAt: served.insight.GeneratedAt}); err != nil {
+			log.Printf("Failed to save insight %s: %v", id, err)
+			ws.insightsMutex.Lock()
+			served.stored = false
+			ws.insightsMutex.Unlock()
+			return
+		}
+	}
+	record := storage.FollowUpRecord{InsightID: id, Question: exchange.Question, Answer: exchange.Answer, Tier: exchange.Tier, AskedAt: exchange.AskedAt}
+	if err := ws.store.SaveFollowUp(record); err != nil {
+		log.Printf("Failed to save follow-up of insight %s: %v", id, err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api-monitor/internal/health"
+)
+
+// stuckCheckAfter is how long a check may run before the scheduler counts as
+// stuck; checks are bounded by their timeouts, so it is far beyond any
+const stuckCheckAfter = 5 * time.Minute
+
+// healthChecks are the dependencies behind /healthz and /readyz: the
+// scheduler, the database and, when enabled, the AI model
+func (ws *WebServer) healthChecks() []health.Check {
+	checks := []health.Check{
+		{Name: "scheduler", Required: true, Run: ws.checkScheduler},
+		{Name: "database", Required: ws.config.HealthRequireDatabase, Run: ws.checkDatabase},
+	}
+	if ws.aiClient != nil {
+		// Asked once a minute at most, probes come far more often
+		checks = append(checks, health.Check{Name: "ai", Required: ws.config.HealthRequireAI, Run: ws.aiClient.Ping, Every: time.Minute})
+	}
+	return checks
+}
+
+// checkScheduler fails when a check is stuck or checks start later than
+// SCHEDULER_LAG_THRESHOLD
+func (ws *WebServer) checkScheduler(ctx context.Context) error {
+	metrics := ws.selfMetrics()
+	if metrics.OldestInFlight > stuckCheckAfter {
+		return fmt.Errorf("a check has been running for %v", metrics.OldestInFlight.Round(time.Second))
+	}
+	if metrics.Lagging {
+		return fmt.Errorf("p95 scheduler lag %v exceeds %v", metrics.LagP95.Round(time.Millisecond), metrics.LagThreshold)
+	}
+	return nil
+}
+
+// checkDatabase fails when the database doesn't answer, or couldn't be
+// opened at startup, which only a restart retries
+func (ws *WebServer) checkDatabase(ctx context.Context) error {
+	if ws.store == nil {
+		return errors.New("database unavailable since startup")
+	}
+	return ws.store.WithContext(ctx).Ping()
+}
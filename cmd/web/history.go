@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// maxHistoryBuckets bounds how many buckets one history request may return
+const maxHistoryBuckets = 2000
+
+// LatencyHistory is an endpoint's latency and error rate over time
+type LatencyHistory struct {
+	URL     string                  `json:"url"`
+	From    time.Time               `json:"from"`
+	To      time.Time               `json:"to"`
+	Bucket  time.Duration           `json:"bucket"`
+	Buckets []storage.LatencyBucket `json:"buckets"`
+}
+
+// parseSpan parses a duration such as "90m" or "24h", also accepting days such as "7d"
+func parseSpan(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// handleHistory returns time-bucketed average, p50, p95 and p99 response
+// times and error rates of an endpoint, aggregated by the database so charts
+// don't need the raw results. ?window= (default 24h) ends now; ?bucket=
+// defaults to 5m.
+func (ws *WebServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	query := r.URL.Query()
+	url := query.Get("url")
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+
+	window, bucket := 24*time.Hour, 5*time.Minute
+	var err error
+	if value := query.Get("window"); value != "" {
+		if window, err = parseSpan(value); err != nil {
+			http.Error(w, "window: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("bucket"); value != "" {
+		if bucket, err = parseSpan(value); err != nil {
+			http.Error(w, "bucket: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if bucket < time.Second || bucket%time.Second != 0 {
+		http.Error(w, "bucket must be a whole number of seconds", http.StatusBadRequest)
+		return
+	}
+	if window/bucket > maxHistoryBuckets {
+		http.Error(w, fmt.Sprintf("window/bucket would exceed %d buckets, use a larger bucket", maxHistoryBuckets), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	buckets, err := store.GetLatencyBuckets(url, from, to, bucket)
+	if err != nil {
+		log.Printf("Failed to load history for %s: %v", url, err)
+		http.Error(w, "Failed to load history", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(LatencyHistory{URL: url, From: from, To: to, Bucket: bucket, Buckets: buckets})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// parseTimeRange reads the optional from/to query parameters (RFC 3339),
+// defaulting to the span before now
+func parseTimeRange(r *http.Request, span time.Duration) (time.Time, time.Time, error) {
+	to := time.Now().UTC()
+	from := to.Add(-span)
+	var err error
+	if value := r.URL.Query().Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fmt.Errorf("from must be an RFC 3339 timestamp")
+		}
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, fmt.Errorf("to must be an RFC 3339 timestamp")
+		}
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from.UTC(), to.UTC(), nil
+}
+
+// handleIncidents lists the outages of an endpoint, including incidents
+// imported from other uptime tools. Defaults to the last 30 days.
+func (ws *WebServer) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+	from, to, err := parseTimeRange(r, 30*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	incidents, err := store.GetIncidents(url, from, to)
+	if err != nil {
+		log.Printf("Failed to load incidents for %s: %v", url, err)
+		http.Error(w, "Failed to load incidents", http.StatusInternalServerError)
+		return
+	}
+	if incidents == nil {
+		incidents = []storage.Incident{}
+	}
+	json.NewEncoder(w).Encode(incidents)
+}
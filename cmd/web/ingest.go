@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/subtle"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"api-monitor/internal/ingest"
+)
+
+// maxRemoteWriteBytes caps the size of a compressed remote-write request
+const maxRemoteWriteBytes = 10 << 20
+
+// requireIngestToken protects the ingest endpoint with INGEST_TOKEN when it is
+// set, since remote-write clients can't log in to the dashboard
+func (ws *WebServer) requireIngestToken(next http.HandlerFunc) http.HandlerFunc {
+	if ws.config.IngestToken == "" {
+		return ws.requireAuth(next)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(ws.config.IngestToken)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleRemoteWrite accepts Prometheus remote-write requests and stores the
+// mapped series as check results, so external probes count towards latency
+// percentiles and uptime
+func (ws *WebServer) handleRemoteWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRemoteWriteBytes))
+	if err != nil {
+		http.Error(w, "Request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	series, err := ingest.DecodeRemoteWrite(body)
+	if err != nil {
+		// 4xx tells Prometheus not to retry a payload that will never decode
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mapping := ingest.Mapping{
+		URLLabel:           ws.config.IngestURLLabel,
+		LatencyMetric:      ws.config.IngestLatencyMetric,
+		AvailabilityMetric: ws.config.IngestAvailabilityMetric,
+	}
+	results := mapping.Results(series)
+	for _, result := range results {
+		ws.sketches.Add(result)
+		if err := ws.store.SaveResult(result); err != nil {
+			log.Printf("Failed to save ingested result for %s: %v", result.URL, err)
+			http.Error(w, "Failed to store results", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
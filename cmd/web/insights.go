@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/events"
+)
+
+// insightsRefreshTimeout bounds a background refresh of on-demand insights
+const insightsRefreshTimeout = time.Minute
+
+// InsightsResponse is the answer to GET /api/insights
+type InsightsResponse struct {
+	Insights    []ai.Insight `json:"insights"`
+	Refreshing  bool         `json:"refreshing"` // newer AI insights are being generated
+	GeneratedAt *time.Time   `json:"generatedAt,omitempty"`
+}
+
+// insightsCache is the last on-demand analysis, when AI_SCHEDULES is not set
+type insightsCache struct {
+	insights    []ai.Insight
+	generatedAt time.Time
+	refreshing  bool
+	mutex       sync.Mutex
+}
+
+// currentInsights serves the cached on-demand insights without waiting for
+// the model. Once they are older than AI_INSIGHTS_TTL a refresh starts in the
+// background and the old ones are served meanwhile; before the model has
+// answered once, the rule-based insights are.
+func (ws *WebServer) currentInsights() InsightsResponse {
+	results, eligible := ws.scopeResults(allTags)
+	if ws.aiClient == nil || len(eligible) == 0 {
+		now := time.Now()
+		return InsightsResponse{Insights: ws.analyze(context.Background(), results, eligible), GeneratedAt: &now}
+	}
+
+	cache := &ws.onDemand
+	cache.mutex.Lock()
+	if !cache.refreshing && time.Since(cache.generatedAt) >= ws.config.AIInsightsTTL {
+		cache.refreshing = true
+		go ws.refreshInsights()
+	}
+	response := InsightsResponse{Insights: cache.insights, Refreshing: cache.refreshing}
+	if cache.insights != nil {
+		generatedAt := cache.generatedAt
+		response.GeneratedAt = &generatedAt
+	}
+	cache.mutex.Unlock()
+
+	if response.Insights == nil {
+		now := time.Now()
+		response.Insights = ws.rememberInsights(ai.WithConfidence(ws.ruleBasedInsights(results, ws.endpointTrends(results)), ws.config.AIMinConfidence), results)
+		response.GeneratedAt = &now
+	}
+	return response
+}
+
+// refreshInsights asks the model for new on-demand insights, caches them and
+// pushes them to the event streams. A failed run caches the rule-based
+// fallback, so a model that is down is not retried before the TTL.
+func (ws *WebServer) refreshInsights() {
+	results, eligible := ws.scopeResults(allTags)
+	ctx, cancel := context.WithTimeout(context.Background(), insightsRefreshTimeout)
+	insights := ws.analyze(ctx, results, eligible)
+	cancel()
+	if insights == nil {
+		insights = []ai.Insight{}
+	}
+
+	now := time.Now()
+	ws.onDemand.mutex.Lock()
+	ws.onDemand.insights, ws.onDemand.generatedAt, ws.onDemand.refreshing = insights, now, false
+	ws.onDemand.mutex.Unlock()
+	ws.publishInsights("", insights, now)
+}
+
+// publishInsights announces a new analysis on /api/events and /api/stream
+func (ws *WebServer) publishInsights(tag string, insights []ai.Insight, at time.Time) {
+	event := events.Event{Type: events.TypeInsights, Tag: tag, Insights: insights, Timestamp: at}
+	ws.events.Publish(event)
+	ws.live.Publish(event)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// LatencyPercentiles summarizes an endpoint's latency over a time range
+type LatencyPercentiles struct {
+	URL   string        `json:"url"`
+	From  time.Time     `json:"from"`
+	To    time.Time     `json:"to"`
+	Count uint64        `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// handleLatency answers percentile queries from stored sketches, so long
+// ranges are served without scanning raw results
+func (ws *WebServer) handleLatency(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+
+	from, to, err := parseTimeRange(r, 24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sketch, err := store.LoadSketch(url, from, to)
+	if err != nil {
+		log.Printf("Failed to load latency sketches for %s: %v", url, err)
+		http.Error(w, "Failed to load latency data", http.StatusInternalServerError)
+		return
+	}
+	// Include the windows that have not been flushed yet
+	sketch.Merge(ws.sketches.Pending(url, from, to))
+
+	json.NewEncoder(w).Encode(LatencyPercentiles{
+		URL:   url,
+		From:  from,
+		To:    to,
+		Count: sketch.Count,
+		Mean:  sketch.Mean(),
+		P50:   sketch.Quantile(0.50),
+		P90:   sketch.Quantile(0.90),
+		P95:   sketch.Quantile(0.95),
+		P99:   sketch.Quantile(0.99),
+		Max:   sketch.Max,
+	})
+}
+
+// flushSketches periodically saves latency sketches whose window has closed
+func (ws *WebServer) flushSketches() {
+	interval := ws.sketches.Window()
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := ws.sketches.Flush(ws.store, time.Now().UTC()); err != nil {
+			log.Printf("Failed to save latency sketches: %v", err)
+		}
+	}
+}
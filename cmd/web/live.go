@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/events"
+
+	"golang.org/x/net/websocket"
+)
+
+// LiveMessage is one message on the /api/stream WebSocket. The first message
+// is a snapshot of every endpoint; later ones update or remove a single endpoint,
+// or carry new AI insights.
+type LiveMessage struct {
+	Type       string           `json:"type"` // "snapshot", "status", "removed", "insights" or "heartbeat"
+	Statuses   []EndpointStatus `json:"statuses,omitempty"`
+	Status     *EndpointStatus  `json:"status,omitempty"`
+	EndpointID string           `json:"endpointId,omitempty"`
+	Tag        string           `json:"tag,omitempty"` // scope of scheduled insights
+	Insights   []ai.Insight     `json:"insights,omitempty"`
+}
+
+// handleLiveStream upgrades to a WebSocket that pushes each check result as
+// it completes, so the dashboard does not have to poll /api/status
+func (ws *WebServer) handleLiveStream(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{
+		Handshake: sameOrigin,
+		Handler:   ws.streamLive,
+	}
+	server.ServeHTTP(w, r)
+}
+
+// sameOrigin rejects cross-site upgrades, which would otherwise ride on the
+// dashboard's session cookie. Clients that send no Origin (e.g. CLIs) are allowed.
+func sameOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host != r.Host {
+		return websocket.ErrBadWebSocketOrigin
+	}
+	config.Origin = parsed
+	return nil
+}
+
+// streamLive writes the snapshot and then every result until the client leaves
+func (ws *WebServer) streamLive(conn *websocket.Conn) {
+	defer conn.Close()
+
+	// Subscribe before taking the snapshot so no result falls in between
+	eventsCh, unsubscribe := ws.live.Subscribe(256)
+	defer unsubscribe()
+
+	if err := websocket.JSON.Send(conn, LiveMessage{Type: "snapshot", Statuses: ws.statuses()}); err != nil {
+		return
+	}
+
+	// The client sends nothing; reading only detects when it disconnects
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var ignored string
+		for websocket.Message.Receive(conn, &ignored) == nil {
+		}
+	}()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var message LiveMessage
+		select {
+		case event, ok := <-eventsCh:
+			if !ok {
+				return
+			}
+			message = ws.liveMessage(event)
+		case <-heartbeat.C:
+			message = LiveMessage{Type: "heartbeat"}
+		case <-closed:
+			return
+		}
+		if message.Type == "" {
+			continue
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := websocket.JSON.Send(conn, message); err != nil {
+			return
+		}
+	}
+}
+
+// liveMessage converts a broker event into a stream message
+func (ws *WebServer) liveMessage(event events.Event) LiveMessage {
+	switch event.Type {
+	case events.TypeResult:
+		endpoint, ok := ws.scheduler.Get(event.EndpointID)
+		if !ok || event.Result == nil {
+			return LiveMessage{}
+		}
+		paused := ws.scheduler.IsPaused(endpoint)
+		status := newEndpointStatus(endpoint, *event.Result, paused)
+		ws.describeState(&status, endpointSnapshot{endpoint: endpoint, result: *event.Result, hasResult: true, paused: paused}.state())
+		return LiveMessage{Type: "status", Status: &status}
+	case events.TypeEndpointRemoved:
+		return LiveMessage{Type: "removed", EndpointID: event.EndpointID}
+	case events.TypeInsights:
+		return LiveMessage{Type: "insights", Tag: event.Tag, Insights: event.Insights}
+	}
+	return LiveMessage{}
+}
@@ -3,57 +3,473 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"api-monitor/internal/agents"
 	"api-monitor/internal/ai"
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/anomaly"
+	"api-monitor/internal/auth"
+	"api-monitor/internal/cache"
 	"api-monitor/internal/checker"
 	"api-monitor/internal/config"
+	"api-monitor/internal/cost"
+	"api-monitor/internal/drift"
+	"api-monitor/internal/events"
+	"api-monitor/internal/geoip"
+	"api-monitor/internal/health"
+	"api-monitor/internal/output"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/stats"
+	"api-monitor/internal/storage"
+	"api-monitor/internal/tlsutil"
+	"api-monitor/internal/tracing"
 )
 
 type WebServer struct {
 	checker   *checker.HTTPChecker
+	custom    map[string]checker.Checker // registered non-HTTP check types
 	aiClient  *ai.GPTOSSClient
-	urls      []string
-	urlsMutex sync.RWMutex
+	scheduler *scheduler.Scheduler
+	store     storage.Store // nil when the database is unavailable
+	sampler   *storage.Sampler
+	coalescer *storage.Coalescer
+	sketches  *storage.SketchRecorder
+	cache     cache.Cache // shared with other replicas when Redis is configured
 	config    *config.Config
+
+	events      *events.Broker
+	live        *events.Broker // every check result, for /api/stream
+	transitions *events.TransitionDetector
+	alerts      *alerting.Dispatcher
+	metricRules *alerting.MetricEngine
+	downAlerts  *alerting.HealthTracker  // nil unless ALERTING_ENABLED
+	staleAlerts *alerting.StaleTracker   // nil unless STALE_ALERTS
+	anomalies   *anomaly.Detector        // nil when ANOMALY_SIGMA is 0
+	slowAlerts  *alerting.AnomalyTracker // nil unless ALERTING_ENABLED and ANOMALY_ALERT_AFTER
+	burnRates   *alerting.BurnRateEngine
+
+	// Scheduled AI analyses by tag, when AI_SCHEDULES is set
+	analyses      map[string]*TagAnalysis
+	analysesMutex sync.RWMutex
+
+	// Recently served insights, for follow-up questions
+	servedInsights map[string]*servedInsight
+	insightOrder   []string
+	insightsMutex  sync.Mutex
+	
+	onDemand insightsCache // insights served on request, without AI_SCHEDULES
+
+	sessions     *auth.SessionManager
+	loginLimiter *auth.LoginLimiter
+	geo          *geoip.Resolver
+	drift        *drift.Detector
+	agents       *agents.Registry // nil unless AGENT_TOKEN is set
+	apiKeysUsed  sync.Map         // API key ID → when its last use was recorded
+	costs        *cost.Tracker
+	health       *health.Prober // behind /healthz and /readyz
+
+	// stopTracing flushes and stops the span exporter, nil unless
+	// OTEL_EXPORTER_OTLP_ENDPOINT is set
+	stopTracing func(context.Context) error
+
+	// Debug captures: when capturing each endpoint's checks ends
+	captures      map[string]time.Time
+	capturesMutex sync.Mutex
+
+	// Hot reload: the configuration as last loaded, while config stays the
+	// one the server started with, and the lock serializing reloads
+	reloaded      *config.Config
+	reloadedMutex sync.RWMutex
+	reloadMutex   sync.Mutex
 }
 
 type EndpointStatus struct {
-	URL          string        `json:"url"`
-	IsHealthy    bool          `json:"isHealthy"`
-	StatusCode   int           `json:"statusCode"`
-	ResponseTime time.Duration `json:"responseTime"`
-	LastChecked  time.Time     `json:"lastChecked"`
-	Error        string        `json:"error,omitempty"`
+	ID              string        `json:"id"`
+	URL             string        `json:"url"`
+	IsHealthy       bool          `json:"isHealthy"`
+	StatusCode      int           `json:"statusCode"`
+	ResponseTime    time.Duration `json:"responseTime"`
+	TTFB            time.Duration `json:"ttfb"`
+	DNSLookup       time.Duration `json:"dnsLookup"`
+	TCPConnect      time.Duration `json:"tcpConnect"`
+	TLSHandshake    time.Duration `json:"tlsHandshake"`
+	BodyDownload    time.Duration `json:"bodyDownload"`
+	LastChecked     time.Time     `json:"lastChecked"`
+	Error           string        `json:"error,omitempty"`
+	RemoteIP        string        `json:"remoteIp,omitempty"`
+	Country         string        `json:"country,omitempty"`
+	ASN             uint          `json:"asn,omitempty"`
+	ASOrg           string        `json:"asOrg,omitempty"`
+	ThroughputMBps  float64       `json:"throughputMBps,omitempty"`
+	BytesDownloaded int64         `json:"bytesDownloaded,omitempty"`
+	Tags            []string      `json:"tags,omitempty"`
+	Paused          bool          `json:"paused,omitempty"`
+	Disabled        bool          `json:"disabled,omitempty"`
+
+	// State is healthy, unhealthy, paused, disabled or unknown (not checked
+	// yet, or Stale: no result for STALE_AFTER_INTERVALS scheduled checks).
+	// StateSince is when the endpoint last changed health, or when this
+	// instance first checked it.
+	Stale               bool          `json:"stale,omitempty"`
+	State               string        `json:"state"`
+	StateSince          *time.Time    `json:"stateSince,omitempty"`
+	StateDuration       time.Duration `json:"stateDuration,omitempty"`
+	ConsecutiveFailures int           `json:"consecutiveFailures"`
+	Uptime              *float64      `json:"uptime,omitempty"` // percent over ?uptime=, when asked for
+
+	Metrics map[string]float64 `json:"metrics,omitempty"` // values extracted from the last response
+	Labels  map[string]string  `json:"labels,omitempty"`  // static labels of the instance that checked it
 }
 
 type EndpointRequest struct {
-	URL string `json:"url"`
+	URL        string `json:"url"`
+	Throughput bool   `json:"throughput,omitempty"`
+	Cron       string `json:"cron,omitempty"`     // replaces the default interval
+	Timezone   string `json:"timezone,omitempty"` // IANA timezone for Cron
+	Tags       []string `json:"tags,omitempty"`
+
+	// Request to send instead of a plain GET, e.g. POST with a JSON body
+	Method      string `json:"method,omitempty"`
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// Head checks only availability with HEAD, falling back to GET when the
+	// server rejects it, for endpoints with large responses
+	Head bool `json:"head,omitempty"`
+
+	// Headers for authenticated APIs, e.g. {"Authorization": "Bearer ..."}.
+	// Values are never returned by GET /api/endpoints.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Type selects a custom checker registered with checker.Register
+	Type    string            `json:"type,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+
+	// Numeric fields to extract from JSON responses, e.g. {"queue_depth": "$.queue_depth"},
+	// optionally bounded by thresholds such as {"queue_depth": {"max": 1000}}
+	Extract    map[string]string            `json:"extract,omitempty"`
+	Thresholds map[string]checker.Threshold `json:"thresholds,omitempty"`
+
+	Sampling *storage.SamplingPolicy `json:"sampling,omitempty"`
+
+	// Per-endpoint overrides of CHECK_INTERVAL and REQUEST_TIMEOUT as durations
+	// such as "30s". Enabled false registers the endpoint without checking it.
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+	Enabled  *bool  `json:"enabled,omitempty"`
+
+	// AIAnalysis false leaves the endpoint out of AI analysis to save tokens
+	AIAnalysis *bool `json:"aiAnalysis,omitempty"`
+
+	// EmailRoutes send the endpoint's alerts to specific recipients,
+	// e.g. [{"to": ["oncall@example.com"], "severity": "critical"}]
+	EmailRoutes []alerting.EmailRoute `json:"emailRoutes,omitempty"`
+
+	// RecoveryWebhook is posted the outage duration, failed checks, first
+	// and last error and incident link when the endpoint recovers, e.g.
+	// {"url": "https://tickets.example.com/hooks/recovered", "secret": "..."}
+	RecoveryWebhook *alerting.RecoveryWebhook `json:"recoveryWebhook,omitempty"`
+
+	// Runbook is a link to how the endpoint's alerts are handled, shown in
+	// alert templates as {{.Runbook}}
+	Runbook string `json:"runbook,omitempty"`
+
+	// Source binds HTTP checks to a local IP address or network interface
+	// of this host, e.g. "10.0.1.5" or "eth1"
+	Source string `json:"source,omitempty"`
+
+	// DependsOn names the endpoints (by URL or ID) this one depends on,
+	// e.g. a database health check behind an API, for GET /api/topology
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// SLA commits to an availability per billing period for
+	// GET /api/sla/statement, e.g. {"target": 99.9, "exclusions": ["maintenance"]}
+	SLA *sla.Contract `json:"sla,omitempty"`
+
+	// SLO sets an internal objective whose error budget is watched by burn
+	// rate alerts, e.g. {"target": 99.9, "window": "30d"}
+	SLO *sla.Objective `json:"slo,omitempty"`
+
+	// Agents chooses which remote agents check the endpoint, e.g.
+	// {"strategy": "region-affinity", "regions": ["eu-west-1"]}
+	Agents *agents.Placement `json:"agents,omitempty"`
+}
+
+// endpointTiming parses the optional per-endpoint interval and timeout.
+// Intervals may go down to minInterval; sub-second endpoints without an
+// explicit timeout time out at their interval so checks don't pile up.
+func endpointTiming(req EndpointRequest, defaultInterval, minInterval time.Duration) (time.Duration, time.Duration, error) {
+	interval := defaultInterval
+	if value := strings.TrimSpace(req.Interval); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("interval must be a duration such as \"30s\"")
+		}
+		if parsed < minInterval {
+			return 0, 0, fmt.Errorf("interval must be at least %v", minInterval)
+		}
+		interval = parsed
+	}
+
+	var timeout time.Duration
+	if value := strings.TrimSpace(req.Timeout); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf("timeout must be a positive duration such as \"5s\"")
+		}
+		if parsed > interval && strings.TrimSpace(req.Cron) == "" {
+			return 0, 0, fmt.Errorf("timeout must not exceed the interval (%v)", interval)
+		}
+		timeout = parsed
+	} else if interval < time.Second && strings.TrimSpace(req.Cron) == "" {
+		timeout = interval
+	}
+
+	return interval, timeout, nil
 }
 
 func NewWebServer() *WebServer {
 	cfg := config.Load()
+	output.SetStyle(cfg.OutputStyle) // also from CONFIG_FILE or the profile
+	for _, loadErr := range cfg.LoadErrors {
+		log.Printf("Configuration problem: %s", loadErr)
+	}
 	
 	var aiClient *ai.GPTOSSClient
     if cfg.AIEnabled {
         aiClient = ai.NewGPTOSSClient(cfg.AIBaseURL, cfg.AIAPIKey, cfg.AIModel)
+		aiClient.SetStatsMode(cfg.StatsMode, cfg.StatsTrimPercent)
+		aiClient.SetTierTimeout(cfg.AITierTimeout)
+		for _, fallback := range cfg.AIFallbacks {
+			aiClient.AddFallback(ai.Tier{Name: fallback.Name, Model: fallback.Model, BaseURL: fallback.BaseURL, APIKey: fallback.APIKey})
+		}
     }
 	
-	return &WebServer{
-		checker:  checker.NewHTTPChecker(cfg.RequestTimeout),
-		aiClient: aiClient,
-		config:   cfg,
-		urls: []string{
-			"https://api.github.com/users/octocat",
-			"https://jsonplaceholder.typicode.com/posts/1",
-			"https://httpbin.org/status/200",
-			"https://httpbin.org/delay/2",
-		},
+	geo, err := geoip.NewResolver(cfg.GeoIPCountryDB, cfg.GeoIPASNDB)
+	if err != nil {
+		log.Printf("GeoIP databases unavailable, resolving IPs only: %v", err)
+		geo, _ = geoip.NewResolver("", "")
+	}
+	
+	httpChecker := checker.NewHTTPChecker(cfg.RequestTimeout)
+	httpChecker.SetMaxPayloadBytes(cfg.ThroughputMaxBytes)
+	httpChecker.SetMaxConcurrency(cfg.MaxConcurrency)
+	// Middleware shared by every check type
+	checkMiddleware := []checker.Middleware{checker.RedactSecrets(), checker.StaticLabels(cfg.Labels), cfg.SecretResolver.Middleware()}
+	httpChecker.Use(checkMiddleware...)
+	
+	store, err := storage.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Database unavailable, check results will not be persisted: %v", err)
+		store = nil
+	} else if signer, err := cfg.ResultSigner(); err != nil {
+		log.Fatalf("Invalid RESULT_SIGNING_KEY: %v", err)
+	} else if signer != nil {
+		store.SetSigner(signer)
+		log.Printf("Signing stored results with %s key %s", signer.Algorithm(), signer.ID())
+	}
+	
+	sharedCache, err := cache.New(cfg.RedisURL)
+	if err != nil {
+		log.Printf("Shared cache unavailable, falling back to in-memory (sessions and limits are per replica): %v", err)
+		sharedCache = cache.NewMemory()
+	}
+	
+	var stopTracing func(context.Context) error
+	if cfg.TracingEndpoint != "" {
+		stopTracing, err = tracing.Setup(tracing.Options{
+			Endpoint:    cfg.TracingEndpoint,
+			Headers:     cfg.TracingHeaders,
+			ServiceName: cfg.TracingServiceName,
+			SampleRatio: float64(cfg.TracingSamplePercent) / 100,
+		})
+		if err != nil {
+			log.Printf("Tracing disabled: %v", err)
+		}
+	}
+	
+	ws := &WebServer{
+		checker:      httpChecker,
+		custom:       newCustomCheckers(cfg.RequestTimeout, checkMiddleware),
+		aiClient:     aiClient,
+		store:        store,
+		config:       cfg,
+		cache:        sharedCache,
+		sessions:     auth.NewSessionManager(sharedCache, cfg.SessionTTL),
+		loginLimiter: auth.NewLoginLimiter(sharedCache, cfg.LoginMaxAttempts, cfg.LoginLockout),
+		geo:          geo,
+		drift:        drift.NewDetector(),
+		sampler:      storage.NewSampler(),
+		coalescer:    storage.NewCoalescer(),
+		sketches:     storage.NewSketchRecorder(cfg.SketchWindow),
+		events:       events.NewBroker(),
+		live:         events.NewBroker(),
+		transitions:  events.NewTransitionDetector(),
+		alerts:       alerting.NewDispatcher(),
+		analyses:     make(map[string]*TagAnalysis),
+		costs:        cost.NewTracker(time.Now()),
+		captures:     make(map[string]time.Time),
+		reloaded:     cfg,
+		stopTracing:  stopTracing,
+		
+		servedInsights: make(map[string]*servedInsight),
+	}
+	if aiClient != nil {
+		aiClient.OnUsage(ws.costs.AddTokens)
+	}
+	ws.health = health.NewProber(cfg.HealthLivenessGrace, ws.healthChecks()...)
+	ws.metricRules = alerting.NewMetricEngine(ws.alerts)
+	ws.burnRates = alerting.NewBurnRateEngine(ws.alerts, cfg.SLOBurnRules)
+	if cfg.StaleAlerts && cfg.StaleAfterIntervals > 0 {
+		ws.staleAlerts = alerting.NewStaleTracker(ws.alerts)
+	}
+	if cfg.AnomalySigma > 0 {
+		var history anomaly.History
+		if store != nil {
+			history = store.GetRecentResults
+		}
+		ws.anomalies = anomaly.NewDetector(cfg.LatencyMetric, cfg.AnomalySigma, cfg.AnomalyWindow, history)
+		if cfg.AlertingEnabled && cfg.AnomalyAlertAfter > 0 {
+			ws.slowAlerts = alerting.NewAnomalyTracker(ws.alerts, cfg.AnomalyAlertAfter)
+		}
+	}
+	ws.alerts.Replace(ws.notifiers(cfg))
+	if cfg.AlertingEnabled {
+		var alertStates alerting.StateStore
+		if store != nil {
+			alertStates = store
+		}
+		ws.downAlerts = alerting.NewHealthTracker(ws.alerts, healthPolicy(cfg), alertStates)
+		ws.downAlerts.OnRecovery(ws.recordOutage)
+	}
+	ws.scheduler = scheduler.New(ws.runCheck)
+	ws.scheduler.OnResult(ws.recordResult)
+	if cfg.AgentToken != "" && cfg.AgentHeartbeatInterval > 0 {
+		ws.agents = agents.NewRegistry(cfg.AgentHeartbeatInterval, cfg.AgentMissedHeartbeats)
+		ws.scheduler.SetDelegate(func(endpoint scheduler.Endpoint) bool {
+			return ws.agents.Delegated(endpoint.ID)
+		})
+	}
+	ws.scheduler.SetPaused(cfg.MonitoringPaused)
+	for _, tag := range cfg.PausedTags {
+		ws.scheduler.SetTagPaused(tag, true)
+	}
+	
+	throughputURLs := make(map[string]bool)
+	for _, url := range cfg.ThroughputURLs {
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+		throughputURLs[url] = true
+	}
+	
+	loaded := ws.loadEndpoints(throughputURLs)
+	if cfg.EndpointsFile != "" {
+		if _, problems := ws.applyEndpointsFile(cfg.EndpointsFile, cfg.CheckInterval); len(problems) > 0 {
+			log.Printf("Endpoints file not applied: %s", strings.Join(problems, "; "))
+		}
+		return ws
+	}
+	if loaded {
+		return ws
+	}
+	
+	// First start: monitor a few public APIs and persist them like any other endpoint
+	urls := []string{
+		"https://api.github.com/users/octocat",
+		"https://jsonplaceholder.typicode.com/posts/1",
+		"https://httpbin.org/status/200",
+		"https://httpbin.org/delay/2",
+	}
+	for _, url := range urls {
+		url, _ = checker.NormalizeURL(url)
+		endpoint := scheduler.Endpoint{
+			ID:         scheduler.EndpointID(url),
+			URL:        url,
+			Interval:   cfg.CheckInterval,
+			Throughput: throughputURLs[url],
+		}
+		if owned, _ := ws.grpcOwned(endpoint.ID); owned {
+			continue // monitored by the gRPC server
+		}
+		ws.scheduler.Add(endpoint)
+		if err := ws.persistEndpoint(endpoint); err != nil {
+			log.Printf("Failed to persist endpoint %s: %v", url, err)
+		}
+	}
+	
+	return ws
+}
+
+// notifiers builds the alert channels a configuration enables: the event
+// stream always, and the configured channels when alerting is enabled
+func (ws *WebServer) notifiers(cfg *config.Config) []alerting.Notifier {
+	notifiers := []alerting.Notifier{alerting.NotifierFunc{ChannelName: "events", Func: ws.publishAlert}}
+	if !cfg.AlertingEnabled {
+		return notifiers
+	}
+	if cfg.SlackWebhook != "" {
+		slack := alerting.NewSlackNotifier(cfg.SlackWebhook)
+		if cfg.SlackTemplate != "" {
+			if err := slack.SetTemplate(cfg.SlackTemplate, ws.templateData); err != nil {
+				log.Printf("Using the default Slack message: %v", err)
+			}
+		}
+		notifiers = append(notifiers, slack)
+	}
+	if cfg.EmailFrom != "" {
+		email := alerting.NewEmailNotifier(alerting.SMTPConfig{
+			Host:     cfg.EmailSMTPHost,
+			Port:     cfg.EmailSMTPPort,
+			Username: cfg.EmailUsername,
+			Password: cfg.EmailPassword,
+			From:     cfg.EmailFrom,
+		}, cfg.EmailTo, ws.emailRoutes)
+		if cfg.EmailTemplate != "" {
+			if err := email.SetTemplate(cfg.EmailTemplate, ws.templateData); err != nil {
+				log.Printf("Using the default email body: %v", err)
+			}
+		}
+		notifiers = append(notifiers, email)
+	}
+	if cfg.PagerDutyRoutingKey != "" {
+		notifiers = append(notifiers, alerting.Sustained(alerting.NewPagerDutyNotifier(cfg.PagerDutyRoutingKey, cfg.PagerDutyEventsURL), cfg.PageAfter))
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		notifiers = append(notifiers, alerting.Sustained(alerting.NewOpsgenieNotifier(cfg.OpsgenieAPIKey, cfg.OpsgenieAPIURL), cfg.PageAfter))
+	}
+	for _, webhook := range cfg.Webhooks {
+		notifier, err := alerting.NewWebhookNotifier(webhook.Name, webhook.URL, webhook.Secret, webhook.Template)
+		if err != nil {
+			log.Printf("Skipping webhook: %v", err)
+			continue
+		}
+		notifier.SetTemplateContext(ws.templateData)
+		notifiers = append(notifiers, notifier)
+	}
+	return append(notifiers, alerting.NewRecoveryNotifier(ws.recoveryWebhook))
+}
+
+// healthPolicy is the downtime alert policy a configuration sets
+func healthPolicy(cfg *config.Config) alerting.HealthPolicy {
+	return alerting.HealthPolicy{
+		FailuresBeforeAlert: cfg.AlertAfterFailures,
+		Escalation:          cfg.AlertEscalation,
+		Renotify:            cfg.AlertRenotify,
+		FlapWindow:          cfg.FlapWindow,
+		FlapThreshold:       cfg.FlapThreshold,
 	}
 }
 
@@ -69,27 +485,395 @@ func (ws *WebServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ws.urlsMutex.RLock()
-	urls := make([]string, len(ws.urls))
-	copy(urls, ws.urls)
-	ws.urlsMutex.RUnlock()
+	query, err := parseStatusQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.uptime != nil && ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	statuses, err := ws.filterStatuses(query)
+	if err != nil {
+		log.Printf("Failed to compute uptime for /api/status: %v", err)
+		http.Error(w, "Failed to compute uptime", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(statuses)
+}
 
-	results := ws.checker.CheckMultiple(urls)
-	
-	var statuses []EndpointStatus
-	for _, result := range results {
-		status := EndpointStatus{
-			URL:          result.URL,
-			IsHealthy:    result.IsHealthy,
-			StatusCode:   result.StatusCode,
-			ResponseTime: result.ResponseTime,
-			LastChecked:  result.CheckedAt,
-			Error:        result.Error,
-		}
+// statuses returns the current status of every endpoint
+func (ws *WebServer) statuses() []EndpointStatus {
+	statuses := []EndpointStatus{}
+	for _, current := range ws.snapshot() {
+		status := newEndpointStatus(current.endpoint, current.result, current.paused)
+		status.Stale = current.stale
+		ws.describeState(&status, current.state())
 		statuses = append(statuses, status)
 	}
+	return statuses
+}
 
-	json.NewEncoder(w).Encode(statuses)
+// describeState fills in a status's state, how long it has lasted and the
+// consecutive failures
+func (ws *WebServer) describeState(status *EndpointStatus, state string) {
+	status.State = state
+	if transition, ok := ws.transitions.Current(status.ID); ok {
+		since := transition.Since
+		status.StateSince = &since
+		status.StateDuration = time.Since(since)
+		status.ConsecutiveFailures = transition.Failures
+	}
+}
+
+// newEndpointStatus describes an endpoint and its latest result
+func newEndpointStatus(endpoint scheduler.Endpoint, result checker.CheckResult, paused bool) EndpointStatus {
+	return EndpointStatus{
+		ID:              endpoint.ID,
+		URL:             endpoint.URL,
+		Tags:            endpoint.Tags,
+		Paused:          paused,
+		Disabled:        endpoint.Disabled,
+		IsHealthy:       result.IsHealthy,
+		StatusCode:      result.StatusCode,
+		ResponseTime:    result.ResponseTime,
+		TTFB:            result.TTFB,
+		DNSLookup:       result.DNSLookup,
+		TCPConnect:      result.TCPConnect,
+		TLSHandshake:    result.TLSHandshake,
+		BodyDownload:    result.BodyDownload,
+		LastChecked:     result.CheckedAt,
+		Error:           result.Error,
+		RemoteIP:        result.RemoteIP,
+		Country:         result.Country,
+		ASN:             result.ASN,
+		ASOrg:           result.ASOrg,
+		ThroughputMBps:  result.ThroughputMBps,
+		BytesDownloaded: result.BytesDownloaded,
+		Metrics:         result.Metrics,
+		Labels:          result.Labels,
+	}
+}
+
+// runCheck performs a single check for the scheduler
+func (ws *WebServer) runCheck(endpoint scheduler.Endpoint) checker.CheckResult {
+	if endpoint.Type != "" && endpoint.Type != checker.TypeHTTP {
+		custom, ok := ws.custom[endpoint.Type]
+		if !ok {
+			return checker.CheckResult{
+				URL:       endpoint.URL,
+				CheckedAt: time.Now(),
+				Error:     fmt.Sprintf("check type %q is not available", endpoint.Type),
+			}
+		}
+		return custom.CheckWith(endpoint.Spec())
+	}
+	spec := endpoint.Spec()
+	spec.Capture = ws.capturing(endpoint.ID)
+	if endpoint.Throughput {
+		return ws.checker.CheckThroughputWith(spec)
+	}
+	return ws.checker.CheckWith(spec)
+}
+
+// newCustomCheckers instantiates every registered check type other than HTTP,
+// wrapped in middleware
+func newCustomCheckers(timeout time.Duration, middleware []checker.Middleware) map[string]checker.Checker {
+	custom := make(map[string]checker.Checker)
+	for _, name := range checker.Types() {
+		if name == checker.TypeHTTP {
+			continue
+		}
+		factory, _ := checker.Lookup(name)
+		c, err := factory(timeout)
+		if err != nil {
+			log.Printf("Check type %q unavailable: %v", name, err)
+			continue
+		}
+		custom[name] = checker.Wrap(c, middleware...)
+	}
+	return custom
+}
+
+// recordResult enriches, analyzes and persists every completed check
+func (ws *WebServer) recordResult(endpoint scheduler.Endpoint, result *checker.CheckResult) {
+	ws.geo.Enrich(result)
+
+	for _, change := range ws.drift.Observe(*result) {
+		log.Printf("⚠️ Drift detected on %s: %s changed from %q to %q",
+			change.URL, change.Field, change.Previous, change.Current)
+		if ws.currentConfig().AlertingEnabled {
+			ws.alerts.Dispatch(alerting.DriftAlert(endpoint.ID, change))
+		}
+	}
+	if ws.anomalies != nil {
+		result.Anomaly = ws.anomalies.Observe(*result)
+	}
+
+	ws.publishStatus(endpoint, *result)
+	if ws.live.Subscribers() > 0 {
+		resultCopy := *result
+		ws.live.Publish(events.Event{Type: events.TypeResult, EndpointID: endpoint.ID, URL: endpoint.URL, Result: &resultCopy})
+	}
+
+	if previous, current, changed := ws.transitions.Observe(endpoint.ID, *result); changed {
+		resultCopy := *result
+		ws.events.Publish(events.Event{
+			Type:       events.TypeStateChange,
+			EndpointID: endpoint.ID,
+			URL:        endpoint.URL,
+			Previous:   previous,
+			Current:    current,
+			Result:     &resultCopy,
+		})
+	}
+
+	ws.metricRules.Observe(endpoint.ID, *result)
+	if ws.downAlerts != nil {
+		ws.downAlerts.Observe(endpoint.ID, *result)
+	}
+	if ws.staleAlerts != nil {
+		ws.staleAlerts.Update(endpoint.ID, endpoint.URL, false, result.CheckedAt, time.Now())
+	}
+	if ws.slowAlerts != nil {
+		ws.slowAlerts.Observe(endpoint.ID, *result)
+	}
+
+	usage := cost.Usage{Checks: 1, BytesReceived: result.ResponseBytes}
+	if endpoint.Type == "" || endpoint.Type == checker.TypeHTTP {
+		usage.BytesSent = int64(len(endpoint.Body))
+	}
+	defer func() { ws.costs.Add(endpoint.URL, usage) }()
+
+	if ws.store == nil {
+		return
+	}
+	if result.Capture != nil && ws.saveCapture(endpoint, *result) {
+		usage.RowsWritten++
+	}
+
+	// Sketches see every result, so percentiles stay accurate under
+	// sampling and coalescing
+	ws.sketches.Add(*result)
+
+	// Sub-second endpoints store one aggregate per second instead of every sample
+	stored := *result
+	if endpoint.Realtime() {
+		aggregate, ok := ws.coalescer.Add(*result)
+		if !ok {
+			return
+		}
+		stored = aggregate
+	}
+	usage.RowsWritten += ws.saveResult(endpoint, stored)
+}
+
+// saveResult stores a result's extracted metrics, and the result itself
+// unless sampling drops it, returning the rows written
+func (ws *WebServer) saveResult(endpoint scheduler.Endpoint, stored checker.CheckResult) int64 {
+	var rows int64
+	// Extracted metrics are never sampled
+	if err := ws.store.SaveMetrics(stored); err != nil {
+		log.Printf("Failed to save metrics for %s: %v", stored.URL, err)
+	} else {
+		rows += int64(len(stored.Metrics))
+	}
+
+	if ws.sampler.ShouldStore(ws.samplingPolicy(endpoint), stored) {
+		if err := ws.store.SaveResult(stored); err != nil {
+			log.Printf("Failed to save result for %s: %v", stored.URL, err)
+		} else {
+			rows++
+		}
+	}
+	return rows
+}
+
+// statusSnapshotTTL bounds how long a replica's published status stays visible
+const statusSnapshotTTL = time.Hour
+
+// publishStatus shares the latest result with other replicas
+func (ws *WebServer) publishStatus(endpoint scheduler.Endpoint, result checker.CheckResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := ws.cache.Set(context.Background(), "status:"+endpoint.ID, data, statusSnapshotTTL); err != nil {
+		log.Printf("Failed to publish status for %s: %v", endpoint.URL, err)
+	}
+}
+
+// latestResult returns the newest result for an endpoint known to this
+// replica or published by any other replica
+func (ws *WebServer) latestResult(endpoint scheduler.Endpoint) (checker.CheckResult, bool) {
+	local, hasLocal := ws.scheduler.Latest(endpoint.ID)
+
+	data, exists, err := ws.cache.Get(context.Background(), "status:"+endpoint.ID)
+	if err != nil || !exists {
+		return local, hasLocal
+	}
+
+	var shared checker.CheckResult
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return local, hasLocal
+	}
+	if !hasLocal || shared.CheckedAt.After(local.CheckedAt) {
+		return shared, true
+	}
+	return local, true
+}
+
+// emailRoutes returns the email routes of an endpoint for the email notifier
+func (ws *WebServer) emailRoutes(endpointID string) []alerting.EmailRoute {
+	endpoint, ok := ws.scheduler.Get(endpointID)
+	if !ok {
+		return nil
+	}
+	return endpoint.EmailRoutes
+}
+
+// realtimeEndpoints counts the endpoints checked at sub-second intervals
+func (ws *WebServer) realtimeEndpoints() int {
+	count := 0
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.Realtime() {
+			count++
+		}
+	}
+	return count
+}
+
+// samplingPolicy returns the endpoint's storage sampling policy or the global default
+func (ws *WebServer) samplingPolicy(endpoint scheduler.Endpoint) storage.SamplingPolicy {
+	if endpoint.Sampling != nil {
+		return *endpoint.Sampling
+	}
+	return storage.SamplingPolicy{Mode: ws.config.SamplingMode, Every: ws.config.SamplingEvery}
+}
+
+// endpointSnapshot pairs an endpoint with its latest result
+type endpointSnapshot struct {
+	endpoint  scheduler.Endpoint
+	result    checker.CheckResult
+	hasResult bool
+	paused    bool
+	stale     bool // the result is older than STALE_AFTER_INTERVALS checks
+}
+
+// snapshot returns the latest state of every endpoint, checking any active
+// endpoint that has not produced a result yet
+func (ws *WebServer) snapshot() []endpointSnapshot {
+	endpoints := ws.scheduler.List()
+	snapshots := make([]endpointSnapshot, len(endpoints))
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		snapshots[i].endpoint = endpoint
+		snapshots[i].paused = ws.scheduler.IsPaused(endpoint)
+		if result, ok := ws.latestResult(endpoint); ok {
+			snapshots[i].result = result
+			snapshots[i].hasResult = true
+			snapshots[i].stale = ws.isStale(endpoint, result, snapshots[i].paused, now)
+			continue
+		}
+		if snapshots[i].paused {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, endpoint scheduler.Endpoint) {
+			defer wg.Done()
+			snapshots[i].result = ws.scheduler.CheckNow(endpoint)
+			snapshots[i].hasResult = true
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	return snapshots
+}
+
+// PauseRequest pauses or resumes monitoring fleet-wide, or for one tag
+type PauseRequest struct {
+	Paused bool   `json:"paused"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// PauseState describes what is currently paused
+type PauseState struct {
+	Paused     bool     `json:"paused"`
+	PausedTags []string `json:"pausedTags"`
+}
+
+func (ws *WebServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var req PauseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if tag := strings.TrimSpace(req.Tag); tag != "" {
+			ws.scheduler.SetTagPaused(tag, req.Paused)
+		} else {
+			ws.scheduler.SetPaused(req.Paused)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json.NewEncoder(w).Encode(PauseState{
+		Paused:     ws.scheduler.Paused(),
+		PausedTags: ws.scheduler.PausedTags(),
+	})
+}
+
+// SelfMetrics reports whether the monitor itself is keeping up
+type SelfMetrics struct {
+	scheduler.Metrics
+	Lagging      bool          `json:"lagging"`
+	LagThreshold time.Duration `json:"lagThreshold"`
+}
+
+func (ws *WebServer) selfMetrics() SelfMetrics {
+	metrics := ws.scheduler.Metrics()
+	return SelfMetrics{
+		Metrics:      metrics,
+		Lagging:      metrics.LagP95 > ws.config.SchedulerLagThreshold,
+		LagThreshold: ws.config.SchedulerLagThreshold,
+	}
+}
+
+func (ws *WebServer) handleSelfMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.selfMetrics())
+}
+
+// watchSchedulerLag periodically warns when checks run later than scheduled
+func (ws *WebServer) watchSchedulerLag(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	wasLagging := false
+	for range ticker.C {
+		metrics := ws.selfMetrics()
+		if metrics.Lagging && !wasLagging {
+			log.Printf("⚠️ Monitor falling behind: p95 scheduler lag %v exceeds %v (%d checks in flight)",
+				metrics.LagP95.Round(time.Millisecond), metrics.LagThreshold, metrics.InFlight)
+		} else if !metrics.Lagging && wasLagging {
+			log.Printf("Monitor caught up: p95 scheduler lag %v", metrics.LagP95.Round(time.Millisecond))
+		}
+		wasLagging = metrics.Lagging
+	}
+}
+
+func (ws *WebServer) handleDrift(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ws.drift.Recent())
 }
 
 func (ws *WebServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
@@ -108,41 +892,94 @@ func (ws *WebServer) handleAIInsights(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get current status
-	ws.urlsMutex.RLock()
-	urls := make([]string, len(ws.urls))
-	copy(urls, ws.urls)
-	ws.urlsMutex.RUnlock()
+	endpoint := r.URL.Query().Get("endpoint")
+	if stored, ok := ws.scheduler.Get(endpoint); ok {
+		endpoint = stored.URL
+	}
+	// A request can only be stricter than AI_MIN_CONFIDENCE, which also
+	// covers analyses stored before it was raised
+	minConfidence := ws.config.AIMinConfidence
+	if value := r.URL.Query().Get("min_confidence"); value != "" {
+		requested, err := strconv.ParseFloat(value, 64)
+		if err != nil || requested < 0 || requested > 1 {
+			http.Error(w, "min_confidence must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		minConfidence = max(minConfidence, requested)
+	}
 
-	results := ws.checker.CheckMultiple(urls)
-	
-	var insights []ai.Insight
-	
-	// Try AI-powered insights first
-	if ws.aiClient != nil {
-		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-		defer cancel()
-		
-		aiInsights, err := ws.aiClient.AnalyzeEndpoints(ctx, results)
-		if err != nil {
-			log.Printf("AI insights failed: %v", err)
-			// Fall back to rule-based insights
-			insights = ws.convertLegacyInsights(ws.generateInsights(results))
-		} else {
-			insights = aiInsights
+	if len(ws.config.AISchedules) > 0 {
+		// Scheduled mode: serve the last runs instead of spending tokens per request
+		insights := ai.WithConfidence(ws.scheduledInsights(r.URL.Query().Get("tag")), minConfidence)
+		json.NewEncoder(w).Encode(InsightsResponse{Insights: insightsFor(insights, endpoint)})
+		return
+	}
+
+	response := ws.currentInsights()
+	response.Insights = insightsFor(ai.WithConfidence(response.Insights, minConfidence), endpoint)
+	json.NewEncoder(w).Encode(response)
+}
+
+// insightsFor keeps the insights affecting url; an empty url keeps them all
+func insightsFor(insights []ai.Insight, url string) []ai.Insight {
+	if url == "" {
+		return insights
+	}
+	filtered := []ai.Insight{}
+	for _, insight := range insights {
+		if insight.Affects(url) {
+			filtered = append(filtered, insight)
 		}
-	} else {
+	}
+	return filtered
+}
+
+// analyze produces insights with the AI model when available, falling back to
+// the rule-based analysis. Only eligible results, and their trends, are sent
+// to the model. Insights below AI_MIN_CONFIDENCE are dropped here, before
+// anything serves, publishes or stores them.
+func (ws *WebServer) analyze(ctx context.Context, results, eligible []checker.CheckResult) []ai.Insight {
+	trends := ws.endpointTrends(results)
+	if ws.aiClient == nil || len(eligible) == 0 {
 		// Use rule-based insights if AI is disabled
-		insights = ws.convertLegacyInsights(ws.generateInsights(results))
+		return ws.rememberInsights(ai.WithConfidence(ws.ruleBasedInsights(results, trends), ws.config.AIMinConfidence), results)
 	}
-	
-	json.NewEncoder(w).Encode(insights)
+
+	insights, err := ws.aiClient.AnalyzeEndpoints(ctx, eligible, trendsOf(trends, eligible))
+	if err != nil {
+		log.Printf("AI insights failed: %v", err)
+		// Fall back to rule-based insights
+		return ws.rememberInsights(ai.WithConfidence(ws.ruleBasedInsights(results, trends), ws.config.AIMinConfidence), results)
+	}
+	// Predictions come from the stored trends, not the model
+	insights = append(ws.predictionInsights(trends), insights...)
+	return ws.rememberInsights(ai.WithConfidence(insights, ws.config.AIMinConfidence), eligible)
+}
+
+// ruleBasedInsights returns the predicted breaches and trend insights of
+// results followed by the rule-based insights of their latest checks
+func (ws *WebServer) ruleBasedInsights(results []checker.CheckResult, trends []ai.Trend) []ai.Insight {
+	insights := append(ws.predictionInsights(trends), ai.TrendInsights(trends)...)
+	return append(insights, ws.convertLegacyInsights(ws.generateInsights(results))...)
+}
+
+// hasBaseline reports whether url's latency is judged against its baseline
+// rather than LATENCY_THRESHOLD
+func (ws *WebServer) hasBaseline(url string) bool {
+	if ws.anomalies == nil {
+		return false
+	}
+	_, ok := ws.anomalies.Baseline(url)
+	return ok
 }
 
 type AIInsight struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
 	Type    string `json:"type"` // "alert", "warning", "info", "success"
+
+	AffectedEndpoints []string `json:"affectedEndpoints,omitempty"`
+	SuggestedActions  []string `json:"suggestedActions,omitempty"`
 }
 
 func (ws *WebServer) generateInsights(results []checker.CheckResult) []AIInsight {
@@ -150,8 +987,8 @@ func (ws *WebServer) generateInsights(results []checker.CheckResult) []AIInsight
 	
 	// Count unhealthy endpoints
 	unhealthy := 0
-	var unhealthyURLs []string
-	totalResponseTime := time.Duration(0)
+	var unhealthyURLs, slowURLs, anomalousURLs, anomalies []string
+	latencies := make([]time.Duration, 0, len(results))
 	slowEndpoints := 0
 	
 	for _, result := range results {
@@ -159,13 +996,25 @@ func (ws *WebServer) generateInsights(results []checker.CheckResult) []AIInsight
 			unhealthy++
 			unhealthyURLs = append(unhealthyURLs, result.URL)
 		}
-		totalResponseTime += result.ResponseTime
-		if result.ResponseTime > 2*time.Second {
+		if result.Usable() {
+			latencies = append(latencies, result.ResponseTime)
+		}
+		// Endpoints with a latency baseline are judged against it, the
+		// others against the fixed threshold
+		switch {
+		case result.Anomaly != nil:
+			anomalousURLs = append(anomalousURLs, result.URL)
+			anomalies = append(anomalies, fmt.Sprintf("%s at %v (%.1fσ above its usual %v)", result.URL,
+				result.Anomaly.Latency.Round(time.Millisecond), result.Anomaly.Sigmas, result.Anomaly.Baseline.Round(time.Millisecond)))
+		case !ws.hasBaseline(result.URL) && result.Latency(ws.config.LatencyMetric) > ws.currentConfig().LatencyThreshold:
 			slowEndpoints++
+			slowURLs = append(slowURLs, result.URL)
 		}
 	}
 	
-	avgResponseTime := totalResponseTime / time.Duration(len(results))
+	// A few timeouts can dominate a plain mean, so the summary mode is configurable
+	summary := stats.Summarize(latencies, ws.config.StatsMode, ws.config.StatsTrimPercent)
+	avgResponseTime := summary.Central
 	
 	// Generate insights based on analysis
 	if unhealthy > 0 {
@@ -173,21 +1022,76 @@ func (ws *WebServer) generateInsights(results []checker.CheckResult) []AIInsight
 			Title:   "🚨 Service Disruption Detected",
 			Content: fmt.Sprintf("%d endpoint(s) are currently down. Immediate attention required for: %v", unhealthy, unhealthyURLs),
 			Type:    "alert",
+			
+			AffectedEndpoints: unhealthyURLs,
+			SuggestedActions:  []string{"Check the endpoints' error messages and recent deployments", "Verify DNS and TLS for the affected hosts"},
+		})
+	}
+	
+	// Correlate failures sharing a hosting provider
+	for _, group := range geoip.GroupFailuresByProvider(results) {
+		if len(group.URLs) < 2 {
+			continue
+		}
+		insights = append(insights, AIInsight{
+			Title:   "🌐 Provider-Wide Failures",
+			Content: fmt.Sprintf("%d failing endpoint(s) are hosted by %s (AS%d), suggesting a provider-level issue: %v", len(group.URLs), group.Provider, group.ASN, group.URLs),
+			Type:    "alert",
+			
+			AffectedEndpoints: group.URLs,
+			SuggestedActions:  []string{fmt.Sprintf("Check %s's status page", group.Provider), "Fail over to another provider if one is configured"},
+		})
+	}
+	
+	// The monitor's own data can't be trusted when it falls behind schedule
+	if metrics := ws.selfMetrics(); metrics.Lagging {
+		insights = append(insights, AIInsight{
+			Title:   "🐢 Monitor Falling Behind",
+			Content: fmt.Sprintf("Checks are starting %v late (p95) with %d in flight. Results may be stale; consider raising intervals or adding capacity.", metrics.LagP95.Round(time.Millisecond), metrics.InFlight),
+			Type:    "warning",
+			
+			SuggestedActions: []string{"Raise check intervals", "Increase MAX_CONCURRENCY or add replicas"},
+		})
+	}
+	
+	// Unexpected identity changes may indicate DNS hijacking or misrouting
+	for _, change := range ws.drift.Since(time.Now().Add(-1 * time.Hour)) {
+		insights = append(insights, AIInsight{
+			Title:   "🔀 Endpoint Drift Detected",
+			Content: fmt.Sprintf("%s: %s changed from %q to %q at %s. Verify this was a planned migration.", change.URL, change.Field, change.Previous, change.Current, change.DetectedAt.Format("15:04:05")),
+			Type:    "alert",
+			
+			AffectedEndpoints: []string{change.URL},
+			SuggestedActions:  []string{"Confirm the change with the endpoint's owners", "Check the DNS records for unexpected changes"},
+		})
+	}
+	
+	if len(anomalies) > 0 {
+		insights = append(insights, AIInsight{
+			Title:   "📈 Latency Anomalies",
+			Content: fmt.Sprintf("%d endpoint(s) are far slower than their historical baseline: %s.", len(anomalies), strings.Join(anomalies, "; ")),
+			Type:    "warning",
+			
+			AffectedEndpoints: anomalousURLs,
+			SuggestedActions:  []string{"Check for recent deployments or traffic changes on the affected hosts", "Compare the DNS, connect, TLS and TTFB breakdown to locate the slow phase"},
 		})
 	}
 	
 	if slowEndpoints > 0 {
 		insights = append(insights, AIInsight{
 			Title:   "⚠️ Performance Degradation Alert",
-			Content: fmt.Sprintf("%d endpoint(s) showing elevated response times (>2s). This may indicate network congestion or server load issues.", slowEndpoints),
+			Content: fmt.Sprintf("%d endpoint(s) showing elevated %s latency (>%v). This may indicate network congestion or server load issues.", slowEndpoints, ws.config.LatencyMetric, ws.currentConfig().LatencyThreshold),
 			Type:    "warning",
+			
+			AffectedEndpoints: slowURLs,
+			SuggestedActions:  []string{"Compare the DNS, connect, TLS and TTFB breakdown to locate the slow phase", "Check server load on the affected hosts"},
 		})
 	}
 	
 	if avgResponseTime < 500*time.Millisecond && unhealthy == 0 {
 		insights = append(insights, AIInsight{
 			Title:   "✅ Optimal System Performance",
-			Content: fmt.Sprintf("All endpoints healthy with excellent average response time of %v. System operating within optimal parameters.", avgResponseTime.Round(time.Millisecond)),
+			Content: fmt.Sprintf("All endpoints healthy with excellent %s response time of %v. System operating within optimal parameters.", summary.Label(), avgResponseTime.Round(time.Millisecond)),
 			Type:    "success",
 		})
 	}
@@ -203,7 +1107,7 @@ func (ws *WebServer) generateInsights(results []checker.CheckResult) []AIInsight
 	if avgResponseTime > 1*time.Second {
 		insights = append(insights, AIInsight{
 			Title:   "📊 Pattern Analysis",
-			Content: fmt.Sprintf("Average response time of %v suggests potential bottlenecks. Recommend investigating database query optimization and caching strategies.", avgResponseTime.Round(time.Millisecond)),
+			Content: fmt.Sprintf("A %s response time of %v suggests potential bottlenecks. Recommend investigating database query optimization and caching strategies.", summary.Label(), avgResponseTime.Round(time.Millisecond)),
 			Type:    "info",
 		})
 	}
@@ -221,6 +1125,10 @@ func (ws *WebServer) convertLegacyInsights(legacyInsights []AIInsight) []ai.Insi
 			Type:        legacy.Type,
 			Confidence:  0.8, // Default confidence for rule-based insights
 			GeneratedAt: time.Now(),
+			Tier:        ai.RuleBasedTier,
+
+			AffectedEndpoints: legacy.AffectedEndpoints,
+			SuggestedActions:  legacy.SuggestedActions,
 		}
 	}
 	return insights
@@ -240,12 +1148,15 @@ func (ws *WebServer) handleEndpoints(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case "GET":
-		ws.urlsMutex.RLock()
-		urls := make([]string, len(ws.urls))
-		copy(urls, ws.urls)
-		ws.urlsMutex.RUnlock()
+		endpoints := ws.scheduler.List()
+		urls := make([]string, len(endpoints))
+		for i, endpoint := range endpoints {
+			urls[i] = endpoint.URL
+			endpoints[i].Headers = checker.RedactHeaders(endpoint.Headers)
+			endpoints[i].RecoveryWebhook = endpoint.RecoveryWebhook.Redacted()
+		}
 		
-		json.NewEncoder(w).Encode(map[string][]string{"urls": urls})
+		json.NewEncoder(w).Encode(map[string]interface{}{"urls": urls, "endpoints": endpoints})
 
 	case "POST":
 		var req EndpointRequest
@@ -254,34 +1165,13 @@ func (ws *WebServer) handleEndpoints(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Validate URL
-		url := strings.TrimSpace(req.URL)
-		if url == "" {
-			http.Error(w, "URL is required", http.StatusBadRequest)
-			return
-		}
-
-		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-			http.Error(w, "URL must start with http:// or https://", http.StatusBadRequest)
+		endpoint, status, err := ws.addEndpoint(req)
+		if err != nil {
+			http.Error(w, err.Error(), status)
 			return
 		}
-
-		// Add URL
-		ws.urlsMutex.Lock()
-		// Check if URL already exists
-		for _, existingURL := range ws.urls {
-			if existingURL == url {
-				ws.urlsMutex.Unlock()
-				http.Error(w, "URL already being monitored", http.StatusConflict)
-				return
-			}
-		}
-		ws.urls = append(ws.urls, url)
-		ws.urlsMutex.Unlock()
-
-		log.Printf("Added endpoint: %s", url)
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Endpoint added successfully"})
+		json.NewEncoder(w).Encode(map[string]string{"message": "Endpoint added successfully", "id": endpoint.ID})
 
 	case "DELETE":
 		var req EndpointRequest
@@ -296,19 +1186,16 @@ func (ws *WebServer) handleEndpoints(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Remove URL
-		ws.urlsMutex.Lock()
-		found := false
-		for i, existingURL := range ws.urls {
-			if existingURL == url {
-				ws.urls = append(ws.urls[:i], ws.urls[i+1:]...)
-				found = true
-				break
+		// Accept any spelling of a normalized endpoint URL
+		if _, exists := ws.scheduler.Get(scheduler.EndpointID(url)); !exists {
+			if normalized, err := checker.NormalizeURL(url); err == nil {
+				url = normalized
 			}
 		}
-		ws.urlsMutex.Unlock()
 
-		if !found {
+		// Remove URL
+		endpoint, exists := ws.scheduler.Get(scheduler.EndpointID(url))
+		if !exists || !ws.removeEndpoint(endpoint) {
 			http.Error(w, "URL not found", http.StatusNotFound)
 			return
 		}
@@ -321,28 +1208,474 @@ func (ws *WebServer) handleEndpoints(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// addEndpoint validates an endpoint request, starts monitoring it and
+// persists it. On failure it returns the HTTP status that fits the error.
+func (ws *WebServer) addEndpoint(req EndpointRequest) (scheduler.Endpoint, int, error) {
+	endpoint, err := ws.buildEndpoint(req, ws.currentConfig().CheckInterval)
+	if err != nil {
+		return scheduler.Endpoint{}, http.StatusBadRequest, err
+	}
+	if _, exists := ws.scheduler.Get(endpoint.ID); exists {
+		return scheduler.Endpoint{}, http.StatusConflict, errors.New("URL already being monitored")
+	}
+	if owned, err := ws.grpcOwned(endpoint.ID); err != nil {
+		log.Printf("Failed to look up stored endpoint %s: %v", endpoint.URL, err)
+		return scheduler.Endpoint{}, http.StatusInternalServerError, errors.New("Failed to save endpoint")
+	} else if owned {
+		return scheduler.Endpoint{}, http.StatusConflict, errGRPCOwned
+	}
+	if endpoint.Realtime() && ws.realtimeEndpoints() >= ws.config.RealtimeMaxEndpoints {
+		return scheduler.Endpoint{}, http.StatusBadRequest, fmt.Errorf("At most %d endpoints may use sub-second intervals (REALTIME_MAX_ENDPOINTS)", ws.config.RealtimeMaxEndpoints)
+	}
+	if err := ws.scheduler.Add(endpoint); err != nil {
+		return scheduler.Endpoint{}, http.StatusBadRequest, err
+	}
+	if err := ws.persistEndpoint(endpoint); err != nil {
+		ws.removeEndpoint(endpoint)
+		if errors.Is(err, errGRPCOwned) {
+			return scheduler.Endpoint{}, http.StatusConflict, err
+		}
+		log.Printf("Failed to persist endpoint %s: %v", endpoint.URL, err)
+		return scheduler.Endpoint{}, http.StatusInternalServerError, errors.New("Failed to save endpoint")
+	}
+
+	log.Printf("Added endpoint: %s", endpoint.URL)
+	return endpoint, http.StatusCreated, nil
+}
+
+// buildEndpoint validates an endpoint request and turns it into the
+// endpoint to schedule, checked every defaultInterval unless it sets one
+func (ws *WebServer) buildEndpoint(req EndpointRequest, defaultInterval time.Duration) (scheduler.Endpoint, error) {
+	// Validate URL
+	url := strings.TrimSpace(req.URL)
+	if url == "" {
+		return scheduler.Endpoint{}, errors.New("URL is required")
+	}
+
+	checkType := strings.TrimSpace(req.Type)
+	if checkType == "" || checkType == checker.TypeHTTP {
+		// Canonicalize so equivalent spellings share one endpoint and history
+		normalized, err := checker.NormalizeURL(url)
+		if err != nil {
+			return scheduler.Endpoint{}, err
+		}
+		url = normalized
+	} else if _, ok := ws.custom[checkType]; !ok {
+		return scheduler.Endpoint{}, fmt.Errorf("Unknown check type %q (available: %s)", checkType, strings.Join(checker.Types(), ", "))
+	}
+
+	if req.Sampling != nil {
+		if err := req.Sampling.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	for _, route := range req.EmailRoutes {
+		if err := route.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	if req.RecoveryWebhook != nil {
+		if err := req.RecoveryWebhook.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	runbook := strings.TrimSpace(req.Runbook)
+	if runbook != "" {
+		if err := alerting.ValidateRunbook(runbook); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	if req.SLA != nil {
+		if err := req.SLA.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	if req.SLO != nil {
+		if err := req.SLO.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+	if req.Agents != nil {
+		if err := req.Agents.Validate(); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+
+	interval, timeout, err := endpointTiming(req, defaultInterval, ws.config.RealtimeMinInterval)
+	if err != nil {
+		return scheduler.Endpoint{}, err
+	}
+
+	spec := checker.CheckSpec{
+		Type:        checkType,
+		URL:         url,
+		Method:      strings.ToUpper(strings.TrimSpace(req.Method)),
+		Body:        req.Body,
+		ContentType: strings.TrimSpace(req.ContentType),
+		Head:        req.Head,
+		Headers:     req.Headers,
+		Options:     req.Options,
+		Extract:     req.Extract,
+		Thresholds:  req.Thresholds,
+		Timeout:     timeout,
+		Source:      strings.TrimSpace(req.Source),
+	}
+	if err := spec.Validate(); err != nil {
+		return scheduler.Endpoint{}, err
+	}
+	if spec.Head && req.Throughput {
+		return scheduler.Endpoint{}, errors.New("Throughput checks download the response, they cannot be head checks")
+	}
+	if spec.Source != "" {
+		// Sources are local to this host, so they're checked here rather than in Validate
+		if _, err := checker.ResolveSource(spec.Source); err != nil {
+			return scheduler.Endpoint{}, err
+		}
+	}
+
+	id := scheduler.EndpointID(url)
+	dependsOn := ws.resolveDependencies(req.DependsOn)
+	for _, dep := range dependsOn {
+		if dep == id {
+			return scheduler.Endpoint{}, errors.New("An endpoint cannot depend on itself")
+		}
+	}
+	endpoint := scheduler.Endpoint{
+		ID:              id,
+		URL:             url,
+		Interval:        interval,
+		Throughput:      req.Throughput,
+		Tags:            req.Tags,
+		Method:          spec.Method,
+		Body:            spec.Body,
+		ContentType:     spec.ContentType,
+		Head:            spec.Head,
+		Headers:         spec.Headers,
+		Type:            spec.Type,
+		Options:         spec.Options,
+		Extract:         spec.Extract,
+		Thresholds:      spec.Thresholds,
+		Sampling:        req.Sampling,
+		Cron:            strings.TrimSpace(req.Cron),
+		Timezone:        strings.TrimSpace(req.Timezone),
+		Timeout:         spec.Timeout,
+		Disabled:        req.Enabled != nil && !*req.Enabled,
+		AIExcluded:      req.AIAnalysis != nil && !*req.AIAnalysis,
+		EmailRoutes:     req.EmailRoutes,
+		RecoveryWebhook: req.RecoveryWebhook,
+		Runbook:         runbook,
+		Source:          spec.Source,
+		DependsOn:       dependsOn,
+		SLA:             req.SLA,
+		SLO:             req.SLO,
+		Agents:          req.Agents,
+	}
+	return endpoint, nil
+}
+
+// handleEndpointActions routes /api/endpoints/{id}/{action} requests
+func (ws *WebServer) handleEndpointActions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/endpoints/"), "/"), "/")
+	if len(parts) != 2 {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	endpoint, exists := ws.scheduler.Get(parts[0])
+	if !exists {
+		http.Error(w, "Endpoint not found", http.StatusNotFound)
+		return
+	}
+
+	switch parts[1] {
+	case "schedule-check":
+		ws.handleScheduleCheck(w, r, endpoint)
+	case "metrics":
+		ws.handleEndpointMetrics(w, r, endpoint)
+	case "cost":
+		ws.handleEndpointCost(w, r, endpoint)
+	case "capture":
+		ws.requireAdmin(func(w http.ResponseWriter, r *http.Request) { ws.handleCapture(w, r, endpoint) })(w, r)
+	case "captures":
+		ws.requireAdmin(func(w http.ResponseWriter, r *http.Request) { ws.handleCaptures(w, r, endpoint) })(w, r)
+	case "archive":
+		ws.requireAdmin(func(w http.ResponseWriter, r *http.Request) { ws.handleArchive(w, r, endpoint) })(w, r)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// ScheduleCheckRequest asks for a single extra check at a specific time
+type ScheduleCheckRequest struct {
+	At time.Time `json:"at"` // RFC 3339 timestamp
+}
+
+func (ws *WebServer) handleScheduleCheck(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(ws.scheduler.ScheduledChecks(endpoint.ID))
+
+	case "POST":
+		var req ScheduleCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON: 'at' must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		if req.At.IsZero() {
+			http.Error(w, "'at' is required", http.StatusBadRequest)
+			return
+		}
+		if req.At.Before(time.Now()) {
+			http.Error(w, "'at' must be in the future", http.StatusBadRequest)
+			return
+		}
+
+		scheduled, err := ws.scheduler.ScheduleAt(endpoint.ID, req.At)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(scheduled)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func main() {
+	log.SetOutput(output.Writer(os.Stderr))
 	ws := NewWebServer()
 
+	mux := http.NewServeMux()
+
 	// Serve static files
-	http.HandleFunc("/", ws.handleDashboard)
-	http.HandleFunc("/api/status", ws.handleStatus)
-	http.HandleFunc("/api/insights", ws.handleAIInsights)
-	http.HandleFunc("/api/endpoints", ws.handleEndpoints)
+	mux.HandleFunc("/", ws.requireAuth(ws.handleDashboard))
+	mux.HandleFunc("/login", ws.handleLogin)
+	mux.HandleFunc("/logout", ws.handleLogout)
+	mux.HandleFunc("/healthz", ws.health.LivenessHandler)
+	mux.HandleFunc("/readyz", ws.health.ReadinessHandler)
+	mux.HandleFunc("/api/status", ws.requireAuth(ws.handleStatus))
+	mux.HandleFunc("/api/status/wait", ws.requireAuth(ws.handleStatusWait))
+	mux.HandleFunc("/api/topology", ws.requireAuth(ws.handleTopology))
+	mux.HandleFunc("/api/insights", ws.requireAuth(ws.handleAIInsights))
+	mux.HandleFunc("/api/insights/schedule", ws.requireAuth(ws.handleInsightSchedule))
+	mux.HandleFunc("/api/insights/", ws.requireAuth(ws.handleInsightActions))
+	mux.HandleFunc("/api/digests", ws.requireAuth(ws.handleDigests))
+	mux.HandleFunc("/api/endpoints", ws.requireAuth(ws.handleEndpoints))
+	mux.HandleFunc("/api/endpoints/", ws.requireAuth(ws.handleEndpointActions))
+	mux.HandleFunc("/api/endpoints/duplicates", ws.requireAuth(ws.handleDuplicates))
+	mux.HandleFunc("/api/endpoints/merge", ws.requireAuth(ws.handleMerge))
+	mux.HandleFunc("/api/endpoints/validate", ws.requireAuth(ws.handleValidate))
+	mux.HandleFunc("/api/endpoints/import", ws.requireAuth(ws.handleOpenAPIImport))
+	mux.HandleFunc("/api/latency", ws.requireAuth(ws.handleLatency))
+	mux.HandleFunc("/api/history", ws.requireAuth(ws.handleHistory))
+	mux.HandleFunc("/api/rollups", ws.requireAuth(ws.handleRollups))
+	mux.HandleFunc("/api/incidents", ws.requireAuth(ws.handleIncidents))
+	mux.HandleFunc("/api/incidents/", ws.requireAuth(ws.handleIncidentActions))
+	mux.HandleFunc("/api/sla", ws.requireAuth(ws.handleSLA))
+	mux.HandleFunc("/api/sla/statement", ws.requireAuth(ws.handleSLAStatement))
+	mux.HandleFunc("/api/slo", ws.requireAuth(ws.handleSLO))
+	mux.HandleFunc("/api/maintenance", ws.requireAuth(ws.handleMaintenance))
+	mux.HandleFunc("/api/drift", ws.requireAuth(ws.handleDrift))
+	mux.HandleFunc("/api/pause", ws.requireAuth(ws.handlePause))
+	mux.HandleFunc("/api/events", ws.requireAuth(ws.handleEvents))
+	mux.HandleFunc("/api/events/stream", ws.requireAuth(ws.handleEventStream))
+	mux.HandleFunc("/api/stream", ws.requireAuth(ws.handleLiveStream))
+	mux.HandleFunc("/api/alerts", ws.requireAuth(ws.handleAlerts))
+	mux.HandleFunc("/api/alert-rules", ws.requireAuth(ws.handleAlertRules))
+	mux.HandleFunc("/api/alert-rules/preview", ws.requireAuth(ws.handleRulePreview))
+	mux.HandleFunc("/api/ingest/remote-write", ws.requireIngestToken(ws.handleRemoteWrite))
+	mux.HandleFunc("/api/agents", ws.requireAuth(ws.handleAgents))
+	mux.HandleFunc("/api/agents/", ws.requireAgentToken(ws.handleAgentActions))
+	mux.HandleFunc("/api/self/metrics", ws.requireAuth(ws.handleSelfMetrics))
+	mux.HandleFunc("/api/config", ws.requireAuth(ws.handleConfig))
+	mux.HandleFunc("/api/config/reload", ws.requireAdmin(ws.handleConfigReload))
+	mux.HandleFunc("/api/keys", ws.requireAuth(ws.handleAPIKeys))
+	mux.HandleFunc("/api/results", ws.requireAdmin(ws.handleResults))
+	mux.HandleFunc("/api/archives/restore", ws.requireAdmin(ws.handleArchiveRestore))
+	mux.HandleFunc("/api/results/tap", ws.requireAdmin(ws.handleResultTap))
+	mux.HandleFunc("/api/audit", ws.requireAdmin(ws.handleAuditLog))
+	if ws.config.DebugEnabled {
+		ws.registerDebugHandlers(mux)
+	}
+
+	go ws.watchSchedulerLag(30 * time.Second)
+	if ws.staleAlerts != nil {
+		go ws.watchStaleResults()
+	}
+	if ws.agents != nil {
+		go ws.watchAgents()
+	}
+	if ws.store != nil {
+		go ws.flushSketches()
+		go ws.enforceRetention()
+		if ws.config.SLOEvalInterval > 0 {
+			go ws.watchBurnRates()
+		}
+	}
+	ws.startAISchedules()
+	ws.startDigests()
+	if ws.config.ConfigWatchInterval > 0 && (ws.config.ConfigFile != "" || ws.config.EndpointsFile != "") {
+		go ws.watchConfigFiles(ws.config.ConfigWatchInterval)
+	}
+
+	tlsSetup, err := tlsutil.New(ws.config)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+
+	scheme := "http"
+	if tlsSetup != nil {
+		scheme = "https"
+	}
 
 	port := ws.config.WebPort
-	fmt.Printf("🌐 Web dashboard starting on http://localhost:%d\n", port)
-	fmt.Printf("📊 API endpoints:\n")
-	fmt.Printf("   - GET /               - Web dashboard\n")
-	fmt.Printf("   - GET /api/status     - Current endpoint status\n")
-	fmt.Printf("   - GET /api/insights   - AI-powered insights\n")
-	fmt.Printf("   - POST/DELETE /api/endpoints - Manage monitored URLs\n")
+	output.Printf("🌐 Web dashboard starting on %s://localhost:%d\n", scheme, port)
+	output.Printf("📊 API endpoints:\n")
+	output.Printf("   - GET /               - Web dashboard\n")
+	output.Printf("   - GET /healthz, /readyz - Liveness and readiness probes (scheduler, database, AI)\n")
+	output.Printf("   - GET /api/status     - Current endpoint status\n")
+	output.Printf("   - GET /api/status/wait - Long-poll until an endpoint changes state\n")
+	output.Printf("   - GET /api/topology   - Service map of endpoint dependencies with live health\n")
+	output.Printf("   - GET /api/insights   - AI-powered insights\n")
+	output.Printf("   - GET/POST /api/insights/{id}/followup - Ask the AI about an insight\n")
+	if len(ws.config.AISchedules) > 0 {
+		output.Printf("   - GET /api/insights/schedule - Scheduled AI analysis per tag\n")
+	}
+	output.Printf("   - GET/POST /api/digests - Weekly AI digests of the fleet (POST writes one now, admin)\n")
+	output.Printf("   - POST/DELETE /api/endpoints - Manage monitored URLs\n")
+	output.Printf("   - GET /api/endpoints/{id}/metrics - Metrics extracted from JSON responses\n")
+	output.Printf("   - GET /api/endpoints/{id}/cost - Checks, bytes, rows and AI tokens an endpoint costs\n")
+	output.Printf("   - POST /api/endpoints/{id}/capture, GET .../captures - Record full requests and responses for a while (admin)\n")
+	output.Printf("   - GET /api/endpoints/duplicates, POST /api/endpoints/merge - Clean up duplicates\n")
+	output.Printf("   - POST /api/endpoints/validate - Validate a declarative endpoints file\n")
+	output.Printf("   - POST /api/endpoints/import - Add the GET operations of an OpenAPI/Swagger spec\n")
+	output.Printf("   - POST /api/endpoints/{id}/schedule-check - Schedule a one-off check\n")
+	output.Printf("   - GET /api/latency    - Latency percentiles over a time range\n")
+	output.Printf("   - GET /api/history    - Bucketed latency percentiles and error rates for charts\n")
+	output.Printf("   - GET /api/rollups    - Hourly and daily aggregates kept after raw results expire\n")
+	output.Printf("   - GET /api/incidents  - Outages of an endpoint, including imported history\n")
+	output.Printf("   - GET /api/sla        - Uptime, MTTR, MTBF and outages over 24h/7d/30d/90d, with itemized exclusions\n")
+	output.Printf("   - GET /api/sla/statement - Customer SLA compliance statement per billing period\n")
+	output.Printf("   - GET /api/slo - Error budget and burn rates of endpoints with an SLO\n")
+	output.Printf("   - GET/POST/DELETE /api/maintenance - Maintenance windows excluded from SLA reports\n")
+	output.Printf("   - GET /api/drift      - Recent IP/certificate/server changes\n")
+	output.Printf("   - GET/POST /api/pause - Pause monitoring fleet-wide or per tag\n")
+	output.Printf("   - GET /api/events - Server-sent results, state changes and insights (?prefix=, ?stateChanges=true)\n")
+	output.Printf("   - GET /api/events/stream - Server-sent state change events\n")
+	output.Printf("   - GET /api/stream - WebSocket of live check results\n")
+	output.Printf("   - GET /api/alerts, GET/POST/PUT/DELETE /api/alert-rules - Downtime and metric alerting\n")
+	output.Printf("   - POST /api/alert-rules/preview - When a rule would have fired, from stored metrics\n")
+	output.Printf("   - POST /api/ingest/remote-write - Prometheus remote-write ingestion\n")
+	output.Printf("   - GET /api/agents     - Registered agents, their heartbeat health and assigned endpoints\n")
+	output.Printf("   - GET /api/self/metrics - Scheduler lag and backlog\n")
+	output.Printf("   - GET /api/config     - Effective configuration and where each value came from\n")
+	output.Printf("   - POST /api/config/reload - Apply configuration changes without a restart (admin, also on SIGHUP)\n")
+	output.Printf("   - GET/POST/DELETE /api/keys - API keys for scripts and integrations\n")
+	if ws.config.AdminToken != "" {
+		output.Printf("   - DELETE /api/results, GET /api/audit - Purge stored history (admin, audited)\n")
+		output.Printf("   - GET /api/results/tap - Sampled live results as NDJSON for debugging (admin)\n")
+	}
+	if ws.config.DebugEnabled {
+		output.Printf("   - GET /debug/state, /debug/pprof/ - Runtime diagnostics\n")
+	}
+	if ws.config.AuthEnabled {
+		output.Printf("🔒 Dashboard login required (user: %s)\n", ws.config.AuthUsername)
+	}
+	
+	if ws.downAlerts != nil {
+		var channels []string
+		if ws.config.SlackWebhook != "" {
+			channels = append(channels, "Slack")
+		}
+		if ws.config.EmailFrom != "" {
+			channels = append(channels, fmt.Sprintf("email via %s:%d", ws.config.EmailSMTPHost, ws.config.EmailSMTPPort))
+		}
+		if ws.config.PagerDutyRoutingKey != "" {
+			channels = append(channels, "PagerDuty")
+		}
+		if ws.config.OpsgenieAPIKey != "" {
+			channels = append(channels, "Opsgenie")
+		}
+		for _, webhook := range ws.config.Webhooks {
+			channels = append(channels, "webhook "+webhook.Name)
+		}
+		if len(channels) > 0 {
+			output.Printf("🔔 Downtime and recovery alerts sent to %s\n", strings.Join(channels, ", "))
+			if ws.config.AlertAfterFailures > 1 || len(ws.config.AlertEscalation) > 0 {
+				output.Printf("🔔 Alerts open after %d consecutive failure(s), escalation: %v\n", max(ws.config.AlertAfterFailures, 1), ws.config.AlertEscalation)
+			}
+			if ws.config.PageAfter > 0 && (ws.config.PagerDutyRoutingKey != "" || ws.config.OpsgenieAPIKey != "") {
+				output.Printf("📟 On-call is paged for alerts lasting %v\n", ws.config.PageAfter)
+			}
+		} else {
+			output.Printf("🔔 Downtime and recovery alerts enabled (no alert channels configured, event stream only)\n")
+		}
+	}
+	
+	if ws.stopTracing != nil {
+		output.Printf("🔭 Traces exported to %s (%d%% of new traces sampled)\n", ws.config.TracingEndpoint, ws.config.TracingSamplePercent)
+	}
 	
 	if ws.aiClient != nil {
-		fmt.Printf("🤖 AI insights powered by GPT-OSS\n")
+		output.Printf("🤖 AI insights powered by GPT-OSS\n")
+		if tiers := ws.aiClient.Tiers(); len(tiers) > 1 {
+			chain := make([]string, 0, len(tiers)+1)
+			for _, tier := range tiers {
+				chain = append(chain, fmt.Sprintf("%s (%s)", tier.Name, tier.Model))
+			}
+			output.Printf("   Fallback chain: %s → %s\n", strings.Join(chain, " → "), ai.RuleBasedTier)
+		}
 	} else {
-		fmt.Printf("📋 Using rule-based insights (AI disabled)\n")
+		output.Printf("📋 Using rule-based insights (AI disabled)\n")
 	}
 	
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	// SIGINT or SIGTERM shuts down gracefully; a second one exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	// SIGHUP reloads the configuration
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			ws.reloadAndLog("SIGHUP")
+		}
+	}()
+
+	var handler http.Handler = mux
+	if ws.stopTracing != nil {
+		handler = tracing.Handler(mux)
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: handler}
+	served := make(chan error, 1)
+	if tlsSetup == nil {
+		go func() { served <- server.ListenAndServe() }()
+	} else {
+		// ACME HTTP-01 challenges must be answered on plain HTTP
+		if tlsSetup.ChallengeHandler != nil {
+			go func() {
+				addr := fmt.Sprintf(":%d", ws.config.TLSACMEHTTPPort)
+				log.Printf("ACME challenge listener on %s", addr)
+				if err := http.ListenAndServe(addr, tlsSetup.ChallengeHandler); err != nil {
+					log.Printf("ACME challenge listener stopped: %v", err)
+				}
+			}()
+		}
+
+		server.TLSConfig = tlsSetup.Config
+		go func() { served <- server.ListenAndServeTLS("", "") }()
+	}
+
+	select {
+	case err := <-served:
+		log.Fatal(err)
+	case <-ctx.Done():
+		stop()
+		ws.shutdown(server)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// MaintenanceRequest plans a maintenance window. Without url or tag it
+// covers every endpoint.
+type MaintenanceRequest struct {
+	URL      string    `json:"url,omitempty"`
+	Tag      string    `json:"tag,omitempty"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Reason   string    `json:"reason"`
+}
+
+// handleMaintenance lists maintenance windows (GET, ?from= and ?to=, default
+// the last 90 days and everything planned). Creating (POST) and deleting
+// (DELETE ?id=) them changes SLA figures, so both need the admin token and
+// are audited.
+func (ws *WebServer) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		now := time.Now().UTC()
+		from, to := now.Add(-90*24*time.Hour), now.Add(10*365*24*time.Hour)
+		var err error
+		if value := r.URL.Query().Get("from"); value != "" {
+			if from, err = time.Parse(time.RFC3339, value); err != nil {
+				http.Error(w, "from must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		if value := r.URL.Query().Get("to"); value != "" {
+			if to, err = time.Parse(time.RFC3339, value); err != nil {
+				http.Error(w, "to must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		windows, err := ws.store.GetMaintenance(from, to)
+		if err != nil {
+			log.Printf("Failed to load maintenance windows: %v", err)
+			http.Error(w, "Failed to load maintenance windows", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(windows)
+
+	case "POST":
+		ws.requireAdmin(ws.createMaintenance)(w, r)
+
+	case "DELETE":
+		ws.requireAdmin(ws.deleteMaintenance)(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ws *WebServer) createMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	url, tag := strings.TrimSpace(req.URL), strings.TrimSpace(req.Tag)
+	if url != "" && tag != "" {
+		http.Error(w, "Give either url or tag, not both", http.StatusBadRequest)
+		return
+	}
+	if url != "" {
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+	}
+	if req.StartsAt.IsZero() || !req.StartsAt.Before(req.EndsAt) {
+		http.Error(w, "startsAt and endsAt are required and startsAt must be before endsAt", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		http.Error(w, "reason is required, it appears in SLA reports", http.StatusBadRequest)
+		return
+	}
+
+	window, err := ws.store.SaveMaintenance(storage.MaintenanceWindow{
+		URL:       url,
+		Tag:       tag,
+		StartsAt:  req.StartsAt.UTC(),
+		EndsAt:    req.EndsAt.UTC(),
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: ws.actor(r),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Failed to save maintenance window: %v", err)
+		http.Error(w, "Failed to save maintenance window", http.StatusInternalServerError)
+		return
+	}
+	ws.audit(r, "maintenance.create", window)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(window)
+}
+
+func (ws *WebServer) deleteMaintenance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	deleted, err := ws.store.DeleteMaintenance(id)
+	if err != nil {
+		log.Printf("Failed to delete maintenance window %d: %v", id, err)
+		http.Error(w, "Failed to delete maintenance window", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	ws.audit(r, "maintenance.delete", map[string]interface{}{"id": id})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/scheduler"
+)
+
+// handleEndpointMetrics returns the time series of metrics extracted from an
+// endpoint's JSON responses. Optional query parameters: name, from, to (RFC 3339)
+// and limit.
+func (ws *WebServer) handleEndpointMetrics(w http.ResponseWriter, r *http.Request, endpoint scheduler.Endpoint) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	query := r.URL.Query()
+	to := time.Now().UTC()
+	from := to.Add(-24 * time.Hour)
+	var err error
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "from must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "to must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 1000
+	if value := query.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	points, err := store.GetMetrics(endpoint.URL, query.Get("name"), from.UTC(), to.UTC(), limit)
+	if err != nil {
+		log.Printf("Failed to load metrics for %s: %v", endpoint.URL, err)
+		http.Error(w, "Failed to load metrics", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"endpointId": endpoint.ID,
+		"url":        endpoint.URL,
+		"thresholds": endpoint.Thresholds,
+		"points":     points,
+	})
+}
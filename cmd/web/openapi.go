@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/openapi"
+)
+
+// Limits of spec imports
+const (
+	maxSpecBytes     = 10 << 20
+	specFetchTimeout = 15 * time.Second
+)
+
+// ImportedEndpoint is an endpoint created from an OpenAPI operation
+type ImportedEndpoint struct {
+	ID          string `json:"id,omitempty"`
+	URL         string `json:"url"`
+	Path        string `json:"path"`
+	OperationID string `json:"operationId,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an OpenAPI import
+type ImportReport struct {
+	Title    string             `json:"title"`
+	Version  string             `json:"version"`
+	BaseURL  string             `json:"baseUrl"`
+	DryRun   bool               `json:"dryRun,omitempty"`
+	Added    []ImportedEndpoint `json:"added"`    // or, in a dry run, would be added
+	Existing []ImportedEndpoint `json:"existing"` // already monitored
+	Failed   []ImportedEndpoint `json:"failed"`
+	Skipped  []openapi.Skipped  `json:"skipped"` // operations without example parameters etc.
+}
+
+// handleOpenAPIImport registers an endpoint for every GET operation of an
+// OpenAPI 3 or Swagger 2 spec, given as the request body (YAML or JSON) or
+// downloaded from ?specUrl=. ?baseUrl= overrides the spec's servers; ?tag=
+// (repeatable), ?interval= and ?enabled=false apply to every endpoint,
+// which is also tagged with its operation's tags. ?dryRun=true only reports.
+func (ws *WebServer) handleOpenAPIImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	specURL := strings.TrimSpace(query.Get("specUrl"))
+	var data []byte
+	var err error
+	if specURL != "" {
+		data, err = fetchSpec(specURL)
+	} else {
+		data, err = io.ReadAll(io.LimitReader(r.Body, maxSpecBytes))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "Send the spec as the request body or give ?specUrl=", http.StatusBadRequest)
+		return
+	}
+
+	spec, err := openapi.Parse(data, openapi.Options{BaseURL: strings.TrimSpace(query.Get("baseUrl")), SpecURL: specURL})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(query.Get("dryRun"))
+
+	report := ImportReport{
+		Title:    spec.Title,
+		Version:  spec.Version,
+		BaseURL:  spec.BaseURL,
+		DryRun:   dryRun,
+		Added:    []ImportedEndpoint{},
+		Existing: []ImportedEndpoint{},
+		Failed:   []ImportedEndpoint{},
+		Skipped:  spec.Skipped,
+	}
+	for _, operation := range spec.Operations {
+		imported := ImportedEndpoint{URL: operation.URL, Path: operation.Path, OperationID: operation.OperationID}
+		req := EndpointRequest{
+			URL:      operation.URL,
+			Tags:     append(append([]string{}, query["tag"]...), operation.Tags...),
+			Interval: query.Get("interval"),
+		}
+		if enabled, err := strconv.ParseBool(query.Get("enabled")); err == nil {
+			req.Enabled = &enabled
+		}
+
+		if dryRun {
+			report.Added = append(report.Added, imported)
+			continue
+		}
+		endpoint, status, err := ws.addEndpoint(req)
+		switch {
+		case status == http.StatusConflict:
+			report.Existing = append(report.Existing, imported)
+		case err != nil:
+			imported.Error = err.Error()
+			report.Failed = append(report.Failed, imported)
+		default:
+			imported.ID = endpoint.ID
+			imported.URL = endpoint.URL
+			report.Added = append(report.Added, imported)
+		}
+	}
+
+	if !dryRun {
+		log.Printf("Imported %d endpoints from OpenAPI spec %q (%d existing, %d failed, %d skipped)",
+			len(report.Added), spec.Title, len(report.Existing), len(report.Failed), len(report.Skipped))
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// fetchSpec downloads a spec
+func fetchSpec(specURL string) ([]byte, error) {
+	client := &http.Client{Timeout: specFetchTimeout}
+	resp, err := client.Get(specURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download spec: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download spec: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSpecBytes))
+}
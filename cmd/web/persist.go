@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/storage"
+)
+
+// errGRPCOwned reports an endpoint whose stored definition belongs to the
+// gRPC server, which the web server must neither replace nor delete
+var errGRPCOwned = errors.New("URL already being monitored by the gRPC server")
+
+// grpcOwned reports whether the stored definition of an endpoint belongs to
+// the gRPC server
+func (ws *WebServer) grpcOwned(id string) (bool, error) {
+	if ws.store == nil {
+		return false, nil
+	}
+	record, exists, err := ws.store.GetEndpoint(id)
+	if err != nil {
+		return false, err
+	}
+	return exists && record.Owner == storage.OwnerGRPC, nil
+}
+
+// persistEndpoint writes an endpoint definition through to the database so it
+// survives restarts. Without a database endpoints live in memory only.
+// Definitions owned by the gRPC server are left alone and errGRPCOwned is
+// returned.
+func (ws *WebServer) persistEndpoint(endpoint scheduler.Endpoint) error {
+	if ws.store == nil {
+		return nil
+	}
+	if owned, err := ws.grpcOwned(endpoint.ID); err != nil {
+		return err
+	} else if owned {
+		return errGRPCOwned
+	}
+	config, err := json.Marshal(endpoint)
+	if err != nil {
+		return err
+	}
+	return ws.store.SaveEndpoint(storage.EndpointRecord{ID: endpoint.ID, URL: endpoint.URL, Config: config})
+}
+
+// loadEndpoints schedules the persisted endpoints and reports whether it
+// scheduled any, in which case the built-in defaults are skipped
+func (ws *WebServer) loadEndpoints(throughputURLs map[string]bool) bool {
+	if ws.store == nil {
+		return false
+	}
+	records, err := ws.store.ListEndpoints()
+	if err != nil {
+		log.Printf("Failed to load endpoints: %v", err)
+		return false
+	}
+
+	loaded := 0
+	for _, record := range records {
+		if record.Owner != "" {
+			continue // monitored by the gRPC server
+		}
+		var endpoint scheduler.Endpoint
+		if err := json.Unmarshal(record.Config, &endpoint); err != nil {
+			log.Printf("Skipping stored endpoint %s: %v", record.ID, err)
+			continue
+		}
+		endpoint.ID = record.ID
+		endpoint.URL = record.URL
+		if endpoint.Interval <= 0 {
+			endpoint.Interval = ws.config.CheckInterval
+		}
+		endpoint.Throughput = endpoint.Throughput || throughputURLs[endpoint.URL]
+		if err := ws.scheduler.Add(endpoint); err != nil {
+			log.Printf("Skipping stored endpoint %s: %v", record.URL, err)
+			continue
+		}
+		loaded++
+	}
+	if loaded > 0 {
+		log.Printf("Loaded %d endpoints from the database", loaded)
+	}
+	return loaded > 0
+}
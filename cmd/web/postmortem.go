@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/storage"
+)
+
+// maxPostmortemChecks caps how many failed checks a postmortem is written from
+const maxPostmortemChecks = 50
+
+// postmortemTimeout bounds writing one postmortem
+const postmortemTimeout = 2 * time.Minute
+
+// writePostmortem writes the postmortem of a closed incident and stores it
+// on the incident. Endpoints excluded from AI analysis get the rule-based one.
+func (ws *WebServer) writePostmortem(incident storage.Incident) (ai.Postmortem, error) {
+	input, err := ws.postmortemInput(incident)
+	if err != nil {
+		return ai.Postmortem{}, err
+	}
+
+	postmortem := ai.FallbackPostmortem(input)
+	if ws.aiClient != nil && !ws.aiExcluded(incident.URL) {
+		ctx, cancel := context.WithTimeout(context.Background(), postmortemTimeout)
+		postmortem, err = ws.aiClient.WritePostmortem(ctx, input)
+		cancel()
+		if err != nil {
+			log.Printf("AI postmortem of incident %d failed: %v", incident.ID, err)
+		}
+	}
+
+	data, err := json.Marshal(postmortem)
+	if err != nil {
+		return ai.Postmortem{}, err
+	}
+	if err := ws.store.SavePostmortem(incident.ID, data); err != nil {
+		return ai.Postmortem{}, err
+	}
+	log.Printf("📝 Postmortem of incident %d (%s) written by %s", incident.ID, incident.URL, postmortem.Tier)
+	return postmortem, nil
+}
+
+// aiExcluded reports whether the endpoint monitoring url is kept from the AI model
+func (ws *WebServer) aiExcluded(url string) bool {
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.URL == url {
+			return endpoint.AIExcluded
+		}
+	}
+	return false
+}
+
+// postmortemInput gathers the timeline of a closed incident: how many checks
+// ran and failed, and the failed checks themselves
+func (ws *WebServer) postmortemInput(incident storage.Incident) (ai.PostmortemInput, error) {
+	input := ai.PostmortemInput{URL: incident.URL, StartedAt: incident.StartedAt, EndedAt: *incident.EndedAt, Cause: incident.Cause}
+	// The check that recovered closes the incident, so the end is included
+	to := input.EndedAt.Add(time.Second)
+	var err error
+	if input.Checks, input.Failed, err = ws.store.CountChecks(incident.URL, incident.StartedAt, to); err != nil {
+		return input, err
+	}
+	failed, err := ws.store.QueryResults(storage.ResultQuery{URL: incident.URL, From: incident.StartedAt, To: to, Failed: true, Limit: maxPostmortemChecks})
+	if err != nil {
+		return input, err
+	}
+	// Newest first from the store, oldest first in the timeline
+	for i := len(failed) - 1; i >= 0; i-- {
+		input.FailedChecks = append(input.FailedChecks, ai.PostmortemCheck{
+			CheckedAt:  failed[i].CheckedAt,
+			StatusCode: failed[i].StatusCode,
+			Error:      failed[i].Error,
+		})
+	}
+	return input, nil
+}
+
+// handleIncidentActions serves /api/incidents/{id}/postmortem: GET returns
+// the stored postmortem, POST writes it again, e.g. for imported incidents
+func (ws *WebServer) handleIncidentActions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/incidents/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "postmortem" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid incident ID", http.StatusBadRequest)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	incident, ok, err := store.GetIncident(id)
+	if err != nil {
+		log.Printf("Failed to load incident %d: %v", id, err)
+		http.Error(w, "Failed to load incident", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		postmortem, ok, err := store.GetPostmortem(id)
+		if err != nil {
+			log.Printf("Failed to load postmortem of incident %d: %v", id, err)
+			http.Error(w, "Failed to load postmortem", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "No postmortem written for this incident", http.StatusNotFound)
+			return
+		}
+		w.Write(postmortem)
+
+	case "POST":
+		if incident.EndedAt == nil {
+			http.Error(w, "Incident is still ongoing", http.StatusConflict)
+			return
+		}
+		postmortem, err := ws.writePostmortem(incident)
+		if err != nil {
+			log.Printf("Failed to write postmortem of incident %d: %v", id, err)
+			http.Error(w, "Failed to write postmortem", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(postmortem)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/storage"
+)
+
+// recoveryWebhook returns the recovery webhook of an endpoint for the
+// recovery notifier
+func (ws *WebServer) recoveryWebhook(endpointID string) *alerting.RecoveryWebhook {
+	endpoint, ok := ws.scheduler.Get(endpointID)
+	if !ok {
+		return nil
+	}
+	return endpoint.RecoveryWebhook
+}
+
+// recordOutage stores the outage a recovery ends as an incident, so it shows
+// up in /api/incidents, links the recovery alert to it and has its
+// postmortem written in the background
+func (ws *WebServer) recordOutage(alert *alerting.Alert) {
+	if ws.store == nil || alert.Outage == nil {
+		return
+	}
+	outage := alert.Outage
+	endedAt := outage.EndedAt
+	id, err := ws.store.SaveIncident(storage.Incident{
+		URL:       alert.URL,
+		StartedAt: outage.StartedAt,
+		EndedAt:   &endedAt,
+		Cause:     outage.FirstError,
+	})
+	if err != nil {
+		log.Printf("Failed to record incident for %s: %v", alert.URL, err)
+		return
+	}
+	outage.IncidentID = id
+	outage.IncidentURL = ws.incidentLink(alert.URL, outage.StartedAt, endedAt)
+
+	incident := storage.Incident{ID: id, URL: alert.URL, StartedAt: outage.StartedAt, EndedAt: &endedAt, Cause: outage.FirstError}
+	go func() {
+		if _, err := ws.writePostmortem(incident); err != nil {
+			log.Printf("Failed to write postmortem of incident %d: %v", id, err)
+		}
+	}()
+}
+
+// incidentLink points to the incidents of url over an outage
+func (ws *WebServer) incidentLink(endpointURL string, from, to time.Time) string {
+	query := url.Values{
+		"url":  {endpointURL},
+		"from": {from.UTC().Format(time.RFC3339)},
+		"to":   {to.UTC().Add(time.Second).Format(time.RFC3339)},
+	}
+	return fmt.Sprintf("%s/api/incidents?%s", ws.config.PublicURL, query.Encode())
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"api-monitor/internal/config"
+	"api-monitor/internal/manifest"
+	"api-monitor/internal/output"
+	"api-monitor/internal/scheduler"
+)
+
+// Settings a reload applies without a restart, besides CHECK_INTERVAL,
+// ENDPOINTS_FILE, LATENCY_THRESHOLD and OUTPUT_STYLE. The secrets and templates of named webhooks are
+// WEBHOOK_SECRET_<NAME> and WEBHOOK_TEMPLATE_<NAME>.
+var (
+	channelSettings = map[string]bool{
+		"SLACK_WEBHOOK": true, "EMAIL_SMTP_HOST": true, "EMAIL_SMTP_PORT": true, "EMAIL_USERNAME": true,
+		"EMAIL_PASSWORD": true, "EMAIL_FROM": true, "EMAIL_TO": true, "PAGERDUTY_ROUTING_KEY": true,
+		"PAGERDUTY_EVENTS_URL": true, "OPSGENIE_API_KEY": true, "OPSGENIE_API_URL": true,
+		"PAGE_AFTER": true, "WEBHOOKS": true, "SLACK_TEMPLATE": true, "EMAIL_TEMPLATE": true,
+	}
+	policySettings = map[string]bool{
+		"ALERT_AFTER_FAILURES": true, "ALERT_ESCALATION": true, "ALERT_RENOTIFY": true,
+		"FLAP_WINDOW": true, "FLAP_THRESHOLD": true,
+	}
+)
+
+// ReloadResult is what a configuration reload changed
+type ReloadResult struct {
+	Applied         []string         `json:"applied"`             // changed settings now in effect
+	RestartRequired []string         `json:"restartRequired"`     // changed settings that apply after a restart
+	Rescheduled     int              `json:"rescheduled"`         // endpoints moved to the new CHECK_INTERVAL
+	Endpoints       *EndpointChanges `json:"endpoints,omitempty"` // when ENDPOINTS_FILE is set
+}
+
+// EndpointChanges is how the endpoints file was applied, by URL
+type EndpointChanges struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// currentConfig returns the configuration as last loaded
+func (ws *WebServer) currentConfig() *config.Config {
+	ws.reloadedMutex.RLock()
+	defer ws.reloadedMutex.RUnlock()
+	return ws.reloaded
+}
+
+// handleConfigReload loads the configuration again and applies what
+// changed, see reload
+func (ws *WebServer) handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, problems := ws.reload()
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "Configuration not applied", "problems": problems})
+		return
+	}
+	ws.audit(r, "config.reload", result)
+	json.NewEncoder(w).Encode(result)
+}
+
+// reload loads the configuration again, from the environment, CONFIG_FILE
+// and _FILE secrets, and applies what changed without a restart: alert
+// channels, the downtime alert policy, CHECK_INTERVAL for the endpoints
+// using it, LATENCY_THRESHOLD, OUTPUT_STYLE and the endpoints of
+// ENDPOINTS_FILE. Anything else, e.g. the anomaly and burn-rate settings,
+// is reported as needing a restart. Only the monitors of
+// affected endpoints are restarted. Nothing is applied when the
+// configuration has errors; the problems are returned instead.
+func (ws *WebServer) reload() (ReloadResult, []string) {
+	ws.reloadMutex.Lock()
+	defer ws.reloadMutex.Unlock()
+
+	result := ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	cfg := config.Load()
+	if len(cfg.LoadErrors) > 0 {
+		return result, cfg.LoadErrors
+	}
+	previous := ws.currentConfig()
+
+	if cfg.EndpointsFile != "" {
+		changes, problems := ws.applyEndpointsFile(cfg.EndpointsFile, cfg.CheckInterval)
+		if len(problems) > 0 {
+			return result, problems
+		}
+		result.Endpoints = &changes
+	}
+
+	channels, policy := false, false
+	pending := make(map[string]bool)
+	for _, key := range config.Changed(previous.Settings, cfg.Settings) {
+		isChannel := channelSettings[key] || strings.HasPrefix(key, "WEBHOOK_SECRET_") || strings.HasPrefix(key, "WEBHOOK_TEMPLATE_")
+		switch {
+		case key == "CHECK_INTERVAL" || key == "ENDPOINTS_FILE" || key == "LATENCY_THRESHOLD":
+		case key == "OUTPUT_STYLE":
+			output.SetStyle(cfg.OutputStyle)
+		case isChannel && ws.downAlerts != nil:
+			channels = true
+		case policySettings[key] && ws.downAlerts != nil:
+			policy = true
+		default:
+			pending[key] = true
+			result.RestartRequired = append(result.RestartRequired, key)
+			continue
+		}
+		result.Applied = append(result.Applied, key)
+	}
+
+	if channels {
+		ws.alerts.Replace(ws.notifiers(cfg))
+	}
+	if policy {
+		ws.downAlerts.SetPolicy(healthPolicy(cfg))
+	}
+	if cfg.CheckInterval != previous.CheckInterval {
+		result.Rescheduled = ws.rescheduleInterval(previous.CheckInterval, cfg.CheckInterval)
+	}
+
+	// Settings awaiting a restart keep showing their running value
+	settings := make([]config.Setting, 0, len(cfg.Settings))
+	for _, setting := range previous.Settings {
+		if pending[setting.Key] {
+			settings = append(settings, setting)
+		}
+	}
+	for _, setting := range cfg.Settings {
+		if !pending[setting.Key] {
+			settings = append(settings, setting)
+		}
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	cfg.Settings = settings
+
+	ws.reloadedMutex.Lock()
+	ws.reloaded = cfg
+	ws.reloadedMutex.Unlock()
+	return result, nil
+}
+
+// reloadAndLog reloads on a signal or file change, where there's no one to
+// answer but the log
+func (ws *WebServer) reloadAndLog(trigger string) {
+	result, problems := ws.reload()
+	if len(problems) > 0 {
+		log.Printf("Configuration reload on %s rejected: %s", trigger, strings.Join(problems, "; "))
+		return
+	}
+	log.Printf("Configuration reloaded on %s: %d setting(s) applied, %d endpoint(s) rescheduled", trigger, len(result.Applied), result.Rescheduled)
+	if len(result.RestartRequired) > 0 {
+		log.Printf("Changed settings that take effect after a restart: %s", strings.Join(result.RestartRequired, ", "))
+	}
+}
+
+// watchConfigFiles reloads the configuration whenever CONFIG_FILE or
+// ENDPOINTS_FILE changes on disk
+func (ws *WebServer) watchConfigFiles(interval time.Duration) {
+	stamp := func() string {
+		cfg := ws.currentConfig()
+		return fileStamp(cfg.ConfigFile) + "|" + fileStamp(cfg.EndpointsFile)
+	}
+	last := stamp()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if current := stamp(); current != last {
+			last = current
+			ws.reloadAndLog("file change")
+		}
+	}
+}
+
+// fileStamp identifies the version of a file on disk, following symlinks
+// like the ones Kubernetes swaps when a ConfigMap is updated
+func fileStamp(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "missing"
+	}
+	return fmt.Sprintf("%d/%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// rescheduleInterval moves the endpoints still checked every previous, the
+// old CHECK_INTERVAL, to the new interval. Endpoints with an interval or
+// cron schedule of their own are left alone.
+func (ws *WebServer) rescheduleInterval(previous, interval time.Duration) int {
+	rescheduled := 0
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.Managed || endpoint.Cron != "" || endpoint.Interval != previous {
+			continue
+		}
+		endpoint.Interval = interval
+		if err := ws.restartEndpoint(endpoint); err != nil {
+			log.Printf("Failed to reschedule %s: %v", endpoint.URL, err)
+			continue
+		}
+		rescheduled++
+	}
+	return rescheduled
+}
+
+// restartEndpoint replaces the monitor of an endpoint, or starts one, and
+// persists the new definition. The endpoint's alerts, history and pending
+// one-off checks are kept.
+func (ws *WebServer) restartEndpoint(endpoint scheduler.Endpoint) error {
+	if owned, err := ws.grpcOwned(endpoint.ID); err != nil {
+		return err
+	} else if owned {
+		return errGRPCOwned
+	}
+	if err := ws.scheduler.Replace(endpoint); err != nil {
+		return err
+	}
+	return ws.persistEndpoint(endpoint)
+}
+
+// applyEndpointsFile makes the managed endpoints match a declarative
+// endpoints file: new ones are added, changed ones restarted and the ones
+// no longer listed removed. Endpoints added through the API are left alone
+// unless the file takes them over. Nothing changes when any entry is
+// invalid; the problems are returned instead.
+func (ws *WebServer) applyEndpointsFile(path string, defaultInterval time.Duration) (EndpointChanges, []string) {
+	changes := EndpointChanges{Added: []string{}, Updated: []string{}, Removed: []string{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return changes, []string{fmt.Sprintf("ENDPOINTS_FILE: %v", err)}
+	}
+	file, err := manifest.Parse(data)
+	if err != nil {
+		return changes, []string{fmt.Sprintf("ENDPOINTS_FILE: %v", err)}
+	}
+
+	var problems []string
+	problem := func(index int, url, message string) {
+		problems = append(problems, "ENDPOINTS_FILE "+manifest.Problem{Index: index, URL: url, Severity: manifest.SeverityError, Message: message}.String())
+	}
+	desired := make(map[string]scheduler.Endpoint)
+	var order []string
+	realtime := 0
+	for i, entry := range file.Endpoints {
+		var req EndpointRequest
+		if data, err := json.Marshal(entry); err != nil {
+			problem(i, entry.URL, err.Error())
+			continue
+		} else if err := json.Unmarshal(data, &req); err != nil {
+			problem(i, entry.URL, err.Error())
+			continue
+		}
+		endpoint, err := ws.buildEndpoint(req, defaultInterval)
+		if err != nil {
+			problem(i, entry.URL, err.Error())
+			continue
+		}
+		if _, duplicate := desired[endpoint.ID]; duplicate {
+			problem(i, endpoint.URL, "listed more than once")
+			continue
+		}
+		if endpoint.Realtime() {
+			realtime++
+		}
+		endpoint.Managed = true
+		desired[endpoint.ID] = endpoint
+		order = append(order, endpoint.ID)
+	}
+	for _, endpoint := range ws.scheduler.List() {
+		if _, listed := desired[endpoint.ID]; !listed && !endpoint.Managed && endpoint.Realtime() {
+			realtime++
+		}
+	}
+	if realtime > ws.config.RealtimeMaxEndpoints {
+		problems = append(problems, fmt.Sprintf("ENDPOINTS_FILE: at most %d endpoints may use sub-second intervals (REALTIME_MAX_ENDPOINTS)", ws.config.RealtimeMaxEndpoints))
+	}
+	if len(problems) > 0 {
+		return changes, problems
+	}
+
+	for _, id := range order {
+		endpoint := desired[id]
+		current, exists := ws.scheduler.Get(id)
+		if exists && sameEndpoint(current, endpoint) {
+			changes.Unchanged++
+			continue
+		}
+		if err := ws.restartEndpoint(endpoint); err != nil {
+			log.Printf("Failed to apply endpoint %s: %v", endpoint.URL, err)
+			continue
+		}
+		if exists {
+			changes.Updated = append(changes.Updated, endpoint.URL)
+		} else {
+			changes.Added = append(changes.Added, endpoint.URL)
+		}
+	}
+	for _, endpoint := range ws.scheduler.List() {
+		if _, listed := desired[endpoint.ID]; !listed && endpoint.Managed && ws.removeEndpoint(endpoint) {
+			changes.Removed = append(changes.Removed, endpoint.URL)
+		}
+	}
+
+	log.Printf("Applied endpoints file %s: %d added, %d updated, %d removed, %d unchanged",
+		path, len(changes.Added), len(changes.Updated), len(changes.Removed), changes.Unchanged)
+	return changes, nil
+}
+
+// sameEndpoint reports whether two definitions would be monitored the same
+// way, ignoring empty and missing fields alike
+func sameEndpoint(a, b scheduler.Endpoint) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
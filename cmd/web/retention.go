@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// retentionPolicy returns the configured retention periods
+func (ws *WebServer) retentionPolicy() storage.RetentionPolicy {
+	day := 24 * time.Hour
+	return storage.RetentionPolicy{
+		Raw:    time.Duration(ws.config.RetentionRawDays) * day,
+		Hourly: time.Duration(ws.config.RetentionHourlyDays) * day,
+		Daily:  time.Duration(ws.config.RetentionDailyDays) * day,
+	}
+}
+
+// enforceRetention periodically rolls up and deletes expired data. The first
+// run happens right away so a lowered retention takes effect on restart.
+func (ws *WebServer) enforceRetention() {
+	policy := ws.retentionPolicy()
+	if policy.Raw <= 0 && policy.Hourly <= 0 && policy.Daily <= 0 {
+		return
+	}
+	interval := ws.config.RetentionInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	for {
+		started := time.Now()
+		summary, err := ws.store.ApplyRetention(policy, started)
+		switch {
+		case err != nil:
+			log.Printf("Retention run failed: %v", err)
+		case summary.Skipped:
+			log.Printf("Retention run skipped: another replica is running it")
+		case summary.RolledUp+summary.Metrics+summary.Sketches+summary.ExpiredHourly+summary.ExpiredDaily > 0:
+			log.Printf("🧹 Retention: rolled up %d results, deleted %d metrics, %d sketches, %d hourly and %d daily rollups in %v",
+				summary.RolledUp, summary.Metrics, summary.Sketches, summary.ExpiredHourly, summary.ExpiredDaily,
+				time.Since(started).Round(time.Millisecond))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// handleRollups returns the hourly (default) or daily aggregates of an
+// endpoint, which outlive raw results under a retention policy. Defaults to
+// the last 30 days.
+func (ws *WebServer) handleRollups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "url is required", http.StatusBadRequest)
+		return
+	}
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+
+	resolution := storage.RollupHourly
+	switch r.URL.Query().Get("resolution") {
+	case "", "hourly":
+	case "daily":
+		resolution = storage.RollupDaily
+	default:
+		http.Error(w, "resolution must be hourly or daily", http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseTimeRange(r, 30*24*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rollups, err := ws.store.GetRollups(url, from, to, resolution)
+	if err != nil {
+		log.Printf("Failed to load rollups for %s: %v", url, err)
+		http.Error(w, "Failed to load rollups", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(rollups)
+}
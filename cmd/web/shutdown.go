@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/scheduler"
+)
+
+// shutdown stops the web server gracefully within SHUTDOWN_TIMEOUT: streaming
+// clients are let go, in-flight requests and checks finish, then results
+// still held in memory and queued alerts are flushed before the database is
+// closed
+func (ws *WebServer) shutdown(server *http.Server) {
+	log.Printf("Shutting down, waiting up to %v for work in progress", ws.config.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), ws.config.ShutdownTimeout)
+	defer cancel()
+
+	// Ends event streams, live websockets, result taps and long-polls,
+	// which would otherwise hold Shutdown until their clients leave
+	ws.events.Close()
+	ws.live.Close()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP requests still running at shutdown: %v", err)
+	}
+
+	endpoints := make(map[string]scheduler.Endpoint)
+	for _, endpoint := range ws.scheduler.List() {
+		endpoints[endpoint.URL] = endpoint
+	}
+	if err := ws.scheduler.Shutdown(ctx); err != nil {
+		log.Printf("Checks still running at shutdown: %v", err)
+	}
+
+	if ws.store != nil {
+		for _, aggregate := range ws.coalescer.Drain() {
+			ws.saveResult(endpoints[aggregate.URL], aggregate)
+		}
+		// Including the sketches of windows still open
+		if err := ws.sketches.Flush(ws.store, time.Now().Add(ws.sketches.Window())); err != nil {
+			log.Printf("Failed to save latency sketches: %v", err)
+		}
+	}
+
+	if err := ws.alerts.Close(ctx); err != nil {
+		log.Printf("Alerts still queued at shutdown: %v", err)
+	}
+	if ws.store != nil {
+		if err := ws.store.Close(); err != nil {
+			log.Printf("Failed to close the database: %v", err)
+		}
+	}
+	if ws.stopTracing != nil {
+		if err := ws.stopTracing(ctx); err != nil {
+			log.Printf("Failed to export the last spans: %v", err)
+		}
+	}
+	log.Printf("Shutdown complete")
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/storage"
+)
+
+// EndpointSLA holds an endpoint's SLA reports keyed by window name
+type EndpointSLA struct {
+	ID      string                `json:"id,omitempty"`
+	URL     string                `json:"url"`
+	Windows map[string]sla.Report `json:"windows"`
+}
+
+// handleSLA reports uptime, downtime, outages, MTTR and MTBF per endpoint
+// over the 24h, 7d, 30d and 90d windows, or those listed in ?window=. ?url=
+// limits the report to one endpoint. Maintenance windows and monitoring gaps
+// are excluded and itemized unless ?exclusions=false.
+func (ws *WebServer) handleSLA(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	windows := sla.Windows
+	if value := r.URL.Query().Get("window"); value != "" {
+		windows = nil
+		for _, name := range strings.Split(value, ",") {
+			window, err := sla.LookupWindow(strings.TrimSpace(name))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			windows = append(windows, window)
+		}
+	}
+
+	var endpoints []scheduler.Endpoint
+	if url := r.URL.Query().Get("url"); url != "" {
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+		endpoint, ok := ws.scheduler.Get(scheduler.EndpointID(url))
+		if !ok {
+			// History outlives endpoints, so removed ones can still be reported
+			endpoint = scheduler.Endpoint{URL: url}
+		}
+		endpoints = []scheduler.Endpoint{endpoint}
+	} else {
+		endpoints = ws.scheduler.List()
+	}
+
+	now := time.Now().UTC()
+	exclude := r.URL.Query().Get("exclusions") != "false"
+	var maintenance []storage.MaintenanceWindow
+	if exclude {
+		longest := time.Duration(0)
+		for _, window := range windows {
+			if window.Duration > longest {
+				longest = window.Duration
+			}
+		}
+		var err error
+		if maintenance, err = store.GetMaintenance(now.Add(-longest), now); err != nil {
+			log.Printf("Failed to load maintenance windows: %v", err)
+			http.Error(w, "Failed to build SLA report", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	reports := make([]EndpointSLA, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		target := sla.Target{URL: endpoint.URL, Tags: endpoint.Tags}
+		if exclude {
+			target.MinGap = ws.monitoringGap(endpoint)
+		}
+		windowReports, err := sla.Build(store, target, windows, now, maintenance)
+		if err != nil {
+			log.Printf("Failed to build SLA report for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to build SLA report", http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, EndpointSLA{ID: endpoint.ID, URL: endpoint.URL, Windows: windowReports})
+	}
+	json.NewEncoder(w).Encode(reports)
+}
+
+// monitoringGap returns how long an endpoint may go without stored results
+// before the time counts as a monitoring gap, or zero when its results are
+// sparse by design (cron schedules and sampling)
+func (ws *WebServer) monitoringGap(endpoint scheduler.Endpoint) time.Duration {
+	if endpoint.Cron != "" || endpoint.Interval <= 0 {
+		return 0
+	}
+	if mode := ws.samplingPolicy(endpoint).Mode; mode != "" && mode != storage.SampleAll {
+		return 0
+	}
+	gap := ws.config.SLAMinGap
+	if 3*endpoint.Interval > gap {
+		gap = 3 * endpoint.Interval
+	}
+	return gap
+}
+
+// handleSLAStatement renders the customer-facing compliance statement of
+// endpoints' SLA contracts for a billing period: ?period=current (default),
+// previous or a label such as 2026-09, 2026-Q3 or 2026 depending on the
+// contract. ?url= or ?id= picks one endpoint, otherwise every endpoint with
+// a contract is included. ?format=text returns a plain-text document.
+func (ws *WebServer) handleSLAStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		http.Error(w, "format must be json or text", http.StatusBadRequest)
+		return
+	}
+
+	var endpoints []scheduler.Endpoint
+	id := r.URL.Query().Get("id")
+	if url := r.URL.Query().Get("url"); url != "" {
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+		id = scheduler.EndpointID(url)
+	}
+	if id != "" {
+		endpoint, ok := ws.scheduler.Get(id)
+		if !ok {
+			http.Error(w, "Endpoint not found", http.StatusNotFound)
+			return
+		}
+		if endpoint.SLA == nil {
+			http.Error(w, "Endpoint has no SLA contract", http.StatusNotFound)
+			return
+		}
+		endpoints = []scheduler.Endpoint{endpoint}
+	} else {
+		for _, endpoint := range ws.scheduler.List() {
+			if endpoint.SLA != nil {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	statements := make([]sla.Statement, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		contract := *endpoint.SLA
+		from, to, err := contract.ParsePeriod(r.URL.Query().Get("period"), now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var maintenance []storage.MaintenanceWindow
+		if contract.Excludes(sla.ExcludeMaintenance) {
+			if maintenance, err = store.GetMaintenance(from, to); err != nil {
+				log.Printf("Failed to load maintenance windows: %v", err)
+				http.Error(w, "Failed to build SLA statement", http.StatusInternalServerError)
+				return
+			}
+		}
+		target := sla.Target{URL: endpoint.URL, Tags: endpoint.Tags, MinGap: ws.monitoringGap(endpoint)}
+		statement, err := sla.BuildStatement(store, target, contract, from, to, now, maintenance)
+		if err != nil {
+			log.Printf("Failed to build SLA statement for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to build SLA statement", http.StatusInternalServerError)
+			return
+		}
+		statement.EndpointID = endpoint.ID
+		statements = append(statements, statement)
+	}
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for i, statement := range statements {
+			if i > 0 {
+				fmt.Fprint(w, "\n\n")
+			}
+			statement.WriteText(w)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statements)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/storage"
+)
+
+// SLOStatus is an endpoint's objective, the error budget left over its
+// window and how fast the budget is burning
+type SLOStatus struct {
+	EndpointID string        `json:"endpointId"`
+	URL        string        `json:"url"`
+	Objective  sla.Objective `json:"objective"`
+
+	// Availability and BudgetRemaining (percent of the budget, negative once
+	// overspent) cover the objective's window; nil without results
+	Availability    *float64      `json:"availability"`
+	BudgetRemaining *float64      `json:"budgetRemaining"`
+	Downtime        time.Duration `json:"downtime"`
+	AllowedDowntime time.Duration `json:"allowedDowntime"`
+
+	// BurnRates are keyed by the windows of the burn rate rules, e.g. "1h"
+	BurnRates map[string]float64 `json:"burnRates"`
+	Alerts    []alerting.Alert   `json:"alerts"`
+}
+
+// watchBurnRates periodically evaluates the burn rate rules for endpoints
+// with an SLO
+func (ws *WebServer) watchBurnRates() {
+	ticker := time.NewTicker(ws.config.SLOEvalInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ws.evaluateBurnRates(time.Now().UTC())
+	}
+}
+
+// evaluateBurnRates measures the burn rate of every endpoint with an SLO
+// over the rules' windows and raises or resolves their alerts
+func (ws *WebServer) evaluateBurnRates(now time.Time) {
+	windows := ws.burnRates.Windows()
+	if len(windows) == 0 {
+		return
+	}
+	maintenance, err := ws.store.GetMaintenance(now.Add(-windows[len(windows)-1]), now)
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+		return
+	}
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.SLO == nil || endpoint.Disabled {
+			ws.burnRates.ForgetEndpoint(endpoint.ID)
+			continue
+		}
+		rates, err := ws.measureBurnRates(endpoint, windows, now, maintenance)
+		if err != nil {
+			log.Printf("Failed to measure error budget burn of %s: %v", endpoint.URL, err)
+			continue
+		}
+		ws.burnRates.Evaluate(endpoint.ID, endpoint.URL, endpoint.SLO.Period().Duration, rates, now)
+	}
+}
+
+// measureBurnRates returns an endpoint's burn rates by window, excluding
+// maintenance and monitoring gaps like SLA reports do
+func (ws *WebServer) measureBurnRates(endpoint scheduler.Endpoint, windows []time.Duration, now time.Time, maintenance []storage.MaintenanceWindow) (map[time.Duration]float64, error) {
+	target := sla.Target{URL: endpoint.URL, Tags: endpoint.Tags, MinGap: ws.monitoringGap(endpoint)}
+	return sla.BurnRates(ws.store, target, *endpoint.SLO, windows, now, maintenance)
+}
+
+// handleSLO reports the error budget and burn rates of endpoints with an
+// SLO, or of the endpoint named by ?id=
+func (ws *WebServer) handleSLO(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	store := ws.store.WithContext(r.Context())
+
+	var endpoints []scheduler.Endpoint
+	if id := r.URL.Query().Get("id"); id != "" {
+		endpoint, ok := ws.scheduler.Get(id)
+		if !ok {
+			http.Error(w, "Endpoint not found", http.StatusNotFound)
+			return
+		}
+		if endpoint.SLO == nil {
+			http.Error(w, "Endpoint has no SLO", http.StatusNotFound)
+			return
+		}
+		endpoints = []scheduler.Endpoint{endpoint}
+	} else {
+		for _, endpoint := range ws.scheduler.List() {
+			if endpoint.SLO != nil {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	longest := 90 * 24 * time.Hour
+	maintenance, err := store.GetMaintenance(now.Add(-longest), now)
+	if err != nil {
+		log.Printf("Failed to load maintenance windows: %v", err)
+		http.Error(w, "Failed to build SLO status", http.StatusInternalServerError)
+		return
+	}
+
+	active := make(map[string][]alerting.Alert)
+	for _, alert := range ws.burnRates.Active() {
+		active[alert.EndpointID] = append(active[alert.EndpointID], alert)
+	}
+	windows := ws.burnRates.Windows()
+	statuses := make([]SLOStatus, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		objective := *endpoint.SLO
+		target := sla.Target{URL: endpoint.URL, Tags: endpoint.Tags, MinGap: ws.monitoringGap(endpoint)}
+		report, err := sla.BuildRange(store, target, now.Add(-objective.Period().Duration), now, maintenance)
+		if err != nil {
+			log.Printf("Failed to build SLO status for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to build SLO status", http.StatusInternalServerError)
+			return
+		}
+		rates, err := ws.measureBurnRates(endpoint, windows, now, maintenance)
+		if err != nil {
+			log.Printf("Failed to build SLO status for %s: %v", endpoint.URL, err)
+			http.Error(w, "Failed to build SLO status", http.StatusInternalServerError)
+			return
+		}
+
+		status := SLOStatus{
+			EndpointID:      endpoint.ID,
+			URL:             endpoint.URL,
+			Objective:       objective,
+			Availability:    report.UptimePercent,
+			Downtime:        report.Downtime,
+			AllowedDowntime: time.Duration(float64(report.Observed) * objective.Budget()),
+			BurnRates:       make(map[string]float64, len(rates)),
+			Alerts:          active[endpoint.ID],
+		}
+		if status.Objective.Window == "" {
+			status.Objective.Window = objective.Period().Name
+		}
+		if status.AllowedDowntime > 0 {
+			remaining := 100 * (1 - float64(report.Downtime)/float64(status.AllowedDowntime))
+			status.BudgetRemaining = &remaining
+		}
+		for window, rate := range rates {
+			status.BurnRates[alerting.WindowName(window)] = rate
+		}
+		if status.Alerts == nil {
+			status.Alerts = []alerting.Alert{}
+		}
+		statuses = append(statuses, status)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+)
+
+// staleCheckInterval is how often endpoints are looked at for stale results
+const staleCheckInterval = 10 * time.Second
+
+// isStale reports whether an active endpoint's latest result is older than
+// STALE_AFTER_INTERVALS scheduled checks
+func (ws *WebServer) isStale(endpoint scheduler.Endpoint, result checker.CheckResult, paused bool, now time.Time) bool {
+	if endpoint.Disabled || paused {
+		return false
+	}
+	staleAt, ok := endpoint.StaleAt(result.CheckedAt, ws.config.StaleAfterIntervals)
+	return ok && now.After(staleAt)
+}
+
+// watchStaleResults alerts on endpoints whose results stopped arriving, e.g.
+// because the worker or agent checking them died
+func (ws *WebServer) watchStaleResults() {
+	ticker := time.NewTicker(staleCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		for _, endpoint := range ws.scheduler.List() {
+			result, ok := ws.latestResult(endpoint)
+			if !ok {
+				continue
+			}
+			stale := ws.isStale(endpoint, result, ws.scheduler.IsPaused(endpoint), now)
+			ws.staleAlerts.Update(endpoint.ID, endpoint.URL, stale, result.CheckedAt, now)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/sla"
+)
+
+// Orders of /api/status
+var statusSorts = map[string]bool{"url": true, "state": true, "latency": true, "uptime": true, "duration": true, "failures": true}
+
+// statusQuery filters and orders /api/status so clients don't have to
+type statusQuery struct {
+	labels     map[string]string
+	states     map[string]bool // all when empty
+	tag        string
+	errorsOnly bool
+	uptime     *sla.Window // computes Uptime over the window when set
+	sort       string
+	descending bool
+}
+
+// parseStatusQuery reads ?label=, ?state=, ?tag=, ?errorsOnly=, ?uptime=,
+// ?sort= and ?order=
+func parseStatusQuery(values url.Values) (statusQuery, error) {
+	var query statusQuery
+	var err error
+	if query.labels, err = checker.ParseLabels(values["label"]); err != nil {
+		return query, err
+	}
+	if value := values.Get("state"); value != "" {
+		query.states = make(map[string]bool)
+		for _, state := range strings.Split(value, ",") {
+			switch state = strings.TrimSpace(state); state {
+			case nodeHealthy, nodeUnhealthy, nodePaused, nodeDisabled, nodeUnknown:
+				query.states[state] = true
+			default:
+				return query, fmt.Errorf("state must be healthy, unhealthy, paused, disabled or unknown")
+			}
+		}
+	}
+	query.tag = values.Get("tag")
+	query.errorsOnly = values.Get("errorsOnly") == "true"
+
+	query.sort = values.Get("sort")
+	if query.sort != "" && !statusSorts[query.sort] {
+		return query, fmt.Errorf("sort must be url, state, latency, uptime, duration or failures")
+	}
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.descending = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc")
+	}
+
+	window := values.Get("uptime")
+	if window == "" && query.sort == "uptime" {
+		window = "24h"
+	}
+	if window != "" {
+		lookedUp, err := sla.LookupWindow(window)
+		if err != nil {
+			return query, err
+		}
+		query.uptime = &lookedUp
+	}
+	return query, nil
+}
+
+// matches reports whether a status passes the filters
+func (query statusQuery) matches(status EndpointStatus) bool {
+	if !checker.MatchLabels(status.Labels, query.labels) {
+		return false
+	}
+	if len(query.states) > 0 && !query.states[status.State] {
+		return false
+	}
+	if query.errorsOnly && status.Error == "" {
+		return false
+	}
+	if query.tag == "" {
+		return true
+	}
+	for _, tag := range status.Tags {
+		if tag == query.tag {
+			return true
+		}
+	}
+	return false
+}
+
+// filterStatuses applies a query to the current statuses
+func (ws *WebServer) filterStatuses(query statusQuery) ([]EndpointStatus, error) {
+	statuses := []EndpointStatus{}
+	for _, status := range ws.statuses() {
+		if query.matches(status) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	if query.uptime != nil {
+		if err := ws.addUptime(statuses, *query.uptime); err != nil {
+			return nil, err
+		}
+	}
+	if query.sort != "" {
+		sortStatuses(statuses, query.sort, query.descending)
+	}
+	return statuses, nil
+}
+
+// addUptime fills in each status's uptime over a window, excluding
+// maintenance and monitoring gaps like /api/sla. It needs the database.
+func (ws *WebServer) addUptime(statuses []EndpointStatus, window sla.Window) error {
+	now := time.Now().UTC()
+	maintenance, err := ws.store.GetMaintenance(now.Add(-window.Duration), now)
+	if err != nil {
+		return err
+	}
+	for i := range statuses {
+		target := sla.Target{URL: statuses[i].URL, Tags: statuses[i].Tags}
+		if endpoint, ok := ws.scheduler.Get(statuses[i].ID); ok {
+			target.MinGap = ws.monitoringGap(endpoint)
+		}
+		reports, err := sla.Build(ws.store, target, []sla.Window{window}, now, maintenance)
+		if err != nil {
+			return err
+		}
+		statuses[i].Uptime = reports[window.Name].UptimePercent
+	}
+	return nil
+}
+
+// sortStatuses orders statuses by a field; statuses without a value, e.g.
+// the latency of an endpoint not checked yet, come last either way
+func sortStatuses(statuses []EndpointStatus, field string, descending bool) {
+	value := func(status EndpointStatus) (float64, bool) {
+		switch field {
+		case "latency":
+			return float64(status.ResponseTime), !status.LastChecked.IsZero()
+		case "uptime":
+			if status.Uptime == nil {
+				return 0, false
+			}
+			return *status.Uptime, true
+		case "duration":
+			return float64(status.StateDuration), status.StateSince != nil
+		case "failures":
+			return float64(status.ConsecutiveFailures), true
+		}
+		return 0, true
+	}
+	less := func(a, b EndpointStatus) bool {
+		switch field {
+		case "url":
+			return a.URL < b.URL
+		case "state":
+			return a.State < b.State
+		}
+		av, _ := value(a)
+		bv, _ := value(b)
+		return av < bv
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		_, iok := value(statuses[i])
+		_, jok := value(statuses[j])
+		if iok != jok {
+			return iok
+		}
+		if descending {
+			return less(statuses[j], statuses[i])
+		}
+		return less(statuses[i], statuses[j])
+	})
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/events"
+)
+
+// sseHeartbeat keeps idle connections open through proxies
+const sseHeartbeat = 15 * time.Second
+
+// eventFilter selects the events one SSE connection receives
+type eventFilter struct {
+	urlPrefix    string // only events about URLs starting with this
+	stateChanges bool   // health changes instead of every check result
+	labels       map[string]string // only events whose result carries these labels
+}
+
+// parseEventFilter reads ?prefix=, ?stateChanges=true and ?label=name=value
+func parseEventFilter(r *http.Request) (eventFilter, error) {
+	query := r.URL.Query()
+	stateChanges, _ := strconv.ParseBool(query.Get("stateChanges"))
+	labels, err := checker.ParseLabels(query["label"])
+	if err != nil {
+		return eventFilter{}, err
+	}
+	return eventFilter{urlPrefix: query.Get("prefix"), stateChanges: stateChanges, labels: labels}, nil
+}
+
+// match reports whether the connection wants event. Insights pass the URL
+// prefix when any endpoint they affect matches it. Events without a result
+// are not filtered by label.
+func (f eventFilter) match(event events.Event) bool {
+	if event.Result != nil && !checker.MatchLabels(event.Result.Labels, f.labels) {
+		return false
+	}
+	if f.urlPrefix == "" {
+		return true
+	}
+	if event.Type == events.TypeInsights {
+		for _, insight := range event.Insights {
+			for _, url := range insight.AffectedEndpoints {
+				if strings.HasPrefix(url, f.urlPrefix) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return strings.HasPrefix(event.URL, f.urlPrefix)
+}
+
+// handleEventStream streams state-transition events as Server-Sent Events
+func (ws *WebServer) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	ws.streamEvents(w, r, eventFilter{stateChanges: true})
+}
+
+// handleEvents streams check results, state changes, alerts and new insights
+// as Server-Sent Events, for integrations that cannot use WebSockets or gRPC
+func (ws *WebServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ws.streamEvents(w, r, filter)
+}
+
+// streamEvents writes the events matching filter until the client leaves
+func (ws *WebServer) streamEvents(w http.ResponseWriter, r *http.Request, filter eventFilter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable nginx response buffering
+
+	eventsCh, unsubscribe := ws.events.Subscribe(64)
+	defer unsubscribe()
+
+	// Per-check results are not retained, so they cannot be replayed
+	var resultsCh <-chan events.Event
+	if !filter.stateChanges {
+		var unsubscribeResults func()
+		resultsCh, unsubscribeResults = ws.live.Subscribe(256)
+		defer unsubscribeResults()
+	}
+
+	// Replay anything missed since the client's last received event
+	if lastID, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		for _, event := range ws.events.Since(lastID) {
+			if filter.match(event) {
+				writeSSE(w, event)
+			}
+		}
+	}
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-eventsCh:
+			if !ok {
+				return
+			}
+			if !filter.match(event) {
+				continue
+			}
+			writeSSE(w, event)
+			flusher.Flush()
+		case event, ok := <-resultsCh:
+			if !ok {
+				return
+			}
+			if event.Type != events.TypeResult || !filter.match(event) {
+				continue
+			}
+			// Results have their own ID sequence; leave Last-Event-ID on the replayable events
+			event.ID = 0
+			writeSSE(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSE writes one event in text/event-stream format. Events without an
+// ID are sent without an id field.
+func writeSSE(w http.ResponseWriter, event events.Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if event.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", event.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/events"
+)
+
+// Limits of /api/results/tap
+const (
+	defaultTapDuration = time.Minute
+	maxTapDuration     = 10 * time.Minute
+)
+
+// TapLine is one line of the result tap
+type TapLine struct {
+	EndpointID string              `json:"endpointId"`
+	Result     checker.CheckResult `json:"result"`
+}
+
+// handleResultTap streams every ?sample=-th live check result (10 by
+// default, 1 for all) as newline-delimited JSON for ?duration= (1m by
+// default, at most 10m), to inspect labels, metadata and enrichment in
+// production. ?prefix= and ?label= filter like /api/events. Admin only.
+func (ws *WebServer) handleResultTap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sample := 10
+	if value := r.URL.Query().Get("sample"); value != "" {
+		if sample, err = strconv.Atoi(value); err != nil || sample < 1 {
+			http.Error(w, "sample must be a positive integer (1 in sample results)", http.StatusBadRequest)
+			return
+		}
+	}
+	duration := defaultTapDuration
+	if value := r.URL.Query().Get("duration"); value != "" {
+		if duration, err = time.ParseDuration(value); err != nil || duration <= 0 {
+			http.Error(w, "duration must be a positive duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		if duration > maxTapDuration {
+			duration = maxTapDuration
+		}
+	}
+	ws.audit(r, "results.tap", map[string]interface{}{"sample": sample, "duration": duration.String(), "prefix": filter.urlPrefix})
+
+	resultsCh, unsubscribe := ws.live.Subscribe(256)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	encoder := json.NewEncoder(w)
+	seen := 0
+	for {
+		select {
+		case event, ok := <-resultsCh:
+			if !ok {
+				return
+			}
+			if event.Type != events.TypeResult || event.Result == nil || !filter.match(event) {
+				continue
+			}
+			seen++
+			if seen%sample != 0 {
+				continue
+			}
+			if err := encoder.Encode(TapLine{EndpointID: event.EndpointID, Result: *event.Result}); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-deadline.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
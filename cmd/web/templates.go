@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/stats"
+)
+
+// templateStatsChecks is how many recent checks alert templates' stats cover
+const templateStatsChecks = 100
+
+// templateData completes the data alert message templates get with the
+// endpoint's tags, runbook and recent stats
+func (ws *WebServer) templateData(alert alerting.Alert) alerting.TemplateData {
+	data := alerting.TemplateData{
+		Alert:    alert,
+		Endpoint: alerting.TemplateEndpoint{ID: alert.EndpointID, URL: alert.URL},
+		Incident: alert.Outage,
+	}
+	if endpoint, ok := ws.scheduler.Get(alert.EndpointID); ok {
+		data.Endpoint.Tags = endpoint.Tags
+		data.Runbook = endpoint.Runbook
+	}
+	if ws.store == nil {
+		return data
+	}
+	results, err := ws.store.GetRecentResults(alert.URL, templateStatsChecks)
+	if err != nil {
+		log.Printf("Failed to load stats of %s for an alert template: %v", alert.URL, err)
+		return data
+	}
+	summary := &alerting.TemplateStats{Checks: len(results)}
+	latencies := make([]time.Duration, 0, len(results))
+	for _, result := range results {
+		if !result.IsHealthy {
+			summary.Failures++
+		}
+		if result.Usable() {
+			latencies = append(latencies, result.ResponseTime)
+		}
+	}
+	if summary.Checks > 0 {
+		summary.UptimePercent = float64(summary.Checks-summary.Failures) / float64(summary.Checks) * 100
+	}
+	summary.Latency = stats.Summarize(latencies, ws.config.StatsMode, ws.config.StatsTrimPercent)
+	data.Stats = summary
+	return data
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+)
+
+// Node states in the topology
+const (
+	nodeHealthy   = "healthy"
+	nodeUnhealthy = "unhealthy"
+	nodePaused    = "paused"
+	nodeDisabled  = "disabled"
+	nodeUnknown   = "unknown" // not checked yet, or its results are stale
+)
+
+// Topology is the service map: endpoints and their declared dependencies
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// TopologyNode is an endpoint with its live state. A failing node is a root
+// cause when none of its dependencies fail; the others name the root causes
+// they are failing because of.
+type TopologyNode struct {
+	ID           string        `json:"id"`
+	URL          string        `json:"url"`
+	Label        string        `json:"label"` // host, or the URL of non-HTTP checks
+	Tags         []string      `json:"tags,omitempty"`
+	State        string        `json:"state"`
+	RootCause    bool          `json:"rootCause,omitempty"`
+	ImpactedBy   []string      `json:"impactedBy,omitempty"`
+	ResponseTime time.Duration `json:"responseTime,omitempty"`
+	LastChecked  *time.Time    `json:"lastChecked,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// TopologyEdge points from an endpoint to one it depends on. Failing edges
+// lead to an unhealthy dependency.
+type TopologyEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Failing bool   `json:"failing"`
+}
+
+// handleTopology returns the service map with live health so the dashboard
+// can draw it and highlight root causes. ?tag= keeps the endpoints with the
+// tag and the dependencies they reach. Dependencies on endpoints that
+// aren't monitored are left out.
+func (ws *WebServer) handleTopology(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(buildTopology(ws.snapshot(), r.URL.Query().Get("tag")))
+}
+
+// buildTopology computes the service map of the snapshots
+func buildTopology(snapshots []endpointSnapshot, tag string) Topology {
+	nodes := make(map[string]*TopologyNode, len(snapshots))
+	dependsOn := make(map[string][]string, len(snapshots))
+	for _, snapshot := range snapshots {
+		endpoint := snapshot.endpoint
+		node := &TopologyNode{ID: endpoint.ID, URL: endpoint.URL, Label: nodeLabel(endpoint.URL), Tags: endpoint.Tags}
+		node.State = snapshot.state()
+		if node.State == nodeUnhealthy {
+			node.Error = snapshot.result.Error
+		}
+		if snapshot.hasResult {
+			checkedAt := snapshot.result.CheckedAt
+			node.ResponseTime = snapshot.result.ResponseTime
+			node.LastChecked = &checkedAt
+		}
+		nodes[endpoint.ID] = node
+		dependsOn[endpoint.ID] = endpoint.DependsOn
+	}
+
+	// Only edges between monitored endpoints
+	for id, deps := range dependsOn {
+		known := deps[:0:0]
+		for _, dep := range deps {
+			if _, ok := nodes[dep]; ok && dep != id {
+				known = append(known, dep)
+			}
+		}
+		dependsOn[id] = known
+	}
+
+	for id, node := range nodes {
+		if node.State != nodeUnhealthy {
+			continue
+		}
+		causes := rootCauses(id, nodes, dependsOn)
+		if len(causes) == 0 {
+			// No failing dependency, or only a cycle of failing endpoints
+			node.RootCause = true
+			continue
+		}
+		node.ImpactedBy = causes
+	}
+
+	included := make(map[string]bool, len(nodes))
+	for id, node := range nodes {
+		if tag == "" || hasTag(node.Tags, tag) {
+			include(id, dependsOn, included)
+		}
+	}
+
+	topology := Topology{Nodes: []TopologyNode{}, Edges: []TopologyEdge{}}
+	for id := range included {
+		topology.Nodes = append(topology.Nodes, *nodes[id])
+		for _, dep := range dependsOn[id] {
+			topology.Edges = append(topology.Edges, TopologyEdge{From: id, To: dep, Failing: nodes[dep].State == nodeUnhealthy})
+		}
+	}
+	sort.Slice(topology.Nodes, func(i, j int) bool { return topology.Nodes[i].URL < topology.Nodes[j].URL })
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		if topology.Edges[i].From != topology.Edges[j].From {
+			return topology.Edges[i].From < topology.Edges[j].From
+		}
+		return topology.Edges[i].To < topology.Edges[j].To
+	})
+	return topology
+}
+
+// rootCauses follows the failing dependencies of id and returns the failing
+// endpoints at the end of those chains, sorted
+func rootCauses(id string, nodes map[string]*TopologyNode, dependsOn map[string][]string) []string {
+	visited := map[string]bool{id: true}
+	causes := map[string]bool{}
+	var visit func(string)
+	visit = func(current string) {
+		failingDeps := 0
+		for _, dep := range dependsOn[current] {
+			if nodes[dep].State != nodeUnhealthy {
+				continue
+			}
+			failingDeps++
+			if !visited[dep] {
+				visited[dep] = true
+				visit(dep)
+			}
+		}
+		if failingDeps == 0 && current != id {
+			causes[current] = true
+		}
+	}
+	visit(id)
+
+	result := make([]string, 0, len(causes))
+	for cause := range causes {
+		result = append(result, cause)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// include adds id and everything it depends on, directly or not
+func include(id string, dependsOn map[string][]string, included map[string]bool) {
+	if included[id] {
+		return
+	}
+	included[id] = true
+	for _, dep := range dependsOn[id] {
+		include(dep, dependsOn, included)
+	}
+}
+
+// state returns the live state of a snapshot's endpoint
+func (snapshot endpointSnapshot) state() string {
+	switch {
+	case snapshot.endpoint.Disabled:
+		return nodeDisabled
+	case snapshot.paused:
+		return nodePaused
+	case !snapshot.hasResult, snapshot.stale:
+		return nodeUnknown
+	case snapshot.result.IsHealthy:
+		return nodeHealthy
+	default:
+		return nodeUnhealthy
+	}
+}
+
+// nodeLabel returns a short name for an endpoint on the map
+func nodeLabel(endpointURL string) string {
+	if parsed, err := url.Parse(endpointURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return endpointURL
+}
+
+// resolveDependencies turns dependsOn entries, endpoint IDs or URLs, into
+// endpoint IDs. Dependencies don't have to be monitored yet.
+func (ws *WebServer) resolveDependencies(refs []string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		id := ref
+		if _, exists := ws.scheduler.Get(ref); !exists && strings.Contains(ref, "://") {
+			if normalized, err := checker.NormalizeURL(ref); err == nil {
+				ref = normalized
+			}
+			id = scheduler.EndpointID(ref)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/checker"
+)
+
+// endpointTrends compares the endpoints of results over the last
+// TREND_WINDOW with the window before, from the stored history. Endpoints
+// without checks in the recent window are left out, and so is everything
+// without a database or with TREND_WINDOW=0.
+func (ws *WebServer) endpointTrends(results []checker.CheckResult) []ai.Trend {
+	window := ws.config.TrendWindow
+	if ws.store == nil || window <= 0 {
+		return nil
+	}
+	to := time.Now()
+	from := to.Add(-window)
+	previousFrom := from.Add(-window)
+
+	var trends []ai.Trend
+	for _, result := range results {
+		trend, ok, err := ws.endpointTrend(result.URL, previousFrom, from, to)
+		if err != nil {
+			log.Printf("Trend of %s unavailable: %v", result.URL, err)
+			continue
+		}
+		if ok {
+			trends = append(trends, trend)
+		}
+	}
+	return trends
+}
+
+// endpointTrend returns the trend of url over [from, to) against
+// [previousFrom, from), false without checks in [from, to)
+func (ws *WebServer) endpointTrend(url string, previousFrom, from, to time.Time) (ai.Trend, bool, error) {
+	checks, failed, err := ws.store.CountChecks(url, from, to)
+	if err != nil || checks == 0 {
+		return ai.Trend{}, false, err
+	}
+	previousChecks, previousFailed, err := ws.store.CountChecks(url, previousFrom, from)
+	if err != nil {
+		return ai.Trend{}, false, err
+	}
+
+	trend := ai.Trend{URL: url, Window: to.Sub(from), ErrorRate: float64(failed) / float64(checks)}
+	if previousChecks > 0 {
+		trend.PreviousErrorRate = float64(previousFailed) / float64(previousChecks)
+	}
+	if trend.P95, err = ws.storedP95(url, from, to); err != nil {
+		return ai.Trend{}, false, err
+	}
+	if trend.PreviousP95, err = ws.storedP95(url, previousFrom, from); err != nil {
+		return ai.Trend{}, false, err
+	}
+
+	buckets, err := ws.store.GetLatencyBuckets(url, from, to, ws.config.TrendBucket)
+	if err != nil {
+		return ai.Trend{}, false, err
+	}
+	for _, bucket := range buckets {
+		trend.Points = append(trend.Points, ai.TrendPoint{Start: bucket.Start, P95: bucket.P95, ErrorRate: bucket.ErrorRate})
+	}
+	return trend, true, nil
+}
+
+// trendsOf keeps the trends of the endpoints of results
+func trendsOf(trends []ai.Trend, results []checker.CheckResult) []ai.Trend {
+	urls := make(map[string]bool, len(results))
+	for _, result := range results {
+		urls[result.URL] = true
+	}
+	var kept []ai.Trend
+	for _, trend := range trends {
+		if urls[trend.URL] {
+			kept = append(kept, trend)
+		}
+	}
+	return kept
+}
+
+// predictionInsights forecasts from the trends which endpoints breach their
+// limits within PREDICTION_HORIZON: the latency threshold, and the error
+// budget of their SLO when they have one
+func (ws *WebServer) predictionInsights(trends []ai.Trend) []ai.Insight {
+	if ws.config.PredictionHorizon <= 0 || len(trends) == 0 {
+		return nil
+	}
+	budgets := make(map[string]float64)
+	for _, endpoint := range ws.scheduler.List() {
+		if endpoint.SLO != nil {
+			budgets[endpoint.URL] = endpoint.SLO.Budget()
+		}
+	}
+	limits := func(url string) ai.BreachLimits {
+		return ai.BreachLimits{Latency: ws.currentConfig().LatencyThreshold, ErrorRate: budgets[url]}
+	}
+	return ai.PredictionInsights(ai.PredictBreaches(trends, limits, ws.config.PredictionHorizon), limits)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/manifest"
+)
+
+// maxManifestBytes caps the size of a configuration file sent for validation
+const maxManifestBytes = 5 << 20
+
+// handleValidate checks a declarative endpoints file (YAML or JSON) without
+// applying it. Pass ?offline=true to skip the host reachability checks.
+// Responds 422 when the file has errors so CI can use curl --fail.
+func (ws *WebServer) handleValidate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestBytes))
+	if err != nil {
+		http.Error(w, "Configuration file too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var report manifest.Report
+	file, err := manifest.Parse(data)
+	if err != nil {
+		report = manifest.Report{Problems: []manifest.Problem{{
+			Index:    -1,
+			Severity: manifest.SeverityError,
+			Message:  err.Error(),
+		}}}
+	} else {
+		knownTypes := []string{checker.TypeHTTP}
+		for name := range ws.custom {
+			knownTypes = append(knownTypes, name)
+		}
+		report = manifest.Validate(r.Context(), file, manifest.Options{
+			CheckHosts: r.URL.Query().Get("offline") != "true",
+			Timeout:    5 * time.Second,
+			KnownTypes: knownTypes,
+		})
+	}
+
+	if !report.Valid {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/events"
+)
+
+// Long-poll timeouts of /api/status/wait
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+)
+
+// handleStatusWait long-polls for state changes. The response is the same as
+// /api/status with an ETag naming the latest event; a client passes it back
+// as ?since= (or If-None-Match) and the request blocks until an endpoint
+// changes state, or answers 304 Not Modified after ?timeout=.
+// Without since, or with an ETag from before a restart, it answers at once.
+func (ws *WebServer) handleStatusWait(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := parseStatusQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.uptime != nil && ws.store == nil {
+		http.Error(w, "Database unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	labels := query.labels
+	timeout := defaultWaitTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		if timeout, err = time.ParseDuration(value); err != nil || timeout <= 0 {
+			http.Error(w, "timeout must be a positive duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		if timeout > maxWaitTimeout {
+			timeout = maxWaitTimeout
+		}
+	}
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		since = r.Header.Get("If-None-Match")
+	}
+
+	// Subscribe before looking at the history so no change slips in between
+	eventsCh, unsubscribe := ws.events.Subscribe(64)
+	defer unsubscribe()
+
+	if id, err := strconv.ParseInt(strings.Trim(since, `W/"`), 10, 64); err == nil && id <= ws.events.LastID() && !ws.changedSince(id, labels) {
+		wait := time.NewTimer(timeout)
+		defer wait.Stop()
+	waiting:
+		for {
+			select {
+			case event, ok := <-eventsCh:
+				if !ok {
+					return
+				}
+				if isStatusChange(event, labels) {
+					break waiting
+				}
+			case <-wait.C:
+				w.Header().Set("ETag", etag(id))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	statuses, err := ws.filterStatuses(query)
+	if err != nil {
+		log.Printf("Failed to compute uptime for /api/status/wait: %v", err)
+		http.Error(w, "Failed to compute uptime", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(ws.events.LastID()))
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// changedSince reports whether an endpoint changed state after event id
+func (ws *WebServer) changedSince(id int64, labels map[string]string) bool {
+	for _, event := range ws.events.Since(id) {
+		if isStatusChange(event, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStatusChange reports whether event is a state change of an endpoint
+// with the given labels
+func isStatusChange(event events.Event, labels map[string]string) bool {
+	if event.Type != events.TypeStateChange {
+		return false
+	}
+	return event.Result == nil || checker.MatchLabels(event.Result.Labels, labels)
+}
+
+func etag(id int64) string {
+	return fmt.Sprintf(`"%d"`, id)
+}
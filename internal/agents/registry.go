@@ -0,0 +1,428 @@
+// Package agents tracks the remote collectors that run checks on behalf of
+// the server and which endpoints each of them is responsible for
+package agents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent states
+const (
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+)
+
+// Assignment strategies of an endpoint
+const (
+	StrategyRoundRobin     = "round-robin"     // one agent, endpoints spread evenly over all agents (the default)
+	StrategyRegionAffinity = "region-affinity" // one agent in the first listed region that has one online
+	StrategyAllRegions     = "all-regions"     // one agent in every region, or every listed region
+)
+
+// Placement is how an endpoint is assigned to agents
+type Placement struct {
+	Strategy string   `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	Regions  []string `json:"regions,omitempty" yaml:"regions,omitempty"`
+}
+
+// Validate checks the strategy and that region affinity names its regions
+func (p Placement) Validate() error {
+	switch p.Strategy {
+	case "", StrategyRoundRobin, StrategyAllRegions:
+	case StrategyRegionAffinity:
+		if len(p.Regions) == 0 {
+			return fmt.Errorf("agent strategy %q needs regions", StrategyRegionAffinity)
+		}
+	default:
+		return fmt.Errorf("agent strategy must be %q, %q or %q", StrategyRoundRobin, StrategyRegionAffinity, StrategyAllRegions)
+	}
+	for _, region := range p.Regions {
+		if strings.TrimSpace(region) == "" {
+			return fmt.Errorf("agent regions must not be empty")
+		}
+	}
+	return nil
+}
+
+// Registration is what an agent sends when it starts
+type Registration struct {
+	Name    string            `json:"name"`             // unique per agent; re-registering under it keeps the agent's ID
+	Region  string            `json:"region,omitempty"` // where the agent runs, e.g. "eu-west-1"
+	Labels  map[string]string `json:"labels,omitempty"`
+	Version string            `json:"version,omitempty"`
+}
+
+// Heartbeat is what an agent reports periodically
+type Heartbeat struct {
+	InFlight int   `json:"inFlight"` // checks running right now
+	Checks   int64 `json:"checks"`   // checks run since the agent started
+}
+
+// Agent is a registered agent and its health
+type Agent struct {
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Region  string            `json:"region,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Version string            `json:"version,omitempty"`
+
+	Status        string     `json:"status"`
+	RegisteredAt  time.Time  `json:"registeredAt"`
+	LastHeartbeat time.Time  `json:"lastHeartbeat"`
+	OfflineSince  *time.Time `json:"offlineSince,omitempty"`
+
+	// MissedHeartbeats counts the heartbeat intervals since the last one
+	MissedHeartbeats int       `json:"missedHeartbeats"`
+	Heartbeat        Heartbeat `json:"heartbeat"`
+
+	// Endpoints are the IDs of the endpoints assigned to the agent
+	Endpoints []string `json:"endpoints"`
+}
+
+// Registry keeps the registered agents in memory; agents register again
+// when a heartbeat is rejected, e.g. after a server restart
+type Registry struct {
+	interval time.Duration // expected time between heartbeats
+	missed   int           // heartbeats missed before an agent is offline
+
+	agents      map[string]*Agent
+	assignments map[string][]string // endpoint ID → agent IDs
+	mutex       sync.RWMutex
+}
+
+// NewRegistry creates a registry that marks agents offline after missing
+// the given number of heartbeats
+func NewRegistry(interval time.Duration, missed int) *Registry {
+	if missed < 1 {
+		missed = 1
+	}
+	return &Registry{
+		interval:    interval,
+		missed:      missed,
+		agents:      make(map[string]*Agent),
+		assignments: make(map[string][]string),
+	}
+}
+
+// Interval returns how often agents should send heartbeats
+func (r *Registry) Interval() time.Duration {
+	return r.interval
+}
+
+// AgentID derives the stable ID of an agent from its name
+func AgentID(name string) string {
+	sum := sha256.Sum256([]byte("agent:" + name))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Register adds an agent, or brings a known one back online with its
+// details updated and its assignments kept
+func (r *Registry) Register(registration Registration, now time.Time) (Agent, error) {
+	registration.Name = strings.TrimSpace(registration.Name)
+	if registration.Name == "" {
+		return Agent{}, fmt.Errorf("agent name is required")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := AgentID(registration.Name)
+	agent, exists := r.agents[id]
+	if !exists {
+		agent = &Agent{ID: id, RegisteredAt: now}
+		r.agents[id] = agent
+	}
+	agent.Name = registration.Name
+	agent.Region = strings.TrimSpace(registration.Region)
+	agent.Labels = registration.Labels
+	agent.Version = registration.Version
+	agent.Status = StatusOnline
+	agent.LastHeartbeat = now
+	agent.OfflineSince = nil
+	agent.Heartbeat = Heartbeat{}
+	return r.view(agent, now), nil
+}
+
+// Heartbeat records a heartbeat, bringing an offline agent back online. It
+// reports false for unknown agents, which should register again.
+func (r *Registry) Heartbeat(id string, heartbeat Heartbeat, now time.Time) (Agent, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	agent, exists := r.agents[id]
+	if !exists {
+		return Agent{}, false
+	}
+	agent.LastHeartbeat = now
+	agent.Heartbeat = heartbeat
+	agent.Status = StatusOnline
+	agent.OfflineSince = nil
+	return r.view(agent, now), true
+}
+
+// Deregister removes an agent, e.g. on a clean shutdown, and releases its
+// endpoints
+func (r *Registry) Deregister(id string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.agents[id]; !exists {
+		return false
+	}
+	delete(r.agents, id)
+	r.release(id)
+	return true
+}
+
+// Sweep marks agents that missed too many heartbeats offline and releases
+// their endpoints for reassignment. It returns the agents that went offline.
+func (r *Registry) Sweep(now time.Time) []Agent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var offline []Agent
+	deadline := now.Add(-time.Duration(r.missed) * r.interval)
+	for id, agent := range r.agents {
+		if agent.Status != StatusOnline || agent.LastHeartbeat.After(deadline) {
+			continue
+		}
+		view := r.view(agent, now)
+		agent.Status = StatusOffline
+		at := now
+		agent.OfflineSince = &at
+		r.release(id)
+
+		view.Status = StatusOffline
+		view.OfflineSince = &at
+		offline = append(offline, view)
+	}
+	sort.Slice(offline, func(i, j int) bool { return offline[i].Name < offline[j].Name })
+	return offline
+}
+
+// release unassigns every endpoint of an agent. The caller holds the lock.
+func (r *Registry) release(agentID string) {
+	for endpointID, assigned := range r.assignments {
+		kept := assigned[:0]
+		for _, id := range assigned {
+			if id != agentID {
+				kept = append(kept, id)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.assignments, endpointID)
+		} else {
+			r.assignments[endpointID] = kept
+		}
+	}
+}
+
+// Assign brings the assignments in line with the endpoints' placements:
+// endpoints that no longer exist are forgotten, assignments that still fit
+// are kept up to an even share per agent, and every missing agent is the
+// online agent with the fewest endpoints among those the strategy allows.
+// Agents that come online thereby take over endpoints from busier ones. It
+// returns how many endpoint-agent pairs were added or moved.
+func (r *Registry) Assign(endpoints map[string]Placement) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var online []string
+	byRegion := make(map[string][]string)
+	for id, agent := range r.agents {
+		if agent.Status == StatusOnline {
+			online = append(online, id)
+		}
+	}
+	sort.Strings(online)
+	for _, id := range online {
+		region := r.agents[id].Region
+		byRegion[region] = append(byRegion[region], id)
+	}
+
+	for endpointID := range r.assignments {
+		if _, exists := endpoints[endpointID]; !exists {
+			delete(r.assignments, endpointID)
+		}
+	}
+	ids := make([]string, 0, len(endpoints))
+	for endpointID := range endpoints {
+		ids = append(ids, endpointID)
+	}
+	sort.Strings(ids)
+
+	groups := make(map[string][][]string, len(ids))
+	slots := 0
+	for _, endpointID := range ids {
+		groups[endpointID] = placementGroups(endpoints[endpointID], online, byRegion)
+		slots += len(groups[endpointID])
+	}
+	share := 0
+	if len(online) > 0 {
+		share = (slots + len(online) - 1) / len(online)
+	}
+
+	// Keep the agents that still fit before placing anything, so new
+	// assignments see the real load
+	load := make(map[string]int)
+	missing := make(map[string][][]string)
+	previous := make(map[string][]string)
+	for _, endpointID := range ids {
+		var kept []string
+		for _, group := range groups[endpointID] {
+			if agentID, ok := firstOf(r.assignments[endpointID], group); ok && load[agentID] < share {
+				kept = append(kept, agentID)
+				load[agentID]++
+			} else {
+				missing[endpointID] = append(missing[endpointID], group)
+			}
+		}
+		previous[endpointID] = r.assignments[endpointID]
+		if len(kept) == 0 {
+			delete(r.assignments, endpointID)
+		} else {
+			r.assignments[endpointID] = kept
+		}
+	}
+
+	assigned := 0
+	for _, endpointID := range ids {
+		for _, group := range missing[endpointID] {
+			least := group[0]
+			for _, agentID := range group[1:] {
+				if load[agentID] < load[least] {
+					least = agentID
+				}
+			}
+			r.assignments[endpointID] = append(r.assignments[endpointID], least)
+			load[least]++
+			if _, unchanged := firstOf([]string{least}, previous[endpointID]); !unchanged {
+				assigned++
+			}
+		}
+	}
+	return assigned
+}
+
+// placementGroups returns the groups of online agents an endpoint needs one
+// agent from each of
+func placementGroups(placement Placement, online []string, byRegion map[string][]string) [][]string {
+	if len(online) == 0 {
+		return nil
+	}
+	switch placement.Strategy {
+	case StrategyRegionAffinity:
+		for _, region := range placement.Regions {
+			if agents := byRegion[region]; len(agents) > 0 {
+				return [][]string{agents}
+			}
+		}
+		// No agent in the preferred regions is online, so any will do
+		return [][]string{online}
+	case StrategyAllRegions:
+		regions := placement.Regions
+		if len(regions) == 0 {
+			for region := range byRegion {
+				regions = append(regions, region)
+			}
+			sort.Strings(regions)
+		}
+		var groups [][]string
+		for _, region := range regions {
+			if agents := byRegion[region]; len(agents) > 0 {
+				groups = append(groups, agents)
+			}
+		}
+		return groups
+	default:
+		return [][]string{online}
+	}
+}
+
+// firstOf returns the first of ids that is in group
+func firstOf(ids, group []string) (string, bool) {
+	for _, id := range ids {
+		for _, candidate := range group {
+			if id == candidate {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Assigned reports whether an endpoint is assigned to the given agent
+func (r *Registry) Assigned(endpointID, agentID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, id := range r.assignments[endpointID] {
+		if id == agentID {
+			return r.agents[id] != nil && r.agents[id].Status == StatusOnline
+		}
+	}
+	return false
+}
+
+// Delegated reports whether an online agent checks an endpoint
+func (r *Registry) Delegated(endpointID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, id := range r.assignments[endpointID] {
+		if r.agents[id] != nil && r.agents[id].Status == StatusOnline {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns an agent
+func (r *Registry) Get(id string) (Agent, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	agent, exists := r.agents[id]
+	if !exists {
+		return Agent{}, false
+	}
+	return r.view(agent, time.Now()), true
+}
+
+// List returns every agent by name
+func (r *Registry) List(now time.Time) []Agent {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	agents := make([]Agent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		agents = append(agents, r.view(agent, now))
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// view copies an agent with its missed heartbeats and endpoints filled in.
+// The caller holds the lock.
+func (r *Registry) view(agent *Agent, now time.Time) Agent {
+	view := *agent
+	if r.interval > 0 {
+		view.MissedHeartbeats = int(now.Sub(agent.LastHeartbeat) / r.interval)
+	}
+	view.Endpoints = []string{}
+	for endpointID, agentIDs := range r.assignments {
+		for _, agentID := range agentIDs {
+			if agentID == agent.ID {
+				view.Endpoints = append(view.Endpoints, endpointID)
+			}
+		}
+	}
+	sort.Strings(view.Endpoints)
+	return view
+}
@@ -0,0 +1,272 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Week-over-week changes the rule-based digest reports: uptime moving by at
+// least digestUptimeChange percentage points, or p95 latency by at least
+// digestLatencyChange of the previous week's
+const (
+	digestUptimeChange  = 0.1
+	digestLatencyChange = 0.25
+)
+
+// DigestInput is the week a digest is written about
+type DigestInput struct {
+	From      time.Time
+	To        time.Time
+	Endpoints []DigestEndpoint
+	Incidents []DigestIncident
+}
+
+// DigestEndpoint is an endpoint's week next to the week before. Uptime is
+// nil without observed time and P95 zero without latency data.
+type DigestEndpoint struct {
+	URL                   string        `json:"url"`
+	Checks                int           `json:"checks"`
+	FailedChecks          int           `json:"failedChecks"`
+	UptimePercent         *float64      `json:"uptimePercent"`
+	PreviousUptimePercent *float64      `json:"previousUptimePercent"`
+	P95                   time.Duration `json:"p95"`
+	PreviousP95           time.Duration `json:"previousP95"`
+	Outages               int           `json:"outages"`
+	Downtime              time.Duration `json:"downtime"`
+}
+
+// DigestIncident is an outage that overlapped the week
+type DigestIncident struct {
+	URL       string        `json:"url"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+	Ongoing   bool          `json:"ongoing,omitempty"`
+	Cause     string        `json:"cause,omitempty"`
+}
+
+// Digest is the narrative summary of a week
+type Digest struct {
+	Summary      string       `json:"summary"`
+	Regressions  []DigestItem `json:"regressions"`
+	Improvements []DigestItem `json:"improvements"`
+	FocusAreas   []string     `json:"focusAreas"`
+	Tier         string       `json:"tier"`
+}
+
+// DigestItem is one notable change of the week
+type DigestItem struct {
+	Title     string   `json:"title"`
+	Detail    string   `json:"detail,omitempty"`
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// WeeklyDigest writes the digest of a week, trying each tier in turn and
+// falling back to a rule-based digest if all fail
+func (c *GPTOSSClient) WeeklyDigest(ctx context.Context, input DigestInput) (Digest, error) {
+	ctx, span := tracer.Start(ctx, "ai digest", trace.WithAttributes(attribute.Int("ai.endpoints", len(input.Endpoints))))
+	defer span.End()
+	urls := make([]string, 0, len(input.Endpoints))
+	for _, endpoint := range input.Endpoints {
+		urls = append(urls, endpoint.URL)
+	}
+	ctx = withUsageURLs(ctx, urls)
+	messages := []Message{
+		{
+			Role:    "system",
+			Content: "You are a monitoring system AI assistant writing a weekly report for the engineers running these APIs. Respond only with valid JSON.",
+		},
+		{Role: "user", Content: buildDigestPrompt(input)},
+	}
+
+	var failures []string
+	for _, tier := range c.tiers {
+		digest, err := c.digestWith(ctx, tier, messages, urls)
+		if err == nil {
+			span.SetAttributes(attribute.String("ai.tier", tier.Name))
+			return digest, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s): %v", tier.Name, tier.Model, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.String("ai.tier", RuleBasedTier))
+	span.SetStatus(codes.Error, "every tier failed")
+	return FallbackDigest(input), fmt.Errorf("AI digest failed, using fallback: %s", strings.Join(failures, "; "))
+}
+
+// digestWith asks one tier for the digest within the tier timeout. A reply
+// without a summary counts as a failure.
+func (c *GPTOSSClient) digestWith(ctx context.Context, tier Tier, messages []Message, urls []string) (Digest, error) {
+	if c.tierTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.tierTimeout)
+		defer cancel()
+	}
+
+	response, err := c.chat(ctx, tier, messages)
+	if err != nil {
+		return Digest{}, err
+	}
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start == -1 || end <= start {
+		return Digest{}, fmt.Errorf("no digest in response")
+	}
+	var digest Digest
+	if err := json.Unmarshal([]byte(response[start:end+1]), &digest); err != nil {
+		return Digest{}, fmt.Errorf("invalid digest: %w", err)
+	}
+	if digest.Summary = strings.TrimSpace(digest.Summary); digest.Summary == "" {
+		return Digest{}, fmt.Errorf("digest without summary")
+	}
+
+	known := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		known[url] = true
+	}
+	digest.Regressions = cleanDigestItems(digest.Regressions, known)
+	digest.Improvements = cleanDigestItems(digest.Improvements, known)
+	digest.FocusAreas = nonEmpty(digest.FocusAreas)
+	if digest.FocusAreas == nil {
+		digest.FocusAreas = []string{}
+	}
+	digest.Tier = tier.Name
+	return digest, nil
+}
+
+// cleanDigestItems drops untitled items and endpoints the model made up
+func cleanDigestItems(items []DigestItem, known map[string]bool) []DigestItem {
+	cleaned := []DigestItem{}
+	for _, item := range items {
+		if item.Title = strings.TrimSpace(item.Title); item.Title == "" {
+			continue
+		}
+		var endpoints []string
+		for _, url := range item.Endpoints {
+			if url = strings.TrimSpace(url); known[url] {
+				endpoints = append(endpoints, url)
+			}
+		}
+		item.Endpoints = endpoints
+		cleaned = append(cleaned, item)
+	}
+	return cleaned
+}
+
+// buildDigestPrompt lays out the week's figures, the previous week's for
+// comparison, and the incidents
+func buildDigestPrompt(input DigestInput) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Write the weekly digest of an API fleet for %s to %s (UTC).\n\n",
+		input.From.UTC().Format("Mon Jan 2 15:04"), input.To.UTC().Format("Mon Jan 2 15:04")))
+	sb.WriteString("Endpoints, this week versus the week before:\n")
+	for _, endpoint := range input.Endpoints {
+		sb.WriteString(fmt.Sprintf("- %s: uptime %s (was %s), p95 %s (was %s), %d checks, %d failed, %d outages, %v downtime\n",
+			endpoint.URL, formatUptime(endpoint.UptimePercent), formatUptime(endpoint.PreviousUptimePercent),
+			formatP95(endpoint.P95), formatP95(endpoint.PreviousP95), endpoint.Checks, endpoint.FailedChecks,
+			endpoint.Outages, endpoint.Downtime.Round(time.Second)))
+	}
+	if len(input.Incidents) > 0 {
+		sb.WriteString("\nIncidents:\n")
+		for _, incident := range input.Incidents {
+			state := ""
+			if incident.Ongoing {
+				state = ", ongoing"
+			}
+			sb.WriteString(fmt.Sprintf("- %s %s: %v%s %s\n", incident.StartedAt.UTC().Format(time.RFC3339),
+				incident.URL, incident.Duration.Round(time.Second), state, incident.Cause))
+		}
+	}
+
+	sb.WriteString("\nRespond with a JSON object: {\"summary\":\"two or three sentences on the week\",")
+	sb.WriteString("\"regressions\":[{\"title\":\"...\",\"detail\":\"...\",\"endpoints\":[\"https://...\"]}],")
+	sb.WriteString("\"improvements\":[{\"title\":\"...\",\"detail\":\"...\",\"endpoints\":[\"https://...\"]}],")
+	sb.WriteString("\"focusAreas\":[\"a recommended focus for next week\"]}\n")
+	sb.WriteString("List only notable regressions and improvements, using the exact URLs above, and at most three focus areas.\n")
+	return sb.String()
+}
+
+func formatUptime(uptime *float64) string {
+	if uptime == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.3f%%", *uptime)
+}
+
+func formatP95(p95 time.Duration) string {
+	if p95 <= 0 {
+		return "n/a"
+	}
+	return p95.Round(time.Millisecond).String()
+}
+
+// FallbackDigest reports the week from the figures alone: endpoints whose
+// uptime or p95 latency moved notably, and the ones with the most downtime
+// as focus areas
+func FallbackDigest(input DigestInput) Digest {
+	digest := Digest{Regressions: []DigestItem{}, Improvements: []DigestItem{}, FocusAreas: []string{}, Tier: RuleBasedTier}
+
+	uptimeSum, measured := 0.0, 0
+	for _, endpoint := range input.Endpoints {
+		if uptime, previous := endpoint.UptimePercent, endpoint.PreviousUptimePercent; uptime != nil {
+			uptimeSum += *uptime
+			measured++
+			if previous != nil && *uptime <= *previous-digestUptimeChange {
+				digest.Regressions = append(digest.Regressions, DigestItem{
+					Title:     fmt.Sprintf("Uptime of %s fell to %.3f%%", endpoint.URL, *uptime),
+					Detail:    fmt.Sprintf("Down from %.3f%% the week before, with %d outage(s)", *previous, endpoint.Outages),
+					Endpoints: []string{endpoint.URL},
+				})
+			} else if previous != nil && *uptime >= *previous+digestUptimeChange {
+				digest.Improvements = append(digest.Improvements, DigestItem{
+					Title:     fmt.Sprintf("Uptime of %s rose to %.3f%%", endpoint.URL, *uptime),
+					Detail:    fmt.Sprintf("Up from %.3f%% the week before", *previous),
+					Endpoints: []string{endpoint.URL},
+				})
+			}
+		}
+		if endpoint.P95 > 0 && endpoint.PreviousP95 > 0 {
+			change := float64(endpoint.P95-endpoint.PreviousP95) / float64(endpoint.PreviousP95)
+			item := DigestItem{
+				Detail:    fmt.Sprintf("p95 %s, was %s", formatP95(endpoint.P95), formatP95(endpoint.PreviousP95)),
+				Endpoints: []string{endpoint.URL},
+			}
+			if change >= digestLatencyChange {
+				item.Title = fmt.Sprintf("%s is %.0f%% slower", endpoint.URL, change*100)
+				digest.Regressions = append(digest.Regressions, item)
+			} else if change <= -digestLatencyChange {
+				item.Title = fmt.Sprintf("%s is %.0f%% faster", endpoint.URL, -change*100)
+				digest.Improvements = append(digest.Improvements, item)
+			}
+		}
+	}
+
+	down := make([]DigestEndpoint, 0, len(input.Endpoints))
+	for _, endpoint := range input.Endpoints {
+		if endpoint.Downtime > 0 {
+			down = append(down, endpoint)
+		}
+	}
+	sort.Slice(down, func(i, j int) bool { return down[i].Downtime > down[j].Downtime })
+	for _, endpoint := range down[:min(len(down), 3)] {
+		digest.FocusAreas = append(digest.FocusAreas, fmt.Sprintf("Reduce the downtime of %s (%v over %d outage(s))",
+			endpoint.URL, endpoint.Downtime.Round(time.Second), endpoint.Outages))
+	}
+
+	fleetUptime := "n/a"
+	if measured > 0 {
+		fleetUptime = fmt.Sprintf("%.3f%%", uptimeSum/float64(measured))
+	}
+	digest.Summary = fmt.Sprintf("%d endpoint(s) monitored with an average uptime of %s and %d incident(s); %d regression(s) and %d improvement(s) versus the week before.",
+		len(input.Endpoints), fleetUptime, len(input.Incidents), len(digest.Regressions), len(digest.Improvements))
+	return digest
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Exchange is one question about an insight and the model's answer
+type Exchange struct {
+	Question string    `json:"question"`
+	Answer   string    `json:"answer"`
+	Tier     string    `json:"tier,omitempty"`
+	AskedAt  time.Time `json:"askedAt"`
+}
+
+// FollowUpContext is what the model is shown before a follow-up question
+type FollowUpContext struct {
+	Insight  Insight
+	Snapshot []checker.CheckResult // results the insight was generated from
+	History  []checker.CheckResult // recent results of the affected endpoints, newest first
+	Thread   []Exchange            // earlier follow-ups, oldest first
+}
+
+// FollowUp answers a question about an insight, trying each tier in turn.
+// There is no rule-based answer, so an error means no tier could respond.
+func (c *GPTOSSClient) FollowUp(ctx context.Context, followUp FollowUpContext, question string) (Exchange, error) {
+	messages := c.followUpMessages(followUp, question)
+	urls := followUp.Insight.AffectedEndpoints
+	if len(urls) == 0 {
+		for _, result := range followUp.Snapshot {
+			urls = append(urls, result.URL)
+		}
+	}
+	ctx = withUsageURLs(ctx, urls)
+
+	var failures []string
+	for _, tier := range c.tiers {
+		answer, err := c.followUpWith(ctx, tier, messages)
+		if err == nil {
+			return Exchange{Question: question, Answer: answer, Tier: tier.Name, AskedAt: time.Now()}, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s): %v", tier.Name, tier.Model, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Exchange{}, fmt.Errorf("AI follow-up failed: %s", strings.Join(failures, "; "))
+}
+
+// followUpWith asks one tier within the tier timeout
+func (c *GPTOSSClient) followUpWith(ctx context.Context, tier Tier, messages []Message) (string, error) {
+	if c.tierTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.tierTimeout)
+		defer cancel()
+	}
+
+	answer, err := c.chat(ctx, tier, messages)
+	if err != nil {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return "", fmt.Errorf("empty answer")
+	}
+	return answer, nil
+}
+
+// followUpMessages replays the insight as the model's own earlier answer,
+// followed by the thread so far and the new question
+func (c *GPTOSSClient) followUpMessages(followUp FollowUpContext, question string) []Message {
+	var sb strings.Builder
+	sb.WriteString(c.buildAnalysisPrompt(followUp.Snapshot, nil))
+	if len(followUp.History) > 0 {
+		sb.WriteString("\nRecent history of the affected endpoints (newest first):\n")
+		for _, result := range followUp.History {
+			status := "HEALTHY"
+			if !result.IsHealthy {
+				status = "UNHEALTHY"
+			}
+			sb.WriteString(fmt.Sprintf("- %s %s: %s (Status: %d, Response Time: %v, Error: %s)\n",
+				result.CheckedAt.Format(time.RFC3339), result.URL, status, result.StatusCode,
+				result.ResponseTime.Round(time.Millisecond), result.Error))
+		}
+	}
+
+	insight, _ := json.Marshal(followUp.Insight)
+
+	messages := []Message{
+		{
+			Role:    "system",
+			Content: "You are a monitoring system AI assistant. Answer questions about your earlier insight in plain text, briefly, using only the monitoring data provided.",
+		},
+		{Role: "user", Content: sb.String()},
+		{Role: "assistant", Content: string(insight)},
+	}
+	for _, exchange := range followUp.Thread {
+		messages = append(messages,
+			Message{Role: "user", Content: exchange.Question},
+			Message{Role: "assistant", Content: exchange.Answer},
+		)
+	}
+	return append(messages, Message{Role: "user", Content: question})
+}
@@ -11,16 +11,45 @@ import (
 	"time"
 
 	"api-monitor/internal/checker"
+	"api-monitor/internal/stats"
+	"api-monitor/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// GPTOSSClient handles interactions with OpenAI's GPT-OSS model
+var tracer = otel.Tracer("api-monitor/internal/ai")
+
+// RuleBasedTier names insights produced by the built-in rules, the last
+// tier of the fallback chain
+const RuleBasedTier = "rules"
+
+// Tier is one OpenAI-compatible model in the fallback chain
+type Tier struct {
+	Name    string `json:"name"`
+	Model   string `json:"model"`
+	BaseURL string `json:"baseUrl"`
+	APIKey  string `json:"-"`
+}
+
+// GPTOSSClient handles interactions with OpenAI's GPT-OSS model. Further
+// models can be added with AddFallback; they are tried in order when the
+// previous one fails or times out.
 type GPTOSSClient struct {
-	baseURL    string
-	apiKey     string
-	model      string
+	tiers       []Tier
+	tierTimeout time.Duration
 	client     *http.Client
 	maxTokens  int
 	temperature float64
+	
+	// Latency summary used by the rule-based fallback
+	statsMode        string
+	statsTrimPercent float64
+	
+	// usage is told the tokens each request used, see OnUsage
+	usage func(urls []string, tokens int)
 }
 
 // Insight represents an AI-generated monitoring insight
@@ -30,6 +59,35 @@ type Insight struct {
 	Type        string    `json:"type"`        // "alert", "warning", "info", "success"
 	Confidence  float64   `json:"confidence"`  // 0.0 to 1.0
 	GeneratedAt time.Time `json:"generatedAt"`
+	Tier        string    `json:"tier,omitempty"` // fallback tier that produced the insight
+	ID          string    `json:"id,omitempty"`   // assigned when served, for follow-up questions
+
+	AffectedEndpoints []string `json:"affectedEndpoints,omitempty"` // URLs the insight is about
+	SuggestedActions  []string `json:"suggestedActions,omitempty"`  // concrete next steps
+}
+
+// Affects reports whether the insight concerns url
+func (i Insight) Affects(url string) bool {
+	for _, affected := range i.AffectedEndpoints {
+		if affected == url {
+			return true
+		}
+	}
+	return false
+}
+
+// WithConfidence keeps the insights with a confidence of at least min
+func WithConfidence(insights []Insight, min float64) []Insight {
+	if min <= 0 {
+		return insights
+	}
+	kept := []Insight{}
+	for _, insight := range insights {
+		if insight.Confidence >= min {
+			kept = append(kept, insight)
+		}
+	}
+	return kept
 }
 
 // ChatCompletionRequest represents the request structure for GPT-OSS
@@ -53,6 +111,9 @@ type ChatCompletionResponse struct {
 	Created int64    `json:"created"`
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
 }
 
 // Choice represents a completion choice
@@ -63,45 +124,162 @@ type Choice struct {
 
 // NewGPTOSSClient creates a new GPT-OSS client
 func NewGPTOSSClient(baseURL, apiKey, model string) *GPTOSSClient {
-    effectiveModel := strings.TrimSpace(model)
-    if effectiveModel == "" {
-        effectiveModel = "gpt-oss-20b"
-    }
-    return &GPTOSSClient{
-        baseURL:     baseURL,
-        apiKey:      apiKey,
-        model:       effectiveModel,
-        client:      &http.Client{Timeout: 30 * time.Second},
-        maxTokens:   512,
-        temperature: 0.3, // Lower temperature for more consistent analytical responses
-    }
+	effectiveModel := strings.TrimSpace(model)
+	if effectiveModel == "" {
+		effectiveModel = "gpt-oss-20b"
+	}
+	return &GPTOSSClient{
+		tiers:       []Tier{{Name: "primary", Model: effectiveModel, BaseURL: baseURL, APIKey: apiKey}},
+		client:      &http.Client{Timeout: 30 * time.Second, Transport: tracing.Transport(nil)},
+		maxTokens:   512,
+		temperature: 0.3, // Lower temperature for more consistent analytical responses
+	}
+}
+
+// SetStatsMode selects how fallback insights summarize latency (see package stats)
+func (c *GPTOSSClient) SetStatsMode(mode string, trimPercent float64) {
+	c.statsMode = mode
+	c.statsTrimPercent = trimPercent
+}
+
+// AddFallback appends a model to try when the ones before it fail
+func (c *GPTOSSClient) AddFallback(tier Tier) {
+	c.tiers = append(c.tiers, tier)
+}
+
+// SetTierTimeout bounds how long each tier may take before the next is tried.
+// Zero leaves only the HTTP client timeout.
+func (c *GPTOSSClient) SetTierTimeout(timeout time.Duration) {
+	c.tierTimeout = timeout
+}
+
+// Tiers returns the model tiers in the order they are tried
+func (c *GPTOSSClient) Tiers() []Tier {
+	return append([]Tier(nil), c.tiers...)
+}
+
+// OnUsage registers a function told the tokens of every request and the
+// endpoints it was about, e.g. for cost accounting. Tokens are estimated
+// from the text when the model doesn't report them.
+func (c *GPTOSSClient) OnUsage(usage func(urls []string, tokens int)) {
+	c.usage = usage
+}
+
+// usageURLsKey carries the endpoints a request is about in its context
+type usageURLsKey struct{}
+
+// withUsageURLs attributes the tokens of requests made with ctx to urls
+func withUsageURLs(ctx context.Context, urls []string) context.Context {
+	return context.WithValue(ctx, usageURLsKey{}, urls)
+}
+
+// Ping reports whether any tier's server answers. It lists the models,
+// which costs no tokens; any response short of a server error counts, since
+// not every OpenAI-compatible server implements the listing.
+func (c *GPTOSSClient) Ping(ctx context.Context) error {
+	var failures []string
+	for _, tier := range c.tiers {
+		req, err := http.NewRequestWithContext(ctx, "GET", tier.BaseURL+"/v1/models", nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+tier.APIKey)
+		resp, err := c.client.Do(req)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", tier.Name, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 500 {
+			return nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: status %d", tier.Name, resp.StatusCode))
+	}
+	return fmt.Errorf("no AI tier reachable: %s", strings.Join(failures, "; "))
 }
 
-// AnalyzeEndpoints generates AI insights from endpoint monitoring data
-func (c *GPTOSSClient) AnalyzeEndpoints(ctx context.Context, results []checker.CheckResult) ([]Insight, error) {
-	prompt := c.buildAnalysisPrompt(results)
+// AnalyzeEndpoints generates AI insights from the latest results and the
+// recent trends of the endpoints, trying each tier in turn and falling back
+// to rule-based insights if all fail
+func (c *GPTOSSClient) AnalyzeEndpoints(ctx context.Context, results []checker.CheckResult, trends []Trend) ([]Insight, error) {
+	ctx, span := tracer.Start(ctx, "ai analyze", trace.WithAttributes(attribute.Int("ai.endpoints", len(results))))
+	defer span.End()
+	prompt := c.buildAnalysisPrompt(results, trends)
+	urls := make([]string, 0, len(results))
+	for _, result := range results {
+		urls = append(urls, result.URL)
+	}
+	ctx = withUsageURLs(ctx, urls)
 	
-	response, err := c.complete(ctx, prompt)
+	var failures []string
+	for _, tier := range c.tiers {
+		insights, err := c.analyzeWith(ctx, tier, prompt)
+		if err == nil {
+			span.SetAttributes(attribute.String("ai.tier", tier.Name))
+			return keepKnownEndpoints(insights, results), nil
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s): %v", tier.Name, tier.Model, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	
+	// Fallback to rule-based insights if every tier failed
+	span.SetAttributes(attribute.String("ai.tier", RuleBasedTier))
+	span.SetStatus(codes.Error, "every tier failed")
+	return c.fallbackInsights(results, trends), fmt.Errorf("AI analysis failed, using fallback: %s", strings.Join(failures, "; "))
+}
+
+// analyzeWith asks one tier for insights. A response without any parseable
+// insight counts as a failure so the next tier gets a chance.
+func (c *GPTOSSClient) analyzeWith(ctx context.Context, tier Tier, prompt string) ([]Insight, error) {
+	if c.tierTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.tierTimeout)
+		defer cancel()
+	}
+	
+	response, err := c.complete(ctx, tier, prompt)
 	if err != nil {
-		// Fallback to rule-based insights if AI fails
-		return c.fallbackInsights(results), fmt.Errorf("AI analysis failed, using fallback: %w", err)
+		return nil, err
 	}
 	
 	insights := c.parseInsights(response)
 	if len(insights) == 0 {
-		// Fallback if parsing fails
-		return c.fallbackInsights(results), nil
+		return nil, fmt.Errorf("no insights in response")
+	}
+	for i := range insights {
+		insights[i].Tier = tier.Name
 	}
-	
 	return insights, nil
 }
 
+// keepKnownEndpoints drops affected endpoints the model made up or misspelled,
+// so the field can be trusted for filtering
+func keepKnownEndpoints(insights []Insight, results []checker.CheckResult) []Insight {
+	known := make(map[string]bool, len(results))
+	for _, result := range results {
+		known[result.URL] = true
+	}
+	for i := range insights {
+		var affected []string
+		for _, url := range insights[i].AffectedEndpoints {
+			if url = strings.TrimSpace(url); known[url] {
+				affected = append(affected, url)
+			}
+		}
+		insights[i].AffectedEndpoints = affected
+	}
+	return insights
+}
+
 // buildAnalysisPrompt creates a structured prompt for endpoint analysis
-func (c *GPTOSSClient) buildAnalysisPrompt(results []checker.CheckResult) string {
+func (c *GPTOSSClient) buildAnalysisPrompt(results []checker.CheckResult, trends []Trend) string {
 	var sb strings.Builder
 	
 	sb.WriteString("You are an expert system administrator analyzing API endpoint monitoring data. ")
-	sb.WriteString("Provide 2-4 concise insights in JSON format with title, content, type (alert/warning/info/success), and confidence (0.0-1.0).\n\n")
+	sb.WriteString("Provide 2-4 concise insights in JSON format with title, content, type (alert/warning/info/success), confidence (0.0-1.0), ")
+	sb.WriteString("affectedEndpoints (the exact URLs from the list below the insight is about, empty if fleet-wide) and suggestedActions (short concrete steps).\n\n")
 	sb.WriteString("Current endpoint status:\n")
 	
 	for _, result := range results {
@@ -112,32 +290,60 @@ func (c *GPTOSSClient) buildAnalysisPrompt(results []checker.CheckResult) string
 		
 		sb.WriteString(fmt.Sprintf("- %s: %s (Status: %d, Response Time: %v, Error: %s)\n",
 			result.URL, status, result.StatusCode, result.ResponseTime.Round(time.Millisecond), result.Error))
+		if result.ASN != 0 {
+			sb.WriteString(fmt.Sprintf("  Hosted at %s (AS%d, %s, IP %s)\n",
+				result.ASOrg, result.ASN, result.Country, result.RemoteIP))
+		}
+		if anomaly := result.Anomaly; anomaly != nil {
+			sb.WriteString(fmt.Sprintf("  Latency anomaly: %v is %.1f standard deviations above its usual %v\n",
+				anomaly.Latency.Round(time.Millisecond), anomaly.Sigmas, anomaly.Baseline.Round(time.Millisecond)))
+		}
 	}
+	writeTrends(&sb, trends)
 	
-	sb.WriteString("\nProvide insights as JSON array: [{\"title\":\"...\",\"content\":\"...\",\"type\":\"alert|warning|info|success\",\"confidence\":0.9}]\n")
+	sb.WriteString("\nProvide insights as JSON array: [{\"title\":\"...\",\"content\":\"...\",\"type\":\"alert|warning|info|success\",\"confidence\":0.9,")
+	sb.WriteString("\"affectedEndpoints\":[\"https://...\"],\"suggestedActions\":[\"...\"]}]\n")
 	sb.WriteString("Focus on:\n")
 	sb.WriteString("1. Immediate issues requiring attention\n")
-	sb.WriteString("2. Performance trends and patterns\n")
+	sb.WriteString("2. Performance trends and patterns, e.g. latency that has increased over the trend window\n")
 	sb.WriteString("3. Proactive recommendations\n")
 	sb.WriteString("4. System health summary\n")
 	
 	return sb.String()
 }
 
-// complete sends a completion request to GPT-OSS
-func (c *GPTOSSClient) complete(ctx context.Context, prompt string) (string, error) {
-	request := ChatCompletionRequest{
-		Model: c.model,
-		Messages: []Message{
-			{
-				Role:    "system",
-				Content: "You are a monitoring system AI assistant. Respond only with valid JSON.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+// complete sends a completion request to a tier's model
+func (c *GPTOSSClient) complete(ctx context.Context, tier Tier, prompt string) (string, error) {
+	return c.chat(ctx, tier, []Message{
+		{
+			Role:    "system",
+			Content: "You are a monitoring system AI assistant. Respond only with valid JSON.",
 		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	})
+}
+
+// chat sends a conversation to a tier's model and returns its reply
+func (c *GPTOSSClient) chat(ctx context.Context, tier Tier, messages []Message) (reply string, err error) {
+	ctx, span := tracer.Start(ctx, "chat "+tier.Model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", tier.Model),
+		attribute.String("ai.tier", tier.Name),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	request := ChatCompletionRequest{
+		Model:       tier.Model,
+		Messages:    messages,
 		MaxTokens:   c.maxTokens,
 		Temperature: c.temperature,
 	}
@@ -147,13 +353,13 @@ func (c *GPTOSSClient) complete(ctx context.Context, prompt string) (string, err
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 	
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", tier.BaseURL+"/v1/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Authorization", "Bearer "+tier.APIKey)
 	
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -174,7 +380,16 @@ func (c *GPTOSSClient) complete(ctx context.Context, prompt string) (string, err
 	if len(response.Choices) == 0 {
 		return "", fmt.Errorf("no choices in response")
 	}
+	span.SetAttributes(attribute.String("gen_ai.response.model", response.Model), attribute.Int("ai.usage.total_tokens", response.Usage.TotalTokens))
 	
+	if urls, ok := ctx.Value(usageURLsKey{}).([]string); ok && c.usage != nil {
+		tokens := response.Usage.TotalTokens
+		if tokens == 0 {
+			// About four characters per token
+			tokens = (len(jsonData) + len(response.Choices[0].Message.Content)) / 4
+		}
+		c.usage(urls, tokens)
+	}
 	return response.Choices[0].Message.Content, nil
 }
 
@@ -195,6 +410,9 @@ func (c *GPTOSSClient) parseInsights(response string) []Insight {
 		Content    string  `json:"content"`
 		Type       string  `json:"type"`
 		Confidence float64 `json:"confidence"`
+
+		AffectedEndpoints []string `json:"affectedEndpoints"`
+		SuggestedActions  []string `json:"suggestedActions"`
 	}
 	
 	if err := json.Unmarshal([]byte(jsonStr), &rawInsights); err != nil {
@@ -209,12 +427,26 @@ func (c *GPTOSSClient) parseInsights(response string) []Insight {
 			Type:        c.validateType(raw.Type),
 			Confidence:  raw.Confidence,
 			GeneratedAt: time.Now(),
+
+			AffectedEndpoints: raw.AffectedEndpoints,
+			SuggestedActions:  nonEmpty(raw.SuggestedActions),
 		}
 	}
 	
 	return insights
 }
 
+// nonEmpty drops blank entries from a list the model produced
+func nonEmpty(items []string) []string {
+	var kept []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
 // validateType ensures insight type is valid
 func (c *GPTOSSClient) validateType(t string) string {
 	validTypes := map[string]bool{
@@ -231,12 +463,12 @@ func (c *GPTOSSClient) validateType(t string) string {
 }
 
 // fallbackInsights provides rule-based insights when AI is unavailable
-func (c *GPTOSSClient) fallbackInsights(results []checker.CheckResult) []Insight {
-	var insights []Insight
+func (c *GPTOSSClient) fallbackInsights(results []checker.CheckResult, trends []Trend) []Insight {
+	insights := TrendInsights(trends)
 	
 	unhealthy := 0
-	var unhealthyURLs []string
-	totalResponseTime := time.Duration(0)
+	var unhealthyURLs, slowURLs []string
+	latencies := make([]time.Duration, 0, len(results))
 	slowEndpoints := 0
 	
 	for _, result := range results {
@@ -244,13 +476,17 @@ func (c *GPTOSSClient) fallbackInsights(results []checker.CheckResult) []Insight
 			unhealthy++
 			unhealthyURLs = append(unhealthyURLs, result.URL)
 		}
-		totalResponseTime += result.ResponseTime
-		if result.ResponseTime > 2*time.Second {
+		if result.Usable() {
+			latencies = append(latencies, result.ResponseTime)
+		}
+		if result.Anomaly != nil || result.ResponseTime > 2*time.Second {
 			slowEndpoints++
+			slowURLs = append(slowURLs, result.URL)
 		}
 	}
 	
-	avgResponseTime := totalResponseTime / time.Duration(len(results))
+	summary := stats.Summarize(latencies, c.statsMode, c.statsTrimPercent)
+	avgResponseTime := summary.Central
 	
 	if unhealthy > 0 {
 		insights = append(insights, Insight{
@@ -259,26 +495,35 @@ func (c *GPTOSSClient) fallbackInsights(results []checker.CheckResult) []Insight
 			Type:        "alert",
 			Confidence:  1.0,
 			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
+
+			AffectedEndpoints: unhealthyURLs,
+			SuggestedActions:  []string{"Check the endpoints' error messages and recent deployments", "Verify DNS and TLS for the affected hosts"},
 		})
 	}
 	
 	if slowEndpoints > 0 {
 		insights = append(insights, Insight{
 			Title:       "⚠️ Performance Issues",
-			Content:     fmt.Sprintf("%d endpoint(s) showing elevated response times (>2s). Consider investigating server load or network issues.", slowEndpoints),
+			Content:     fmt.Sprintf("%d endpoint(s) showing elevated response times (>2s or far above their baseline). Consider investigating server load or network issues.", slowEndpoints),
 			Type:        "warning",
 			Confidence:  0.9,
 			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
+
+			AffectedEndpoints: slowURLs,
+			SuggestedActions:  []string{"Compare the DNS, connect, TLS and TTFB breakdown to locate the slow phase", "Check server load on the affected hosts"},
 		})
 	}
 	
 	if avgResponseTime < 500*time.Millisecond && unhealthy == 0 {
 		insights = append(insights, Insight{
 			Title:       "✅ System Health Excellent",
-			Content:     fmt.Sprintf("All endpoints healthy with optimal average response time of %v.", avgResponseTime.Round(time.Millisecond)),
+			Content:     fmt.Sprintf("All endpoints healthy with optimal %s response time of %v.", summary.Label(), avgResponseTime.Round(time.Millisecond)),
 			Type:        "success",
 			Confidence:  0.95,
 			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
 		})
 	}
 	
@@ -288,6 +533,9 @@ func (c *GPTOSSClient) fallbackInsights(results []checker.CheckResult) []Insight
 		Type:        "info",
 		Confidence:  0.8,
 		GeneratedAt: time.Now(),
+		Tier:        RuleBasedTier,
+
+		SuggestedActions: []string{"Add alert rules for response times above 3s", "Monitor critical endpoints from more than one region"},
 	})
 	
 	return insights
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostmortemInput is the timeline of a closed incident
+type PostmortemInput struct {
+	URL          string
+	StartedAt    time.Time
+	EndedAt      time.Time
+	Cause        string            // first error of the outage
+	Checks       int               // checks during the incident
+	Failed       int               // how many of them failed
+	FailedChecks []PostmortemCheck // oldest first, possibly a sample of them
+}
+
+// PostmortemCheck is one failed check of an incident
+type PostmortemCheck struct {
+	CheckedAt  time.Time `json:"checkedAt"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Postmortem is the written summary of an incident
+type Postmortem struct {
+	Summary     string    `json:"summary"`
+	Impact      string    `json:"impact"`
+	RootCause   string    `json:"rootCause"` // the likely cause, as far as the checks tell
+	Timeline    []string  `json:"timeline"`
+	ActionItems []string  `json:"actionItems"`
+	Tier        string    `json:"tier"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// WritePostmortem writes the postmortem of an incident, trying each tier in
+// turn and falling back to a rule-based postmortem if all fail
+func (c *GPTOSSClient) WritePostmortem(ctx context.Context, input PostmortemInput) (Postmortem, error) {
+	ctx, span := tracer.Start(ctx, "ai postmortem", trace.WithAttributes(attribute.String("ai.endpoint", input.URL)))
+	defer span.End()
+	ctx = withUsageURLs(ctx, []string{input.URL})
+	messages := []Message{
+		{
+			Role:    "system",
+			Content: "You are a site reliability engineer writing a blameless incident postmortem from monitoring data. Respond only with valid JSON.",
+		},
+		{Role: "user", Content: buildPostmortemPrompt(input)},
+	}
+
+	var failures []string
+	for _, tier := range c.tiers {
+		postmortem, err := c.postmortemWith(ctx, tier, messages)
+		if err == nil {
+			span.SetAttributes(attribute.String("ai.tier", tier.Name))
+			return postmortem, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s (%s): %v", tier.Name, tier.Model, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.String("ai.tier", RuleBasedTier))
+	span.SetStatus(codes.Error, "every tier failed")
+	return FallbackPostmortem(input), fmt.Errorf("AI postmortem failed, using fallback: %s", strings.Join(failures, "; "))
+}
+
+// postmortemWith asks one tier for the postmortem within the tier timeout.
+// A reply without a summary counts as a failure.
+func (c *GPTOSSClient) postmortemWith(ctx context.Context, tier Tier, messages []Message) (Postmortem, error) {
+	if c.tierTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.tierTimeout)
+		defer cancel()
+	}
+
+	response, err := c.chat(ctx, tier, messages)
+	if err != nil {
+		return Postmortem{}, err
+	}
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start == -1 || end <= start {
+		return Postmortem{}, fmt.Errorf("no postmortem in response")
+	}
+	var postmortem Postmortem
+	if err := json.Unmarshal([]byte(response[start:end+1]), &postmortem); err != nil {
+		return Postmortem{}, fmt.Errorf("invalid postmortem: %w", err)
+	}
+	if postmortem.Summary = strings.TrimSpace(postmortem.Summary); postmortem.Summary == "" {
+		return Postmortem{}, fmt.Errorf("postmortem without summary")
+	}
+	postmortem.Timeline = nonEmpty(postmortem.Timeline)
+	postmortem.ActionItems = nonEmpty(postmortem.ActionItems)
+	if postmortem.Timeline == nil {
+		postmortem.Timeline = []string{}
+	}
+	if postmortem.ActionItems == nil {
+		postmortem.ActionItems = []string{}
+	}
+	postmortem.Tier = tier.Name
+	postmortem.GeneratedAt = time.Now()
+	return postmortem, nil
+}
+
+// buildPostmortemPrompt lays out the incident and its failed checks
+func buildPostmortemPrompt(input PostmortemInput) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Write the postmortem of an outage of %s from %s to %s (UTC), lasting %v.\n",
+		input.URL, input.StartedAt.UTC().Format(time.RFC3339), input.EndedAt.UTC().Format(time.RFC3339),
+		input.EndedAt.Sub(input.StartedAt).Round(time.Second)))
+	if input.Cause != "" {
+		sb.WriteString(fmt.Sprintf("First error: %s\n", input.Cause))
+	}
+	sb.WriteString(fmt.Sprintf("%d checks ran during the outage, %d of them failed.\n", input.Checks, input.Failed))
+	if len(input.FailedChecks) > 0 {
+		sb.WriteString("\nFailed checks (oldest first):\n")
+		for _, check := range input.FailedChecks {
+			sb.WriteString(fmt.Sprintf("- %s: status %d, error: %s\n", check.CheckedAt.UTC().Format(time.RFC3339), check.StatusCode, check.Error))
+		}
+	}
+
+	sb.WriteString("\nRespond with a JSON object: {\"summary\":\"two or three sentences\",\"impact\":\"...\",\"rootCause\":\"the likely cause\",")
+	sb.WriteString("\"timeline\":[\"15:04 UTC - what happened\"],\"actionItems\":[\"...\"]}\n")
+	sb.WriteString("Only state what the checks support, call speculation out as such, and list at most five action items.\n")
+	return sb.String()
+}
+
+// FallbackPostmortem writes the postmortem from the timeline alone: when the
+// incident started and ended, and the distinct errors seen in between
+func FallbackPostmortem(input PostmortemInput) Postmortem {
+	duration := input.EndedAt.Sub(input.StartedAt).Round(time.Second)
+	postmortem := Postmortem{
+		Summary:     fmt.Sprintf("%s was down for %v, from %s to %s UTC.", input.URL, duration, input.StartedAt.UTC().Format("Jan 2 15:04"), input.EndedAt.UTC().Format("Jan 2 15:04")),
+		Impact:      fmt.Sprintf("%d of %d checks failed during the outage.", input.Failed, input.Checks),
+		RootCause:   "Unknown from the checks alone",
+		Timeline:    []string{fmt.Sprintf("%s UTC - first failed check", input.StartedAt.UTC().Format("15:04:05"))},
+		ActionItems: []string{"Confirm the root cause with the service owners", "Add an alert or check that would have caught the failure earlier"},
+		Tier:        RuleBasedTier,
+		GeneratedAt: time.Now(),
+	}
+	if input.Cause != "" {
+		postmortem.RootCause = "The checks failed with: " + input.Cause
+	}
+
+	// The timeline notes every change of error, not every check
+	last := input.Cause
+	for _, check := range input.FailedChecks {
+		if check.Error == "" || check.Error == last {
+			continue
+		}
+		postmortem.Timeline = append(postmortem.Timeline, fmt.Sprintf("%s UTC - checks failed with: %s", check.CheckedAt.UTC().Format("15:04:05"), check.Error))
+		last = check.Error
+	}
+	postmortem.Timeline = append(postmortem.Timeline, fmt.Sprintf("%s UTC - recovered", input.EndedAt.UTC().Format("15:04:05")))
+	return postmortem
+}
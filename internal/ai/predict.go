@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// A prediction needs at least minPredictionPoints trend points whose linear
+// fit explains at least minPredictionFit of their variance (R²)
+const (
+	minPredictionPoints = 3
+	minPredictionFit    = 0.5
+)
+
+// BreachLimits are the levels an endpoint breaches its SLA above: p95
+// latency and error rate (0 to 1). Zero leaves a level unchecked.
+type BreachLimits struct {
+	Latency   time.Duration
+	ErrorRate float64
+}
+
+// Prediction is a forecast that an endpoint crosses one of its limits
+type Prediction struct {
+	URL        string        `json:"url"`
+	Metric     string        `json:"metric"` // "latency" or "errorRate"
+	In         time.Duration `json:"in"`     // from the last trend point
+	Confidence float64       `json:"confidence"`
+}
+
+// PredictBreaches fits a line through the points of each trend and reports
+// the endpoints whose p95 latency or error rate will cross their limits
+// within horizon. Endpoints already above a limit are left to the other
+// insights. The fit's R² sets the confidence.
+func PredictBreaches(trends []Trend, limits func(url string) BreachLimits, horizon time.Duration) []Prediction {
+	var predictions []Prediction
+	for _, trend := range trends {
+		if len(trend.Points) < minPredictionPoints {
+			continue
+		}
+		limit := limits(trend.URL)
+		if limit.Latency > 0 {
+			values := make([]float64, len(trend.Points))
+			for i, point := range trend.Points {
+				values[i] = point.P95.Seconds()
+			}
+			if in, fit, ok := timeToCross(trend.Points, values, limit.Latency.Seconds()); ok && in <= horizon {
+				predictions = append(predictions, Prediction{URL: trend.URL, Metric: "latency", In: in, Confidence: predictionConfidence(fit)})
+			}
+		}
+		if limit.ErrorRate > 0 {
+			values := make([]float64, len(trend.Points))
+			for i, point := range trend.Points {
+				values[i] = point.ErrorRate
+			}
+			if in, fit, ok := timeToCross(trend.Points, values, limit.ErrorRate); ok && in <= horizon {
+				predictions = append(predictions, Prediction{URL: trend.URL, Metric: "errorRate", In: in, Confidence: predictionConfidence(fit)})
+			}
+		}
+	}
+	return predictions
+}
+
+// PredictionInsights turns predictions into alert-worthy insights
+func PredictionInsights(predictions []Prediction, limits func(url string) BreachLimits) []Insight {
+	insights := []Insight{}
+	for _, prediction := range predictions {
+		limit := limits(prediction.URL)
+		what := fmt.Sprintf("p95 latency is rising towards its %s limit", formatP95(limit.Latency))
+		if prediction.Metric == "errorRate" {
+			what = fmt.Sprintf("the error rate is rising towards its %.2f%% error budget", limit.ErrorRate*100)
+		}
+		insights = append(insights, Insight{
+			Title:       "🔮 Predicted SLA Breach",
+			Content:     fmt.Sprintf("%s is likely to breach its SLA within %s: %s.", prediction.URL, formatLead(prediction.In), what),
+			Type:        "warning",
+			Confidence:  prediction.Confidence,
+			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
+
+			AffectedEndpoints: []string{prediction.URL},
+			SuggestedActions:  []string{"Look for what changed when the trend started", "Scale out or shed load before the limit is reached"},
+		})
+	}
+	return insights
+}
+
+// timeToCross fits values over the times of points by least squares and
+// returns how long after the last point the line reaches limit, with the
+// fit's R². ok is false for a flat or falling line or a value already at
+// the limit.
+func timeToCross(points []TrendPoint, values []float64, limit float64) (in time.Duration, fit float64, ok bool) {
+	n := float64(len(points))
+	var sumX, sumY float64
+	xs := make([]float64, len(points))
+	for i, point := range points {
+		xs[i] = point.Start.Sub(points[0].Start).Hours()
+		sumX += xs[i]
+		sumY += values[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var sxx, sxy, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, values[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 || sxy <= 0 {
+		return 0, 0, false
+	}
+	slope := sxy / sxx
+	fit = sxy * sxy / (sxx * syy)
+	if fit < minPredictionFit {
+		return 0, 0, false
+	}
+
+	last := xs[len(xs)-1]
+	current := meanY + slope*(last-meanX)
+	if current >= limit || values[len(values)-1] >= limit {
+		return 0, 0, false
+	}
+	hours := (limit - current) / slope
+	return time.Duration(hours * float64(time.Hour)), fit, true
+}
+
+// predictionConfidence maps an R² of minPredictionFit to 1 onto 0.5 to 0.9
+func predictionConfidence(fit float64) float64 {
+	confidence := 0.5 + 0.4*(fit-minPredictionFit)/(1-minPredictionFit)
+	return math.Round(confidence*100) / 100
+}
+
+// formatLead writes a lead time in whole minutes, or hours past two
+func formatLead(in time.Duration) string {
+	if in < 2*time.Hour {
+		return fmt.Sprintf("%d minutes", max(1, int(in.Round(time.Minute).Minutes())))
+	}
+	return fmt.Sprintf("%.0f hours", in.Hours())
+}
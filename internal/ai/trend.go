@@ -0,0 +1,146 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Changes the rule-based trend insights report: p95 latency moving by at
+// least trendLatencyChange of the previous window's, or the error rate by at
+// least trendErrorChange (a share of checks)
+const (
+	trendLatencyChange = 0.25
+	trendErrorChange   = 0.01
+)
+
+// maxTrendInsights caps how many degraded endpoints get an insight of their own
+const maxTrendInsights = 3
+
+// Trend is how an endpoint's latency and errors moved over the recent window
+// compared with the window before, with points of the recent one
+type Trend struct {
+	URL               string        `json:"url"`
+	Window            time.Duration `json:"window"`
+	P95               time.Duration `json:"p95"`
+	PreviousP95       time.Duration `json:"previousP95"`
+	ErrorRate         float64       `json:"errorRate"` // failed share of checks, 0 to 1
+	PreviousErrorRate float64       `json:"previousErrorRate"`
+	Points            []TrendPoint  `json:"points"`
+}
+
+// TrendPoint is one bucket of a trend
+type TrendPoint struct {
+	Start     time.Time     `json:"start"`
+	P95       time.Duration `json:"p95"`
+	ErrorRate float64       `json:"errorRate"`
+}
+
+// P95Change returns the relative change of p95 latency, false unless both
+// windows have latency data
+func (t Trend) P95Change() (float64, bool) {
+	if t.P95 <= 0 || t.PreviousP95 <= 0 {
+		return 0, false
+	}
+	return float64(t.P95-t.PreviousP95) / float64(t.PreviousP95), true
+}
+
+// TrendInsights reports notable changes from the trends alone: an insight
+// for each of the most degraded endpoints and one for those that improved
+func TrendInsights(trends []Trend) []Insight {
+	type degradation struct {
+		trend    Trend
+		severity float64 // for ranking: relative latency change or error rate change
+		reasons  []string
+	}
+	var degraded []degradation
+	var improvedURLs, improvements []string
+
+	for _, trend := range trends {
+		hours := formatWindow(trend.Window)
+		var reasons []string
+		severity := 0.0
+		change, hasLatency := trend.P95Change()
+		if hasLatency && change >= trendLatencyChange {
+			reasons = append(reasons, fmt.Sprintf("p95 latency has increased %.0f%% over the last %s (%s, was %s)",
+				change*100, hours, formatP95(trend.P95), formatP95(trend.PreviousP95)))
+			severity = change
+		}
+		if errorChange := trend.ErrorRate - trend.PreviousErrorRate; errorChange >= trendErrorChange {
+			reasons = append(reasons, fmt.Sprintf("the error rate rose to %.1f%% from %.1f%%",
+				trend.ErrorRate*100, trend.PreviousErrorRate*100))
+			severity = max(severity, errorChange*10)
+		}
+		switch {
+		case len(reasons) > 0:
+			degraded = append(degraded, degradation{trend: trend, severity: severity, reasons: reasons})
+		case hasLatency && change <= -trendLatencyChange:
+			improvedURLs = append(improvedURLs, trend.URL)
+			improvements = append(improvements, fmt.Sprintf("%s (p95 down %.0f%% to %s)", trend.URL, -change*100, formatP95(trend.P95)))
+		}
+	}
+
+	sort.Slice(degraded, func(i, j int) bool { return degraded[i].severity > degraded[j].severity })
+	var insights []Insight
+	for _, d := range degraded[:min(len(degraded), maxTrendInsights)] {
+		insights = append(insights, Insight{
+			Title:       "📉 Degrading Trend",
+			Content:     fmt.Sprintf("For %s, %s.", d.trend.URL, strings.Join(d.reasons, " and ")),
+			Type:        "warning",
+			Confidence:  0.85,
+			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
+
+			AffectedEndpoints: []string{d.trend.URL},
+			SuggestedActions:  []string{"Check deployments and traffic changes in the window", "Compare the timing breakdown of recent checks with older ones"},
+		})
+	}
+	if len(degraded) > maxTrendInsights {
+		insights[len(insights)-1].Content += fmt.Sprintf(" %d more endpoint(s) are degrading as well.", len(degraded)-maxTrendInsights)
+	}
+	if len(improvements) > 0 {
+		insights = append(insights, Insight{
+			Title:       "📈 Improving Trend",
+			Content:     fmt.Sprintf("Latency improved notably over the last %s for %s.", formatWindow(trends[0].Window), strings.Join(improvements, ", ")),
+			Type:        "success",
+			Confidence:  0.85,
+			GeneratedAt: time.Now(),
+			Tier:        RuleBasedTier,
+
+			AffectedEndpoints: improvedURLs,
+		})
+	}
+	return insights
+}
+
+// writeTrends adds the trends to a prompt, with the points of the recent window
+func writeTrends(sb *strings.Builder, trends []Trend) {
+	if len(trends) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\nTrends over the last %s versus the %s before (p95 latency, error rate):\n",
+		formatWindow(trends[0].Window), formatWindow(trends[0].Window)))
+	for _, trend := range trends {
+		sb.WriteString(fmt.Sprintf("- %s: p95 %s (was %s), errors %.1f%% (was %.1f%%)\n", trend.URL,
+			formatP95(trend.P95), formatP95(trend.PreviousP95), trend.ErrorRate*100, trend.PreviousErrorRate*100))
+		if len(trend.Points) > 0 {
+			points := make([]string, 0, len(trend.Points))
+			for _, point := range trend.Points {
+				points = append(points, fmt.Sprintf("%s %s/%.1f%%", point.Start.UTC().Format("15:04"), formatP95(point.P95), point.ErrorRate*100))
+			}
+			sb.WriteString("  Series (UTC): " + strings.Join(points, ", ") + "\n")
+		}
+	}
+}
+
+// formatWindow writes whole-hour windows as "6 hours"
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		if hours := int(window / time.Hour); hours != 1 {
+			return fmt.Sprintf("%d hours", hours)
+		}
+		return "hour"
+	}
+	return window.String()
+}
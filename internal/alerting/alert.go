@@ -0,0 +1,212 @@
+package alerting
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Alert kinds
+const (
+	KindMetric = "metric" // an extracted metric crossed a rule threshold
+)
+
+// Alert states
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Severities
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert is a notification about an endpoint that every channel receives
+type Alert struct {
+	Kind       string               `json:"kind"`
+	State      string               `json:"state"`
+	Severity   string               `json:"severity"`
+	EndpointID string               `json:"endpointId"`
+	URL        string               `json:"url"`
+	Message    string               `json:"message"`
+	StartedAt  time.Time            `json:"startedAt"` // when the condition began
+	At         time.Time            `json:"at"`        // when this notification was raised
+	Result     *checker.CheckResult `json:"result,omitempty"`
+
+	// Set for health alerts
+	Failures int  `json:"failures,omitempty"` // consecutive failed checks
+	Repeat   bool `json:"repeat,omitempty"`   // a reminder for an alert that is still firing
+
+	// Outage summarizes the outage a resolved health alert ends
+	Outage *Outage `json:"outage,omitempty"`
+
+	// Channels limits delivery to these channels (all when empty)
+	Channels []string `json:"-"`
+
+	// Set for metric alerts
+	RuleID    string  `json:"ruleId,omitempty"`
+	RuleName  string  `json:"ruleName,omitempty"`
+	Metric    string  `json:"metric,omitempty"`
+	Operator  string  `json:"operator,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Value     float64 `json:"value,omitempty"`
+
+	// Set for rate-of-change rules, whose Value is Current/Baseline: the
+	// metric's aggregate over the latest window and over the one before.
+	// Latency anomalies set Baseline to the endpoint's usual latency.
+	Current  float64 `json:"current,omitempty"`
+	Baseline float64 `json:"baseline,omitempty"`
+}
+
+// Notifier delivers alerts to one channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc struct {
+	ChannelName string
+	Func        func(ctx context.Context, alert Alert) error
+}
+
+// Name returns the channel name
+func (f NotifierFunc) Name() string { return f.ChannelName }
+
+// Notify calls the function
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error { return f.Func(ctx, alert) }
+
+// notifyTimeout bounds how long a single channel may take to deliver an alert
+const notifyTimeout = 10 * time.Second
+
+// queueSize is how many alerts may wait for delivery before new ones are dropped
+const queueSize = 256
+
+// Dispatcher fans alerts out to every configured channel. Alerts are delivered
+// in the order they were raised, so a resolution never overtakes its firing.
+type Dispatcher struct {
+	notifiers []Notifier
+	queue     chan Alert
+	closed    bool
+	done      chan struct{} // closed once the queue is drained after Close
+	mutex     sync.RWMutex
+}
+
+// NewDispatcher creates a dispatcher with no channels
+func NewDispatcher() *Dispatcher {
+	d := &Dispatcher{queue: make(chan Alert, queueSize), done: make(chan struct{})}
+	go d.deliver()
+	return d
+}
+
+// Close stops accepting alerts and waits until the queued ones have been
+// delivered, or ctx is done
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mutex.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mutex.Unlock()
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Add registers a channel
+func (d *Dispatcher) Add(notifier Notifier) {
+	d.mutex.Lock()
+	d.notifiers = append(d.notifiers, notifier)
+	d.mutex.Unlock()
+}
+
+// Replace swaps all registered channels at once, e.g. after a configuration
+// reload; queued alerts go to the new ones
+func (d *Dispatcher) Replace(notifiers []Notifier) {
+	d.mutex.Lock()
+	d.notifiers = append([]Notifier(nil), notifiers...)
+	d.mutex.Unlock()
+}
+
+// Channels returns the names of the registered channels
+func (d *Dispatcher) Channels() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	names := make([]string, len(d.notifiers))
+	for i, notifier := range d.notifiers {
+		names[i] = notifier.Name()
+	}
+	return names
+}
+
+// Dispatch queues an alert for delivery to all channels
+func (d *Dispatcher) Dispatch(alert Alert) {
+	if alert.At.IsZero() {
+		alert.At = time.Now()
+	}
+	to := ""
+	if len(alert.Channels) > 0 {
+		to = " → " + strings.Join(alert.Channels, ", ")
+	}
+	log.Printf("🔔 Alert %s [%s] %s: %s%s", alert.State, alert.Severity, alert.URL, alert.Message, to)
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.closed {
+		log.Printf("Shutting down, dropping %s alert for %s", alert.State, alert.URL)
+		return
+	}
+	select {
+	case d.queue <- alert:
+	default:
+		log.Printf("Alert queue full, dropping %s alert for %s", alert.State, alert.URL)
+	}
+}
+
+// deliver sends queued alerts to every channel in parallel, one alert at a time
+func (d *Dispatcher) deliver() {
+	defer close(d.done)
+	for alert := range d.queue {
+		d.mutex.RLock()
+		var notifiers []Notifier
+		for _, notifier := range d.notifiers {
+			if len(alert.Channels) == 0 || contains(alert.Channels, notifier.Name()) {
+				notifiers = append(notifiers, notifier)
+			}
+		}
+		d.mutex.RUnlock()
+
+		var wg sync.WaitGroup
+		for _, notifier := range notifiers {
+			wg.Add(1)
+			go func(notifier Notifier) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+				defer cancel()
+				if err := notifier.Notify(ctx, alert); err != nil {
+					log.Printf("Failed to deliver alert via %s: %v", notifier.Name(), err)
+				}
+			}(notifier)
+		}
+		wg.Wait()
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// KindAnomaly alerts report an endpoint whose latency stays far above its
+// historical baseline
+const KindAnomaly = "latency_anomaly"
+
+// AnomalyTracker raises an alert after a number of anomalous checks in a row
+// and resolves it at the first check back within the baseline
+type AnomalyTracker struct {
+	dispatcher *Dispatcher
+	after      int
+	streaks    map[string]int   // consecutive anomalous checks by endpoint ID
+	active     map[string]Alert // by endpoint ID
+	mutex      sync.Mutex
+}
+
+// NewAnomalyTracker creates a tracker that raises alerts through dispatcher
+// after the given number of anomalous checks in a row
+func NewAnomalyTracker(dispatcher *Dispatcher, after int) *AnomalyTracker {
+	return &AnomalyTracker{
+		dispatcher: dispatcher,
+		after:      after,
+		streaks:    make(map[string]int),
+		active:     make(map[string]Alert),
+	}
+}
+
+// Observe records whether a check was anomalous. Failed checks are left to
+// the health alerts and change nothing here.
+func (t *AnomalyTracker) Observe(endpointID string, result checker.CheckResult) {
+	if !result.IsHealthy || !result.Usable() {
+		return
+	}
+
+	t.mutex.Lock()
+	alert, firing := t.active[endpointID]
+	anomaly := result.Anomaly
+	now := time.Now()
+	switch {
+	case anomaly != nil:
+		t.streaks[endpointID]++
+		if firing || t.streaks[endpointID] < t.after {
+			t.mutex.Unlock()
+			return
+		}
+		alert = Alert{
+			Kind:       KindAnomaly,
+			State:      StateFiring,
+			Severity:   SeverityWarning,
+			EndpointID: endpointID,
+			URL:        result.URL,
+			Message: fmt.Sprintf("%s latency is %v, %.1fσ above its baseline of %v, for %d checks in a row",
+				result.URL, anomaly.Latency.Round(time.Millisecond), anomaly.Sigmas,
+				anomaly.Baseline.Round(time.Millisecond), t.streaks[endpointID]),
+			StartedAt: now,
+			At:        now,
+			Result:    &result,
+			Metric:    "latency_ms",
+			Value:     float64(anomaly.Latency.Milliseconds()),
+			Baseline:  float64(anomaly.Baseline.Milliseconds()),
+		}
+		t.active[endpointID] = alert
+	case firing:
+		delete(t.streaks, endpointID)
+		delete(t.active, endpointID)
+		alert.State = StateResolved
+		alert.At = now
+		alert.Result = &result
+		alert.Message = fmt.Sprintf("%s latency is back within its baseline", result.URL)
+	default:
+		delete(t.streaks, endpointID)
+		t.mutex.Unlock()
+		return
+	}
+	t.mutex.Unlock()
+
+	t.dispatcher.Dispatch(alert)
+}
+
+// Active returns the anomaly alerts currently firing, oldest first
+func (t *AnomalyTracker) Active() []Alert {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	alerts := make([]Alert, 0, len(t.active))
+	for _, alert := range t.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartedAt.Before(alerts[j].StartedAt) })
+	return alerts
+}
+
+// ForgetEndpoint drops the state of a removed endpoint
+func (t *AnomalyTracker) ForgetEndpoint(endpointID string) {
+	t.mutex.Lock()
+	delete(t.streaks, endpointID)
+	delete(t.active, endpointID)
+	t.mutex.Unlock()
+}
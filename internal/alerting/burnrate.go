@@ -0,0 +1,198 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KindBurnRate alerts report an endpoint spending its SLO error budget too fast
+const KindBurnRate = "burn_rate"
+
+// BurnRule alerts when Budget percent of a 30 day error budget (or whatever
+// the objective's window is) would be spent within Long, and the spending is
+// still going on over Short. Requiring both windows keeps brief blips from
+// alerting and resolves the alert soon after the endpoint recovers.
+type BurnRule struct {
+	Severity string
+	Budget   float64 // percent of the error budget
+	Long     time.Duration
+	Short    time.Duration
+}
+
+// ParseBurnRule parses "severity:long/short=budget", e.g. "critical:1h/5m=2"
+func ParseBurnRule(text string) (BurnRule, error) {
+	invalid := fmt.Errorf("%q must look like critical:1h/5m=2", text)
+	severity, rest, ok := strings.Cut(strings.TrimSpace(text), ":")
+	windows, budget, hasBudget := strings.Cut(rest, "=")
+	long, short, hasShort := strings.Cut(windows, "/")
+	if !ok || !hasBudget || !hasShort {
+		return BurnRule{}, invalid
+	}
+	rule := BurnRule{Severity: strings.TrimSpace(severity)}
+	var err error
+	if rule.Budget, err = strconv.ParseFloat(strings.TrimSpace(budget), 64); err != nil {
+		return BurnRule{}, invalid
+	}
+	if rule.Long, err = time.ParseDuration(strings.TrimSpace(long)); err != nil {
+		return BurnRule{}, invalid
+	}
+	if rule.Short, err = time.ParseDuration(strings.TrimSpace(short)); err != nil {
+		return BurnRule{}, invalid
+	}
+	return rule, rule.Validate()
+}
+
+// Validate checks the rule's severity, budget and windows
+func (r BurnRule) Validate() error {
+	if r.Severity != SeverityWarning && r.Severity != SeverityCritical {
+		return fmt.Errorf("burn rate severity must be %q or %q", SeverityWarning, SeverityCritical)
+	}
+	if r.Budget <= 0 || r.Budget > 100 {
+		return fmt.Errorf("burn rate budget must be a percentage above 0 and at most 100")
+	}
+	if r.Short <= 0 || r.Long <= r.Short {
+		return fmt.Errorf("burn rate windows need a long window longer than the short one")
+	}
+	return nil
+}
+
+// Name describes the rule, e.g. "2% of budget in 1h"
+func (r BurnRule) Name() string {
+	return fmt.Sprintf("%g%% of budget in %s", r.Budget, WindowName(r.Long))
+}
+
+// WindowName prints a burn rate window without zero units, e.g. "1h" or "30m"
+func WindowName(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// Threshold returns the burn rate at which the rule fires for an objective
+// whose budget is spent over period, e.g. 14.4 for 2% in 1h of 30 days
+func (r BurnRule) Threshold(period time.Duration) float64 {
+	return r.Budget / 100 * float64(period) / float64(r.Long)
+}
+
+// BurnRateEngine raises and resolves burn rate alerts from burn rates
+// measured periodically, e.g. by sla.BurnRates
+type BurnRateEngine struct {
+	dispatcher *Dispatcher
+	rules      []BurnRule
+	active     map[string]Alert // by rule name and endpoint ID
+	mutex      sync.Mutex
+}
+
+// NewBurnRateEngine creates an engine that raises alerts through dispatcher
+func NewBurnRateEngine(dispatcher *Dispatcher, rules []BurnRule) *BurnRateEngine {
+	return &BurnRateEngine{dispatcher: dispatcher, rules: rules, active: make(map[string]Alert)}
+}
+
+// Rules returns the configured rules
+func (e *BurnRateEngine) Rules() []BurnRule {
+	return append([]BurnRule(nil), e.rules...)
+}
+
+// Windows returns every window the rules need burn rates for, shortest first
+func (e *BurnRateEngine) Windows() []time.Duration {
+	seen := make(map[time.Duration]bool)
+	var windows []time.Duration
+	for _, rule := range e.rules {
+		for _, window := range []time.Duration{rule.Long, rule.Short} {
+			if !seen[window] {
+				seen[window] = true
+				windows = append(windows, window)
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+	return windows
+}
+
+// Active returns the burn rate alerts currently firing, oldest first
+func (e *BurnRateEngine) Active() []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	alerts := make([]Alert, 0, len(e.active))
+	for _, alert := range e.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartedAt.Before(alerts[j].StartedAt) })
+	return alerts
+}
+
+// Evaluate checks every rule against an endpoint's burn rates by window, for
+// an objective whose budget is spent over period. A rule fires while both of
+// its windows burn at or above its threshold and resolves once either drops
+// below or has no data.
+func (e *BurnRateEngine) Evaluate(endpointID, url string, period time.Duration, rates map[time.Duration]float64, now time.Time) {
+	var raised []Alert
+	e.mutex.Lock()
+	for _, rule := range e.rules {
+		threshold := rule.Threshold(period)
+		long, hasLong := rates[rule.Long]
+		short, hasShort := rates[rule.Short]
+		burning := hasLong && hasShort && long >= threshold && short >= threshold
+
+		key := rule.Name() + "/" + endpointID
+		alert, firing := e.active[key]
+		switch {
+		case burning && !firing:
+			alert = Alert{
+				Kind:       KindBurnRate,
+				State:      StateFiring,
+				Severity:   rule.Severity,
+				EndpointID: endpointID,
+				URL:        url,
+				Message: fmt.Sprintf("%s is burning its error budget at %.1fx over %s and %.1fx over %s (threshold %.1fx: %s)",
+					url, long, WindowName(rule.Long), short, WindowName(rule.Short), threshold, rule.Name()),
+				StartedAt: now,
+				At:        now,
+				RuleName:  rule.Name(),
+				Threshold: threshold,
+				Value:     long,
+			}
+			e.active[key] = alert
+			raised = append(raised, alert)
+		case burning:
+			alert.Value = long
+			e.active[key] = alert
+		case firing:
+			alert.State = StateResolved
+			alert.At = now
+			alert.Value = long
+			alert.Message = fmt.Sprintf("%s is no longer burning its error budget faster than %s", url, rule.Name())
+			delete(e.active, key)
+			raised = append(raised, alert)
+		}
+	}
+	e.mutex.Unlock()
+
+	for _, alert := range raised {
+		e.dispatcher.Dispatch(alert)
+	}
+}
+
+// ForgetEndpoint drops the alerts of a removed endpoint or one whose
+// objective was removed
+func (e *BurnRateEngine) ForgetEndpoint(endpointID string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	suffix := "/" + endpointID
+	for key := range e.active {
+		if strings.HasSuffix(key, suffix) {
+			delete(e.active, key)
+		}
+	}
+}
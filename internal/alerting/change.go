@@ -0,0 +1,145 @@
+package alerting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// MetricLatency is the response time of every successful check in
+// milliseconds; rules can use it like an extracted metric
+const MetricLatency = "latency_ms"
+
+// Bounds of rate-of-change rules: windows are kept in memory, so they are
+// capped in length and in samples per endpoint
+const (
+	maxChangeWindow   = 24 * time.Hour
+	maxChangeSamples  = 20000
+	defaultMinSamples = 5
+)
+
+// Change makes a rule compare a metric with its own recent past instead of
+// a fixed value, which suits endpoints whose normal differs widely: the
+// Aggregate over the latest Window is divided by the same aggregate over
+// the window before it, and the rule's Threshold applies to that ratio.
+// p95 latency doubling versus the previous hour is
+// {"metric": "latency_ms", "operator": ">=", "threshold": 2, "change": {"aggregate": "p95", "window": "1h"}}
+type Change struct {
+	Aggregate  string `json:"aggregate,omitempty"`  // mean, max or a percentile such as p95 (the default)
+	Window     string `json:"window"`               // e.g. "1h"
+	MinSamples int    `json:"minSamples,omitempty"` // needed in both windows to evaluate (default 5)
+
+	window   time.Duration
+	quantile float64 // 0-1, or -1 for the mean
+}
+
+// Validate checks the condition and fills in defaults
+func (c *Change) Validate() error {
+	if c.Aggregate == "" {
+		c.Aggregate = "p95"
+	}
+	switch aggregate := strings.ToLower(c.Aggregate); {
+	case aggregate == "mean":
+		c.quantile = -1
+	case aggregate == "max":
+		c.quantile = 1
+	case strings.HasPrefix(aggregate, "p"):
+		percentile, err := strconv.ParseFloat(aggregate[1:], 64)
+		if err != nil || percentile <= 0 || percentile >= 100 {
+			return fmt.Errorf("change aggregate must be mean, max or a percentile such as p95")
+		}
+		c.quantile = percentile / 100
+	default:
+		return fmt.Errorf("change aggregate must be mean, max or a percentile such as p95")
+	}
+
+	d, err := time.ParseDuration(c.Window)
+	if err != nil || d <= 0 || d > maxChangeWindow {
+		return fmt.Errorf("change window must be a duration up to %s such as \"1h\"", WindowName(maxChangeWindow))
+	}
+	c.window = d
+
+	if c.MinSamples < 0 {
+		return fmt.Errorf("change minSamples must not be negative")
+	}
+	if c.MinSamples == 0 {
+		c.MinSamples = defaultMinSamples
+	}
+	return nil
+}
+
+// describe names the compared aggregate of a metric, e.g. "p95 latency_ms"
+func (c *Change) describe(metric string) string {
+	return strings.ToLower(c.Aggregate) + " " + metric
+}
+
+// aggregate computes the condition's aggregate of some values
+func (c *Change) aggregate(values []float64) float64 {
+	if c.quantile < 0 {
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	}
+	sort.Float64s(values)
+	index := int(math.Ceil(c.quantile*float64(len(values)))) - 1
+	return values[max(index, 0)]
+}
+
+// changeSeries holds the recent samples of a metric for a change rule
+type changeSeries struct {
+	samples []Sample
+}
+
+// add records a value and returns the aggregates over the latest window and
+// the one before. ok is false until both windows have enough samples, the
+// previous one covers at least half its length and its aggregate is positive.
+func (s *changeSeries) add(change *Change, at time.Time, value float64) (current, baseline float64, ok bool) {
+	s.samples = append(s.samples, Sample{At: at, Value: value})
+	cutoff := at.Add(-2 * change.window)
+	drop := 0
+	for drop < len(s.samples) && !s.samples[drop].At.After(cutoff) {
+		drop++
+	}
+	drop = max(drop, len(s.samples)-maxChangeSamples)
+	s.samples = s.samples[drop:]
+
+	split := at.Add(-change.window)
+	if s.samples[0].At.After(split.Add(-change.window / 2)) {
+		return 0, 0, false
+	}
+	var recent, previous []float64
+	for _, sample := range s.samples {
+		if sample.At.After(split) {
+			recent = append(recent, sample.Value)
+		} else {
+			previous = append(previous, sample.Value)
+		}
+	}
+	if len(recent) < change.MinSamples || len(previous) < change.MinSamples {
+		return 0, 0, false
+	}
+	current, baseline = change.aggregate(recent), change.aggregate(previous)
+	if baseline <= 0 {
+		return 0, 0, false
+	}
+	return current, baseline, true
+}
+
+// MetricValue returns a result's value of a metric, including MetricLatency
+func MetricValue(result checker.CheckResult, metric string) (float64, bool) {
+	if metric == MetricLatency {
+		if result.Error != "" || !result.Usable() || result.ResponseTime <= 0 {
+			return 0, false
+		}
+		return float64(result.ResponseTime) / float64(time.Millisecond), true
+	}
+	value, ok := result.Metrics[metric]
+	return value, ok
+}
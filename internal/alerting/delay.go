@@ -0,0 +1,82 @@
+package alerting
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// delayedNotifier holds firing alerts back until they have lasted a while
+type delayedNotifier struct {
+	notifier Notifier
+	after    time.Duration
+	pending  map[string]*time.Timer // firing alerts still waiting out the delay
+	sent     map[string]bool        // firing alerts that were delivered
+	mutex    sync.Mutex
+}
+
+// Sustained only passes a firing alert on once it has lasted for after, so
+// pagers aren't woken by a single failed check. Recoveries are only passed on
+// for alerts that were delivered; a condition that clears within the delay
+// produces nothing at all.
+func Sustained(notifier Notifier, after time.Duration) Notifier {
+	if after <= 0 {
+		return notifier
+	}
+	return &delayedNotifier{
+		notifier: notifier,
+		after:    after,
+		pending:  make(map[string]*time.Timer),
+		sent:     make(map[string]bool),
+	}
+}
+
+// Name returns the wrapped channel's name
+func (d *delayedNotifier) Name() string { return d.notifier.Name() }
+
+// Notify schedules a firing alert or passes a recovery on
+func (d *delayedNotifier) Notify(ctx context.Context, alert Alert) error {
+	key := DedupKey(alert)
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if alert.State == StateResolved {
+		if timer, ok := d.pending[key]; ok {
+			timer.Stop()
+			delete(d.pending, key)
+			return nil
+		}
+		if !d.sent[key] {
+			return nil
+		}
+		delete(d.sent, key)
+		return d.notifier.Notify(ctx, alert)
+	}
+
+	if d.sent[key] {
+		return d.notifier.Notify(ctx, alert)
+	}
+	if _, ok := d.pending[key]; ok {
+		return nil
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d.after, func() {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		if d.pending[key] != timer {
+			return
+		}
+		delete(d.pending, key)
+		d.sent[key] = true
+
+		// Still holding the lock, so a recovery can't be sent before this
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := d.notifier.Notify(ctx, alert); err != nil {
+			log.Printf("Failed to deliver alert via %s: %v", d.notifier.Name(), err)
+		}
+	})
+	d.pending[key] = timer
+	return nil
+}
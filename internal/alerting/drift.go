@@ -0,0 +1,26 @@
+package alerting
+
+import (
+	"fmt"
+
+	"api-monitor/internal/drift"
+)
+
+// KindDrift alerts report a change in how an endpoint is served: its
+// resolved IP, certificate issuer or server header. They have no resolved
+// counterpart, the new identity simply becomes the baseline.
+const KindDrift = "drift"
+
+// DriftAlert returns the alert for a drift change of an endpoint
+func DriftAlert(endpointID string, change drift.Change) Alert {
+	return Alert{
+		Kind:       KindDrift,
+		State:      StateFiring,
+		Severity:   SeverityWarning,
+		EndpointID: endpointID,
+		URL:        change.URL,
+		Message:    fmt.Sprintf("%s %s changed from %q to %q", change.URL, change.Field, change.Previous, change.Current),
+		StartedAt:  change.DetectedAt,
+		At:         change.DetectedAt,
+	}
+}
@@ -0,0 +1,332 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailRoute sends an endpoint's alerts to recipients, optionally only the
+// alerts of some kinds or at least some severity. Recoveries go to whoever
+// the matching firing alert went to.
+type EmailRoute struct {
+	To       []string `json:"to" yaml:"to"`
+	Severity string   `json:"severity,omitempty" yaml:"severity,omitempty"` // minimum severity, e.g. "critical"
+	Kinds    []string `json:"kinds,omitempty" yaml:"kinds,omitempty"`       // e.g. ["health"]; all kinds when empty
+}
+
+// severityRank orders severities for EmailRoute.Severity
+var severityRank = map[string]int{SeverityWarning: 1, SeverityCritical: 2}
+
+// Validate checks the route's addresses, severity and kinds
+func (r EmailRoute) Validate() error {
+	if len(r.To) == 0 {
+		return fmt.Errorf("email route needs at least one recipient")
+	}
+	for _, to := range r.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid email address %q", to)
+		}
+	}
+	if r.Severity != "" && severityRank[r.Severity] == 0 {
+		return fmt.Errorf("severity must be %q or %q", SeverityWarning, SeverityCritical)
+	}
+	for _, kind := range r.Kinds {
+		if kind != KindHealth && kind != KindMetric {
+			return fmt.Errorf("kind must be %q or %q", KindHealth, KindMetric)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the route wants alert
+func (r EmailRoute) Matches(alert Alert) bool {
+	if r.Severity != "" && severityRank[alert.Severity] < severityRank[r.Severity] {
+		return false
+	}
+	if len(r.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range r.Kinds {
+		if kind == alert.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// SMTPConfig is the mail server alerts are sent through. Port 465 uses
+// implicit TLS; other ports upgrade with STARTTLS when the server offers it.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// EmailNotifier sends HTML emails when endpoints go down and recover
+type EmailNotifier struct {
+	smtp     SMTPConfig
+	defaults []string
+	routes   func(endpointID string) []EmailRoute
+	template *template.Template
+	context  TemplateContext
+}
+
+// NewEmailNotifier creates a notifier. routes returns an endpoint's email
+// routes; endpoints without any are mailed to defaults.
+func NewEmailNotifier(config SMTPConfig, defaults []string, routes func(endpointID string) []EmailRoute) *EmailNotifier {
+	return &EmailNotifier{smtp: config, defaults: defaults, routes: routes}
+}
+
+// Name returns the channel name
+func (e *EmailNotifier) Name() string { return "email" }
+
+// SetTemplate replaces the default HTML body with a Go html/template over
+// TemplateData; the subject stays the same. context completes the data for
+// each alert.
+func (e *EmailNotifier) SetTemplate(body string, context TemplateContext) error {
+	tmpl, err := parseHTMLTemplate("email", body)
+	if err != nil {
+		return fmt.Errorf("invalid email template: %w", err)
+	}
+	e.template, e.context = tmpl, context
+	return nil
+}
+
+// Recipients returns who an alert is mailed to, without duplicates
+func (e *EmailNotifier) Recipients(alert Alert) []string {
+	var routes []EmailRoute
+	if e.routes != nil {
+		routes = e.routes(alert.EndpointID)
+	}
+	if len(routes) == 0 {
+		return e.defaults
+	}
+
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		if !route.Matches(alert) {
+			continue
+		}
+		for _, to := range route.To {
+			if key := strings.ToLower(to); !seen[key] {
+				seen[key] = true
+				recipients = append(recipients, to)
+			}
+		}
+	}
+	return recipients
+}
+
+// Notify mails the alert to its recipients
+func (e *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	recipients := e.Recipients(alert)
+	if len(recipients) == 0 {
+		return nil
+	}
+	message, err := e.render(recipients, alert)
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, recipients, message)
+}
+
+// SendReport mails a report to the default recipients
+func (e *EmailNotifier) SendReport(ctx context.Context, report Report) error {
+	if len(e.defaults) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	if err := reportTemplate.Execute(&body, report); err != nil {
+		return err
+	}
+	return e.send(ctx, e.defaults, mimeMessage(e.smtp.From, e.defaults, report.Title, report.At, body.Bytes()))
+}
+
+// render builds the message for an alert, from the custom template if set
+func (e *EmailNotifier) render(recipients []string, alert Alert) ([]byte, error) {
+	if e.template == nil {
+		return renderEmail(e.smtp.From, recipients, alert)
+	}
+	var body bytes.Buffer
+	if err := e.template.Execute(&body, templateData(e.context, alert)); err != nil {
+		return nil, err
+	}
+	return emailMessage(e.smtp.From, recipients, alert, body.Bytes()), nil
+}
+
+// send delivers one message over SMTP within ctx's deadline
+func (e *EmailNotifier) send(ctx context.Context, recipients []string, message []byte) error {
+	addr := net.JoinHostPort(e.smtp.Host, strconv.Itoa(e.smtp.Port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	var err error
+	if e.smtp.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: e.smtp.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && e.smtp.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: e.smtp.Host}); err != nil {
+			return err
+		}
+	}
+	// PlainAuth refuses to send credentials over an unencrypted connection
+	// except to localhost
+	if e.smtp.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.smtp.Username, e.smtp.Password, e.smtp.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.smtp.From); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailTemplate renders the HTML body of an alert email
+var emailTemplate = template.Must(template.New("alert").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #1f2933;">
+  <h2 style="color: {{.Color}};">{{.Headline}}</h2>
+  <p>{{.Alert.Message}}</p>
+  <table cellpadding="6" style="border-collapse: collapse;">
+    <tr><td><b>URL</b></td><td><a href="{{.Alert.URL}}">{{.Alert.URL}}</a></td></tr>
+    {{- if .Alert.Result}}
+    <tr><td><b>Status</b></td><td>{{if .Alert.Result.StatusCode}}{{.Alert.Result.StatusCode}}{{else}}no response{{end}}</td></tr>
+    <tr><td><b>Latency</b></td><td>{{.Latency}}</td></tr>
+    {{- if .Alert.Result.Error}}
+    <tr><td><b>Error</b></td><td><code>{{.Alert.Result.Error}}</code></td></tr>
+    {{- end}}
+    {{- end}}
+    {{- if .Alert.RuleName}}
+    <tr><td><b>Rule</b></td><td>{{.Alert.RuleName}} ({{.Alert.Metric}} = {{.Alert.Value}})</td></tr>
+    {{- end}}
+    <tr><td><b>Severity</b></td><td>{{.Alert.Severity}}</td></tr>
+    <tr><td><b>Since</b></td><td>{{.Since}}</td></tr>
+  </table>
+  <p style="color: #7b8794; font-size: 12px;">Sent by API Monitor at {{.At}}</p>
+</body>
+</html>
+`))
+
+// reportTemplate renders the HTML body of a report email
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #1f2933;">
+  <h2>📊 {{.Title}}</h2>
+  <p>{{.Summary}}</p>
+  {{- range .Sections}}
+  <h3>{{.Title}}</h3>
+  <ul>
+    {{- range .Items}}
+    <li>{{.}}</li>
+    {{- end}}
+  </ul>
+  {{- end}}
+  <p style="color: #7b8794; font-size: 12px;">Sent by API Monitor at {{.At.UTC.Format "Mon, 02 Jan 2006 15:04:05 MST"}}</p>
+</body>
+</html>
+`))
+
+// emailHeadline is an alert's subject and heading, and its color
+func emailHeadline(alert Alert) (string, string) {
+	headline := fmt.Sprintf("🚨 %s is down", alert.URL)
+	color := "#d64545"
+	if alert.Kind == KindMetric {
+		headline = fmt.Sprintf("⚠️ %s on %s", alert.RuleName, alert.URL)
+		color = "#cb6e17"
+	}
+	if alert.State == StateResolved {
+		headline = fmt.Sprintf("✅ %s recovered", alert.URL)
+		if alert.Kind == KindMetric {
+			headline = fmt.Sprintf("✅ %s resolved on %s", alert.RuleName, alert.URL)
+		}
+		color = "#3f9142"
+	}
+	return headline, color
+}
+
+// renderEmail builds the MIME message for an alert
+func renderEmail(from string, to []string, alert Alert) ([]byte, error) {
+	headline, color := emailHeadline(alert)
+	latency := ""
+	if alert.Result != nil {
+		latency = alert.Result.ResponseTime.Round(time.Millisecond).String()
+	}
+
+	var body bytes.Buffer
+	err := emailTemplate.Execute(&body, map[string]interface{}{
+		"Alert":    alert,
+		"Headline": headline,
+		"Color":    color,
+		"Latency":  latency,
+		"Since":    alert.StartedAt.UTC().Format(time.RFC1123),
+		"At":       alert.At.UTC().Format(time.RFC1123),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return emailMessage(from, to, alert, body.Bytes()), nil
+}
+
+// emailMessage wraps an HTML body in the MIME message for an alert
+func emailMessage(from string, to []string, alert Alert, body []byte) []byte {
+	headline, _ := emailHeadline(alert)
+	return mimeMessage(from, to, headline, alert.At, body)
+}
+
+// mimeMessage builds an HTML message
+func mimeMessage(from string, to []string, subject string, at time.Time, body []byte) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[API Monitor] "+subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", at.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	message.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	message.Write(bytes.ReplaceAll(body, []byte("\n"), []byte("\r\n")))
+	return message.Bytes()
+}
@@ -0,0 +1,345 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// KindHealth alerts report an endpoint going down or recovering
+const KindHealth = "health"
+
+// HealthPolicy decides when health alerts are raised and which channels
+// receive them
+type HealthPolicy struct {
+	// FailuresBeforeAlert consecutive failed checks open an alert (1 when zero)
+	FailuresBeforeAlert int
+
+	// Escalation overrides how many consecutive failures a channel waits for,
+	// e.g. {"slack": 1, "pagerduty": 5}. Other channels are notified as soon
+	// as the alert opens.
+	Escalation map[string]int
+
+	// Renotify reminds the notified channels while the alert keeps firing
+	// (never when zero)
+	Renotify time.Duration
+
+	// An endpoint whose health changes FlapThreshold times within FlapWindow
+	// is flapping: no alerts are opened or resolved until it settles
+	// (disabled when FlapThreshold is zero)
+	FlapWindow    time.Duration
+	FlapThreshold int
+}
+
+// threshold returns the consecutive failures before a channel is notified
+func (p HealthPolicy) threshold(channel string) int {
+	if n, ok := p.Escalation[channel]; ok && n > 0 {
+		return n
+	}
+	return p.opensAfter()
+}
+
+// opensAfter returns the consecutive failures that open an alert
+func (p HealthPolicy) opensAfter() int {
+	if p.FailuresBeforeAlert < 1 {
+		return 1
+	}
+	return p.FailuresBeforeAlert
+}
+
+// StateStore persists tracker state so restarts neither lose open alerts nor
+// page again for them. storage.Store implements it.
+type StateStore interface {
+	SaveAlertState(endpointID string, state []byte) error
+	LoadAlertStates() (map[string][]byte, error)
+	DeleteAlertState(endpointID string) error
+}
+
+// healthState tracks one endpoint
+type healthState struct {
+	Healthy      bool        `json:"healthy"`
+	Known        bool        `json:"known"`
+	Failures     int         `json:"failures"` // consecutive failed checks
+	FailingSince time.Time   `json:"failingSince,omitempty"`
+	FirstError   string      `json:"firstError,omitempty"` // why the first check of the outage failed
+	Down         *Alert      `json:"down,omitempty"`     // the open alert
+	Notified     []string    `json:"notified,omitempty"` // channels told about Down
+	LastNotified time.Time   `json:"lastNotified,omitempty"`
+	Changes      []time.Time `json:"changes,omitempty"` // health changes within the flap window
+	Flapping     bool        `json:"flapping,omitempty"`
+}
+
+// HealthTracker raises an alert when an endpoint keeps failing, escalates it
+// to more channels the longer it fails, and resolves it on recovery
+type HealthTracker struct {
+	dispatcher *Dispatcher
+	policy     HealthPolicy
+	store      StateStore // nil keeps state in memory only
+	states     map[string]*healthState
+	onRecovery func(alert *Alert)
+	mutex      sync.Mutex
+}
+
+// Outage summarizes an outage once the endpoint recovered
+type Outage struct {
+	StartedAt    time.Time     `json:"startedAt"`
+	EndedAt      time.Time     `json:"endedAt"`
+	Duration     time.Duration `json:"duration"`
+	FailedChecks int           `json:"failedChecks"`
+	FirstError   string        `json:"firstError"`
+	LastError    string        `json:"lastError"`
+
+	// The incident the outage was recorded as, when it was
+	IncidentID  int64  `json:"incidentId,omitempty"`
+	IncidentURL string `json:"incidentUrl,omitempty"`
+}
+
+// NewHealthTracker creates a tracker that raises alerts through dispatcher,
+// restoring earlier state from store when it isn't nil
+func NewHealthTracker(dispatcher *Dispatcher, policy HealthPolicy, store StateStore) *HealthTracker {
+	t := &HealthTracker{dispatcher: dispatcher, policy: policy, store: store, states: make(map[string]*healthState)}
+	if store == nil {
+		return t
+	}
+	saved, err := store.LoadAlertStates()
+	if err != nil {
+		log.Printf("Failed to load alert states: %v", err)
+		return t
+	}
+	open := 0
+	for id, data := range saved {
+		state := &healthState{}
+		if err := json.Unmarshal(data, state); err != nil {
+			log.Printf("Ignoring unreadable alert state of %s: %v", id, err)
+			continue
+		}
+		t.states[id] = state
+		if state.Down != nil {
+			open++
+		}
+	}
+	if open > 0 {
+		log.Printf("🔔 Restored %d open alert(s)", open)
+	}
+	return t
+}
+
+// OnRecovery registers fn to complete the resolved alert of an outage
+// before it is dispatched, e.g. with the incident it was recorded as
+func (t *HealthTracker) OnRecovery(fn func(alert *Alert)) {
+	t.mutex.Lock()
+	t.onRecovery = fn
+	t.mutex.Unlock()
+}
+
+// SetPolicy replaces the policy, e.g. after a configuration reload. Open
+// alerts keep the channels they were sent to.
+func (t *HealthTracker) SetPolicy(policy HealthPolicy) {
+	t.mutex.Lock()
+	t.policy = policy
+	t.mutex.Unlock()
+}
+
+// Observe records a check result and raises, escalates, repeats or resolves
+// the endpoint's alert
+func (t *HealthTracker) Observe(endpointID string, result checker.CheckResult) {
+	now := result.CheckedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	t.mutex.Lock()
+	state, ok := t.states[endpointID]
+	if !ok {
+		state = &healthState{}
+		t.states[endpointID] = state
+	}
+	changed := state.Known && state.Healthy != result.IsHealthy
+	if result.IsHealthy && state.Known && state.Healthy && state.Down == nil && len(state.Changes) == 0 {
+		// Steady and healthy, nothing to do or save
+		t.mutex.Unlock()
+		return
+	}
+	state.Known = true
+	state.Healthy = result.IsHealthy
+	t.trackFlapping(result.URL, state, changed, now)
+
+	resultCopy := result
+	var alerts []Alert
+	var recovered *Alert
+	if result.IsHealthy {
+		if state.Down != nil && !state.Flapping {
+			alert := *state.Down
+			alert.State = StateResolved
+			alert.At = time.Now()
+			alert.Result = &resultCopy
+			alert.Repeat = false
+			alert.Message = fmt.Sprintf("recovered after %s (status %d, %v)",
+				now.Sub(state.Down.StartedAt).Round(time.Second), result.StatusCode, result.ResponseTime.Round(time.Millisecond))
+			alert.Channels = state.Notified
+			alert.Outage = &Outage{
+				StartedAt:    state.Down.StartedAt,
+				EndedAt:      now,
+				Duration:     now.Sub(state.Down.StartedAt),
+				FailedChecks: state.Failures,
+				FirstError:   state.FirstError,
+			}
+			if state.Down.Result != nil {
+				alert.Outage.LastError = failureReason(*state.Down.Result)
+			}
+			if len(alert.Channels) > 0 {
+				alerts = append(alerts, alert)
+				recovered = &alerts[len(alerts)-1]
+			}
+			state.Down = nil
+			state.Notified = nil
+			state.LastNotified = time.Time{}
+		}
+		state.Failures = 0
+		state.FailingSince = time.Time{}
+		state.FirstError = ""
+	} else {
+		state.Failures++
+		if state.FailingSince.IsZero() {
+			state.FailingSince = now
+			state.FirstError = failureReason(result)
+		}
+		if !state.Flapping {
+			alerts = t.fire(endpointID, state, &resultCopy, now)
+		}
+	}
+
+	data, err := json.Marshal(state)
+	onRecovery := t.onRecovery
+	t.mutex.Unlock()
+
+	if recovered != nil && onRecovery != nil {
+		onRecovery(recovered)
+	}
+	for _, alert := range alerts {
+		t.dispatcher.Dispatch(alert)
+	}
+	if t.store != nil && err == nil {
+		if err := t.store.SaveAlertState(endpointID, data); err != nil {
+			log.Printf("Failed to save alert state of %s: %v", endpointID, err)
+		}
+	}
+}
+
+// fire opens the alert once enough checks failed, notifies the channels
+// whose escalation threshold was reached and reminds the others when due
+func (t *HealthTracker) fire(endpointID string, state *healthState, result *checker.CheckResult, now time.Time) []Alert {
+	if state.Down == nil {
+		if state.Failures < t.policy.opensAfter() {
+			return nil
+		}
+		state.Down = &Alert{
+			Kind:       KindHealth,
+			State:      StateFiring,
+			Severity:   SeverityCritical,
+			EndpointID: endpointID,
+			URL:        result.URL,
+			StartedAt:  state.FailingSince,
+		}
+	}
+	state.Down.Failures = state.Failures
+	state.Down.Message = describeFailure(*result)
+	state.Down.Result = result
+
+	var due []string
+	for _, channel := range t.dispatcher.Channels() {
+		if state.Failures >= t.policy.threshold(channel) && !contains(state.Notified, channel) {
+			due = append(due, channel)
+		}
+	}
+
+	alert := *state.Down
+	alert.At = time.Now()
+	switch {
+	case len(due) > 0:
+		alert.Channels = due
+		state.Notified = append(state.Notified, due...)
+	case t.policy.Renotify > 0 && len(state.Notified) > 0 && now.Sub(state.LastNotified) >= t.policy.Renotify:
+		alert.Channels = state.Notified
+		alert.Repeat = true
+		alert.Message = fmt.Sprintf("still down after %s: %s", now.Sub(state.Down.StartedAt).Round(time.Second), alert.Message)
+	default:
+		return nil
+	}
+	state.LastNotified = now
+	return []Alert{alert}
+}
+
+// trackFlapping records a health change and updates whether the endpoint is
+// flapping
+func (t *HealthTracker) trackFlapping(url string, state *healthState, changed bool, now time.Time) {
+	if t.policy.FlapThreshold <= 0 {
+		state.Changes = nil
+		return
+	}
+	if changed {
+		state.Changes = append(state.Changes, now)
+	}
+	cutoff := now.Add(-t.policy.FlapWindow)
+	kept := state.Changes[:0]
+	for _, at := range state.Changes {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	state.Changes = kept
+
+	flapping := len(state.Changes) >= t.policy.FlapThreshold
+	if flapping && !state.Flapping {
+		log.Printf("〰️ %s is flapping (%d health changes within %v), holding its alerts", url, len(state.Changes), t.policy.FlapWindow)
+	} else if !flapping && state.Flapping {
+		log.Printf("%s stopped flapping", url)
+	}
+	state.Flapping = flapping
+}
+
+// describeFailure summarizes why a check failed
+func describeFailure(result checker.CheckResult) string {
+	return "endpoint is down: " + failureReason(result)
+}
+
+// failureReason is the error of a failed check, or its status and latency
+func failureReason(result checker.CheckResult) string {
+	if result.Error != "" {
+		return result.Error
+	}
+	return fmt.Sprintf("status %d after %v", result.StatusCode, result.ResponseTime.Round(time.Millisecond))
+}
+
+// Active returns the health alerts that are currently firing
+func (t *HealthTracker) Active() []Alert {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	alerts := []Alert{}
+	for _, state := range t.states {
+		if state.Down != nil {
+			alerts = append(alerts, *state.Down)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartedAt.Before(alerts[j].StartedAt) })
+	return alerts
+}
+
+// ForgetEndpoint drops the state of a removed endpoint without resolving it
+func (t *HealthTracker) ForgetEndpoint(endpointID string) {
+	t.mutex.Lock()
+	delete(t.states, endpointID)
+	t.mutex.Unlock()
+
+	if t.store != nil {
+		if err := t.store.DeleteAlertState(endpointID); err != nil {
+			log.Printf("Failed to delete alert state of %s: %v", endpointID, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package alerting
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OpsgenieAPIURL is the Opsgenie REST API (EU accounts use https://api.eu.opsgenie.com)
+const OpsgenieAPIURL = "https://api.opsgenie.com"
+
+// OpsgenieNotifier creates Opsgenie alerts and closes them on recovery. The
+// alert alias is the dedup key, so Opsgenie folds repeats into one alert.
+type OpsgenieNotifier struct {
+	apiKey string
+	apiURL string
+	client *http.Client
+}
+
+// NewOpsgenieNotifier creates a notifier for an API integration key. apiURL
+// defaults to OpsgenieAPIURL.
+func NewOpsgenieNotifier(apiKey, apiURL string) *OpsgenieNotifier {
+	if apiURL == "" {
+		apiURL = OpsgenieAPIURL
+	}
+	return &OpsgenieNotifier{apiKey: apiKey, apiURL: strings.TrimRight(apiURL, "/"), client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Name returns the channel name
+func (o *OpsgenieNotifier) Name() string { return "opsgenie" }
+
+// opsgenieAlert is a create alert request
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// opsgenieClose is a close alert request
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Notify creates the endpoint's alert or closes it
+func (o *OpsgenieNotifier) Notify(ctx context.Context, alert Alert) error {
+	headers := map[string]string{"Authorization": "GenieKey " + o.apiKey}
+	alias := DedupKey(alert)
+
+	if alert.State == StateResolved {
+		closeURL := o.apiURL + "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		return postJSON(ctx, o.client, "opsgenie", closeURL, headers, opsgenieClose{Source: "api-monitor", Note: alert.Message})
+	}
+
+	priority := "P1"
+	if alert.Severity == SeverityWarning {
+		priority = "P3"
+	}
+	return postJSON(ctx, o.client, "opsgenie", o.apiURL+"/v2/alerts", headers, opsgenieAlert{
+		Message:     truncate(alertSummary(alert), 130),
+		Alias:       alias,
+		Description: alert.Message,
+		Priority:    priority,
+		Source:      "api-monitor",
+		Entity:      alert.URL,
+		Tags:        []string{"api-monitor", alert.Kind, alert.Severity},
+		Details:     alertDetails(alert),
+	})
+}
@@ -0,0 +1,170 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PagerDutyEventsURL is the Events API v2 endpoint (EU accounts use
+// https://events.eu.pagerduty.com/v2/enqueue)
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier opens PagerDuty incidents through the Events API v2 and
+// resolves them when the endpoint recovers
+type PagerDutyNotifier struct {
+	routingKey string
+	eventsURL  string
+	client     *http.Client
+}
+
+// NewPagerDutyNotifier creates a notifier for a service integration's
+// routing key. eventsURL defaults to PagerDutyEventsURL.
+func NewPagerDutyNotifier(routingKey, eventsURL string) *PagerDutyNotifier {
+	if eventsURL == "" {
+		eventsURL = PagerDutyEventsURL
+	}
+	return &PagerDutyNotifier{routingKey: routingKey, eventsURL: eventsURL, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Name returns the channel name
+func (p *PagerDutyNotifier) Name() string { return "pagerduty" }
+
+// pagerDutyEvent is an Events API v2 request
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Notify triggers or resolves the endpoint's incident
+func (p *PagerDutyNotifier) Notify(ctx context.Context, alert Alert) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    DedupKey(alert),
+	}
+	if alert.State == StateResolved {
+		event.EventAction = "resolve"
+	} else {
+		event.Payload = &pagerDutyPayload{
+			Summary:       truncate(alertSummary(alert), 1024),
+			Source:        alert.URL,
+			Severity:      alert.Severity,
+			Timestamp:     alert.StartedAt.UTC().Format(time.RFC3339),
+			Component:     alert.EndpointID,
+			Class:         alert.Kind,
+			CustomDetails: alertDetails(alert),
+		}
+		event.Links = []pagerDutyLink{{Href: alert.URL, Text: "Endpoint"}}
+	}
+	return postJSON(ctx, p.client, "pagerduty", p.eventsURL, nil, event)
+}
+
+// DedupKey identifies the incident an alert belongs to, so repeated alerts
+// for the same outage update one incident and the recovery resolves it
+func DedupKey(alert Alert) string {
+	key := "api-monitor:" + alert.Kind + ":" + alert.EndpointID
+	if alert.RuleID != "" {
+		key += ":" + alert.RuleID
+	}
+	return key
+}
+
+// alertSummary is a one-line description of a firing alert
+func alertSummary(alert Alert) string {
+	if alert.Kind == KindMetric {
+		return fmt.Sprintf("%s on %s: %s", alert.RuleName, alert.URL, alert.Message)
+	}
+	return fmt.Sprintf("%s is down: %s", alert.URL, strings.TrimPrefix(alert.Message, "endpoint is down: "))
+}
+
+// alertDetails collects the fields on-call engineers need to triage an alert
+func alertDetails(alert Alert) map[string]string {
+	details := map[string]string{
+		"url":        alert.URL,
+		"message":    alert.Message,
+		"started_at": alert.StartedAt.UTC().Format(time.RFC3339),
+	}
+	if result := alert.Result; result != nil {
+		details["status_code"] = fmt.Sprintf("%d", result.StatusCode)
+		details["latency"] = result.ResponseTime.Round(time.Millisecond).String()
+		if result.Error != "" {
+			details["error"] = result.Error
+		}
+		for name, value := range result.Labels {
+			details["label_"+name] = value
+		}
+	}
+	if alert.Kind == KindMetric {
+		details["metric"] = alert.Metric
+		details["value"] = fmt.Sprintf("%g", alert.Value)
+		details["threshold"] = fmt.Sprintf("%s %g", alert.Operator, alert.Threshold)
+	}
+	return details
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// postJSON posts payload as JSON and treats any 2xx response as delivered
+func postJSON(ctx context.Context, client *http.Client, channel, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return postBody(ctx, client, channel, url, headers, body)
+}
+
+// postBody posts an already encoded JSON body
+func postBody(ctx context.Context, client *http.Client, channel, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned status %d: %s", channel, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package alerting
+
+import (
+	"sort"
+	"time"
+)
+
+// Sample is one historical value of a metric
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// PreviewPeriod is a period in which a rule would have fired
+type PreviewPeriod struct {
+	EndpointID   string     `json:"endpointId,omitempty"`
+	URL          string     `json:"url"`
+	PendingSince time.Time  `json:"pendingSince"` // when the condition started holding
+	FiredAt      time.Time  `json:"firedAt"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty"` // nil when still firing at the end of the history
+	Peak         float64    `json:"peak"`                 // the value furthest past the threshold
+}
+
+// PreviewRule replays a metric's history through a rule, with the same
+// evaluation as live checks, and returns the periods it would have fired in.
+// The peaks of change rules are ratios to the previous window.
+func PreviewRule(rule Rule, endpointID, url string, samples []Sample) ([]PreviewPeriod, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+
+	periods := []PreviewPeriod{}
+	state := &ruleState{}
+	series := &changeSeries{}
+	var open *PreviewPeriod
+	for _, sample := range samples {
+		value := sample.Value
+		if rule.Change != nil {
+			current, baseline, ok := series.add(rule.Change, sample.At, sample.Value)
+			if !ok {
+				continue
+			}
+			value = current / baseline
+		}
+		fired, resolved := state.step(&rule, value, sample.At)
+		switch {
+		case fired:
+			open = &PreviewPeriod{EndpointID: endpointID, URL: url, PendingSince: state.pendingSince, FiredAt: sample.At, Peak: value}
+		case resolved:
+			at := sample.At
+			open.ResolvedAt = &at
+			periods = append(periods, *open)
+			open = nil
+		case open != nil && furtherPast(rule.Operator, value, open.Peak):
+			open.Peak = value
+		}
+	}
+	if open != nil {
+		periods = append(periods, *open)
+	}
+	return periods, nil
+}
+
+// furtherPast reports whether value is further past a threshold than peak
+// for the operator's direction
+func furtherPast(operator string, value, peak float64) bool {
+	switch operator {
+	case ">", ">=":
+		return value > peak
+	case "<", "<=":
+		return value < peak
+	default:
+		return false
+	}
+}
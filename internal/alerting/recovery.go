@@ -0,0 +1,104 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// RecoveryChannel is the name of the channel delivering recovery webhooks
+const RecoveryChannel = "recovery-webhook"
+
+// EventRecovered is the event of recovery webhook payloads
+const EventRecovered = "recovered"
+
+// RecoveryWebhook is an endpoint's own webhook that receives a summary of
+// every outage once the endpoint recovers, e.g. to close the ticket an
+// automation opened for it
+type RecoveryWebhook struct {
+	URL    string `json:"url" yaml:"url"`
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"` // signs requests like WEBHOOKS, unsigned when empty
+}
+
+// Validate checks the webhook's URL
+func (h RecoveryWebhook) Validate() error {
+	parsed, err := url.Parse(h.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("recovery webhook url must be an http or https URL")
+	}
+	return nil
+}
+
+// Redacted returns a copy with the secret masked, for listing endpoints
+func (h *RecoveryWebhook) Redacted() *RecoveryWebhook {
+	if h == nil || h.Secret == "" {
+		return h
+	}
+	redacted := *h
+	redacted.Secret = "********"
+	return &redacted
+}
+
+// RecoveryPayload is the body posted to recovery webhooks
+type RecoveryPayload struct {
+	Event      string               `json:"event"` // always "recovered"
+	EndpointID string               `json:"endpointId"`
+	URL        string               `json:"url"`
+	At         time.Time            `json:"at"`
+	Outage     Outage               `json:"outage"`
+	Result     *checker.CheckResult `json:"result,omitempty"` // the check that succeeded again
+}
+
+// RecoveryNotifier posts the outage summary of resolved health alerts to the
+// recovery webhook of their endpoint. Other alerts are ignored.
+type RecoveryNotifier struct {
+	hooks  func(endpointID string) *RecoveryWebhook
+	client *http.Client
+}
+
+// NewRecoveryNotifier creates a notifier that looks up each endpoint's
+// webhook with hooks
+func NewRecoveryNotifier(hooks func(endpointID string) *RecoveryWebhook) *RecoveryNotifier {
+	return &RecoveryNotifier{hooks: hooks, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Name returns the channel name
+func (n *RecoveryNotifier) Name() string { return RecoveryChannel }
+
+// Notify posts the payload of a recovery
+func (n *RecoveryNotifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.Kind != KindHealth || alert.State != StateResolved || alert.Outage == nil {
+		return nil
+	}
+	hook := n.hooks(alert.EndpointID)
+	if hook == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(RecoveryPayload{
+		Event:      EventRecovered,
+		EndpointID: alert.EndpointID,
+		URL:        alert.URL,
+		At:         alert.At,
+		Outage:     *alert.Outage,
+		Result:     alert.Result,
+	})
+	if err != nil {
+		return err
+	}
+	var headers map[string]string
+	if hook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers = map[string]string{
+			WebhookTimestampHeader: timestamp,
+			WebhookSignatureHeader: "sha256=" + SignWebhook(hook.Secret, timestamp, body),
+		}
+	}
+	return postBody(ctx, n.client, n.Name(), hook.URL, headers, body)
+}
@@ -0,0 +1,83 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// reportTimeout bounds how long a single channel may take to deliver a report
+const reportTimeout = 30 * time.Second
+
+// Report is a periodic summary, such as the weekly digest, sent to the
+// channels that implement Reporter. Paging channels don't take reports.
+type Report struct {
+	Kind     string          `json:"kind"` // e.g. "weekly_digest"
+	Title    string          `json:"title"`
+	Summary  string          `json:"summary"`
+	Sections []ReportSection `json:"sections"`
+	At       time.Time       `json:"at"`
+	Data     interface{}     `json:"data,omitempty"` // the full report, for webhooks
+}
+
+// ReportSection is a titled list in a report; empty sections are left out
+type ReportSection struct {
+	Title string   `json:"title"`
+	Items []string `json:"items"`
+}
+
+// Reporter is a channel that can deliver reports as well as alerts
+type Reporter interface {
+	Notifier
+	SendReport(ctx context.Context, report Report) error
+}
+
+// SendReport delivers a report to the registered channels that take
+// reports, or only to those named in channels, and waits for them. It
+// returns the channels that received it.
+func (d *Dispatcher) SendReport(report Report, channels []string) ([]string, error) {
+	if report.At.IsZero() {
+		report.At = time.Now()
+	}
+	sections := report.Sections[:0:0]
+	for _, section := range report.Sections {
+		if len(section.Items) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	report.Sections = sections
+
+	d.mutex.RLock()
+	var reporters []Reporter
+	for _, notifier := range d.notifiers {
+		if reporter, ok := notifier.(Reporter); ok && (len(channels) == 0 || contains(channels, notifier.Name())) {
+			reporters = append(reporters, reporter)
+		}
+	}
+	d.mutex.RUnlock()
+
+	delivered := []string{}
+	var failures []error
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, reporter := range reporters {
+		wg.Add(1)
+		go func(reporter Reporter) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+			defer cancel()
+			err := reporter.SendReport(ctx, report)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s: %w", reporter.Name(), err))
+				return
+			}
+			delivered = append(delivered, reporter.Name())
+		}(reporter)
+	}
+	wg.Wait()
+	return delivered, errors.Join(failures...)
+}
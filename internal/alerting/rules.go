@@ -0,0 +1,368 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Comparison operators supported by metric rules
+var operators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// Rule alerts when an extracted metric, or MetricLatency, satisfies a
+// condition for a duration, e.g. queue_depth > 1000 for 5m. With Change the
+// condition applies to the metric's ratio to its previous window.
+type Rule struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name,omitempty"`
+	EndpointID string  `json:"endpointId,omitempty"` // empty applies to every endpoint reporting the metric
+	Metric     string  `json:"metric"`
+	Operator   string  `json:"operator"`
+	Threshold  float64 `json:"threshold"`
+	For        string  `json:"for,omitempty"` // how long the condition must hold, e.g. "5m"
+	Severity   string  `json:"severity,omitempty"`
+	Change     *Change `json:"change,omitempty"`
+
+	// Disabled rules are kept but not evaluated, e.g. while tuning them
+	// with PreviewRule
+	Disabled bool `json:"disabled,omitempty"`
+
+	forDuration time.Duration
+}
+
+// Validate checks the rule and fills in defaults
+func (r *Rule) Validate() error {
+	if r.Metric == "" {
+		return fmt.Errorf("metric is required")
+	}
+	if _, ok := operators[r.Operator]; !ok {
+		return fmt.Errorf("operator must be one of >, >=, <, <=, ==, !=")
+	}
+	r.forDuration = 0
+	if r.For != "" {
+		d, err := time.ParseDuration(r.For)
+		if err != nil || d < 0 {
+			return fmt.Errorf("for must be a duration such as \"5m\"")
+		}
+		r.forDuration = d
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("severity must be %q or %q", SeverityWarning, SeverityCritical)
+	}
+	if r.Change != nil {
+		if err := r.Change.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s %s %g", r.Metric, r.Operator, r.Threshold)
+		if r.Change != nil {
+			r.Name = fmt.Sprintf("%s %s %gx previous %s", r.Change.describe(r.Metric), r.Operator, r.Threshold, WindowName(r.Change.window))
+		}
+	}
+	return nil
+}
+
+// ruleState tracks one rule on one endpoint
+type ruleState struct {
+	pendingSince time.Time // when the condition started holding
+	firing       bool
+}
+
+// step evaluates a new value of the rule's metric and reports whether the
+// rule started or stopped firing
+func (state *ruleState) step(rule *Rule, value float64, now time.Time) (fired, resolved bool) {
+	if operators[rule.Operator](value, rule.Threshold) {
+		if state.pendingSince.IsZero() {
+			state.pendingSince = now
+		}
+		if !state.firing && now.Sub(state.pendingSince) >= rule.forDuration {
+			state.firing = true
+			return true, false
+		}
+		return false, false
+	}
+
+	resolved = state.firing
+	state.pendingSince = time.Time{}
+	state.firing = false
+	return false, resolved
+}
+
+// MetricEngine evaluates metric rules against every check result
+type MetricEngine struct {
+	dispatcher *Dispatcher
+	rules      map[string]*Rule
+	states     map[string]*ruleState    // keyed by rule ID + endpoint ID
+	series     map[string]*changeSeries // of change rules, keyed like states
+	active     map[string]Alert
+	nextID     int
+	mutex      sync.Mutex
+}
+
+// NewMetricEngine creates an engine that raises alerts through dispatcher
+func NewMetricEngine(dispatcher *Dispatcher) *MetricEngine {
+	return &MetricEngine{
+		dispatcher: dispatcher,
+		rules:      make(map[string]*Rule),
+		states:     make(map[string]*ruleState),
+		series:     make(map[string]*changeSeries),
+		active:     make(map[string]Alert),
+	}
+}
+
+// AddRule validates and adds a rule, assigning an ID when it has none
+func (e *MetricEngine) AddRule(rule Rule) (Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if rule.ID == "" {
+		e.nextID++
+		rule.ID = fmt.Sprintf("rule_%d", e.nextID)
+	}
+	if _, exists := e.rules[rule.ID]; exists {
+		return Rule{}, fmt.Errorf("rule %s already exists", rule.ID)
+	}
+	e.rules[rule.ID] = &rule
+	return rule, nil
+}
+
+// UpdateRule validates and replaces an existing rule. Alerts the old rule
+// has firing are resolved and its state is reset, so the new condition is
+// evaluated from scratch.
+func (e *MetricEngine) UpdateRule(rule Rule) (Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return Rule{}, err
+	}
+
+	e.mutex.Lock()
+	if _, exists := e.rules[rule.ID]; !exists {
+		e.mutex.Unlock()
+		return Rule{}, fmt.Errorf("rule %s not found", rule.ID)
+	}
+	e.rules[rule.ID] = &rule
+	resolved := e.resetRule(rule.ID, "rule %q was changed")
+	e.mutex.Unlock()
+
+	for _, alert := range resolved {
+		e.dispatcher.Dispatch(alert)
+	}
+	return rule, nil
+}
+
+// RemoveRule deletes a rule, resolving any alert it has firing
+func (e *MetricEngine) RemoveRule(id string) bool {
+	e.mutex.Lock()
+	if _, exists := e.rules[id]; !exists {
+		e.mutex.Unlock()
+		return false
+	}
+	delete(e.rules, id)
+	resolved := e.resetRule(id, "rule %q was removed")
+	e.mutex.Unlock()
+
+	for _, alert := range resolved {
+		e.dispatcher.Dispatch(alert)
+	}
+	return true
+}
+
+// resetRule drops a rule's state and returns its firing alerts as resolved
+// with the given reason. The caller holds the mutex.
+func (e *MetricEngine) resetRule(id, reason string) []Alert {
+	var resolved []Alert
+	for key, alert := range e.active {
+		if alert.RuleID == id {
+			alert.State = StateResolved
+			alert.At = time.Now()
+			alert.Message = fmt.Sprintf(reason, alert.RuleName)
+			resolved = append(resolved, alert)
+			delete(e.active, key)
+		}
+	}
+	prefix := id + "/"
+	for key := range e.states {
+		if strings.HasPrefix(key, prefix) {
+			delete(e.states, key)
+		}
+	}
+	for key := range e.series {
+		if strings.HasPrefix(key, prefix) {
+			delete(e.series, key)
+		}
+	}
+	return resolved
+}
+
+// Rule returns a rule by ID
+func (e *MetricEngine) Rule(id string) (Rule, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	rule, exists := e.rules[id]
+	if !exists {
+		return Rule{}, false
+	}
+	return *rule, true
+}
+
+// Rules returns all rules sorted by ID
+func (e *MetricEngine) Rules() []Rule {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	rules := make([]Rule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, *rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules
+}
+
+// Active returns the alerts that are currently firing
+func (e *MetricEngine) Active() []Alert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	alerts := make([]Alert, 0, len(e.active))
+	for _, alert := range e.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartedAt.Before(alerts[j].StartedAt) })
+	return alerts
+}
+
+// Observe evaluates every applicable rule against a check result. Rules whose
+// metric is missing from the result keep their current state, as do change
+// rules until both their windows have enough samples.
+func (e *MetricEngine) Observe(endpointID string, result checker.CheckResult) {
+	now := result.CheckedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var raised []Alert
+	e.mutex.Lock()
+	for _, rule := range e.rules {
+		if rule.Disabled || (rule.EndpointID != "" && rule.EndpointID != endpointID) {
+			continue
+		}
+		value, ok := MetricValue(result, rule.Metric)
+		if !ok {
+			continue
+		}
+
+		key := rule.ID + "/" + endpointID
+		var current, baseline float64
+		if rule.Change != nil {
+			series, ok := e.series[key]
+			if !ok {
+				series = &changeSeries{}
+				e.series[key] = series
+			}
+			if current, baseline, ok = series.add(rule.Change, now, value); !ok {
+				continue
+			}
+			value = current / baseline
+		}
+
+		state, ok := e.states[key]
+		if !ok {
+			state = &ruleState{}
+			e.states[key] = state
+		}
+
+		since := state.pendingSince
+		fired, resolved := state.step(rule, value, now)
+		switch {
+		case fired:
+			alert := e.alertFor(rule, endpointID, result, value, current, baseline, state.pendingSince, StateFiring)
+			e.active[key] = alert
+			raised = append(raised, alert)
+		case resolved:
+			raised = append(raised, e.alertFor(rule, endpointID, result, value, current, baseline, since, StateResolved))
+			delete(e.active, key)
+		}
+	}
+	e.mutex.Unlock()
+
+	for _, alert := range raised {
+		e.dispatcher.Dispatch(alert)
+	}
+}
+
+// ForgetEndpoint drops rule state for a removed endpoint
+func (e *MetricEngine) ForgetEndpoint(endpointID string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	suffix := "/" + endpointID
+	for key := range e.states {
+		if len(key) > len(suffix) && key[len(key)-len(suffix):] == suffix {
+			delete(e.states, key)
+			delete(e.series, key)
+			delete(e.active, key)
+		}
+	}
+}
+
+// alertFor builds a rule's alert; current and baseline are the window
+// aggregates of change rules, whose value is their ratio
+func (e *MetricEngine) alertFor(rule *Rule, endpointID string, result checker.CheckResult, value, current, baseline float64, since time.Time, state string) Alert {
+	resultCopy := result
+	message := fmt.Sprintf("%s is %g (%s %g", rule.Metric, value, rule.Operator, rule.Threshold)
+	if rule.Change != nil {
+		message = fmt.Sprintf("%s is %.2fx the previous %s, %.4g vs %.4g (%s %gx",
+			rule.Change.describe(rule.Metric), value, WindowName(rule.Change.window), current, baseline, rule.Operator, rule.Threshold)
+	}
+	if rule.forDuration > 0 {
+		message += fmt.Sprintf(" for %s", rule.forDuration)
+	}
+	message += ")"
+	if state == StateResolved {
+		message = fmt.Sprintf("%s back to %g, condition %s %g no longer holds", rule.Metric, value, rule.Operator, rule.Threshold)
+		if rule.Change != nil {
+			message = fmt.Sprintf("%s back to %.2fx the previous %s, condition %s %gx no longer holds",
+				rule.Change.describe(rule.Metric), value, WindowName(rule.Change.window), rule.Operator, rule.Threshold)
+		}
+	}
+
+	return Alert{
+		Kind:       KindMetric,
+		State:      state,
+		Severity:   rule.Severity,
+		EndpointID: endpointID,
+		URL:        result.URL,
+		Message:    message,
+		StartedAt:  since,
+		At:         time.Now(),
+		Result:     &resultCopy,
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Metric:     rule.Metric,
+		Operator:   rule.Operator,
+		Threshold:  rule.Threshold,
+		Value:      value,
+		Current:    current,
+		Baseline:   baseline,
+	}
+}
@@ -0,0 +1,165 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	template   *template.Template
+	context    TemplateContext
+	client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier for an incoming webhook URL
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// Name returns the channel name
+func (s *SlackNotifier) Name() string { return "slack" }
+
+// SetTemplate replaces the default message with a Go template over
+// TemplateData rendering the whole webhook payload, e.g. Block Kit blocks.
+// context completes the data for each alert.
+func (s *SlackNotifier) SetTemplate(payload string, context TemplateContext) error {
+	tmpl, err := parseJSONTemplate("slack", payload)
+	if err != nil {
+		return fmt.Errorf("invalid slack template: %w", err)
+	}
+	s.template, s.context = tmpl, context
+	return nil
+}
+
+// Render builds the payload for an alert
+func (s *SlackNotifier) Render(alert Alert) ([]byte, error) {
+	if s.template == nil {
+		return json.Marshal(slackPayload(alert))
+	}
+	return renderJSON(s.template, templateData(s.context, alert))
+}
+
+// slackMessage is the incoming webhook payload
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Fields []slackField `json:"fields"`
+	Footer string       `json:"footer,omitempty"`
+	TS     int64        `json:"ts,omitempty"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Notify posts the alert
+func (s *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := s.Render(alert)
+	if err != nil {
+		return err
+	}
+	return s.post(ctx, body)
+}
+
+// SendReport posts a report as a formatted message
+func (s *SlackNotifier) SendReport(ctx context.Context, report Report) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, ":bar_chart: *%s*\n%s", report.Title, report.Summary)
+	for _, section := range report.Sections {
+		fmt.Fprintf(&text, "\n\n*%s*", section.Title)
+		for _, item := range section.Items {
+			text.WriteString("\n• " + item)
+		}
+	}
+	body, err := json.Marshal(slackMessage{Text: text.String()})
+	if err != nil {
+		return err
+	}
+	return s.post(ctx, body)
+}
+
+// post sends a payload to the webhook
+func (s *SlackNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// slackPayload formats an alert as a headline with URL, status, latency and
+// error fields, red while firing and green once resolved
+func slackPayload(alert Alert) slackMessage {
+	color := "danger"
+	if alert.Severity == SeverityWarning {
+		color = "warning"
+	}
+	headline := fmt.Sprintf(":rotating_light: *%s is down*", alert.URL)
+	if alert.Kind == KindMetric {
+		headline = fmt.Sprintf(":warning: *%s* on %s", alert.RuleName, alert.URL)
+	}
+	if alert.State == StateResolved {
+		color = "good"
+		headline = fmt.Sprintf(":white_check_mark: *%s recovered*", alert.URL)
+		if alert.Kind == KindMetric {
+			headline = fmt.Sprintf(":white_check_mark: *%s* resolved on %s", alert.RuleName, alert.URL)
+		}
+	}
+
+	fields := []slackField{{Title: "URL", Value: alert.URL}}
+	if result := alert.Result; result != nil {
+		status := "no response"
+		if result.StatusCode > 0 {
+			status = fmt.Sprintf("%d", result.StatusCode)
+		}
+		fields = append(fields,
+			slackField{Title: "Status", Value: status, Short: true},
+			slackField{Title: "Latency", Value: result.ResponseTime.Round(time.Millisecond).String(), Short: true},
+		)
+		if result.Error != "" {
+			fields = append(fields, slackField{Title: "Error", Value: result.Error})
+		}
+	}
+	if alert.Kind == KindMetric {
+		fields = append(fields, slackField{Title: alert.Metric, Value: fmt.Sprintf("%g (%s %g)", alert.Value, alert.Operator, alert.Threshold), Short: true})
+	}
+	if alert.State == StateFiring && alert.Kind == KindHealth {
+		fields = append(fields, slackField{Title: "Down since", Value: alert.StartedAt.UTC().Format(time.RFC1123), Short: true})
+	}
+
+	return slackMessage{
+		Text: headline + "\n" + alert.Message,
+		Attachments: []slackAttachment{{
+			Color:  color,
+			Fields: fields,
+			Footer: "API Monitor",
+			TS:     alert.At.Unix(),
+		}},
+	}
+}
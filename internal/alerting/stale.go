@@ -0,0 +1,77 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KindStale alerts report an endpoint that stopped producing results, e.g.
+// because the worker or shard checking it is gone
+const KindStale = "stale"
+
+// StaleTracker raises an alert when an endpoint's results stop arriving and
+// resolves it once a new one comes in
+type StaleTracker struct {
+	dispatcher *Dispatcher
+	active     map[string]Alert // by endpoint ID
+	mutex      sync.Mutex
+}
+
+// NewStaleTracker creates a tracker that raises alerts through dispatcher
+func NewStaleTracker(dispatcher *Dispatcher) *StaleTracker {
+	return &StaleTracker{dispatcher: dispatcher, active: make(map[string]Alert)}
+}
+
+// Update records whether an endpoint is stale; lastChecked is the time of
+// its latest result
+func (t *StaleTracker) Update(endpointID, url string, stale bool, lastChecked, now time.Time) {
+	t.mutex.Lock()
+	alert, firing := t.active[endpointID]
+	switch {
+	case stale && !firing:
+		alert = Alert{
+			Kind:       KindStale,
+			State:      StateFiring,
+			Severity:   SeverityWarning,
+			EndpointID: endpointID,
+			URL:        url,
+			Message:    fmt.Sprintf("No results from %s since %s; its state is unknown", url, lastChecked.UTC().Format(time.RFC3339)),
+			StartedAt:  now,
+			At:         now,
+		}
+		t.active[endpointID] = alert
+	case !stale && firing:
+		alert.State = StateResolved
+		alert.At = now
+		alert.Message = fmt.Sprintf("Results from %s are arriving again", url)
+		delete(t.active, endpointID)
+	default:
+		t.mutex.Unlock()
+		return
+	}
+	t.mutex.Unlock()
+
+	t.dispatcher.Dispatch(alert)
+}
+
+// Active returns the stale alerts currently firing, oldest first
+func (t *StaleTracker) Active() []Alert {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	alerts := make([]Alert, 0, len(t.active))
+	for _, alert := range t.active {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].StartedAt.Before(alerts[j].StartedAt) })
+	return alerts
+}
+
+// ForgetEndpoint drops the alert of a removed endpoint
+func (t *StaleTracker) ForgetEndpoint(endpointID string) {
+	t.mutex.Lock()
+	delete(t.active, endpointID)
+	t.mutex.Unlock()
+}
@@ -0,0 +1,96 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"text/template"
+
+	"api-monitor/internal/stats"
+)
+
+// TemplateData is what channel templates are executed with: the alert's
+// own fields, e.g. {{.Message}} or {{.Result.StatusCode}}, plus its
+// endpoint, incident, recent stats and runbook
+type TemplateData struct {
+	Alert
+	Endpoint TemplateEndpoint `json:"endpoint"`
+	Incident *Outage          `json:"incident,omitempty"` // the outage a resolved health alert ends, with its incident link
+	Stats    *TemplateStats   `json:"stats,omitempty"`    // nil when the database is unavailable
+	Runbook  string           `json:"runbook,omitempty"`  // the endpoint's runbook URL
+}
+
+// TemplateEndpoint describes an alert's endpoint
+type TemplateEndpoint struct {
+	ID   string   `json:"id"`
+	URL  string   `json:"url"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// TemplateStats summarizes an endpoint's recent checks
+type TemplateStats struct {
+	Checks        int           `json:"checks"`
+	Failures      int           `json:"failures"`
+	UptimePercent float64       `json:"uptimePercent"`
+	Latency       stats.Summary `json:"latency"` // of the successful checks
+}
+
+// TemplateContext completes the data templates get for an alert, e.g. with
+// the endpoint's stats from the database
+type TemplateContext func(alert Alert) TemplateData
+
+// templateData returns what templates get for an alert; without a context
+// they only know the alert and its endpoint's ID and URL
+func templateData(context TemplateContext, alert Alert) TemplateData {
+	if context != nil {
+		return context(alert)
+	}
+	return TemplateData{Alert: alert, Endpoint: TemplateEndpoint{ID: alert.EndpointID, URL: alert.URL}, Incident: alert.Outage}
+}
+
+// ValidateRunbook checks an endpoint's runbook link, which must be an
+// absolute http(s) URL
+func ValidateRunbook(runbook string) error {
+	parsed, err := url.Parse(runbook)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("runbook must be an http(s) URL")
+	}
+	return nil
+}
+
+// ValidateTemplate parses a channel's template: HTML for email, a JSON
+// payload for the others
+func ValidateTemplate(channel, text string) error {
+	var err error
+	if channel == "email" {
+		_, err = parseHTMLTemplate(channel, text)
+	} else {
+		_, err = parseJSONTemplate(channel, text)
+	}
+	return err
+}
+
+// parseJSONTemplate parses a template rendering a JSON payload
+func parseJSONTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// parseHTMLTemplate parses a template rendering an HTML email body, with
+// values escaped
+func parseHTMLTemplate(name, text string) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Option("missingkey=error").Parse(text)
+}
+
+// renderJSON executes a payload template, rejecting output that isn't JSON
+func renderJSON(tmpl *template.Template, data TemplateData) ([]byte, error) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, fmt.Errorf("template rendered invalid JSON: %s", truncate(body.String(), 200))
+	}
+	return body.Bytes(), nil
+}
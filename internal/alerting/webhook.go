@@ -0,0 +1,121 @@
+package alerting
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Webhook signature headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook's secret, so receivers can
+// reject forged and replayed requests.
+const (
+	WebhookTimestampHeader = "X-Monitor-Timestamp"
+	WebhookSignatureHeader = "X-Monitor-Signature"
+)
+
+// WebhookNotifier posts alerts as JSON rendered from a Go template, so
+// services such as Teams, Discord or Mattermost can be integrated through
+// configuration alone
+type WebhookNotifier struct {
+	name     string
+	url      string
+	secret   string
+	template *template.Template
+	context  TemplateContext
+	client   *http.Client
+}
+
+// templateFuncs are available in webhook, Slack and email templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value, e.g. "text": {{json .Message}}, so quotes and
+	// newlines in messages can't break the payload
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper":   strings.ToUpper,
+	"ms":      func(d time.Duration) int64 { return d.Milliseconds() },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+// NewWebhookNotifier creates a notifier that posts to url. payload is a Go
+// template over TemplateData; when empty the alert itself is posted as
+// JSON. An empty secret sends requests unsigned.
+func NewWebhookNotifier(name, url, secret, payload string) (*WebhookNotifier, error) {
+	w := &WebhookNotifier{name: name, url: url, secret: secret, client: &http.Client{Timeout: notifyTimeout}}
+	if strings.TrimSpace(payload) != "" {
+		tmpl, err := parseJSONTemplate(name, payload)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", name, err)
+		}
+		w.template = tmpl
+	}
+	return w, nil
+}
+
+// Name returns the channel name
+func (w *WebhookNotifier) Name() string { return "webhook:" + w.name }
+
+// SetTemplateContext sets where the payload template's endpoint, stats and
+// runbook come from
+func (w *WebhookNotifier) SetTemplateContext(context TemplateContext) {
+	w.context = context
+}
+
+// Render builds the request body for an alert
+func (w *WebhookNotifier) Render(alert Alert) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(alert)
+	}
+	return renderJSON(w.template, templateData(w.context, alert))
+}
+
+// Notify posts the rendered alert
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := w.Render(alert)
+	if err != nil {
+		return err
+	}
+	return w.post(ctx, body)
+}
+
+// SendReport posts a report as JSON; the payload template applies to alerts
+// only
+func (w *WebhookNotifier) SendReport(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return w.post(ctx, body)
+}
+
+// post sends a body, signed when the webhook has a secret
+func (w *WebhookNotifier) post(ctx context.Context, body []byte) error {
+	var headers map[string]string
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers = map[string]string{
+			WebhookTimestampHeader: timestamp,
+			WebhookSignatureHeader: "sha256=" + SignWebhook(w.secret, timestamp, body),
+		}
+	}
+	return postBody(ctx, w.client, w.Name(), w.url, headers, body)
+}
+
+// SignWebhook computes the signature of a webhook request
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
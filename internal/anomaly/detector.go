@@ -0,0 +1,167 @@
+package anomaly
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// minSamples is how many checks an endpoint needs before its baseline is
+// trusted to flag anomalies
+const minSamples = 20
+
+// minStdDevFraction keeps very steady endpoints from flagging jitter: the
+// standard deviation used is at least this fraction of the mean, and at least
+// a millisecond
+const minStdDevFraction = 0.05
+
+// History loads an endpoint's stored results, newest first, to seed its
+// baseline when the detector first sees it
+type History func(url string, limit int) ([]checker.CheckResult, error)
+
+// Baseline is an endpoint's usual latency
+type Baseline struct {
+	Mean    time.Duration `json:"mean"`
+	StdDev  time.Duration `json:"stddev"`
+	Samples int           `json:"samples"`
+}
+
+// baseline is an exponentially weighted mean and variance, in seconds
+type baseline struct {
+	mean     float64
+	variance float64
+	samples  int
+}
+
+// Detector keeps a rolling latency baseline per endpoint and flags checks far
+// above it
+type Detector struct {
+	metric  string
+	sigmas  float64
+	window  int
+	alpha   float64
+	history History
+
+	baselines map[string]*baseline // by URL
+	mutex     sync.Mutex
+}
+
+// NewDetector creates a detector flagging latencies (of checker.LatencyTotal
+// or checker.LatencyTTFB) more than sigmas standard deviations above an EWMA
+// over about window checks. history may be nil, in which case baselines start
+// empty.
+func NewDetector(metric string, sigmas float64, window int, history History) *Detector {
+	return &Detector{
+		metric:    metric,
+		sigmas:    sigmas,
+		window:    window,
+		alpha:     2 / float64(window+1),
+		history:   history,
+		baselines: make(map[string]*baseline),
+	}
+}
+
+// Observe compares a check with its endpoint's baseline, returning the
+// anomaly it is if any, and then folds it into the baseline. Failed checks
+// and suspect timings are ignored. An anomalous latency moves the baseline
+// only as far as the anomaly threshold, so a lasting shift is learned
+// gradually while one-off spikes barely move it.
+func (d *Detector) Observe(result checker.CheckResult) *checker.Anomaly {
+	if !result.IsHealthy || !result.Usable() {
+		return nil
+	}
+	b := d.baseline(result.URL)
+	latency := result.Latency(d.metric).Seconds()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var anomaly *checker.Anomaly
+	if b.samples >= minSamples {
+		stdDev := b.stdDev()
+		if sigmas := (latency - b.mean) / stdDev; sigmas > d.sigmas {
+			anomaly = &checker.Anomaly{
+				Latency:  result.Latency(d.metric),
+				Baseline: seconds(b.mean),
+				StdDev:   seconds(stdDev),
+				Sigmas:   math.Round(sigmas*10) / 10,
+			}
+			latency = b.mean + d.sigmas*stdDev
+		}
+	}
+	b.add(latency, d.alpha)
+	return anomaly
+}
+
+// Baseline returns an endpoint's baseline, false until it has enough samples
+// to flag anomalies
+func (d *Detector) Baseline(url string) (Baseline, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	b, ok := d.baselines[url]
+	if !ok || b.samples < minSamples {
+		return Baseline{}, false
+	}
+	return Baseline{Mean: seconds(b.mean), StdDev: seconds(b.stdDev()), Samples: b.samples}, true
+}
+
+// Forget drops the baseline of a removed endpoint
+func (d *Detector) Forget(url string) {
+	d.mutex.Lock()
+	delete(d.baselines, url)
+	d.mutex.Unlock()
+}
+
+// baseline returns an endpoint's baseline, seeding a new one from the stored
+// results. The history is loaded without holding the lock.
+func (d *Detector) baseline(url string) *baseline {
+	d.mutex.Lock()
+	b, ok := d.baselines[url]
+	d.mutex.Unlock()
+	if ok {
+		return b
+	}
+
+	seeded := &baseline{}
+	if d.history != nil {
+		results, err := d.history(url, d.window)
+		if err == nil {
+			for i := len(results) - 1; i >= 0; i-- {
+				if results[i].IsHealthy && results[i].Usable() {
+					seeded.add(results[i].Latency(d.metric).Seconds(), d.alpha)
+				}
+			}
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if b, ok := d.baselines[url]; ok {
+		return b // seeded concurrently
+	}
+	d.baselines[url] = seeded
+	return seeded
+}
+
+// add folds a latency into the baseline. The first samples are averaged
+// evenly so that the baseline isn't skewed towards the earliest ones.
+func (b *baseline) add(latency, alpha float64) {
+	b.samples++
+	weight := math.Max(alpha, 1/float64(b.samples))
+	diff := latency - b.mean
+	increment := weight * diff
+	b.mean += increment
+	b.variance = (1 - weight) * (b.variance + diff*increment)
+}
+
+// stdDev returns the standard deviation, floored by minStdDevFraction
+func (b *baseline) stdDev() float64 {
+	return math.Max(math.Sqrt(b.variance), math.Max(b.mean*minStdDevFraction, 0.001))
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
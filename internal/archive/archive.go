@@ -0,0 +1,317 @@
+// Package archive moves the history of endpoints out of the database into
+// compressed files and back. An archive is gzipped JSON lines: a header,
+// then one line per result, extracted metric, latency sketch, rollup and
+// incident.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// formatVersion is written to every header; Restore refuses newer archives
+const formatVersion = 1
+
+// restoreBatch is how many results Restore saves at once
+const restoreBatch = 500
+
+// Header is the first line of an archive
+type Header struct {
+	Version    int                     `json:"version"`
+	URL        string                  `json:"url"`
+	Endpoint   *storage.EndpointRecord `json:"endpoint,omitempty"` // nil when the URL was no longer monitored
+	From       *time.Time              `json:"from,omitempty"`     // first and last raw result, nil without any
+	To         *time.Time              `json:"to,omitempty"`
+	ExportedAt time.Time               `json:"exportedAt"`
+}
+
+// Summary counts the rows an archive holds
+type Summary struct {
+	Results   int `json:"results"`
+	Metrics   int `json:"metrics"`
+	Sketches  int `json:"sketches"`
+	Rollups   int `json:"rollups"`
+	Incidents int `json:"incidents"`
+}
+
+// line is every line after the header, with one of its fields set
+type line struct {
+	Result     *checker.CheckResult  `json:"result,omitempty"`
+	Metric     *storage.MetricPoint  `json:"metric,omitempty"`
+	Sketch     *storage.SketchWindow `json:"sketch,omitempty"`
+	Rollup     *storage.RollupRecord `json:"rollup,omitempty"`
+	Incident   *storage.Incident     `json:"incident,omitempty"`
+	Postmortem json.RawMessage       `json:"postmortem,omitempty"` // of the incident on the same line
+}
+
+// Name returns where the archive of an endpoint exported at now is kept
+func Name(endpointID string, now time.Time) string {
+	return fmt.Sprintf("%s/%s.jsonl.gz", endpointID, now.UTC().Format("20060102T150405Z"))
+}
+
+// Export writes the full stored history of url: results with their
+// signatures, extracted metrics, latency sketches, the rollups of periods
+// whose results retention already deleted, and incidents with their
+// postmortems. The header tells the period of the raw results.
+func Export(store storage.Store, url string, endpoint *storage.EndpointRecord, now time.Time) ([]byte, Header, Summary, error) {
+	header := Header{Version: formatVersion, URL: url, Endpoint: endpoint, ExportedAt: now.UTC()}
+	var summary Summary
+	// The lines are compressed as they are read; the header, known once
+	// they are, goes in a gzip member of its own in front of them
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	encoder := json.NewEncoder(zw)
+
+	var from, to time.Time
+	err := store.EachResult(url, func(result checker.CheckResult) error {
+		if summary.Results == 0 {
+			from = result.CheckedAt
+		}
+		to = result.CheckedAt
+		summary.Results++
+		return encoder.Encode(line{Result: &result})
+	})
+	if err != nil {
+		return nil, header, summary, fmt.Errorf("exporting results: %w", err)
+	}
+
+	metrics, err := store.GetMetrics(url, "", time.Time{}, now.Add(time.Hour), math.MaxInt32)
+	if err != nil {
+		return nil, header, summary, fmt.Errorf("exporting metrics: %w", err)
+	}
+	for i := range metrics {
+		if err := encoder.Encode(line{Metric: &metrics[i]}); err != nil {
+			return nil, header, summary, err
+		}
+	}
+	summary.Metrics = len(metrics)
+
+	err = store.EachSketch(url, func(sketch storage.SketchWindow) error {
+		summary.Sketches++
+		return encoder.Encode(line{Sketch: &sketch})
+	})
+	if err != nil {
+		return nil, header, summary, fmt.Errorf("exporting latency sketches: %w", err)
+	}
+	rollups, err := store.GetRollupRecords(url)
+	if err != nil {
+		return nil, header, summary, fmt.Errorf("exporting rollups: %w", err)
+	}
+	for i := range rollups {
+		if err := encoder.Encode(line{Rollup: &rollups[i]}); err != nil {
+			return nil, header, summary, err
+		}
+	}
+	summary.Rollups = len(rollups)
+
+	incidents, err := store.GetIncidents(url, time.Time{}, now.Add(time.Hour))
+	if err != nil {
+		return nil, header, summary, fmt.Errorf("exporting incidents: %w", err)
+	}
+	for i := range incidents {
+		postmortem, _, err := store.GetPostmortem(incidents[i].ID)
+		if err != nil {
+			return nil, header, summary, fmt.Errorf("exporting incidents: %w", err)
+		}
+		if err := encoder.Encode(line{Incident: &incidents[i], Postmortem: postmortem}); err != nil {
+			return nil, header, summary, err
+		}
+	}
+	summary.Incidents = len(incidents)
+	if err := zw.Close(); err != nil {
+		return nil, header, summary, err
+	}
+
+	if summary.Results > 0 {
+		header.From, header.To = &from, &to
+	}
+	var archive bytes.Buffer
+	hw := gzip.NewWriter(&archive)
+	if err := json.NewEncoder(hw).Encode(header); err != nil {
+		return nil, header, summary, err
+	}
+	if err := hw.Close(); err != nil {
+		return nil, header, summary, err
+	}
+	body.WriteTo(&archive)
+	return archive.Bytes(), header, summary, nil
+}
+
+// ReadHeader returns the header of an archive without reading the rest
+func ReadHeader(data []byte) (Header, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Header{}, fmt.Errorf("not a gzipped archive: %w", err)
+	}
+	defer zr.Close()
+	var header Header
+	if err := json.NewDecoder(zr).Decode(&header); err != nil {
+		return Header{}, fmt.Errorf("invalid archive header: %w", err)
+	}
+	if header.Version < 1 || header.Version > formatVersion {
+		return Header{}, fmt.Errorf("unsupported archive version %d", header.Version)
+	}
+	if header.URL == "" {
+		return Header{}, fmt.Errorf("archive header without url")
+	}
+	return header, nil
+}
+
+// Span returns the earliest and latest time of the rows in an archive:
+// result and metric times and sketch, rollup and incident starts. Both are
+// zero for an archive without rows.
+func Span(data []byte) (time.Time, time.Time, error) {
+	var from, to time.Time
+	_, err := eachLine(data, func(entry line) error {
+		var at time.Time
+		switch {
+		case entry.Result != nil:
+			at = entry.Result.CheckedAt
+		case entry.Metric != nil:
+			at = entry.Metric.CheckedAt
+		case entry.Sketch != nil:
+			at = entry.Sketch.Start
+		case entry.Rollup != nil:
+			at = entry.Rollup.Start
+		case entry.Incident != nil:
+			at = entry.Incident.StartedAt
+		default:
+			return nil
+		}
+		if from.IsZero() || at.Before(from) {
+			from = at
+		}
+		if at.After(to) {
+			to = at
+		}
+		return nil
+	})
+	return from, to, err
+}
+
+// eachLine passes every line after the header to fn
+func eachLine(data []byte, fn func(line) error) (Header, error) {
+	header, err := ReadHeader(data)
+	if err != nil {
+		return header, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return header, err
+	}
+	defer zr.Close()
+
+	decoder := json.NewDecoder(zr)
+	var skipped Header
+	if err := decoder.Decode(&skipped); err != nil {
+		return header, err
+	}
+	for decoder.More() {
+		var entry line
+		if err := decoder.Decode(&entry); err != nil {
+			return header, fmt.Errorf("invalid archive line: %w", err)
+		}
+		if err := fn(entry); err != nil {
+			return header, err
+		}
+	}
+	return header, nil
+}
+
+// Restore loads an archive back into the database. Results keep the
+// signatures they were archived with, so results edited in the archive fail
+// verification; incidents get new IDs. Restoring the same archive twice
+// duplicates its rows, so callers check that the database holds no history
+// of the URL over its Span first. A failed restore removes the history it
+// wrote over that span again, so it can simply be retried.
+func Restore(store storage.Store, data []byte) (Header, Summary, error) {
+	header, err := ReadHeader(data)
+	if err != nil {
+		return header, Summary{}, err
+	}
+	from, to, err := Span(data)
+	if err != nil {
+		return header, Summary{}, err
+	}
+	summary, err := restore(store, header.URL, data)
+	if err != nil && !from.IsZero() {
+		if _, undoErr := store.DeleteHistoryBetween(header.URL, from, to); undoErr != nil {
+			return header, summary, fmt.Errorf("%w; removing the partly restored history failed too: %v", err, undoErr)
+		}
+		summary = Summary{}
+	}
+	return header, summary, err
+}
+
+// restore saves the rows of an archive of url
+func restore(store storage.Store, url string, data []byte) (Summary, error) {
+	var summary Summary
+	batch := make([]checker.CheckResult, 0, restoreBatch)
+	var rollups []storage.RollupRecord
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := store.RestoreResults(batch); err != nil {
+			return fmt.Errorf("restoring results: %w", err)
+		}
+		summary.Results += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	_, err := eachLine(data, func(entry line) error {
+		switch {
+		case entry.Result != nil:
+			entry.Result.URL = url
+			if batch = append(batch, *entry.Result); len(batch) == restoreBatch {
+				return flush()
+			}
+		case entry.Metric != nil:
+			metric := checker.CheckResult{URL: url, CheckedAt: entry.Metric.CheckedAt, Metrics: map[string]float64{entry.Metric.Name: entry.Metric.Value}}
+			if err := store.SaveMetrics(metric); err != nil {
+				return fmt.Errorf("restoring metrics: %w", err)
+			}
+			summary.Metrics++
+		case entry.Sketch != nil:
+			if err := store.SaveSketch(url, entry.Sketch.Start, entry.Sketch.Window, entry.Sketch.Sketch); err != nil {
+				return fmt.Errorf("restoring latency sketches: %w", err)
+			}
+			summary.Sketches++
+		case entry.Rollup != nil:
+			rollups = append(rollups, *entry.Rollup)
+		case entry.Incident != nil:
+			entry.Incident.URL = url
+			id, err := store.SaveIncident(*entry.Incident)
+			if err != nil {
+				return fmt.Errorf("restoring incidents: %w", err)
+			}
+			if len(entry.Postmortem) > 0 {
+				if err := store.SavePostmortem(id, entry.Postmortem); err != nil {
+					return fmt.Errorf("restoring incidents: %w", err)
+				}
+			}
+			summary.Incidents++
+		}
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	if err := flush(); err != nil {
+		return summary, err
+	}
+	// Rollups are few, one per hour and day at most, so they are saved at once
+	if err := store.SaveRollupRecords(url, rollups); err != nil {
+		return summary, fmt.Errorf("restoring rollups: %w", err)
+	}
+	summary.Rollups = len(rollups)
+	return summary, nil
+}
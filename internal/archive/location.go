@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"api-monitor/internal/secretref"
+)
+
+// Location is where archives are kept: a local directory or an
+// S3-compatible bucket
+type Location interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	String() string
+}
+
+// Open returns the location a spec names: "s3://bucket/prefix",
+// "gs://bucket/prefix" (through the GCS XML API with HMAC keys), or a local
+// directory, optionally as "file:///path". Bucket requests are signed with
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY; endpoint overrides the
+// service URL, e.g. for MinIO.
+func Open(spec, region, endpoint string, client *http.Client) (Location, error) {
+	scheme, rest, isURL := strings.Cut(spec, "://")
+	if !isURL {
+		return dirLocation(spec), nil
+	}
+	if scheme == "file" {
+		return dirLocation(rest), nil
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("%s needs a bucket name", spec)
+	}
+	location := &bucketLocation{spec: spec, prefix: strings.Trim(prefix, "/"), region: region, client: client}
+
+	switch scheme {
+	case "s3":
+		location.baseURL = "https://" + bucket + ".s3." + region + ".amazonaws.com"
+	case "gs":
+		location.baseURL = "https://storage.googleapis.com/" + bucket
+		location.region = "auto"
+	default:
+		return nil, fmt.Errorf("unknown archive scheme %q (use s3://, gs:// or a directory)", scheme)
+	}
+	if endpoint != "" {
+		// Custom endpoints are addressed path-style
+		location.baseURL = strings.TrimRight(endpoint, "/") + "/" + bucket
+	}
+	return location, nil
+}
+
+// dirLocation keeps archives as files under a directory
+type dirLocation string
+
+func (d dirLocation) Put(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Written aside and renamed, so a crash never leaves half an archive
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (d dirLocation) Get(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d dirLocation) String() string { return string(d) }
+
+// bucketLocation keeps archives as objects in an S3-compatible bucket
+type bucketLocation struct {
+	spec    string
+	prefix  string
+	baseURL string
+	region  string
+	client  *http.Client
+}
+
+func (b *bucketLocation) Put(ctx context.Context, name string, data []byte) error {
+	resp, err := b.do(ctx, "PUT", name, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (b *bucketLocation) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := b.do(ctx, "GET", name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (b *bucketLocation) String() string { return b.spec }
+
+// do sends a signed request for one object and fails on non-2xx answers
+func (b *bucketLocation) do(ctx context.Context, method, name string, body []byte) (*http.Response, error) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for %s", b.spec)
+	}
+	key := name
+	if b.prefix != "" {
+		key = b.prefix + "/" + name
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	secretref.SignV4(req, body, accessKey, secretKey, b.region, "s3", time.Now().UTC())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", method, key, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
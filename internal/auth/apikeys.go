@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from other
+// bearer tokens such as the admin token
+const APIKeyPrefix = "apimon_"
+
+// API key scopes
+const (
+	ScopeRead  = "read"  // GET requests only
+	ScopeWrite = "write" // every request
+)
+
+// GenerateAPIKey returns a new API key and its ID, the first characters
+// after the prefix, which identify the key in listings without revealing it
+func GenerateAPIKey() (key, id string, err error) {
+	token, err := randomToken(24)
+	if err != nil {
+		return "", "", err
+	}
+	return APIKeyPrefix + token, token[:8], nil
+}
+
+// HashAPIKey returns the hash API keys are stored and looked up by. Keys are
+// random, so a plain SHA-256 is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestAPIKey returns the API key a request carries in X-API-Key or as an
+// Authorization bearer token
+func RequestAPIKey(r *http.Request) (string, bool) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	return key, strings.HasPrefix(key, APIKeyPrefix)
+}
+
+// ValidateScope checks an API key scope
+func ValidateScope(scope string) error {
+	if scope != ScopeRead && scope != ScopeWrite {
+		return fmt.Errorf("scope must be %q or %q", ScopeRead, ScopeWrite)
+	}
+	return nil
+}
+
+// ScopeAllows reports whether a key of the given scope may send a request
+// with the given method
+func ScopeAllows(scope, method string) bool {
+	if scope == ScopeWrite {
+		return true
+	}
+	return method == "GET" || method == "HEAD"
+}
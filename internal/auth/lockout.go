@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"api-monitor/internal/cache"
+)
+
+// LoginLimiter locks out a key (username or client IP) after repeated failed
+// logins. Counters live in the shared cache so limits hold across replicas.
+type LoginLimiter struct {
+	cache       cache.Cache
+	maxAttempts int
+	lockout     time.Duration
+}
+
+// NewLoginLimiter creates a limiter allowing maxAttempts failures before locking for lockout
+func NewLoginLimiter(c cache.Cache, maxAttempts int, lockout time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		cache:       c,
+		maxAttempts: maxAttempts,
+		lockout:     lockout,
+	}
+}
+
+// Locked reports whether the key is currently locked out and for how much longer
+func (l *LoginLimiter) Locked(key string) (bool, time.Duration) {
+	data, exists, err := l.cache.Get(context.Background(), lockKey(key))
+	if err != nil {
+		log.Printf("Failed to read login lockout: %v", err)
+		return false, 0
+	}
+	if !exists {
+		return false, 0
+	}
+
+	until, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return false, 0
+	}
+	remaining := time.Until(time.Unix(until, 0))
+	if remaining > 0 {
+		return true, remaining
+	}
+	return false, 0
+}
+
+// Failure records a failed login and returns true if the key is now locked out
+func (l *LoginLimiter) Failure(key string) bool {
+	ctx := context.Background()
+
+	// Failures are counted within a window as long as the lockout itself
+	failures, err := l.cache.Incr(ctx, failureKey(key), l.lockout)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return false
+	}
+
+	if failures >= int64(l.maxAttempts) {
+		until := time.Now().Add(l.lockout).Unix()
+		l.cache.Set(ctx, lockKey(key), []byte(strconv.FormatInt(until, 10)), l.lockout)
+		l.cache.Delete(ctx, failureKey(key))
+		return true
+	}
+	return false
+}
+
+// Success clears the failure history for a key
+func (l *LoginLimiter) Success(key string) {
+	l.cache.Delete(context.Background(), failureKey(key))
+}
+
+func failureKey(key string) string {
+	return "login:failures:" + key
+}
+
+func lockKey(key string) string {
+	return "login:locked:" + key
+}
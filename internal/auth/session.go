@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"api-monitor/internal/cache"
+)
+
+// SessionCookieName is the cookie carrying the dashboard session token
+const SessionCookieName = "apimon_session"
+
+// Session represents an authenticated dashboard login
+type Session struct {
+	Token     string    `json:"token"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SessionManager keeps track of active login sessions. Sessions live in the
+// shared cache so any web replica can validate them.
+type SessionManager struct {
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewSessionManager creates a session manager whose sessions expire after ttl
+func NewSessionManager(c cache.Cache, ttl time.Duration) *SessionManager {
+	return &SessionManager{
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+// Create starts a new session for the given user
+func (m *SessionManager) Create(username string) (*Session, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		Token:     token,
+		Username:  username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.cache.Set(context.Background(), sessionKey(token), data, m.ttl); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Get returns the session for a token if it exists and has not expired
+func (m *SessionManager) Get(token string) (*Session, bool) {
+	data, exists, err := m.cache.Get(context.Background(), sessionKey(token))
+	if err != nil {
+		log.Printf("Failed to load session: %v", err)
+		return nil, false
+	}
+	if !exists {
+		return nil, false
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		m.Delete(token)
+		return nil, false
+	}
+	return &session, true
+}
+
+// Delete ends a session
+func (m *SessionManager) Delete(token string) {
+	if err := m.cache.Delete(context.Background(), sessionKey(token)); err != nil {
+		log.Printf("Failed to delete session: %v", err)
+	}
+}
+
+// TTL returns how long new sessions stay valid
+func (m *SessionManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// CheckPassword compares credentials in constant time
+func CheckPassword(expectedUser, expectedPassword, username, password string) bool {
+	userOK := subtle.ConstantTimeCompare([]byte(expectedUser), []byte(username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(expectedPassword), []byte(password)) == 1
+	return userOK && passOK
+}
+
+func sessionKey(token string) string {
+	return "session:" + token
+}
+
+// randomToken returns a hex encoded random token of n bytes
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
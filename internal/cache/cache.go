@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache is a key/value store shared by web replicas. The in-memory
+// implementation serves a single instance; Redis makes data consistent
+// across replicas.
+type Cache interface {
+	// Get returns the value for key and whether it exists
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores a value that expires after ttl (no expiry when ttl is 0)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes a key
+	Delete(ctx context.Context, key string) error
+	// Incr atomically increments a counter, setting its expiry when it is created
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Close releases any connections
+	Close() error
+}
+
+// New returns a Redis cache when redisURL is set and an in-memory cache otherwise
+func New(redisURL string) (Cache, error) {
+	if redisURL == "" {
+		return NewMemory(), nil
+	}
+	return NewRedis(redisURL)
+}
+
+// Memory is an in-process Cache
+type Memory struct {
+	items map[string]memoryItem
+	mutex sync.Mutex
+}
+
+type memoryItem struct {
+	value     []byte
+	counter   int64
+	expiresAt time.Time
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && now.After(i.expiresAt)
+}
+
+// NewMemory creates an in-memory cache
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]memoryItem)}
+}
+
+// Get implements Cache
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item, exists := m.items[key]
+	if !exists || item.expired(time.Now()) {
+		delete(m.items, key)
+		return nil, false, nil
+	}
+	return item.value, true, nil
+}
+
+// Set implements Cache
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	m.items[key] = item
+	m.sweep()
+	return nil
+}
+
+// Delete implements Cache
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mutex.Lock()
+	delete(m.items, key)
+	m.mutex.Unlock()
+	return nil
+}
+
+// Incr implements Cache
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	item, exists := m.items[key]
+	if !exists || item.expired(now) {
+		item = memoryItem{}
+		if ttl > 0 {
+			item.expiresAt = now.Add(ttl)
+		}
+	}
+	item.counter++
+	m.items[key] = item
+	return item.counter, nil
+}
+
+// Close implements Cache
+func (m *Memory) Close() error {
+	return nil
+}
+
+// sweep drops expired items once the map grows; callers must hold the mutex
+func (m *Memory) sweep() {
+	if len(m.items) < 1024 {
+		return
+	}
+	now := time.Now()
+	for key, item := range m.items {
+		if item.expired(now) {
+			delete(m.items, key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package checker
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxCaptureBodyBytes caps how much of each request and response body a
+// debug capture keeps
+const maxCaptureBodyBytes = 64 << 10
+
+// Exchange is the request an HTTP check sent and the response it received,
+// recorded when CheckSpec.Capture is set. Credentials are masked.
+type Exchange struct {
+	Request  CapturedRequest   `json:"request"`
+	Response *CapturedResponse `json:"response,omitempty"` // nil when no response arrived
+}
+
+// CapturedRequest is the request as it went out, after middleware
+type CapturedRequest struct {
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Host          string              `json:"host"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// CapturedResponse is the response status, headers and body
+type CapturedResponse struct {
+	Proto         string              `json:"proto"`
+	StatusCode    int                 `json:"statusCode"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// sensitiveHeaders are masked in captures in addition to the headers the
+// endpoint configures
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// captureRequest records a request about to be sent
+func captureRequest(req *http.Request, spec CheckSpec) *Exchange {
+	body, truncated := spec.Body, false
+	if len(body) > maxCaptureBodyBytes {
+		body, truncated = body[:maxCaptureBodyBytes], true
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	return &Exchange{Request: CapturedRequest{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		Host:          host,
+		Headers:       maskHeaders(req.Header, spec.Headers),
+		Body:          body,
+		BodyTruncated: truncated,
+	}}
+}
+
+// wireHeaders collects the header fields the transport wrote to the
+// connection, reported by httptrace on another goroutine
+type wireHeaders struct {
+	headers http.Header
+	mutex   sync.Mutex
+}
+
+func (w *wireHeaders) wrote(key string, values []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.headers == nil {
+		w.headers = make(http.Header)
+	}
+	w.headers[key] = append(w.headers[key], values...)
+}
+
+// captureWire replaces the captured request headers with those written to
+// the connection, which include the ones the transport adds, e.g.
+// User-Agent and Accept-Encoding
+func (e *Exchange) captureWire(wire *wireHeaders, spec CheckSpec) {
+	wire.mutex.Lock()
+	defer wire.mutex.Unlock()
+	if len(wire.headers) > 0 {
+		e.Request.Headers = maskHeaders(wire.headers, spec.Headers)
+	}
+}
+
+// captureResponse records a response; body holds at most
+// maxCaptureBodyBytes of the read bytes
+func (e *Exchange) captureResponse(resp *http.Response, body []byte, read int64) {
+	e.Response = &CapturedResponse{
+		Proto:         resp.Proto,
+		StatusCode:    resp.StatusCode,
+		Headers:       maskHeaders(resp.Header, nil),
+		Body:          string(body),
+		BodyTruncated: read > int64(len(body)),
+	}
+}
+
+// maskHeaders copies headers with the values of sensitive and configured
+// ones masked
+func maskHeaders(headers http.Header, configured map[string]string) map[string][]string {
+	masked := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		masked[name] = append([]string(nil), values...)
+	}
+	mask := func(name string) {
+		for key, values := range masked {
+			if strings.EqualFold(key, name) {
+				for i := range values {
+					values[i] = "********"
+				}
+			}
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		mask(name)
+	}
+	for name := range configured {
+		mask(name)
+	}
+	return masked
+}
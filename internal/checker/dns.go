@@ -0,0 +1,183 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TypeDNS is the check type for DNSChecker
+const TypeDNS = "dns"
+
+// DNS record types a DNSChecker can resolve
+const (
+	RecordA     = "A"
+	RecordAAAA  = "AAAA"
+	RecordCNAME = "CNAME"
+	RecordMX    = "MX"
+)
+
+func init() {
+	Register(TypeDNS, func(timeout time.Duration) (Checker, error) {
+		return NewDNSChecker(timeout), nil
+	})
+}
+
+// DNSChecker checks that a name resolves, separately from any HTTP check on it.
+// Endpoints use the URL "dns://<name>" and these options:
+//
+//	record   - A (default), AAAA, CNAME or MX
+//	expect   - comma-separated answers that are allowed; anything else is
+//	           reported as unhealthy, which catches hijacked records
+//	resolver - DNS server to query, e.g. "1.1.1.1:53" (system resolver by default)
+type DNSChecker struct {
+	timeout time.Duration
+}
+
+// NewDNSChecker creates a DNS checker with timeout
+func NewDNSChecker(timeout time.Duration) *DNSChecker {
+	return &DNSChecker{timeout: timeout}
+}
+
+// CheckWith resolves the name in spec and evaluates the expected answers
+func (c *DNSChecker) CheckWith(spec CheckSpec) CheckResult {
+	start := time.Now()
+	result := CheckResult{
+		URL:       spec.URL,
+		CheckedAt: start,
+	}
+
+	name, err := dnsName(spec.URL)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	record := strings.ToUpper(spec.Options["record"])
+	if record == "" {
+		record = RecordA
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), spec.timeoutOr(c.timeout))
+	defer cancel()
+
+	answers, err := resolve(ctx, resolverFor(spec.Options["resolver"]), name, record)
+	result.ResponseTime = time.Since(start)
+	AssessQuality(&result)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(answers) == 0 {
+		result.Error = fmt.Sprintf("no %s records for %s", record, name)
+		return result
+	}
+	result.DNSAnswers = answers
+	if record == RecordA || record == RecordAAAA {
+		result.RemoteIP = answers[0]
+	}
+
+	if expected := parseExpected(spec.Options["expect"]); len(expected) > 0 {
+		var unexpected []string
+		for _, answer := range answers {
+			if !expected[strings.ToLower(answer)] {
+				unexpected = append(unexpected, answer)
+			}
+		}
+		if len(unexpected) > 0 {
+			result.Error = fmt.Sprintf("unexpected %s answer(s) for %s: %s", record, name, strings.Join(unexpected, ", "))
+			return result
+		}
+	}
+
+	result.IsHealthy = true
+	return result
+}
+
+// dnsName extracts the host name from "dns://name" or a bare name
+func dnsName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if strings.Contains(name, "://") {
+		u, err := url.Parse(name)
+		if err != nil {
+			return "", fmt.Errorf("invalid DNS target: %w", err)
+		}
+		name = u.Hostname()
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return "", fmt.Errorf("DNS target has no name")
+	}
+	return name, nil
+}
+
+// resolverFor returns a resolver that queries server, or the system resolver
+func resolverFor(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// resolve looks up one record type and returns the answers sorted
+func resolve(ctx context.Context, resolver *net.Resolver, name, record string) ([]string, error) {
+	var answers []string
+
+	switch record {
+	case RecordA, RecordAAAA:
+		network := "ip4"
+		if record == RecordAAAA {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case RecordCNAME:
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, strings.TrimSuffix(cname, "."))
+	case RecordMX:
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range records {
+			answers = append(answers, strings.TrimSuffix(mx.Host, "."))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported DNS record type %q", record)
+	}
+
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// parseExpected turns "a, b" into a lowercase set, trimming trailing dots
+func parseExpected(value string) map[string]bool {
+	expected := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(item), "."))
+		if item != "" {
+			expected[item] = true
+		}
+	}
+	return expected
+}
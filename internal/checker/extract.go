@@ -0,0 +1,99 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Threshold bounds an extracted metric; a value outside it fails the check
+type Threshold struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// validateExtraction checks the extraction paths and that thresholds refer to extracted metrics
+func (s CheckSpec) validateExtraction() error {
+	for name, path := range s.Extract {
+		if name == "" {
+			return fmt.Errorf("extracted metrics need a name")
+		}
+		if _, err := parseJSONPath(path); err != nil {
+			return err
+		}
+	}
+	for name, threshold := range s.Thresholds {
+		if _, ok := s.Extract[name]; !ok {
+			return fmt.Errorf("threshold for %q has no matching extract path", name)
+		}
+		if threshold.Min != nil && threshold.Max != nil && *threshold.Min > *threshold.Max {
+			return fmt.Errorf("threshold for %q has min above max", name)
+		}
+	}
+	return nil
+}
+
+// extractMetrics evaluates the spec's JSONPath expressions against a response
+// body, records the values on the result and applies thresholds
+func extractMetrics(spec CheckSpec, body []byte, result *CheckResult) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		result.Error = fmt.Sprintf("metric extraction failed: response is not JSON: %v", err)
+		result.IsHealthy = false
+		return
+	}
+
+	names := make([]string, 0, len(spec.Extract))
+	for name := range spec.Extract {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	result.Metrics = make(map[string]float64, len(names))
+	for _, name := range names {
+		value, err := extractNumber(document, spec.Extract[name])
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		result.Metrics[name] = value
+
+		threshold, ok := spec.Thresholds[name]
+		if !ok {
+			continue
+		}
+		if threshold.Max != nil && value > *threshold.Max {
+			problems = append(problems, fmt.Sprintf("%s %g above max %g", name, value, *threshold.Max))
+		}
+		if threshold.Min != nil && value < *threshold.Min {
+			problems = append(problems, fmt.Sprintf("%s %g below min %g", name, value, *threshold.Min))
+		}
+	}
+
+	if len(problems) > 0 {
+		result.Error = "metric check failed: " + strings.Join(problems, "; ")
+		result.IsHealthy = false
+	}
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest
+type cappedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,109 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// TypeGRPC is the check type for GRPCHealthChecker
+const TypeGRPC = "grpc"
+
+func init() {
+	Register(TypeGRPC, func(timeout time.Duration) (Checker, error) {
+		return NewGRPCHealthChecker(timeout), nil
+	})
+}
+
+// GRPCHealthChecker calls the standard grpc.health.v1 Health/Check RPC.
+// Endpoints use "grpc://host:port" for plaintext or "grpcs://host:port" for
+// TLS, with these options:
+//
+//	service         - service name to ask about (empty means the whole server)
+//	tls_skip_verify - "true" to accept self-signed certificates with grpcs
+//
+// Only the SERVING status counts as healthy.
+type GRPCHealthChecker struct {
+	timeout time.Duration
+}
+
+// NewGRPCHealthChecker creates a gRPC health checker with timeout
+func NewGRPCHealthChecker(timeout time.Duration) *GRPCHealthChecker {
+	return &GRPCHealthChecker{timeout: timeout}
+}
+
+// CheckWith dials the target in spec and asks for its serving status
+func (c *GRPCHealthChecker) CheckWith(spec CheckSpec) CheckResult {
+	start := time.Now()
+	result := CheckResult{
+		URL:       spec.URL,
+		CheckedAt: start,
+	}
+
+	target, creds, err := grpcTarget(spec)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), spec.timeoutOr(c.timeout))
+	defer cancel()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: spec.Options["service"],
+	})
+	result.ResponseTime = time.Since(start)
+	AssessQuality(&result)
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			result.Error = fmt.Sprintf("health check failed: %s: %s", s.Code(), s.Message())
+		} else {
+			result.Error = err.Error()
+		}
+		return result
+	}
+
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		result.Error = fmt.Sprintf("serving status %s", resp.GetStatus())
+		return result
+	}
+
+	result.IsHealthy = true
+	return result
+}
+
+// grpcTarget parses a grpc:// or grpcs:// URL into a dial target and credentials
+func grpcTarget(spec CheckSpec) (string, credentials.TransportCredentials, error) {
+	u, err := url.Parse(spec.URL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid gRPC target: %w", err)
+	}
+	if u.Host == "" {
+		return "", nil, fmt.Errorf("gRPC target must look like grpc://host:port")
+	}
+
+	switch u.Scheme {
+	case "grpc":
+		return u.Host, insecure.NewCredentials(), nil
+	case "grpcs":
+		return u.Host, credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: spec.Options["tls_skip_verify"] == "true",
+		}), nil
+	}
+	return "", nil, fmt.Errorf("gRPC target must use grpc:// or grpcs://, got %q", u.Scheme)
+}
@@ -1,78 +1,588 @@
 package checker
 
 import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Latency metrics that thresholds can target
+const (
+	LatencyTotal = "total" // full response including the body
+	LatencyTTFB  = "ttfb"  // time to first response byte
+)
+
+// maxHealthBodyBytes caps how much of the body a regular check reads
+const maxHealthBodyBytes = 1 << 20
+
+// CheckSpec describes the request sent by a check
+type CheckSpec struct {
+	Type        string `json:"type,omitempty"` // registered check type, "http" when empty
+	URL         string `json:"url"`
+	Method      string `json:"method,omitempty"` // GET when empty
+	Body        string `json:"body,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+
+	// Head checks only availability with a HEAD request, falling back to GET
+	// when the server rejects HEAD, to spare downloading large responses
+	Head bool `json:"head,omitempty"`
+
+	// Headers are sent with every request, e.g. API keys, bearer tokens or cookies
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Options carry settings for custom check types, e.g. a Kafka topic
+	Options map[string]string `json:"options,omitempty"`
+
+	// Extract maps metric names to JSONPath expressions evaluated against the
+	// JSON response, e.g. {"queue_depth": "$.queue_depth"}. Thresholds bound them.
+	Extract    map[string]string    `json:"extract,omitempty"`
+	Thresholds map[string]Threshold `json:"thresholds,omitempty"`
+
+	// Timeout overrides the checker's timeout when positive
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Source binds HTTP checks to a local IP address or network interface,
+	// e.g. "10.0.1.5" or "eth1", to test a specific network path
+	Source string `json:"source,omitempty"`
+
+	// Capture records the full request and response of HTTP checks in
+	// CheckResult.Capture, for debugging
+	Capture bool `json:"-"`
+}
+
+// timeoutOr returns the spec's timeout, or fallback when it has none
+func (s CheckSpec) timeoutOr(fallback time.Duration) time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return fallback
+}
+
+// supportedMethods are the HTTP methods a check may use
+var supportedMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// method returns the normalized HTTP method, defaulting to GET
+func (s CheckSpec) method() string {
+	if s.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(s.Method)
+}
+
+// Validate checks that the spec describes a request the checker can send.
+// Custom check types only need to be registered; their checker validates the rest.
+func (s CheckSpec) Validate() error {
+	if s.Type != "" && s.Type != TypeHTTP {
+		if _, ok := Lookup(s.Type); !ok {
+			return fmt.Errorf("unknown check type %q", s.Type)
+		}
+		if s.Head {
+			return fmt.Errorf("head is only supported for http checks")
+		}
+		return nil
+	}
+
+	method := s.method()
+	if !supportedMethods[method] {
+		return fmt.Errorf("unsupported HTTP method %q", s.Method)
+	}
+	if s.Body != "" && (method == http.MethodGet || method == http.MethodHead) {
+		return fmt.Errorf("%s requests cannot have a body", method)
+	}
+	if s.Head && method != http.MethodGet {
+		return fmt.Errorf("head checks send HEAD or GET, not %s", method)
+	}
+	if s.Head && len(s.Extract) > 0 {
+		return fmt.Errorf("head checks cannot extract metrics, there is no response body")
+	}
+	for name, value := range s.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+	}
+	return s.validateExtraction()
+}
+
+// RedactHeaders returns a copy of headers with every value masked, so
+// configured credentials are never echoed back by APIs or logs
+func RedactHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name := range headers {
+		redacted[name] = "********"
+	}
+	return redacted
+}
+
 // CheckResult holds the result of checking an endpoint
 type CheckResult struct {
 	URL          string        `json:"url"`
 	StatusCode   int           `json:"status_code"`
-	ResponseTime time.Duration `json:"response_time"`
+	ResponseTime time.Duration `json:"response_time"` // full-body latency
+	TTFB         time.Duration `json:"ttfb"`          // time to first response byte
+	
+	// Phases of ResponseTime. Connection phases are zero when a kept-alive
+	// connection was reused; the server's own processing is roughly
+	// TTFB minus DNS, connect and TLS.
+	DNSLookup    time.Duration `json:"dns_lookup,omitempty"`
+	TCPConnect   time.Duration `json:"tcp_connect,omitempty"`
+	TLSHandshake time.Duration `json:"tls_handshake,omitempty"`
+	BodyDownload time.Duration `json:"body_download,omitempty"`
 	IsHealthy    bool          `json:"is_healthy"`
 	Error        string        `json:"error,omitempty"`
 	CheckedAt    time.Time     `json:"checked_at"`
+	Trigger      string        `json:"trigger,omitempty"` // what caused the check, e.g. "interval" or "one-off"
+	
+	// Network location of the target, filled by the checker and GeoIP enrichment
+	RemoteIP string `json:"remote_ip,omitempty"`
+	Country  string `json:"country,omitempty"`
+	ASN      uint   `json:"asn,omitempty"`
+	ASOrg    string `json:"as_org,omitempty"`
+	
+	// Identity of the serving side, used for drift detection
+	TLSIssuer    string `json:"tls_issuer,omitempty"`
+	ServerHeader string `json:"server_header,omitempty"`
+	
+	// Numeric values extracted from the JSON response, see CheckSpec.Extract
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	
+	// Data quality of the timings, see AssessQuality
+	Quality      string `json:"quality,omitempty"`
+	QualityIssue string `json:"quality_issue,omitempty"`
+	
+	// Records returned by a DNS check
+	DNSAnswers []string `json:"dns_answers,omitempty"`
+	
+	// Static labels of the instance that ran the check, e.g. datacenter or team
+	Labels map[string]string `json:"labels,omitempty"`
+	
+	// Structured fields without a column of their own, e.g. protocol or
+	// error class. Stored as JSON and filterable with storage.ResultQuery.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	
+	// Size of the response body read by an HTTP check, up to the limit of a
+	// health check or a throughput measurement
+	ResponseBytes int64 `json:"response_bytes,omitempty"`
+	
+	// Throughput measurement, only set by CheckThroughput
+	BytesDownloaded int64   `json:"bytes_downloaded,omitempty"`
+	ThroughputMBps  float64 `json:"throughput_mbps,omitempty"`
+	
+	// Signature of the stored result when result signing is on, see
+	// storage.VerifyResults
+	Signature string `json:"signature,omitempty"`
+	
+	// Anomaly is set when the latency is far above the endpoint's baseline,
+	// see the anomaly package. It is not stored.
+	Anomaly *Anomaly `json:"anomaly,omitempty"`
+	
+	// Capture is the exchange of a check with CheckSpec.Capture set. It is
+	// never encoded with the result, so it stays out of results and streams.
+	Capture *Exchange `json:"-"`
+}
+
+// Anomaly is a latency far above the endpoint's historical baseline
+type Anomaly struct {
+	Latency  time.Duration `json:"latency"`
+	Baseline time.Duration `json:"baseline"` // rolling mean
+	StdDev   time.Duration `json:"stddev"`
+	Sigmas   float64       `json:"sigmas"` // standard deviations above the baseline
+}
+
+// Latency returns the latency for the given metric (LatencyTotal or LatencyTTFB)
+func (r CheckResult) Latency(metric string) time.Duration {
+	if metric == LatencyTTFB {
+		return r.TTFB
+	}
+	return r.ResponseTime
 }
 
 // HTTPChecker performs HTTP health checks
 type HTTPChecker struct {
 	client  *http.Client
 	timeout time.Duration
+	
+	// maxPayloadBytes caps how much a throughput check downloads
+	maxPayloadBytes int64
+
+	// slots bounds how many requests are in flight at once
+	slots chan struct{}
+
+	// sourceClients are bound to a local address, see CheckSpec.Source
+	sourceClients map[string]*http.Client
+	sourceMutex   sync.Mutex
+
+	// headRejected remembers when each URL of a head check last rejected
+	// HEAD, so that it is sent GET directly for a while
+	headRejected map[string]time.Time
+	headMutex    sync.Mutex
+
+	// middleware hooks into every check, see Use
+	middleware []Middleware
 }
 
+// headRetryAfter is how long a URL that rejected HEAD is sent GET directly
+const headRetryAfter = time.Hour
+
+// DefaultMaxConcurrency is how many requests a checker sends at once unless
+// SetMaxConcurrency says otherwise
+const DefaultMaxConcurrency = 10
+
 // NewHTTPChecker creates a new HTTP checker with timeout
 func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
 	return &HTTPChecker{
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		timeout: timeout,
+		timeout:         timeout,
+		maxPayloadBytes: 100 << 20,
+		slots:           make(chan struct{}, DefaultMaxConcurrency),
+		headRejected:    make(map[string]time.Time),
+	}
+}
+
+// SetMaxConcurrency limits how many requests are in flight at once across all
+// callers of this checker. Call it before the checker is in use.
+func (c *HTTPChecker) SetMaxConcurrency(n int) {
+	if n > 0 {
+		c.slots = make(chan struct{}, n)
+	}
+}
+
+// MaxConcurrency returns the in-flight request limit
+func (c *HTTPChecker) MaxConcurrency() int {
+	return cap(c.slots)
+}
+
+// SetMaxPayloadBytes sets the download cap for throughput checks
+func (c *HTTPChecker) SetMaxPayloadBytes(n int64) {
+	if n > 0 {
+		c.maxPayloadBytes = n
 	}
 }
 
-// Check performs a health check on the given URL
+// Check performs a GET health check on the given URL
 func (c *HTTPChecker) Check(url string) CheckResult {
+	return c.check(CheckSpec{URL: url}, false)
+}
+
+// CheckWith performs a health check using the request described by spec
+func (c *HTTPChecker) CheckWith(spec CheckSpec) CheckResult {
+	return c.check(spec, false)
+}
+
+// CheckThroughput performs a health check that also downloads the response
+// body and records the transfer rate. Note that the checker timeout covers
+// the whole download, so large payloads need a correspondingly large timeout.
+func (c *HTTPChecker) CheckThroughput(url string) CheckResult {
+	return c.check(CheckSpec{URL: url}, true)
+}
+
+// CheckThroughputWith is CheckThroughput using the request described by spec
+func (c *HTTPChecker) CheckThroughputWith(spec CheckSpec) CheckResult {
+	return c.check(spec, true)
+}
+
+func (c *HTTPChecker) check(spec CheckSpec, measureThroughput bool) CheckResult {
+	var result CheckResult
+	if spec.Head && !measureThroughput {
+		result = c.sendHead(spec)
+	} else {
+		result = c.send(spec, measureThroughput)
+	}
+	runAfter(c.middleware, spec, &result)
+	traceCheck(spec, result)
+	return result
+}
+
+// send performs the request described by spec and times it
+func (c *HTTPChecker) send(spec CheckSpec, measureThroughput bool) CheckResult {
+	// Wait for a slot so thousands of endpoints can't exhaust file descriptors
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+
 	start := time.Now()
 	
 	result := CheckResult{
-		URL:       url,
+		URL:       spec.URL,
 		CheckedAt: start,
 	}
 	
-	resp, err := c.client.Get(url)
+	if err := spec.Validate(); err != nil {
+		result.Error = err.Error()
+		result.IsHealthy = false
+		return result
+	}
+	
+	var body io.Reader
+	if spec.Body != "" {
+		body = strings.NewReader(spec.Body)
+	}
+	req, err := http.NewRequest(spec.method(), spec.URL, body)
+	if err != nil {
+		result.Error = err.Error()
+		result.IsHealthy = false
+		return result
+	}
+	for name, value := range spec.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if spec.ContentType != "" {
+		req.Header.Set("Content-Type", spec.ContentType)
+	}
+	if err := c.before(req); err != nil {
+		result.Error = err.Error()
+		result.IsHealthy = false
+		return result
+	}
+	if spec.Capture {
+		result.Capture = captureRequest(req, spec)
+	}
+	
+	// Record which address we actually connected to, how long each connection
+	// phase took and when the first byte arrived
+	phases := &phaseTimer{}
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { phases.begin(&phases.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { phases.finish(&phases.dnsStart, &phases.dns) },
+		ConnectStart: func(string, string) {
+			phases.begin(&phases.connectStart)
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				phases.finish(&phases.connectStart, &phases.connect)
+			}
+		},
+		TLSHandshakeStart: func() { phases.begin(&phases.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				phases.finish(&phases.tlsStart, &phases.tls)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if addr, ok := info.Conn.RemoteAddr().(*net.TCPAddr); ok {
+				result.RemoteIP = addr.IP.String()
+			}
+			if spec.Source != "" {
+				if addr, ok := info.Conn.LocalAddr().(*net.TCPAddr); ok {
+					result.SetMetadata(MetaSourceIP, addr.IP.String())
+				}
+			}
+		},
+		GotFirstResponseByte: func() {
+			result.TTFB = time.Since(start)
+		},
+	}
+	wire := &wireHeaders{}
+	if result.Capture != nil {
+		trace.WroteHeaderField = wire.wrote
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	
+	client := c.client
+	if spec.Source != "" {
+		if client, err = c.sourceClient(spec.Source); err != nil {
+			result.Error = err.Error()
+			result.IsHealthy = false
+			return result
+		}
+	}
+	if spec.Timeout > 0 {
+		perEndpoint := *client
+		perEndpoint.Timeout = spec.Timeout
+		client = &perEndpoint
+	}
+	resp, err := client.Do(req)
 	result.ResponseTime = time.Since(start)
+	phases.apply(&result)
+	if result.Capture != nil {
+		result.Capture.captureWire(wire, spec)
+	}
 	
 	if err != nil {
+		result.TTFB = 0
 		result.Error = err.Error()
 		result.IsHealthy = false
+		result.SetMetadata(MetaErrorClass, ClassifyError(err))
+		AssessQuality(&result)
 		return result
 	}
 	defer resp.Body.Close()
 	
 	result.StatusCode = resp.StatusCode
+	result.ServerHeader = resp.Header.Get("Server")
+	result.SetMetadata(MetaProtocol, resp.Proto)
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		result.TLSIssuer = resp.TLS.PeerCertificates[0].Issuer.String()
+	}
 	// Consider 2xx status codes as healthy
 	result.IsHealthy = resp.StatusCode >= 200 && resp.StatusCode < 300
 	
+	// Read the body so ResponseTime covers the full download, not just the headers
+	limit := int64(maxHealthBodyBytes)
+	if measureThroughput {
+		limit = c.maxPayloadBytes
+	}
+	var download io.Reader = io.LimitReader(resp.Body, limit)
+	captured := &cappedBuffer{max: maxHealthBodyBytes}
+	if len(spec.Extract) > 0 {
+		download = io.TeeReader(download, captured)
+	}
+	recorded := &cappedBuffer{max: maxCaptureBodyBytes}
+	if result.Capture != nil {
+		download = io.TeeReader(download, recorded)
+	}
+	bodyStart := time.Now()
+	n, err := io.Copy(io.Discard, download)
+	elapsed := time.Since(bodyStart)
+	result.ResponseTime = time.Since(start)
+	result.BodyDownload = elapsed
+	result.ResponseBytes = n
+	if result.Capture != nil {
+		result.Capture.captureResponse(resp, recorded.Bytes(), n)
+	}
+	
+	if err == nil && result.IsHealthy && len(spec.Extract) > 0 {
+		extractMetrics(spec, captured.Bytes(), &result)
+	}
+	
+	if err != nil {
+		result.Error = fmt.Sprintf("body download failed: %v", err)
+		result.IsHealthy = false
+	} else if measureThroughput {
+		result.BytesDownloaded = n
+		if elapsed > 0 {
+			result.ThroughputMBps = float64(n) / 1e6 / elapsed.Seconds()
+		}
+	}
+	
+	AssessQuality(&result)
 	return result
 }
 
+// sendHead checks availability with a HEAD request, sending GET instead when
+// the server answers HEAD with 405 or 501. Such servers get GET directly for
+// headRetryAfter before HEAD is tried again. The method used is recorded in
+// the result's metadata.
+func (c *HTTPChecker) sendHead(spec CheckSpec) CheckResult {
+	spec.Head = false
+	c.headMutex.Lock()
+	rejected, ok := c.headRejected[spec.URL]
+	c.headMutex.Unlock()
+
+	if !ok || time.Since(rejected) >= headRetryAfter {
+		spec.Method = http.MethodHead
+		result := c.send(spec, false)
+		if result.StatusCode != http.StatusMethodNotAllowed && result.StatusCode != http.StatusNotImplemented {
+			if ok {
+				c.headMutex.Lock()
+				delete(c.headRejected, spec.URL)
+				c.headMutex.Unlock()
+			}
+			result.SetMetadata(MetaMethod, http.MethodHead)
+			return result
+		}
+		c.headMutex.Lock()
+		c.headRejected[spec.URL] = time.Now()
+		c.headMutex.Unlock()
+	}
+
+	spec.Method = http.MethodGet
+	result := c.send(spec, false)
+	result.SetMetadata(MetaMethod, http.MethodGet)
+	return result
+}
+
+// phaseTimer collects connection phase durations from httptrace callbacks,
+// which may run on other goroutines (e.g. parallel dials to several addresses)
+type phaseTimer struct {
+	mutex                            sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tls                time.Duration
+}
+
+func (p *phaseTimer) begin(at *time.Time) {
+	p.mutex.Lock()
+	*at = time.Now()
+	p.mutex.Unlock()
+}
+
+func (p *phaseTimer) finish(started *time.Time, into *time.Duration) {
+	p.mutex.Lock()
+	if !started.IsZero() {
+		*into = time.Since(*started)
+	}
+	p.mutex.Unlock()
+}
+
+func (p *phaseTimer) apply(result *CheckResult) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	result.DNSLookup = p.dns
+	result.TCPConnect = p.connect
+	result.TLSHandshake = p.tls
+}
+
 // CheckMultiple checks multiple URLs concurrently
 func (c *HTTPChecker) CheckMultiple(urls []string) []CheckResult {
+	return c.checkConcurrently(urls, c.Check)
+}
+
+// CheckMultipleThroughput runs throughput checks on multiple URLs concurrently
+func (c *HTTPChecker) CheckMultipleThroughput(urls []string) []CheckResult {
+	return c.checkConcurrently(urls, c.CheckThroughput)
+}
+
+// checkConcurrently runs checks through a pool of MaxConcurrency workers and
+// returns results in the same order as urls
+func (c *HTTPChecker) checkConcurrently(urls []string, check func(string) CheckResult) []CheckResult {
 	results := make([]CheckResult, len(urls))
-	done := make(chan CheckResult, len(urls))
-	
-	// Start all checks concurrently
-	for _, url := range urls {
-		go func(u string) {
-			done <- c.Check(u)
-		}(url)
+	jobs := make(chan int)
+
+	workers := c.MaxConcurrency()
+	if workers > len(urls) {
+		workers = len(urls)
 	}
-	
-	// Collect results
-	for i := 0; i < len(urls); i++ {
-		results[i] = <-done
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = check(urls[i])
+			}
+		}()
 	}
-	
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
 	return results
-}
\ No newline at end of file
+}
@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one field name or array index in a parsed path
+type jsonPathStep struct {
+	field string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath parses the subset of JSONPath used for metric extraction:
+// $.field, $.nested.field, $.items[0].count and $['field with dots']
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSONPath %q has an empty field name", path)
+			}
+			steps = append(steps, jsonPathStep{field: rest[:end]})
+			rest = rest[end:]
+
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("JSONPath %q has an unclosed [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{field: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an invalid index [%s]", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIdx: true})
+
+		default:
+			return nil, fmt.Errorf("JSONPath %q is not supported", path)
+		}
+	}
+
+	return steps, nil
+}
+
+// extractNumber evaluates path against a decoded JSON document and returns
+// the number found there. Numeric strings and booleans (1 or 0) are accepted.
+func extractNumber(document interface{}, path string) (float64, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return 0, err
+	}
+
+	current := document
+	for _, step := range steps {
+		if step.isIdx {
+			items, ok := current.([]interface{})
+			if !ok || step.index >= len(items) {
+				return 0, fmt.Errorf("%s: index %d not found", path, step.index)
+			}
+			current = items[step.index]
+			continue
+		}
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("%s: field %q not found", path, step.field)
+		}
+		if current, ok = object[step.field]; !ok {
+			return 0, fmt.Errorf("%s: field %q not found", path, step.field)
+		}
+	}
+
+	switch value := current.(type) {
+	case json.Number:
+		return value.Float64()
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %q is not a number", path, value)
+		}
+		return number, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%s: value is not a number", path)
+}
@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelName restricts label names to what's safe in queries and JSON paths
+var labelName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ParseLabels parses "name=value" pairs such as "team=payments"
+func ParseLabels(pairs []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("label %q must look like name=value", pair)
+		}
+		if !labelName.MatchString(name) {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+// MatchLabels reports whether have carries every label in want
+func MatchLabels(have, want map[string]string) bool {
+	for name, value := range want {
+		if have[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// StaticLabels attaches labels such as datacenter, team or tier to every
+// result, keeping labels the checker already set
+func StaticLabels(labels map[string]string) Middleware {
+	return Middleware{
+		Name: "labels",
+		After: func(spec CheckSpec, result *CheckResult) {
+			if len(labels) == 0 {
+				return
+			}
+			if result.Labels == nil {
+				result.Labels = make(map[string]string, len(labels))
+			}
+			for name, value := range labels {
+				if _, ok := result.Labels[name]; !ok {
+					result.Labels[name] = value
+				}
+			}
+		},
+	}
+}
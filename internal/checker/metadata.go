@@ -0,0 +1,60 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Metadata keys set by the built-in checkers
+const (
+	MetaProtocol   = "protocol"    // HTTP version of the response, e.g. "HTTP/2.0"
+	MetaErrorClass = "error_class" // coarse cause of a failed request, see ClassifyError
+	MetaMethod     = "method"      // HTTP method a head check ended up using, HEAD or GET
+)
+
+// Error classes
+const (
+	ErrorClassTimeout = "timeout"
+	ErrorClassDNS     = "dns"
+	ErrorClassRefused = "connection_refused"
+	ErrorClassReset   = "connection_reset"
+	ErrorClassTLS     = "tls"
+	ErrorClassOther   = "other"
+)
+
+// SetMetadata records a structured field on the result
+func (r *CheckResult) SetMetadata(key string, value interface{}) {
+	if r.Metadata == nil {
+		r.Metadata = make(map[string]interface{})
+	}
+	r.Metadata[key] = value
+}
+
+// ClassifyError groups request errors into a few classes that can be counted
+// and filtered on, unlike the free-form error messages
+func ClassifyError(err error) string {
+	var dnsErr *net.DNSError
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ErrorClassReset
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr),
+		strings.Contains(err.Error(), "tls:"):
+		return ErrorClassTLS
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// Middleware hooks into checks so cross-cutting features such as metrics,
+// tracing, redaction and logging don't have to live inside the checkers.
+// Either hook may be nil.
+type Middleware struct {
+	Name string
+
+	// Before runs in registration order just before an HTTP request is sent
+	// and may modify it, e.g. to add headers. An error fails the check
+	// without sending the request.
+	Before func(req *http.Request) error
+
+	// After runs in reverse registration order once a check has finished,
+	// successful or not, and may adjust the result
+	After func(spec CheckSpec, result *CheckResult)
+}
+
+// Use adds middleware to every check of this checker. Call it before the
+// checker is in use.
+func (c *HTTPChecker) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// before runs the Before hooks, stopping at the first error
+func (c *HTTPChecker) before(req *http.Request) error {
+	for _, m := range c.middleware {
+		if m.Before == nil {
+			continue
+		}
+		if err := m.Before(req); err != nil {
+			return fmt.Errorf("%s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// runAfter runs the After hooks of middleware, innermost first
+func runAfter(middleware []Middleware, spec CheckSpec, result *CheckResult) {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i].After != nil {
+			middleware[i].After(spec, result)
+		}
+	}
+}
+
+// Wrap applies middleware to a checker of another type. Those checkers send
+// no HTTP request, so only the After hooks run.
+func Wrap(checker Checker, middleware ...Middleware) Checker {
+	if len(middleware) == 0 {
+		return checker
+	}
+	return &wrappedChecker{checker: checker, middleware: middleware}
+}
+
+type wrappedChecker struct {
+	checker    Checker
+	middleware []Middleware
+}
+
+func (w *wrappedChecker) CheckWith(spec CheckSpec) CheckResult {
+	result := w.checker.CheckWith(spec)
+	runAfter(w.middleware, spec, &result)
+	traceCheck(spec, result)
+	return result
+}
+
+// UserAgent sends agent as the User-Agent of every request that doesn't set one
+func UserAgent(agent string) Middleware {
+	return Middleware{
+		Name: "user-agent",
+		Before: func(req *http.Request) error {
+			if req.Header.Get("User-Agent") == "" {
+				req.Header.Set("User-Agent", agent)
+			}
+			return nil
+		},
+	}
+}
+
+// urlPattern finds URLs quoted in error messages, e.g. Get "https://...": EOF
+var urlPattern = regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^\s"]+`)
+
+// RedactSecrets masks passwords and query parameter values in result errors,
+// which Go's HTTP client echoes back with the URL. Tokens passed in query
+// strings then never reach logs, storage, alerts or AI prompts.
+func RedactSecrets() Middleware {
+	return Middleware{
+		Name: "redact",
+		After: func(spec CheckSpec, result *CheckResult) {
+			if result.Error != "" {
+				result.Error = urlPattern.ReplaceAllStringFunc(result.Error, redactURL)
+			}
+		},
+	}
+}
+
+// redactURL masks the password and query values of a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			query[name] = []string{"xxxxx"}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// defaultPorts are dropped from normalized URLs
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// NormalizeURL returns the canonical form of an http(s) URL so equivalent
+// spellings map to the same endpoint: the scheme and host are lowercased,
+// internationalized domains are converted to punycode, default ports, the
+// fragment and a bare "/" path are removed. Trailing slashes on longer paths
+// are kept because servers may route /api and /api/ differently.
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("URL must start with http:// or https://")
+	}
+
+	hostname := strings.TrimSuffix(u.Hostname(), ".")
+	if hostname == "" {
+		return "", fmt.Errorf("URL has no host")
+	}
+	port := u.Port()
+
+	// IP literals are left alone, names go through IDNA
+	if net.ParseIP(hostname) == nil {
+		hostname, err = idna.Lookup.ToASCII(hostname)
+		if err != nil {
+			return "", fmt.Errorf("invalid host name: %w", err)
+		}
+	}
+	hostname = strings.ToLower(hostname)
+
+	host := hostname
+	if strings.Contains(hostname, ":") {
+		host = "[" + hostname + "]" // IPv6 literal
+	}
+	if port != "" && port != defaultPorts[u.Scheme] {
+		host = net.JoinHostPort(hostname, port)
+	}
+	u.Host = host
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	if u.Path == "/" {
+		u.Path = ""
+		u.RawPath = ""
+	}
+
+	return u.String(), nil
+}
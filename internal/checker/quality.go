@@ -0,0 +1,48 @@
+package checker
+
+import "time"
+
+// Data quality flags recorded on results
+const (
+	QualityOK      = "ok"
+	QualitySuspect = "suspect" // timings are implausible and should be left out of analytics
+)
+
+// AssessQuality flags results whose timings cannot be right, e.g. zero or
+// negative durations caused by clock adjustments or a broken custom checker.
+// Durations measured with time.Since on a time.Now start use the monotonic
+// clock and are not affected by wall-clock jumps, but CheckedAt is still a
+// wall-clock timestamp and is checked for being far in the future.
+func AssessQuality(result *CheckResult) {
+	result.Quality = QualityOK
+	result.QualityIssue = ""
+
+	switch {
+	case result.ResponseTime < 0 || result.TTFB < 0:
+		result.QualityIssue = "negative duration"
+	case result.ResponseTime == 0 && result.StatusCode != 0:
+		result.QualityIssue = "zero response time for a completed request"
+	case result.TTFB > result.ResponseTime:
+		result.QualityIssue = "time to first byte exceeds response time"
+	case result.CheckedAt.IsZero():
+		result.QualityIssue = "missing check timestamp"
+	case result.CheckedAt.After(time.Now().Add(time.Minute)):
+		result.QualityIssue = "check timestamp in the future"
+	}
+
+	if result.QualityIssue != "" {
+		result.Quality = QualitySuspect
+		// Never report impossible values; analytics should skip the sample anyway
+		if result.ResponseTime < 0 {
+			result.ResponseTime = 0
+		}
+		if result.TTFB < 0 {
+			result.TTFB = 0
+		}
+	}
+}
+
+// Usable reports whether a result's timings can be used for latency analytics
+func (r CheckResult) Usable() bool {
+	return r.Quality != QualitySuspect
+}
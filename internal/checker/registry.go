@@ -0,0 +1,69 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TypeHTTP is the built-in check type used when an endpoint does not name one
+const TypeHTTP = "http"
+
+// Checker performs checks of one type. Implementations must be safe for
+// concurrent use; the scheduler calls CheckWith from many goroutines.
+type Checker interface {
+	CheckWith(spec CheckSpec) CheckResult
+}
+
+// Factory creates a Checker whose checks time out after timeout
+type Factory func(timeout time.Duration) (Checker, error)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Factory{}
+)
+
+func init() {
+	Register(TypeHTTP, func(timeout time.Duration) (Checker, error) {
+		return NewHTTPChecker(timeout), nil
+	})
+}
+
+// Register makes a check type available to endpoints that set Type to name.
+// It is meant to be called from init functions and panics if name is empty
+// or already registered.
+func Register(name string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if name == "" || factory == nil {
+		panic("checker: Register requires a name and a factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("checker: type %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Lookup returns the factory registered for name
+func Lookup(name string) (Factory, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	factory, ok := registry[name]
+	return factory, ok
+}
+
+// Types returns the names of all registered check types, sorted
+func Types() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for name := range registry {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// MetaSourceIP records the local address a source-bound check left from
+const MetaSourceIP = "source_ip"
+
+// ResolveSource returns the local IP a check should bind to. source is
+// either an IP address of this host or a network interface name, in which
+// case the interface's first IPv4 address (or IPv6 without one) is used.
+func ResolveSource(source string) (net.IP, error) {
+	if ip := net.ParseIP(source); ip != nil {
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(source)
+	if err != nil {
+		return nil, fmt.Errorf("source %q is neither an IP address nor a network interface", source)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("source %s: %w", source, err)
+	}
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("interface %s has no IP address", source)
+	}
+	return fallback, nil
+}
+
+// sourceClient returns a client whose connections leave from source. Clients
+// are cached per source so bound checks still reuse connections.
+func (c *HTTPChecker) sourceClient(source string) (*http.Client, error) {
+	c.sourceMutex.Lock()
+	defer c.sourceMutex.Unlock()
+
+	if client, ok := c.sourceClients[source]; ok {
+		return client, nil
+	}
+	ip, err := ResolveSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: ip},
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+
+	client := &http.Client{Timeout: c.timeout, Transport: transport}
+	if c.sourceClients == nil {
+		c.sourceClients = make(map[string]*http.Client)
+	}
+	c.sourceClients[source] = client
+	return client, nil
+}
@@ -0,0 +1,52 @@
+package checker
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("api-monitor/internal/checker")
+
+// traceCheck records a finished check as a span covering its request, not the
+// wait for a free slot, with the timings of its phases. Spans are only
+// exported once tracing is set up.
+func traceCheck(spec CheckSpec, result CheckResult) {
+	checkType := spec.Type
+	if checkType == "" {
+		checkType = TypeHTTP
+	}
+	_, span := tracer.Start(context.Background(), "check "+checkType,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(result.CheckedAt),
+		trace.WithAttributes(
+			attribute.String("check.type", checkType),
+			attribute.String("url.full", result.URL),
+			attribute.Bool("check.healthy", result.IsHealthy),
+		),
+	)
+	if result.StatusCode > 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	}
+	if result.RemoteIP != "" {
+		span.SetAttributes(attribute.String("network.peer.address", result.RemoteIP))
+	}
+	for name, phase := range map[string]int64{
+		"check.dns_ms":      result.DNSLookup.Milliseconds(),
+		"check.connect_ms":  result.TCPConnect.Milliseconds(),
+		"check.tls_ms":      result.TLSHandshake.Milliseconds(),
+		"check.ttfb_ms":     result.TTFB.Milliseconds(),
+		"check.download_ms": result.BodyDownload.Milliseconds(),
+	} {
+		if phase > 0 {
+			span.SetAttributes(attribute.Int64(name, phase))
+		}
+	}
+	if result.Error != "" {
+		span.SetStatus(codes.Error, result.Error)
+	}
+	span.End(trace.WithTimestamp(result.CheckedAt.Add(result.ResponseTime)))
+}
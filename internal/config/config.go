@@ -1,30 +1,264 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+	"api-monitor/internal/secretref"
+	"api-monitor/internal/signing"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/tracing"
 )
 
 // Config holds all configuration for the API monitor
 type Config struct {
+	// LoadErrors lists problems found while loading, e.g. unreadable secret files
+	LoadErrors []string
+	
+	// Settings lists every value read from the environment with its source,
+	// for GET /api/config
+	Settings []Setting
+	
+	// Profile is the deployment profile (small, medium or large) whose presets
+	// replace the defaults of the settings that scale with the fleet, empty
+	// when none is selected
+	Profile string
+	
+	// OutputStyle of CLI messages and logs: "emoji" or "plain" (ASCII only),
+	// applied by package output
+	OutputStyle string
+	
 	// Database configuration
-	DatabaseURL string
+	DatabaseDriver string // postgres or sqlite
+	DatabaseURL    string // connection string, or file path for sqlite
 	
 	// Monitoring configuration
 	CheckInterval   time.Duration
 	RequestTimeout  time.Duration
 	MaxConcurrency  int
 	
+	// Static labels attached to every result this instance produces,
+	// e.g. datacenter, team or tier
+	Labels map[string]string
+	
+	// Self-monitoring: warn when checks start later than this (p95)
+	SchedulerLagThreshold time.Duration
+	
+	// /healthz and /readyz: readiness fails while a required dependency
+	// fails, liveness once it has been failing for HealthLivenessGrace.
+	// The scheduler is always required, the database and AI model as set.
+	HealthLivenessGrace   time.Duration
+	HealthRequireDatabase bool
+	HealthRequireAI       bool
+	
+	// HealthPort serves the gRPC server's probes over HTTP as well, besides
+	// the gRPC health service; zero disables it
+	HealthPort int
+	
+	// Endpoints without a result for StaleAfterIntervals scheduled checks are
+	// reported as unknown (never when zero), and alerted on with StaleAlerts
+	StaleAfterIntervals int
+	StaleAlerts         bool
+	
+	// Sub-second ("real-time") intervals: how many endpoints may use them and
+	// the shortest allowed. Their stored results are coalesced per second.
+	RealtimeMaxEndpoints int
+	RealtimeMinInterval  time.Duration
+	
+	// Shortest period without results that SLA reports exclude as a
+	// monitoring gap (at least three check intervals)
+	SLAMinGap time.Duration
+	
+	// Storage sampling for frequently checked endpoints ("all", "every_n", "on_change")
+	SamplingMode  string
+	SamplingEvery int
+	
+	// Pausing (e.g. during a datacenter migration)
+	MonitoringPaused bool
+	PausedTags       []string
+	
+	// Slow-response threshold and the latency metric it applies to ("total" or "ttfb")
+	LatencyThreshold time.Duration
+	LatencyMetric    string
+	
+	// Latency anomalies: checks more than AnomalySigma standard deviations
+	// above the endpoint's rolling baseline, an EWMA over about AnomalyWindow
+	// checks seeded from stored results. They replace LatencyThreshold once an
+	// endpoint has a baseline; zero AnomalySigma disables them. With alerting
+	// on, AnomalyAlertAfter anomalous checks in a row raise an alert (never
+	// when zero).
+	AnomalySigma      float64
+	AnomalyWindow     int
+	AnomalyAlertAfter int
+	
+	// How latency summaries are computed ("mean", "trimmed_mean" or "median")
+	StatsMode        string
+	StatsTrimPercent float64
+	
+	// Window size of the stored latency sketches used for percentile queries
+	SketchWindow time.Duration
+	
+	// How long debug captures of full requests and responses are kept
+	CaptureRetention time.Duration
+	
+	// Retention in days (0 keeps data forever): raw results older than
+	// RetentionRawDays are rolled up into hourly and daily aggregates, which
+	// are kept for RetentionHourlyDays and RetentionDailyDays. Raw retention
+	// shorter than the longest SLA window is refused unless
+	// RetentionShortRaw accepts SLA reports flagging the rolled-up part.
+	RetentionRawDays    int
+	RetentionHourlyDays int
+	RetentionDailyDays  int
+	RetentionInterval   time.Duration
+	RetentionShortRaw   bool
+	
+	// Endpoint archives go to ArchiveLocation: a directory, "s3://bucket/prefix"
+	// or "gs://bucket/prefix". ArchiveEndpoint overrides the bucket service
+	// URL, e.g. for MinIO. Archiving is disabled without a location.
+	ArchiveLocation string
+	ArchiveRegion   string
+	ArchiveEndpoint string
+	
+	// Result signing: an HMAC secret or "ed25519:<base64 private key>" that
+	// signs every stored result, and an "ed25519:<base64 public key>" that
+	// verifies them where the private key isn't available
+	ResultSigningKey string
+	ResultVerifyKey  string
+	
+	// External secret stores: secrets and endpoint headers may be given as
+	// "vault:<path>#<key>" or "aws-sm:<name>[#<key>]" references. Secrets in
+	// this configuration are resolved by Load, so rotations only reach them
+	// on a restart or reload; endpoint headers are resolved through
+	// SecretResolver before each check and fetched again after
+	// SecretsCacheTTL.
+	SecretsCacheTTL    time.Duration
+	VaultAddr          string
+	VaultToken         string
+	VaultNamespace     string
+	AWSRegion          string
+	AWSSecretsEndpoint string
+	SecretResolver     *secretref.Resolver
+	
+	// Remote agents: the token they register with, how often they send
+	// heartbeats and how many they may miss before their endpoints are
+	// reassigned (the agent API is disabled without a token)
+	AgentToken             string
+	AgentHeartbeatInterval time.Duration
+	AgentMissedHeartbeats  int
+	
+	// Prometheus remote-write ingestion of external probe results
+	IngestToken              string
+	IngestURLLabel           string
+	IngestLatencyMetric      string
+	IngestAvailabilityMetric string
+	
+	// Throughput measurement
+	ThroughputURLs     []string
+	ThroughputMaxBytes int64
+	
 	// Web server configuration
 	WebPort int
 	
+	// PublicURL is where users reach this instance, e.g.
+	// "https://monitor.example.com", for links in alerts (relative when empty)
+	PublicURL string
+	
+	// ShutdownTimeout bounds how long the web and gRPC servers wait for
+	// requests, checks, database writes and alerts in progress on SIGTERM
+	ShutdownTimeout time.Duration
+	
+	// ConfigFile holds settings as KEY=VALUE lines, read like the environment
+	// (which still wins) and read again on every reload. EndpointsFile is a
+	// declarative endpoints file, see apimon validate, whose endpoints the
+	// web server keeps in sync with it. Both are polled for changes every
+	// ConfigWatchInterval (never when zero).
+	ConfigFile          string
+	EndpointsFile       string
+	ConfigWatchInterval time.Duration
+	
+	// TLS configuration
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSDomains      []string
+	TLSCacheDir     string
+	TLSACMEEmail    string
+	TLSACMEHTTPPort int
+	GRPCPort        int
+	
+	// GatewayPort serves the gRPC service as REST through grpc-gateway
+	// (cmd/grpc only); zero disables it
+	GatewayPort int
+	
+	// Shared cache for multi-replica deployments (in-memory when empty)
+	RedisURL string
+	
+	// Debug endpoints (pprof and /debug/state, protected by the dashboard login)
+	DebugEnabled bool
+	
+	// Authentication configuration
+	AuthEnabled      bool
+	AuthUsername     string
+	AuthPassword     string
+	SessionTTL       time.Duration
+	LoginMaxAttempts int
+	LoginLockout     time.Duration
+	
+	// AdminToken enables destructive admin endpoints such as DELETE /api/results
+	AdminToken string
+	
 	// AI configuration
 	AIEnabled   bool
 	AIBaseURL   string
 	AIAPIKey    string
 	AIModel     string
 	
+	// Models tried in order when the primary one fails or exceeds AITierTimeout,
+	// before falling back to rule-based insights
+	AIFallbacks   []AIFallback
+	AITierTimeout time.Duration
+	
+	// Scheduled AI analysis per tag ("*" for the whole fleet). When set, insights
+	// are generated on these intervals and served from the last run instead of
+	// calling the model on every dashboard request.
+	AISchedules map[string]time.Duration
+	
+	// Without schedules, insights served on request are reused for
+	// AIInsightsTTL; older ones are still served while the model refreshes
+	// them in the background
+	AIInsightsTTL time.Duration
+	
+	// Insights less confident than AIMinConfidence (0 to 1) are dropped
+	// before they are served, published or stored
+	AIMinConfidence float64
+	
+	// Insights compare the last TrendWindow of stored history with the
+	// window before, sending the model points of TrendBucket each. A zero
+	// TrendWindow leaves trends out.
+	TrendWindow time.Duration
+	TrendBucket time.Duration
+	
+	// Insights predict SLA breaches within PredictionHorizon from the trend
+	// points: p95 latency crossing LatencyThreshold, or the error rate an
+	// endpoint's SLO budget. Zero disables predictions.
+	PredictionHorizon time.Duration
+	
+	// Weekly AI digest of the fleet, written on the DigestCron schedule
+	// (evaluated in DigestTimezone) and sent to DigestChannels, or to every
+	// channel that takes reports when empty. Disabled without DigestCron.
+	DigestCron     string
+	DigestTimezone string
+	DigestChannels []string
+	
+	// GeoIP configuration
+	GeoIPCountryDB string
+	GeoIPASNDB     string
+	
 	// Alerting configuration
 	AlertingEnabled bool
 	SlackWebhook    string
@@ -32,69 +266,612 @@ type Config struct {
 	EmailSMTPPort   int
 	EmailUsername   string
 	EmailPassword   string
+	EmailFrom       string   // alert emails are only sent when set
+	EmailTo         []string // recipients of endpoints without email routes
+	
+	// Per-channel message templates replacing the default Slack payload and
+	// email body, see alerting.TemplateData
+	SlackTemplate string
+	EmailTemplate string
+	
+	// Paging: alerts open PagerDuty incidents and Opsgenie alerts once they
+	// have lasted PageAfter, and recoveries resolve them
+	PagerDutyRoutingKey string
+	PagerDutyEventsURL  string
+	OpsgenieAPIKey      string
+	OpsgenieAPIURL      string
+	PageAfter           time.Duration
+	
+	// Generic webhooks that receive every alert
+	Webhooks []Webhook
+	
+	// Downtime alert policy: consecutive failures before alerting, per-channel
+	// escalation thresholds (e.g. slack=1,pagerduty=5), reminders while down
+	// and flap suppression
+	AlertAfterFailures int
+	AlertEscalation    map[string]int
+	AlertRenotify      time.Duration
+	FlapWindow         time.Duration
+	FlapThreshold      int
+	
+	// Error budget burn rate alerts on endpoints with an SLO, evaluated every
+	// SLOEvalInterval (disabled when zero)
+	SLOBurnRules    []alerting.BurnRule
+	SLOEvalInterval time.Duration
+	
+	// OpenTelemetry tracing of checks, queries, AI requests and API calls,
+	// exported over OTLP/HTTP when TracingEndpoint is set
+	TracingEndpoint      string
+	TracingHeaders       map[string]string
+	TracingServiceName   string
+	TracingSamplePercent int
 }
 
 // Load loads configuration from environment variables with defaults
 func Load() *Config {
-	return &Config{
-		// Database
-		DatabaseURL: getEnv("DATABASE_URL", "host=localhost port=5432 user=monitor password=password dbname=api_monitor sslmode=disable"),
+	loadMutex.Lock()
+	defer loadMutex.Unlock()
+	recorded = make(map[string]Setting)
+	defer func() { recorded, profile, fileValues = nil, nil, nil }()
+	
+	// The config file is read first since it may set anything, even the
+	// profile, which is selected next so its presets apply to every getter
+	configFile, fileErrors := readConfigFile()
+	profileName, profileErrors := selectProfile()
+	
+	secrets := &secretLoader{}
+	
+	// The secret stores are set up first so the other secrets can reference them
+	secretsCacheTTL := getDuration("SECRETS_CACHE_TTL", 5*time.Minute)
+	vaultAddr := getEnv("VAULT_ADDR", "")
+	vaultToken := secrets.get("VAULT_TOKEN", "")
+	vaultNamespace := getEnv("VAULT_NAMESPACE", "")
+	awsRegion := getEnv("AWS_REGION", "")
+	awsSecretsEndpoint := getEnv("AWS_ENDPOINT_URL_SECRETS_MANAGER", "")
+	secrets.resolver = secretref.NewResolver(secretref.Options{
+		TTL:            secretsCacheTTL,
+		VaultAddr:      vaultAddr,
+		VaultToken:     vaultToken,
+		VaultNamespace: vaultNamespace,
+		AWSRegion:      awsRegion,
+		AWSEndpoint:    awsSecretsEndpoint,
+	})
+	
+	// DATABASE_URL wins; otherwise the Postgres connection string is built
+	// from the discrete DB_* variables, with DB_PASSWORD readable from a file
+	databaseDriver := getEnv("DATABASE_DRIVER", "postgres")
+	var databaseURL string
+	if databaseDriver == "sqlite" {
+		databaseURL = "api-monitor.db"
+	} else {
+		databaseURL = postgresDSN(map[string]string{
+			"host":     getEnv("DB_HOST", "localhost"),
+			"port":     strconv.Itoa(getInt("DB_PORT", 5432)),
+			"user":     getEnv("DB_USER", "monitor"),
+			"password": secrets.get("DB_PASSWORD", "password"),
+			"dbname":   getEnv("DB_NAME", "api_monitor"),
+			"sslmode":  getEnv("DB_SSLMODE", "disable"),
+		})
+	}
+	
+	cfg := &Config{
+		Profile:     profileName,
+		OutputStyle: strings.ToLower(strings.TrimSpace(getEnv("OUTPUT_STYLE", "emoji"))),
+		
+		// Database (postgres, or sqlite for a standalone single-file setup)
+		DatabaseDriver: databaseDriver,
+		DatabaseURL:    secrets.get("DATABASE_URL", databaseURL),
 		
 		// Monitoring
 		CheckInterval:  getDuration("CHECK_INTERVAL", 15*time.Second),
 		RequestTimeout: getDuration("REQUEST_TIMEOUT", 5*time.Second),
 		MaxConcurrency: getInt("MAX_CONCURRENCY", 10),
 		
+		SchedulerLagThreshold: getDuration("SCHEDULER_LAG_THRESHOLD", 5*time.Second),
+		
+		HealthLivenessGrace:   getDuration("HEALTH_LIVENESS_GRACE", 2*time.Minute),
+		HealthRequireDatabase: getBool("HEALTH_REQUIRE_DATABASE", true),
+		HealthRequireAI:       getBool("HEALTH_REQUIRE_AI", false),
+		HealthPort:            getInt("HEALTH_PORT", 0),
+		
+		StaleAfterIntervals: getInt("STALE_AFTER_INTERVALS", 2),
+		StaleAlerts:         getBool("STALE_ALERTS", false),
+		
+		RealtimeMaxEndpoints: getInt("REALTIME_MAX_ENDPOINTS", 5),
+		RealtimeMinInterval:  getDuration("REALTIME_MIN_INTERVAL", 100*time.Millisecond),
+		
+		SLAMinGap: getDuration("SLA_MIN_GAP", 5*time.Minute),
+		
+		SamplingMode:  getEnv("SAMPLING_MODE", "all"),
+		SamplingEvery: getInt("SAMPLING_EVERY", 10),
+		
+		MonitoringPaused: getBool("MONITORING_PAUSED", false),
+		PausedTags:       getList("PAUSED_TAGS", nil),
+		
+		LatencyThreshold: getDuration("LATENCY_THRESHOLD", 2*time.Second),
+		LatencyMetric:    getEnv("LATENCY_METRIC", "total"),
+		
+		AnomalySigma:      float64(getInt("ANOMALY_SIGMA", 3)),
+		AnomalyWindow:     getInt("ANOMALY_WINDOW", 100),
+		AnomalyAlertAfter: getInt("ANOMALY_ALERT_AFTER", 3),
+		
+		StatsMode:        getEnv("STATS_MODE", "mean"),
+		StatsTrimPercent: float64(getInt("STATS_TRIM_PERCENT", 5)),
+		SketchWindow:     getDuration("SKETCH_WINDOW", 5*time.Minute),
+		
+		CaptureRetention: getDuration("CAPTURE_RETENTION", 7*24*time.Hour),
+		
+		RetentionRawDays:    getInt("RETENTION_RAW_DAYS", 0),
+		RetentionHourlyDays: getInt("RETENTION_HOURLY_DAYS", 0),
+		RetentionDailyDays:  getInt("RETENTION_DAILY_DAYS", 0),
+		RetentionInterval:   getDuration("RETENTION_INTERVAL", time.Hour),
+		RetentionShortRaw:   getBool("RETENTION_SHORT_RAW", false),
+		
+		ArchiveLocation: getEnv("ARCHIVE_LOCATION", ""),
+		ArchiveRegion:   getEnv("ARCHIVE_REGION", "us-east-1"),
+		ArchiveEndpoint: getEnv("ARCHIVE_ENDPOINT", ""),
+		
+		ResultSigningKey: secrets.get("RESULT_SIGNING_KEY", ""),
+		ResultVerifyKey:  getEnv("RESULT_VERIFY_KEY", ""),
+		
+		SecretsCacheTTL:    secretsCacheTTL,
+		VaultAddr:          vaultAddr,
+		VaultToken:         vaultToken,
+		VaultNamespace:     vaultNamespace,
+		AWSRegion:          awsRegion,
+		AWSSecretsEndpoint: awsSecretsEndpoint,
+		SecretResolver:     secrets.resolver,
+		
+		AgentToken:             secrets.get("AGENT_TOKEN", ""),
+		AgentHeartbeatInterval: getDuration("AGENT_HEARTBEAT_INTERVAL", 15*time.Second),
+		AgentMissedHeartbeats:  getInt("AGENT_MISSED_HEARTBEATS", 3),
+		
+		// Remote write (defaults match blackbox_exporter)
+		IngestToken:              secrets.get("INGEST_TOKEN", ""),
+		IngestURLLabel:           getEnv("INGEST_URL_LABEL", "instance"),
+		IngestLatencyMetric:      getEnv("INGEST_LATENCY_METRIC", "probe_duration_seconds"),
+		IngestAvailabilityMetric: getEnv("INGEST_AVAILABILITY_METRIC", "probe_success"),
+		
+		// Throughput (URLs whose payload is downloaded to measure MB/s)
+		ThroughputURLs:     getList("THROUGHPUT_URLS", nil),
+		ThroughputMaxBytes: int64(getInt("THROUGHPUT_MAX_BYTES", 100<<20)),
+		
 		// Web server
-		WebPort: getInt("WEB_PORT", 8080),
+		WebPort:         getInt("WEB_PORT", 8080),
+		PublicURL:       strings.TrimRight(getEnv("PUBLIC_URL", ""), "/"),
+		ShutdownTimeout: getDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		
+		// Hot reload
+		ConfigFile:          configFile,
+		EndpointsFile:       getEnv("ENDPOINTS_FILE", ""),
+		ConfigWatchInterval: getDuration("CONFIG_WATCH_INTERVAL", 10*time.Second),
+		
+		// TLS (cert/key files, or automatic Let's Encrypt when TLS_DOMAINS is set)
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		TLSDomains:      getList("TLS_DOMAINS", nil),
+		TLSCacheDir:     getEnv("TLS_CACHE_DIR", "certs"),
+		TLSACMEEmail:    getEnv("TLS_ACME_EMAIL", ""),
+		TLSACMEHTTPPort: getInt("TLS_ACME_HTTP_PORT", 80),
+		GRPCPort:        getInt("GRPC_PORT", 9090),
+		GatewayPort:     getInt("GATEWAY_PORT", 0),
+		
+		RedisURL: secrets.get("REDIS_URL", ""),
+		
+		DebugEnabled: getBool("DEBUG_ENABLED", false),
+		
+		// Authentication (dashboard login)
+		AuthEnabled:      getBool("AUTH_ENABLED", false),
+		AuthUsername:     getEnv("AUTH_USERNAME", "admin"),
+		AuthPassword:     secrets.get("AUTH_PASSWORD", ""),
+		SessionTTL:       getDuration("SESSION_TTL", 12*time.Hour),
+		LoginMaxAttempts: getInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginLockout:     getDuration("LOGIN_LOCKOUT", 15*time.Minute),
+		AdminToken:       secrets.get("ADMIN_TOKEN", ""),
 		
 		// AI configuration (GPT-OSS)
 		AIEnabled: getBool("AI_ENABLED", true),
 		AIBaseURL: getEnv("AI_BASE_URL", "http://localhost:8000"), // Local GPT-OSS server
-		AIAPIKey:  getEnv("AI_API_KEY", "your-api-key-here"),
+		AIAPIKey:  secrets.get("AI_API_KEY", "your-api-key-here"),
 		AIModel:   getEnv("AI_MODEL", "gpt-oss-20b"),
 		
+		AITierTimeout:   getDuration("AI_TIER_TIMEOUT", 20*time.Second),
+		AIInsightsTTL:   getDuration("AI_INSIGHTS_TTL", time.Minute),
+		AIMinConfidence: getFloat("AI_MIN_CONFIDENCE", 0),
+		TrendWindow:     getDuration("TREND_WINDOW", 6*time.Hour),
+		TrendBucket:     getDuration("TREND_BUCKET", time.Hour),
+		
+		PredictionHorizon: getDuration("PREDICTION_HORIZON", 2*time.Hour),
+		
+		// GeoIP (MaxMind GeoLite2 databases)
+		GeoIPCountryDB: getEnv("GEOIP_COUNTRY_DB", ""),
+		GeoIPASNDB:     getEnv("GEOIP_ASN_DB", ""),
+		
 		// Alerting
 		AlertingEnabled: getBool("ALERTING_ENABLED", false),
-		SlackWebhook:    getEnv("SLACK_WEBHOOK", ""),
+		SlackWebhook:    secrets.get("SLACK_WEBHOOK", ""),
 		EmailSMTPHost:   getEnv("EMAIL_SMTP_HOST", "smtp.gmail.com"),
 		EmailSMTPPort:   getInt("EMAIL_SMTP_PORT", 587),
 		EmailUsername:   getEnv("EMAIL_USERNAME", ""),
-		EmailPassword:   getEnv("EMAIL_PASSWORD", ""),
+		EmailPassword:   secrets.get("EMAIL_PASSWORD", ""),
+		EmailFrom:       getEnv("EMAIL_FROM", getEnv("EMAIL_USERNAME", "")),
+		EmailTo:         getList("EMAIL_TO", nil),
+		SlackTemplate:   secrets.get("SLACK_TEMPLATE", ""),
+		EmailTemplate:   secrets.get("EMAIL_TEMPLATE", ""),
+		
+		PagerDutyRoutingKey: secrets.get("PAGERDUTY_ROUTING_KEY", ""),
+		PagerDutyEventsURL:  getEnv("PAGERDUTY_EVENTS_URL", alerting.PagerDutyEventsURL),
+		OpsgenieAPIKey:      secrets.get("OPSGENIE_API_KEY", ""),
+		OpsgenieAPIURL:      getEnv("OPSGENIE_API_URL", alerting.OpsgenieAPIURL),
+		PageAfter:           getDuration("PAGE_AFTER", 0),
+		
+		AlertAfterFailures: getInt("ALERT_AFTER_FAILURES", 1),
+		AlertRenotify:      getDuration("ALERT_RENOTIFY", 0),
+		FlapWindow:         getDuration("FLAP_WINDOW", 10*time.Minute),
+		FlapThreshold:      getInt("FLAP_THRESHOLD", 0),
+		
+		SLOEvalInterval: getDuration("SLO_EVAL_INTERVAL", time.Minute),
+		
+		DigestCron:     getEnv("DIGEST_CRON", ""),
+		DigestTimezone: getEnv("DIGEST_TIMEZONE", "UTC"),
+		DigestChannels: getList("DIGEST_CHANNELS", nil),
+		
+		TracingEndpoint:      getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		TracingServiceName:   getEnv("OTEL_SERVICE_NAME", "api-monitor"),
+		TracingSamplePercent: getInt("TRACING_SAMPLE_PERCENT", 100),
+	}
+	var labelsErr error
+	cfg.Labels, labelsErr = checker.ParseLabels(getList("LABELS", nil))
+	var scheduleErrors []string
+	cfg.AISchedules, scheduleErrors = getSchedules("AI_SCHEDULES")
+	var fallbackErrors []string
+	cfg.AIFallbacks, fallbackErrors = getAIFallbacks("AI_FALLBACKS", secrets)
+	var webhookErrors []string
+	cfg.Webhooks, webhookErrors = getWebhooks("WEBHOOKS", secrets)
+	var escalationErrors []string
+	cfg.AlertEscalation, escalationErrors = getEscalation("ALERT_ESCALATION")
+	var burnRuleErrors []string
+	cfg.SLOBurnRules, burnRuleErrors = getBurnRules("SLO_BURN_RULES")
+	var tracingHeadersErr error
+	cfg.TracingHeaders, tracingHeadersErr = tracing.ParseHeaders(secrets.get("OTEL_EXPORTER_OTLP_HEADERS", ""))
+	cfg.LoadErrors = append(fileErrors, profileErrors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, secrets.errors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, scheduleErrors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, fallbackErrors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, webhookErrors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, escalationErrors...)
+	cfg.LoadErrors = append(cfg.LoadErrors, burnRuleErrors...)
+	if cfg.OutputStyle != "emoji" && cfg.OutputStyle != "plain" {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("OUTPUT_STYLE: unknown style %q (use emoji or plain)", cfg.OutputStyle))
+	}
+	if _, err := cfg.ResultSigner(); err != nil {
+		cfg.LoadErrors = append(cfg.LoadErrors, "RESULT_SIGNING_KEY: "+err.Error())
+	}
+	if cfg.ResultVerifyKey != "" {
+		if _, err := signing.ParsePublicKey(cfg.ResultVerifyKey); err != nil {
+			cfg.LoadErrors = append(cfg.LoadErrors, "RESULT_VERIFY_KEY: "+err.Error())
+		}
+	}
+	if labelsErr != nil {
+		cfg.LoadErrors = append(cfg.LoadErrors, "LABELS: "+labelsErr.Error())
+	}
+	if tracingHeadersErr != nil {
+		cfg.LoadErrors = append(cfg.LoadErrors, "OTEL_EXPORTER_OTLP_HEADERS: "+tracingHeadersErr.Error())
+	}
+	if longest := sla.LongestWindow(); cfg.RetentionRawDays > 0 && !cfg.RetentionShortRaw &&
+		time.Duration(cfg.RetentionRawDays)*24*time.Hour < longest.Duration {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("RETENTION_RAW_DAYS: %d days is shorter than the %s SLA window; set RETENTION_SHORT_RAW=true to accept SLA reports from rollups",
+			cfg.RetentionRawDays, longest.Name))
+	}
+	if cfg.TracingSamplePercent < 0 || cfg.TracingSamplePercent > 100 {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("TRACING_SAMPLE_PERCENT: %d is not between 0 and 100", cfg.TracingSamplePercent))
+	}
+	if cfg.AnomalySigma < 0 {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("ANOMALY_SIGMA: %v is negative", cfg.AnomalySigma))
+	}
+	if cfg.AnomalyWindow < 2 {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("ANOMALY_WINDOW: %d is less than 2 checks", cfg.AnomalyWindow))
+	}
+	if cfg.AIMinConfidence < 0 || cfg.AIMinConfidence > 1 {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("AI_MIN_CONFIDENCE: %v is not between 0 and 1", cfg.AIMinConfidence))
+	}
+	if cfg.TrendWindow > 0 && (cfg.TrendBucket < time.Minute || cfg.TrendBucket > cfg.TrendWindow) {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("TREND_BUCKET: %v is not between 1m and TREND_WINDOW", cfg.TrendBucket))
+	}
+	if _, err := scheduler.ParseCron(cfg.DigestCron, cfg.DigestTimezone); err != nil {
+		cfg.LoadErrors = append(cfg.LoadErrors, "DIGEST_CRON: "+err.Error())
+	}
+	if cfg.SlackTemplate != "" {
+		if err := alerting.ValidateTemplate("slack", cfg.SlackTemplate); err != nil {
+			cfg.LoadErrors = append(cfg.LoadErrors, "SLACK_TEMPLATE: "+err.Error())
+		}
+	}
+	if cfg.EmailTemplate != "" {
+		if err := alerting.ValidateTemplate("email", cfg.EmailTemplate); err != nil {
+			cfg.LoadErrors = append(cfg.LoadErrors, "EMAIL_TEMPLATE: "+err.Error())
+		}
 	}
+	if databaseDriver != "postgres" && databaseDriver != "sqlite" {
+		cfg.LoadErrors = append(cfg.LoadErrors, fmt.Sprintf("DATABASE_DRIVER: unknown driver %q (use postgres or sqlite)", databaseDriver))
+	}
+	cfg.Settings = recordedSettings()
+	
+	return cfg
+}
+
+// postgresDSN builds a keyword/value connection string, quoting values
+// with spaces or quotes, e.g. passwords
+func postgresDSN(params map[string]string) string {
+	var parts []string
+	for _, key := range []string{"host", "port", "user", "password", "dbname", "sslmode"} {
+		value := params[key]
+		if value == "" || strings.ContainsAny(value, " '\\") {
+			value = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		}
+		parts = append(parts, key+"="+value)
+	}
+	return strings.Join(parts, " ")
+}
+
+// secretLoader reads sensitive values either from KEY or from the file named
+// by KEY_FILE, which is how Docker and Kubernetes secrets are usually mounted.
+// Values referencing Vault or AWS Secrets Manager are resolved once loaded,
+// with a fresh resolver per Load so a reload reads rotated values.
+type secretLoader struct {
+	errors   []string
+	resolver *secretref.Resolver
+}
+
+func (l *secretLoader) get(key, defaultValue string) string {
+	value := defaultValue
+	setting := Setting{Key: key, Source: SourceDefault, Secret: true}
+	if env, source := lookup(key); env != "" {
+		value, setting.Source = env, source
+	}
+	if path, _ := lookup(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.errors = append(l.errors, fmt.Sprintf("%s_FILE: %v", key, err))
+		} else {
+			value, setting.Source = strings.TrimRight(string(data), "\r\n"), SourceFile
+		}
+	}
+	if l.resolver != nil && secretref.IsReference(value) {
+		setting.Reference = value
+		secret, err := l.resolver.Resolve(value)
+		if err != nil {
+			l.errors = append(l.errors, fmt.Sprintf("%s: %v", key, err))
+			secret = ""
+		}
+		value = secret
+	}
+	setting.Value = value
+	record(setting)
+	return value
 }
 
 // Helper functions for environment variable parsing
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value, source := lookup(key); value != "" {
+		record(Setting{Key: key, Value: value, Source: source})
 		return value
 	}
+	record(Setting{Key: key, Value: defaultValue, Source: SourceDefault})
 	return defaultValue
 }
 
+func getList(key string, defaultValue []string) []string {
+	value, source := lookup(key)
+	if value == "" {
+		record(Setting{Key: key, Value: strings.Join(defaultValue, ","), Source: SourceDefault})
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	record(Setting{Key: key, Value: strings.Join(list, ","), Source: source})
+	return list
+}
+
+// getSchedules parses "tag=interval" pairs such as "prod=1h,dev=24h"
+func getSchedules(key string) (map[string]time.Duration, []string) {
+	schedules := make(map[string]time.Duration)
+	var errors []string
+	for _, item := range getList(key, nil) {
+		tag, value, ok := strings.Cut(item, "=")
+		tag = strings.TrimSpace(tag)
+		interval, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || tag == "" || err != nil || interval < time.Minute {
+			errors = append(errors, fmt.Sprintf("%s: %q must look like tag=1h with an interval of at least 1m", key, item))
+			continue
+		}
+		schedules[tag] = interval
+	}
+	return schedules, errors
+}
+
+// getEscalation parses "channel=failures" pairs such as "slack=1,pagerduty=5"
+func getEscalation(key string) (map[string]int, []string) {
+	escalation := make(map[string]int)
+	var errors []string
+	for _, item := range getList(key, nil) {
+		i := strings.LastIndex(item, "=")
+		if i <= 0 {
+			errors = append(errors, fmt.Sprintf("%s: %q must look like channel=5", key, item))
+			continue
+		}
+		failures, err := strconv.Atoi(strings.TrimSpace(item[i+1:]))
+		if err != nil || failures < 1 {
+			errors = append(errors, fmt.Sprintf("%s: %q must look like channel=5 with at least 1 failure", key, item))
+			continue
+		}
+		escalation[strings.TrimSpace(item[:i])] = failures
+	}
+	return escalation, errors
+}
+
+// getBurnRules parses "severity:long/short=budget" entries such as
+// "critical:1h/5m=2", the defaults when unset
+func getBurnRules(key string) ([]alerting.BurnRule, []string) {
+	var rules []alerting.BurnRule
+	var errors []string
+	for _, item := range getList(key, []string{"critical:1h/5m=2", "warning:6h/30m=5"}) {
+		rule, err := alerting.ParseBurnRule(item)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errors
+}
+
+// getAIFallbacks parses "name=model@baseURL" entries such as
+// "openai=gpt-4o-mini@https://api.openai.com". Each entry's API key is read
+// from AI_API_KEY_<NAME> (or its _FILE variant).
+func getAIFallbacks(key string, secrets *secretLoader) ([]AIFallback, []string) {
+	var fallbacks []AIFallback
+	var errors []string
+	for _, item := range getList(key, nil) {
+		name, target, ok := strings.Cut(item, "=")
+		model, baseURL, hasURL := strings.Cut(target, "@")
+		name, model, baseURL = strings.TrimSpace(name), strings.TrimSpace(model), strings.TrimSpace(baseURL)
+		if !ok || !hasURL || name == "" || model == "" || !strings.HasPrefix(baseURL, "http") {
+			errors = append(errors, fmt.Sprintf("%s: %q must look like name=model@https://host", key, item))
+			continue
+		}
+		fallbacks = append(fallbacks, AIFallback{
+			Name:    name,
+			Model:   model,
+			BaseURL: strings.TrimRight(baseURL, "/"),
+			APIKey:  secrets.get("AI_API_KEY_"+strings.ToUpper(strings.ReplaceAll(name, "-", "_")), ""),
+		})
+	}
+	return fallbacks, errors
+}
+
+// getWebhooks parses "name=url" entries such as
+// "teams=https://example.webhook.office.com/...". Each webhook's signing
+// secret is read from WEBHOOK_SECRET_<NAME> and its payload template from
+// WEBHOOK_TEMPLATE_<NAME> (or their _FILE variants).
+func getWebhooks(key string, secrets *secretLoader) ([]Webhook, []string) {
+	var webhooks []Webhook
+	var errors []string
+	var masked []string
+	for _, item := range getList(key, nil) {
+		name, url, ok := strings.Cut(item, "=")
+		name, url = strings.TrimSpace(name), strings.TrimSpace(url)
+		if !ok || name == "" || !strings.HasPrefix(url, "http") {
+			errors = append(errors, fmt.Sprintf("%s: %q must look like name=https://host/path", key, item))
+			continue
+		}
+		// Webhook URLs usually carry their credentials in the path
+		masked = append(masked, name+"="+maskedValue)
+		suffix := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		webhook := Webhook{
+			Name:     name,
+			URL:      url,
+			Secret:   secrets.get("WEBHOOK_SECRET_"+suffix, ""),
+			Template: secrets.get("WEBHOOK_TEMPLATE_"+suffix, ""),
+		}
+		if webhook.Template != "" {
+			if err := alerting.ValidateTemplate("webhook", webhook.Template); err != nil {
+				errors = append(errors, fmt.Sprintf("WEBHOOK_TEMPLATE_%s: %v", suffix, err))
+			}
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	redact(key, strings.Join(masked, ","))
+	return webhooks, errors
+}
+
 func getInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	value, source := lookup(key)
+	if value != "" {
 		if i, err := strconv.Atoi(value); err == nil {
+			record(Setting{Key: key, Value: strconv.Itoa(i), Source: source})
 			return i
 		}
 	}
+	record(Setting{Key: key, Value: strconv.Itoa(defaultValue), Source: SourceDefault, Ignored: value})
 	return defaultValue
 }
 
 func getBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	value, source := lookup(key)
+	if value != "" {
 		if b, err := strconv.ParseBool(value); err == nil {
+			record(Setting{Key: key, Value: strconv.FormatBool(b), Source: source})
 			return b
 		}
 	}
+	record(Setting{Key: key, Value: strconv.FormatBool(defaultValue), Source: SourceDefault, Ignored: value})
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	value, source := lookup(key)
+	if value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			record(Setting{Key: key, Value: strconv.FormatFloat(f, 'g', -1, 64), Source: source})
+			return f
+		}
+	}
+	record(Setting{Key: key, Value: strconv.FormatFloat(defaultValue, 'g', -1, 64), Source: SourceDefault, Ignored: value})
 	return defaultValue
 }
 
 func getDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	value, source := lookup(key)
+	if value != "" {
 		if d, err := time.ParseDuration(value); err == nil {
+			record(Setting{Key: key, Value: d.String(), Source: source})
 			return d
 		}
 	}
+	record(Setting{Key: key, Value: defaultValue.String(), Source: SourceDefault, Ignored: value})
 	return defaultValue
-}
\ No newline at end of file
+}
+// AIFallback is an OpenAI-compatible model endpoint in the AI fallback chain
+type AIFallback struct {
+	Name    string
+	Model   string
+	BaseURL string
+	APIKey  string
+}
+
+// Webhook is an outbound alert webhook
+type Webhook struct {
+	Name     string
+	URL      string
+	Secret   string // HMAC-SHA256 signing key, unsigned when empty
+	Template string // Go template of the JSON payload, the alert itself when empty
+}
+
+// TLSEnabled reports whether the servers should terminate TLS themselves
+func (c *Config) TLSEnabled() bool {
+	return len(c.TLSDomains) > 0 || (c.TLSCertFile != "" && c.TLSKeyFile != "")
+}
+
+// ResultSigner returns the key that signs stored results, nil when signing is off
+func (c *Config) ResultSigner() (*signing.Key, error) {
+	if c.ResultSigningKey == "" {
+		return nil, nil
+	}
+	return signing.ParseKey(c.ResultSigningKey)
+}
+
+// ResultVerifier returns the key that verifies stored results: the verify
+// key when set, otherwise the signing key
+func (c *Config) ResultVerifier() (*signing.Key, error) {
+	if c.ResultVerifyKey != "" {
+		return signing.ParsePublicKey(c.ResultVerifyKey)
+	}
+	if c.ResultSigningKey == "" {
+		return nil, fmt.Errorf("set RESULT_SIGNING_KEY or RESULT_VERIFY_KEY")
+	}
+	return c.ResultSigner()
+}
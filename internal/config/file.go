@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileValues holds the settings of the CONFIG_FILE read by the Load in
+// progress, guarded by loadMutex like recorded
+var fileValues map[string]string
+
+// readConfigFile reads the file named by CONFIG_FILE: one KEY=VALUE per line
+// like a .env file, with blank lines and # comments ignored and values
+// optionally quoted. It returns the file's path.
+func readConfigFile() (string, []string) {
+	path := strings.TrimSpace(os.Getenv("CONFIG_FILE"))
+	record(Setting{Key: "CONFIG_FILE", Value: path, Source: SourceEnv})
+	if path == "" {
+		return "", nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return path, []string{fmt.Sprintf("CONFIG_FILE: %v", err)}
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	var errors []string
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			errors = append(errors, fmt.Sprintf("CONFIG_FILE line %d: must look like KEY=VALUE", number))
+			continue
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		errors = append(errors, fmt.Sprintf("CONFIG_FILE: %v", err))
+	}
+	fileValues = values
+	return path, errors
+}
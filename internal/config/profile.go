@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Deployment profiles preset the settings that have to grow together with
+// the number of monitored endpoints: check concurrency, how results are
+// sampled, batched into sketches and rolled up, how long they are kept and
+// how often AI analysis runs. Variables set explicitly still win. Raw
+// results are kept for 90 days in every profile since SLA reports read them
+// for windows of up to 90 days.
+var profiles = map[string]map[string]string{
+	// A single host with up to ~50 endpoints, e.g. on SQLite: every result is
+	// checked and insights are generated when asked for
+	"small": {
+		"MAX_CONCURRENCY":       "10",
+		"SAMPLING_MODE":         "all",
+		"SKETCH_WINDOW":         "5m",
+		"RETENTION_RAW_DAYS":    "90",
+		"RETENTION_HOURLY_DAYS": "180",
+		"RETENTION_DAILY_DAYS":  "730",
+		"RETENTION_INTERVAL":    "6h",
+	},
+	// A few hundred endpoints on PostgreSQL
+	"medium": {
+		"MAX_CONCURRENCY":       "50",
+		"SAMPLING_MODE":         "every_n",
+		"SAMPLING_EVERY":        "5",
+		"SKETCH_WINDOW":         "5m",
+		"RETENTION_RAW_DAYS":    "90",
+		"RETENTION_HOURLY_DAYS": "180",
+		"RETENTION_DAILY_DAYS":  "365",
+		"RETENTION_INTERVAL":    "1h",
+		"AI_SCHEDULES":          "*=1h",
+	},
+	// Thousands of endpoints, usually spread over several replicas or agents
+	"large": {
+		"MAX_CONCURRENCY":        "200",
+		"SAMPLING_MODE":          "every_n",
+		"SAMPLING_EVERY":         "10",
+		"SKETCH_WINDOW":          "15m",
+		"RETENTION_RAW_DAYS":     "90",
+		"RETENTION_HOURLY_DAYS":  "120",
+		"RETENTION_DAILY_DAYS":   "365",
+		"RETENTION_INTERVAL":     "30m",
+		"REALTIME_MAX_ENDPOINTS": "20",
+		"AI_SCHEDULES":           "*=6h",
+	},
+}
+
+// profile holds the presets of the profile selected for the Load in
+// progress, guarded by loadMutex like recorded
+var profile map[string]string
+
+// Profiles returns the names of the deployment profiles
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectProfile reads DEPLOYMENT_PROFILE and makes its presets the defaults
+// of the following getters
+func selectProfile() (string, []string) {
+	name := strings.ToLower(strings.TrimSpace(getEnv("DEPLOYMENT_PROFILE", "")))
+	if name == "" {
+		return "", nil
+	}
+	presets, ok := profiles[name]
+	if !ok {
+		return "", []string{fmt.Sprintf("DEPLOYMENT_PROFILE: unknown profile %q (use %s)", name, strings.Join(Profiles(), ", "))}
+	}
+	profile = presets
+	return name, nil
+}
+
+// lookup returns a variable's value from the environment, or else from the
+// config file or the selected profile, with its source
+func lookup(key string) (string, string) {
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv
+	}
+	if value := fileValues[key]; value != "" {
+		return value, SourceConfig
+	}
+	if value := profile[key]; value != "" {
+		return value, SourceProfile
+	}
+	return "", SourceDefault
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+)
+
+// Sources of a setting
+const (
+	SourceEnv     = "env"     // the environment variable
+	SourceFile    = "file"    // the file named by its _FILE variable
+	SourceConfig  = "config"  // the CONFIG_FILE
+	SourceProfile = "profile" // preset by DEPLOYMENT_PROFILE
+	SourceDefault = "default" // not set, or set to a value that couldn't be parsed
+)
+
+// maskedValue replaces secrets in Settings
+const maskedValue = "********"
+
+// Setting is one configuration value as the running instance loaded it
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"` // secrets are masked
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+
+	// Reference is the Vault or AWS Secrets Manager reference a secret was
+	// resolved from
+	Reference string `json:"reference,omitempty"`
+
+	// Ignored is the environment value that couldn't be parsed, so the
+	// default applies
+	Ignored string `json:"ignored,omitempty"`
+
+	// digest identifies the unmasked value so reloads notice rotated secrets
+	digest string
+}
+
+// recorded collects the settings read by the Load in progress; Load holds
+// loadMutex so the getters can record without passing a recorder around
+var (
+	loadMutex sync.Mutex
+	recorded  map[string]Setting
+)
+
+// record notes the effective value of a setting
+func record(setting Setting) {
+	if recorded == nil {
+		return
+	}
+	sum := sha256.Sum256([]byte(setting.Value))
+	setting.digest = hex.EncodeToString(sum[:])
+	if setting.Secret && setting.Value != "" {
+		setting.Value = maskedValue
+	}
+	recorded[setting.Key] = setting
+}
+
+// recordedSettings returns the settings recorded by Load sorted by key
+func recordedSettings() []Setting {
+	settings := make([]Setting, 0, len(recorded))
+	for _, setting := range recorded {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// redact replaces the recorded value of a setting that isn't a secret as a
+// whole but holds some, e.g. webhook URLs
+func redact(key, value string) {
+	if setting, ok := recorded[key]; ok {
+		setting.Value = value
+		recorded[key] = setting
+	}
+}
+
+// Changed returns the keys of the settings that differ between two loads,
+// including secrets whose masked values look alike
+func Changed(before, after []Setting) []string {
+	previous := make(map[string]Setting, len(before))
+	for _, setting := range before {
+		previous[setting.Key] = setting
+	}
+	var keys []string
+	for _, setting := range after {
+		old, ok := previous[setting.Key]
+		delete(previous, setting.Key)
+		if !ok || old.digest != setting.digest || old.Source != setting.Source || old.Reference != setting.Reference {
+			keys = append(keys, setting.Key)
+		}
+	}
+	for key := range previous {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+// Package cost accounts for the work each endpoint causes the monitor, so
+// large installations can find the checks that cost the most
+package cost
+
+import (
+	"sync"
+	"time"
+)
+
+// Usage is the work done for one endpoint
+type Usage struct {
+	Checks        int64 `json:"checks"`
+	BytesSent     int64 `json:"bytesSent"`     // request bodies
+	BytesReceived int64 `json:"bytesReceived"` // response bodies
+	RowsWritten   int64 `json:"rowsWritten"`   // stored results and metric points
+	AITokens      int64 `json:"aiTokens"`      // its share of AI prompts that included it
+}
+
+// add accumulates other into u
+func (u *Usage) add(other Usage) {
+	u.Checks += other.Checks
+	u.BytesSent += other.BytesSent
+	u.BytesReceived += other.BytesReceived
+	u.RowsWritten += other.RowsWritten
+	u.AITokens += other.AITokens
+}
+
+// Tracker accumulates usage per URL in memory since it was created
+type Tracker struct {
+	since time.Time
+	usage map[string]*Usage
+	mutex sync.Mutex
+}
+
+// NewTracker creates a tracker counting from now
+func NewTracker(now time.Time) *Tracker {
+	return &Tracker{since: now, usage: make(map[string]*Usage)}
+}
+
+// Since returns when the tracker started counting
+func (t *Tracker) Since() time.Time {
+	return t.since
+}
+
+// Add records work done for a URL
+func (t *Tracker) Add(url string, usage Usage) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	total, exists := t.usage[url]
+	if !exists {
+		total = &Usage{}
+		t.usage[url] = total
+	}
+	total.add(usage)
+}
+
+// AddTokens splits the tokens of an AI request evenly between the URLs it
+// covered; the remainder goes to the first
+func (t *Tracker) AddTokens(urls []string, tokens int) {
+	if len(urls) == 0 || tokens <= 0 {
+		return
+	}
+	share, remainder := tokens/len(urls), tokens%len(urls)
+	for i, url := range urls {
+		usage := Usage{AITokens: int64(share)}
+		if i == 0 {
+			usage.AITokens += int64(remainder)
+		}
+		t.Add(url, usage)
+	}
+}
+
+// Get returns the usage of a URL
+func (t *Tracker) Get(url string) Usage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if usage, exists := t.usage[url]; exists {
+		return *usage
+	}
+	return Usage{}
+}
@@ -0,0 +1,98 @@
+package dedupe
+
+import (
+	"net"
+	"net/url"
+	"sort"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+)
+
+// Reasons two endpoints are considered duplicates
+const (
+	ReasonCanonicalURL   = "canonical_url"   // same URL after normalization
+	ReasonResolvedTarget = "resolved_target" // same IP, port and path under different host names
+)
+
+// Group is a set of endpoints that effectively monitor the same thing
+type Group struct {
+	Reason    string               `json:"reason"`
+	Key       string               `json:"key"`
+	Endpoints []scheduler.Endpoint `json:"endpoints"`
+}
+
+// LatestFunc returns the most recent result for an endpoint, if any
+type LatestFunc func(endpoint scheduler.Endpoint) (checker.CheckResult, bool)
+
+// Find groups endpoints that share a canonical URL or, using the latest
+// results, the same resolved address and path. Endpoints that already appear
+// in a canonical URL group are not reported again by target.
+func Find(endpoints []scheduler.Endpoint, latest LatestFunc) []Group {
+	var groups []Group
+	grouped := make(map[string]bool)
+
+	byURL := make(map[string][]scheduler.Endpoint)
+	for _, endpoint := range endpoints {
+		if endpoint.Type != "" && endpoint.Type != checker.TypeHTTP {
+			continue
+		}
+		canonical, err := checker.NormalizeURL(endpoint.URL)
+		if err != nil {
+			continue
+		}
+		byURL[canonical] = append(byURL[canonical], endpoint)
+	}
+	for key, members := range byURL {
+		if len(members) > 1 {
+			groups = append(groups, Group{Reason: ReasonCanonicalURL, Key: key, Endpoints: members})
+			for _, member := range members {
+				grouped[member.ID] = true
+			}
+		}
+	}
+
+	byTarget := make(map[string][]scheduler.Endpoint)
+	for _, endpoint := range endpoints {
+		if grouped[endpoint.ID] {
+			continue
+		}
+		result, ok := latest(endpoint)
+		if !ok || result.RemoteIP == "" {
+			continue
+		}
+		if key, ok := targetKey(endpoint.URL, result.RemoteIP); ok {
+			byTarget[key] = append(byTarget[key], endpoint)
+		}
+	}
+	for key, members := range byTarget {
+		if len(members) > 1 {
+			groups = append(groups, Group{Reason: ReasonResolvedTarget, Key: key, Endpoints: members})
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+// targetKey identifies where a URL actually goes: scheme, resolved IP, port and path
+func targetKey(rawURL, remoteIP string) (string, bool) {
+	canonical, err := checker.NormalizeURL(rawURL)
+	if err != nil {
+		return "", false
+	}
+	u, err := url.Parse(canonical)
+	if err != nil {
+		return "", false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	u.Host = net.JoinHostPort(remoteIP, port)
+	return u.String(), true
+}
@@ -0,0 +1,125 @@
+package drift
+
+import (
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Change describes an unexpected change in how an endpoint is served
+type Change struct {
+	URL        string    `json:"url"`
+	Field      string    `json:"field"` // "remote_ip", "tls_issuer" or "server_header"
+	Previous   string    `json:"previous"`
+	Current    string    `json:"current"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// fingerprint is what we remember about an endpoint between checks
+type fingerprint struct {
+	knownIPs     map[string]bool
+	tlsIssuer    string
+	serverHeader string
+	lastIP       string
+}
+
+// Detector compares each check with the previously observed identity of the endpoint
+type Detector struct {
+	fingerprints map[string]*fingerprint
+	recent       []Change
+	mutex        sync.Mutex
+	maxKnownIPs  int
+	maxRecent    int
+}
+
+// NewDetector creates a drift detector
+func NewDetector() *Detector {
+	return &Detector{
+		fingerprints: make(map[string]*fingerprint),
+		maxKnownIPs:  16,
+		maxRecent:    100,
+	}
+}
+
+// Observe records a check result and returns any changes it reveals.
+// Failed checks are ignored since they carry no reliable identity.
+func (d *Detector) Observe(result checker.CheckResult) []Change {
+	if result.StatusCode == 0 {
+		return nil
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	fp, exists := d.fingerprints[result.URL]
+	if !exists {
+		// First sighting establishes the baseline
+		fp = &fingerprint{
+			knownIPs:     make(map[string]bool),
+			tlsIssuer:    result.TLSIssuer,
+			serverHeader: result.ServerHeader,
+			lastIP:       result.RemoteIP,
+		}
+		if result.RemoteIP != "" {
+			fp.knownIPs[result.RemoteIP] = true
+		}
+		d.fingerprints[result.URL] = fp
+		return nil
+	}
+
+	var changes []Change
+	now := time.Now()
+
+	// Round-robin DNS rotates through a stable pool, so only never-seen IPs count
+	if result.RemoteIP != "" && !fp.knownIPs[result.RemoteIP] {
+		if len(fp.knownIPs) > 0 {
+			changes = append(changes, Change{URL: result.URL, Field: "remote_ip", Previous: fp.lastIP, Current: result.RemoteIP, DetectedAt: now})
+		}
+		if len(fp.knownIPs) >= d.maxKnownIPs {
+			fp.knownIPs = make(map[string]bool)
+		}
+		fp.knownIPs[result.RemoteIP] = true
+	}
+	if result.RemoteIP != "" {
+		fp.lastIP = result.RemoteIP
+	}
+
+	if result.TLSIssuer != fp.tlsIssuer {
+		changes = append(changes, Change{URL: result.URL, Field: "tls_issuer", Previous: fp.tlsIssuer, Current: result.TLSIssuer, DetectedAt: now})
+		fp.tlsIssuer = result.TLSIssuer
+	}
+
+	if result.ServerHeader != fp.serverHeader {
+		changes = append(changes, Change{URL: result.URL, Field: "server_header", Previous: fp.serverHeader, Current: result.ServerHeader, DetectedAt: now})
+		fp.serverHeader = result.ServerHeader
+	}
+
+	d.recent = append(d.recent, changes...)
+	if len(d.recent) > d.maxRecent {
+		d.recent = d.recent[len(d.recent)-d.maxRecent:]
+	}
+
+	return changes
+}
+
+// Recent returns the most recently detected changes, oldest first
+func (d *Detector) Recent() []Change {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	changes := make([]Change, len(d.recent))
+	copy(changes, d.recent)
+	return changes
+}
+
+// Since returns changes detected after the given time
+func (d *Detector) Since(t time.Time) []Change {
+	var changes []Change
+	for _, change := range d.Recent() {
+		if change.DetectedAt.After(t) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,147 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"api-monitor/internal/ai"
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+)
+
+// Event types
+const (
+	TypeStateChange     = "state_change"
+	TypeAlert           = "alert"
+	TypeResult          = "result"
+	TypeEndpointRemoved = "endpoint_removed"
+	TypeInsights        = "insights"
+)
+
+// Health states reported in state change events
+const (
+	StateHealthy   = "healthy"
+	StateUnhealthy = "unhealthy"
+)
+
+// Event is something that happened in the monitor that clients can subscribe to
+type Event struct {
+	ID         int64                `json:"id"`
+	Type       string               `json:"type"`
+	EndpointID string               `json:"endpointId,omitempty"`
+	URL        string               `json:"url,omitempty"`
+	Previous   string               `json:"previous,omitempty"`
+	Current    string               `json:"current,omitempty"`
+	Result     *checker.CheckResult `json:"result,omitempty"`
+	Alert      *alerting.Alert      `json:"alert,omitempty"`
+	Tag        string               `json:"tag,omitempty"`      // scope of an insights event
+	Insights   []ai.Insight         `json:"insights,omitempty"` // a new analysis
+	Timestamp  time.Time            `json:"timestamp"`
+}
+
+// historySize is how many recent events are kept for clients that reconnect
+const historySize = 256
+
+// Broker fans events out to subscribers. Slow subscribers miss events rather
+// than blocking the publisher.
+type Broker struct {
+	subscribers map[chan Event]struct{}
+	history     []Event
+	nextID      int64
+	closed      bool
+	mutex       sync.RWMutex
+}
+
+// NewBroker creates an event broker
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish assigns the event an ID and delivers it to all subscribers
+func (b *Broker) Publish(event Event) Event {
+	b.mutex.Lock()
+	b.nextID++
+	event.ID = b.nextID
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up, drop the event for it
+		}
+	}
+	b.mutex.Unlock()
+
+	return event
+}
+
+// Subscribe returns a channel of future events and a function to unsubscribe
+func (b *Broker) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	b.mutex.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mutex.Unlock()
+
+	unsubscribe := func() {
+		b.mutex.Lock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+		b.mutex.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// Close ends every subscription, so streaming clients are let go on
+// shutdown. Later subscriptions get a closed channel.
+func (b *Broker) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Since returns retained events with an ID greater than id, oldest first
+func (b *Broker) Since(id int64) []Event {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	var events []Event
+	for _, event := range b.history {
+		if event.ID > id {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// LastID returns the ID of the most recently published event, zero before any
+func (b *Broker) LastID() int64 {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.nextID
+}
+
+// Subscribers returns the number of active subscribers
+func (b *Broker) Subscribers() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.subscribers)
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Transition is an endpoint's current health state, when it began and the
+// consecutive failed checks so far
+type Transition struct {
+	State    string
+	Since    time.Time // the first check in this state, or since the detector started
+	Failures int
+}
+
+// TransitionDetector remembers each endpoint's health state and reports changes
+type TransitionDetector struct {
+	states map[string]Transition
+	mutex  sync.Mutex
+}
+
+// NewTransitionDetector creates a detector with no known states
+func NewTransitionDetector() *TransitionDetector {
+	return &TransitionDetector{states: make(map[string]Transition)}
+}
+
+// Observe records a result for an endpoint and returns the previous and current
+// state along with whether the state changed. The first observation of an
+// endpoint counts as a change from the empty state.
+func (d *TransitionDetector) Observe(endpointID string, result checker.CheckResult) (previous, current string, changed bool) {
+	current = StateUnhealthy
+	if result.IsHealthy {
+		current = StateHealthy
+	}
+	at := result.CheckedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	transition := d.states[endpointID]
+	previous = transition.State
+	if previous != current {
+		transition = Transition{State: current, Since: at}
+	}
+	if result.IsHealthy {
+		transition.Failures = 0
+	} else {
+		transition.Failures++
+	}
+	d.states[endpointID] = transition
+	return previous, current, previous != current
+}
+
+// Current returns the remembered state of an endpoint
+func (d *TransitionDetector) Current(endpointID string) (Transition, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	transition, ok := d.states[endpointID]
+	return transition, ok
+}
+
+// Forget drops the remembered state of an endpoint
+func (d *TransitionDetector) Forget(endpointID string) {
+	d.mutex.Lock()
+	delete(d.states, endpointID)
+	d.mutex.Unlock()
+}
@@ -0,0 +1,135 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+
+	"api-monitor/internal/checker"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Resolver enriches check results with the target's IP, country and ASN
+type Resolver struct {
+	countryDB *geoip2.Reader
+	asnDB     *geoip2.Reader
+}
+
+// NewResolver opens the local GeoIP databases. Either path may be empty, in which
+// case only IP resolution (and whichever lookup has a database) is performed.
+func NewResolver(countryDBPath, asnDBPath string) (*Resolver, error) {
+	r := &Resolver{}
+
+	if countryDBPath != "" {
+		db, err := geoip2.Open(countryDBPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open country database: %w", err)
+		}
+		r.countryDB = db
+	}
+
+	if asnDBPath != "" {
+		db, err := geoip2.Open(asnDBPath)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to open ASN database: %w", err)
+		}
+		r.asnDB = db
+	}
+
+	return r, nil
+}
+
+// Enrich fills in RemoteIP, Country, ASN and ASOrg on a check result
+func (r *Resolver) Enrich(result *checker.CheckResult) {
+	// Failed connections never report a remote address, so resolve it ourselves
+	if result.RemoteIP == "" {
+		result.RemoteIP = resolveHost(result.URL)
+	}
+
+	ip := net.ParseIP(result.RemoteIP)
+	if ip == nil {
+		return
+	}
+
+	if r.countryDB != nil {
+		if record, err := r.countryDB.Country(ip); err == nil {
+			result.Country = record.Country.IsoCode
+		}
+	}
+
+	if r.asnDB != nil {
+		if record, err := r.asnDB.ASN(ip); err == nil {
+			result.ASN = record.AutonomousSystemNumber
+			result.ASOrg = record.AutonomousSystemOrganization
+		}
+	}
+}
+
+// EnrichAll enriches a batch of results in place
+func (r *Resolver) EnrichAll(results []checker.CheckResult) {
+	for i := range results {
+		r.Enrich(&results[i])
+	}
+}
+
+// Close releases the GeoIP databases
+func (r *Resolver) Close() {
+	if r.countryDB != nil {
+		r.countryDB.Close()
+	}
+	if r.asnDB != nil {
+		r.asnDB.Close()
+	}
+}
+
+// ProviderFailures groups the URLs of failing endpoints hosted by the same provider
+type ProviderFailures struct {
+	Provider string   `json:"provider"`
+	ASN      uint     `json:"asn"`
+	URLs     []string `json:"urls"`
+}
+
+// GroupFailuresByProvider groups unhealthy results by hosting provider (ASN),
+// largest group first, so correlated provider outages stand out
+func GroupFailuresByProvider(results []checker.CheckResult) []ProviderFailures {
+	groups := make(map[uint]*ProviderFailures)
+	for _, result := range results {
+		if result.IsHealthy || result.ASN == 0 {
+			continue
+		}
+		group, exists := groups[result.ASN]
+		if !exists {
+			group = &ProviderFailures{Provider: result.ASOrg, ASN: result.ASN}
+			groups[result.ASN] = group
+		}
+		group.URLs = append(group.URLs, result.URL)
+	}
+
+	grouped := make([]ProviderFailures, 0, len(groups))
+	for _, group := range groups {
+		grouped = append(grouped, *group)
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		if len(grouped[i].URLs) != len(grouped[j].URLs) {
+			return len(grouped[i].URLs) > len(grouped[j].URLs)
+		}
+		return grouped[i].ASN < grouped[j].ASN
+	})
+	return grouped
+}
+
+// resolveHost looks up the first IP address for the host of a URL
+func resolveHost(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return ""
+	}
+	ips, err := net.LookupIP(parsed.Hostname())
+	if err != nil || len(ips) == 0 {
+		return ""
+	}
+	return ips[0].String()
+}
@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// healthMethods are served without a token, so probes need no credentials
+const healthMethods = "/grpc.health.v1.Health/"
+
+// SetToken requires every call, native or through the REST gateway, to
+// carry "authorization: Bearer <token>"; the health service stays open. An
+// empty token leaves the service unauthenticated.
+func (s *MonitorServer) SetToken(token string) {
+	s.token = token
+}
+
+// authorize checks the bearer token of a call to method
+func (s *MonitorServer) authorize(ctx context.Context, method string) error {
+	if s.token == "" || strings.HasPrefix(method, healthMethods) {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token := strings.TrimPrefix(value, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "a valid bearer token is required")
+}
+
+// unaryAuth rejects unary calls without the token
+func (s *MonitorServer) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuth rejects streaming calls without the token
+func (s *MonitorServer) streamAuth(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	pb "api-monitor/proto/monitor"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// gatewayBuffer sizes the in-memory connection between the REST gateway and
+// the gRPC server
+const gatewayBuffer = 1 << 20
+
+// SetGateway serves the REST gateway (/v1/...) on port alongside the gRPC
+// service; zero disables it
+func (s *MonitorServer) SetGateway(port int) {
+	s.gatewayPort = port
+}
+
+// startGateway serves the REST gateway until it is shut down. It proxies
+// every call to server over an in-memory listener rather than calling the
+// service directly, so REST requests go through the same gRPC server as
+// native clients and /v1/results:stream streams like StreamResults.
+func (s *MonitorServer) startGateway(ctx context.Context, server *grpc.Server, tlsConfig *tls.Config) (*http.Server, error) {
+	inMemory := bufconn.Listen(gatewayBuffer)
+	go server.Serve(inMemory)
+
+	mux := runtime.NewServeMux()
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()), // never leaves the process
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return inMemory.DialContext(ctx)
+		}),
+	}
+	if err := pb.RegisterMonitorManagerHandlerFromEndpoint(ctx, mux, "passthrough:///gateway", opts); err != nil {
+		return nil, fmt.Errorf("registering REST gateway: %w", err)
+	}
+
+	gateway := &http.Server{Addr: fmt.Sprintf(":%d", s.gatewayPort), Handler: mux, TLSConfig: tlsConfig}
+	go func() {
+		log.Printf("🔌 REST gateway serving /v1 on port %d (TLS: %v)", s.gatewayPort, tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			err = gateway.ListenAndServeTLS("", "")
+		} else {
+			err = gateway.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("REST gateway failed: %v", err)
+		}
+	}()
+	return gateway, nil
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api-monitor/internal/health"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// probeInterval is how often the gRPC health statuses are brought up to date
+const probeInterval = 10 * time.Second
+
+// Health service names: Kubernetes gRPC probes pick one with their service
+// field. The empty name is the server as a whole, like readiness.
+const (
+	LivenessService  = "liveness"
+	ReadinessService = "readiness"
+)
+
+// HealthChecks are the dependencies behind the server's probes: its
+// monitoring loops and, required when requireDatabase is set, the database
+func (s *MonitorServer) HealthChecks(requireDatabase bool) []health.Check {
+	return []health.Check{
+		{Name: "monitoring", Required: true, Run: s.checkMonitoring},
+		{Name: "database", Required: requireDatabase, Run: s.checkDatabase},
+	}
+}
+
+// SetHealth serves the standard gRPC health service from prober
+func (s *MonitorServer) SetHealth(prober *health.Prober) {
+	s.health = prober
+}
+
+// checkMonitoring fails when a monitoring loop hasn't come round for two
+// intervals plus its timeout, e.g. because saving a result hangs
+func (s *MonitorServer) checkMonitoring(ctx context.Context) error {
+	s.endpointsMutex.RLock()
+	defer s.endpointsMutex.RUnlock()
+	for id, endpoint := range s.endpoints {
+		beat, ok := s.heartbeats.Load(id)
+		if !ok {
+			continue
+		}
+		allowed := time.Duration(2*endpoint.IntervalSeconds+endpoint.TimeoutSeconds) * time.Second
+		if since := time.Since(beat.(time.Time)); since > allowed {
+			return fmt.Errorf("monitoring of %s stalled for %v", endpoint.URL, since.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// checkDatabase fails when the database doesn't answer, or couldn't be
+// opened at startup, which only a restart retries
+func (s *MonitorServer) checkDatabase(ctx context.Context) error {
+	if s.store == nil {
+		return errors.New("database unavailable since startup")
+	}
+	return s.store.WithContext(ctx).Ping()
+}
+
+// watchHealth updates the gRPC health statuses from the prober until ctx is done
+func (s *MonitorServer) watchHealth(ctx context.Context, server *grpchealth.Server) {
+	status := func(report health.Report) healthpb.HealthCheckResponse_ServingStatus {
+		if report.Status == health.StatusFail {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+	for {
+		readiness := status(s.health.Readiness(ctx))
+		server.SetServingStatus("", readiness)
+		server.SetServingStatus(ReadinessService, readiness)
+		server.SetServingStatus(LivenessService, status(s.health.Liveness(ctx)))
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
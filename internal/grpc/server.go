@@ -2,16 +2,34 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"sync"
 	"time"
 
 	"api-monitor/internal/checker"
+	"api-monitor/internal/events"
+	"api-monitor/internal/health"
+	"api-monitor/internal/scheduler"
 	"api-monitor/internal/storage"
+	pb "api-monitor/proto/monitor"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Errors returned by AddEndpoint
+var (
+	ErrEndpointExists = errors.New("URL already being monitored")
+	ErrInvalidTiming  = errors.New("interval and timeout must be positive")
 )
 
 // MonitorEndpoint represents a monitored endpoint
@@ -25,31 +43,101 @@ type MonitorEndpoint struct {
 
 // MonitorServer implements our monitoring gRPC service
 type MonitorServer struct {
-	store           *storage.PostgresStore
-	endpoints       map[string]*MonitorEndpoint
-	endpointsMutex  sync.RWMutex
-	checker         *checker.HTTPChecker
-	stopChannels    map[string]chan bool
-	resultStream    chan *checker.CheckResult
+	store          storage.Store
+	endpoints      map[string]*MonitorEndpoint
+	endpointsMutex sync.RWMutex
+	checker        *checker.HTTPChecker
+	stopChannels   map[string]chan bool
+	monitoring     sync.WaitGroup // running monitoring loops
+	results        *events.Broker // fans check results out to streaming clients
+	middleware     []checker.Middleware
+	heartbeats     sync.Map // endpoint ID → when its monitoring loop last came round
+	health         *health.Prober
+	gatewayPort    int    // REST gateway port, see SetGateway
+	token          string // bearer token calls need, see SetToken
 }
 
-// NewMonitorServer creates a new gRPC monitor server
-func NewMonitorServer(store *storage.PostgresStore) *MonitorServer {
-	return &MonitorServer{
+// NewMonitorServer creates a new gRPC monitor server and resumes monitoring
+// the endpoints persisted in store. Every check runs through middleware.
+func NewMonitorServer(store storage.Store, middleware ...checker.Middleware) *MonitorServer {
+	s := &MonitorServer{
 		store:        store,
 		endpoints:    make(map[string]*MonitorEndpoint),
 		checker:      checker.NewHTTPChecker(10 * time.Second),
 		stopChannels: make(map[string]chan bool),
-		resultStream: make(chan *checker.CheckResult, 100),
+		results:      events.NewBroker(),
+		middleware:   middleware,
 	}
+	s.loadEndpoints()
+	return s
+}
+
+// storedEndpoint is the definition of an endpoint the gRPC server persists.
+// It shares the endpoints table with the web server, owning its rows with
+// storage.OwnerGRPC.
+type storedEndpoint struct {
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+	Disabled bool          `json:"disabled,omitempty"`
 }
 
-// AddEndpoint adds a new endpoint to monitor
+// loadEndpoints starts monitoring the persisted endpoints the gRPC server
+// created; those of the web server are checked there
+func (s *MonitorServer) loadEndpoints() {
+	if s.store == nil {
+		return
+	}
+	records, err := s.store.ListEndpoints()
+	if err != nil {
+		log.Printf("Failed to load endpoints: %v", err)
+		return
+	}
+
+	s.endpointsMutex.Lock()
+	defer s.endpointsMutex.Unlock()
+	for _, record := range records {
+		if record.Owner != storage.OwnerGRPC {
+			continue
+		}
+		var stored storedEndpoint
+		if err := json.Unmarshal(record.Config, &stored); err != nil {
+			log.Printf("Skipping stored endpoint %s: %v", record.ID, err)
+			continue
+		}
+		endpoint := &MonitorEndpoint{
+			ID:              record.ID,
+			URL:             record.URL,
+			IntervalSeconds: int32(stored.Interval / time.Second),
+			TimeoutSeconds:  int32(stored.Timeout / time.Second),
+			Enabled:         !stored.Disabled,
+		}
+		if endpoint.IntervalSeconds <= 0 {
+			endpoint.IntervalSeconds = 30
+		}
+		if endpoint.TimeoutSeconds <= 0 {
+			endpoint.TimeoutSeconds = 10
+		}
+		s.endpoints[endpoint.ID] = endpoint
+		s.startMonitoring(endpoint)
+	}
+}
+
+// AddEndpoint adds a new endpoint to monitor and persists it
 func (s *MonitorServer) AddEndpoint(ctx context.Context, url string, intervalSec, timeoutSec int32) (string, error) {
+	if intervalSec <= 0 || timeoutSec <= 0 {
+		return "", ErrInvalidTiming
+	}
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+
 	s.endpointsMutex.Lock()
 	defer s.endpointsMutex.Unlock()
 
-	endpointID := fmt.Sprintf("endpoint_%d", time.Now().Unix())
+	endpointID := scheduler.EndpointID(url)
+	if _, exists := s.endpoints[endpointID]; exists {
+		return "", ErrEndpointExists
+	}
 	
 	endpoint := &MonitorEndpoint{
 		ID:              endpointID,
@@ -59,6 +147,25 @@ func (s *MonitorServer) AddEndpoint(ctx context.Context, url string, intervalSec
 		Enabled:         true,
 	}
 
+	if s.store != nil {
+		// The web server may monitor the URL already
+		if record, exists, err := s.store.GetEndpoint(endpointID); err != nil {
+			return "", fmt.Errorf("failed to look up endpoint: %w", err)
+		} else if exists && record.Owner != storage.OwnerGRPC {
+			return "", ErrEndpointExists
+		}
+		config, err := json.Marshal(storedEndpoint{
+			Interval: time.Duration(intervalSec) * time.Second,
+			Timeout:  time.Duration(timeoutSec) * time.Second,
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := s.store.SaveEndpoint(storage.EndpointRecord{ID: endpointID, URL: url, Config: config, Owner: storage.OwnerGRPC}); err != nil {
+			return "", fmt.Errorf("failed to save endpoint: %w", err)
+		}
+	}
+
 	s.endpoints[endpointID] = endpoint
 	
 	// Start monitoring this endpoint
@@ -81,10 +188,10 @@ func (s *MonitorServer) ListEndpoints() []*MonitorEndpoint {
 	return endpoints
 }
 
-// GetResults gets recent results for a URL
-func (s *MonitorServer) GetResults(url string, limit int) ([]checker.CheckResult, error) {
+// GetResults gets recent results for a URL carrying labels
+func (s *MonitorServer) GetResults(url string, labels map[string]string, limit int) ([]checker.CheckResult, error) {
 	if s.store != nil {
-		return s.store.GetRecentResults(url, limit)
+		return s.store.QueryResults(storage.ResultQuery{URL: url, Labels: labels, Limit: limit})
 	}
 	return []checker.CheckResult{}, nil
 }
@@ -94,12 +201,18 @@ func (s *MonitorServer) startMonitoring(endpoint *MonitorEndpoint) {
 	stopChan := make(chan bool, 1)
 	s.stopChannels[endpoint.ID] = stopChan
 
+	s.monitoring.Add(1)
 	go func() {
+		defer s.monitoring.Done()
+		defer s.heartbeats.Delete(endpoint.ID)
+		s.heartbeats.Store(endpoint.ID, time.Now())
 		ticker := time.NewTicker(time.Duration(endpoint.IntervalSeconds) * time.Second)
 		defer ticker.Stop()
 
 		// Create checker with endpoint-specific timeout
 		endpointChecker := checker.NewHTTPChecker(time.Duration(endpoint.TimeoutSeconds) * time.Second)
+		endpointChecker.Use(checker.RedactSecrets())
+		endpointChecker.Use(s.middleware...)
 
 		for {
 			select {
@@ -114,11 +227,15 @@ func (s *MonitorServer) startMonitoring(endpoint *MonitorEndpoint) {
 						}
 					}
 
-					// Send to stream
-					select {
-					case s.resultStream <- &result:
-					default:
-						// Channel full, skip this result
+					// Send to streaming clients
+					if s.results.Subscribers() > 0 {
+						s.results.Publish(events.Event{
+							Type:       events.TypeResult,
+							EndpointID: endpoint.ID,
+							URL:        endpoint.URL,
+							Result:     &result,
+							Timestamp:  result.CheckedAt,
+						})
 					}
 
 					// Log the result
@@ -128,6 +245,7 @@ func (s *MonitorServer) startMonitoring(endpoint *MonitorEndpoint) {
 					}
 					log.Printf("%s %s - %v", status, endpoint.URL, result.ResponseTime.Round(time.Millisecond))
 				}
+				s.heartbeats.Store(endpoint.ID, time.Now())
 
 			case <-stopChan:
 				log.Printf("Stopped monitoring %s", endpoint.URL)
@@ -137,32 +255,135 @@ func (s *MonitorServer) startMonitoring(endpoint *MonitorEndpoint) {
 	}()
 }
 
-// StopMonitoring stops monitoring an endpoint
-func (s *MonitorServer) StopMonitoring(endpointID string) {
+// StopMonitoring stops monitoring an endpoint and deletes its stored
+// definition, leaving those of the web server alone. It reports whether the
+// endpoint was monitored.
+func (s *MonitorServer) StopMonitoring(endpointID string) bool {
 	s.endpointsMutex.Lock()
 	defer s.endpointsMutex.Unlock()
 
-	if stopChan, exists := s.stopChannels[endpointID]; exists {
+	stopChan, exists := s.stopChannels[endpointID]
+	if exists {
 		close(stopChan)
 		delete(s.stopChannels, endpointID)
 		delete(s.endpoints, endpointID)
 	}
+	if s.store != nil {
+		record, stored, err := s.store.GetEndpoint(endpointID)
+		if err != nil {
+			log.Printf("Failed to look up stored endpoint %s: %v", endpointID, err)
+		} else if stored && record.Owner == storage.OwnerGRPC {
+			deleted, err := s.store.DeleteEndpoint(endpointID)
+			if err != nil {
+				log.Printf("Failed to delete stored endpoint %s: %v", endpointID, err)
+			}
+			exists = exists || deleted
+		}
+	}
+	return exists
 }
 
-// GetResultStream returns the channel for streaming results
-func (s *MonitorServer) GetResultStream() <-chan *checker.CheckResult {
-	return s.resultStream
+// resultBuffer is how many results a streaming client may fall behind by
+// before it starts missing them
+const resultBuffer = 100
+
+// SubscribeResults returns a channel of future check results and a function
+// to unsubscribe. Every subscriber receives every result.
+func (s *MonitorServer) SubscribeResults() (<-chan events.Event, func()) {
+	return s.results.Subscribe(resultBuffer)
+}
+
+// stopAll stops every monitoring loop, keeping the stored definitions, and
+// waits until their checks in progress are saved or ctx is done
+func (s *MonitorServer) stopAll(ctx context.Context) error {
+	s.endpointsMutex.Lock()
+	for id, stopChan := range s.stopChannels {
+		close(stopChan)
+		delete(s.stopChannels, id)
+	}
+	s.endpointsMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.monitoring.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// StartGRPCServer starts the gRPC server
-func (s *MonitorServer) StartGRPCServer(port int) error {
+// StartGRPCServer serves the gRPC service, and the REST gateway when
+// SetGateway gave it a port, until ctx is cancelled, serving TLS when
+// tlsConfig is non-nil. On cancellation monitoring stops once the checks
+// in progress are saved, result streams end and running calls get up to
+// shutdownTimeout to finish.
+func (s *MonitorServer) StartGRPCServer(ctx context.Context, port int, tlsConfig *tls.Config, shutdownTimeout time.Duration) error {
 	listen, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
 
-	server := grpc.NewServer()
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryAuth),
+		grpc.ChainStreamInterceptor(s.streamAuth),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterMonitorManagerServer(server, &managerService{monitor: s})
+	reflection.Register(server) // lets grpcurl and similar tools discover the service
+	var healthServer *grpchealth.Server
+	if s.health != nil {
+		healthServer = grpchealth.NewServer()
+		healthpb.RegisterHealthServer(server, healthServer)
+		go s.watchHealth(ctx, healthServer)
+	}
 	
-	log.Printf("🚀 gRPC server starting on port %d", port)
-	return server.Serve(listen)
+	var gateway *http.Server
+	if s.gatewayPort > 0 {
+		if gateway, err = s.startGateway(ctx, server, tlsConfig); err != nil {
+			return err
+		}
+	}
+	
+	log.Printf("🚀 gRPC server starting on port %d (TLS: %v)", port, tlsConfig != nil)
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listen) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, waiting up to %v for work in progress", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	s.results.Close()
+	if gateway != nil {
+		// REST streams end with the broker; unary calls get the shutdown timeout
+		go gateway.Shutdown(shutdownCtx)
+	}
+	if healthServer != nil {
+		healthServer.Shutdown() // every service reports NOT_SERVING while draining
+	}
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	if err := s.stopAll(shutdownCtx); err != nil {
+		log.Printf("Checks still running at shutdown: %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		log.Printf("gRPC calls still running at shutdown, closing them")
+		server.Stop()
+	}
+	return <-served
 }
\ No newline at end of file
@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+
+	"api-monitor/internal/checker"
+	pb "api-monitor/proto/monitor"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxResultsLimit caps how many results one GetResults call returns
+const maxResultsLimit = 1000
+
+// managerService exposes a MonitorServer as the MonitorManager gRPC service
+type managerService struct {
+	pb.UnimplementedMonitorManagerServer
+	monitor *MonitorServer
+}
+
+// AddEndpoint starts monitoring a URL
+func (m *managerService) AddEndpoint(ctx context.Context, req *pb.AddEndpointRequest) (*pb.AddEndpointResponse, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+	id, err := m.monitor.AddEndpoint(ctx, req.GetUrl(), req.GetIntervalSeconds(), req.GetTimeoutSeconds())
+	switch {
+	case errors.Is(err, ErrEndpointExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrInvalidTiming):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.AddEndpointResponse{EndpointId: id, Success: true, Message: "monitoring started"}, nil
+}
+
+// RemoveEndpoint stops monitoring an endpoint
+func (m *managerService) RemoveEndpoint(ctx context.Context, req *pb.RemoveEndpointRequest) (*pb.RemoveEndpointResponse, error) {
+	if !m.monitor.StopMonitoring(req.GetEndpointId()) {
+		return nil, status.Errorf(codes.NotFound, "endpoint %q not found", req.GetEndpointId())
+	}
+	return &pb.RemoveEndpointResponse{Success: true, Message: "monitoring stopped"}, nil
+}
+
+// ListEndpoints returns every monitored endpoint ordered by URL
+func (m *managerService) ListEndpoints(ctx context.Context, req *pb.ListEndpointsRequest) (*pb.ListEndpointsResponse, error) {
+	endpoints := m.monitor.ListEndpoints()
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+
+	response := &pb.ListEndpointsResponse{}
+	for _, endpoint := range endpoints {
+		response.Endpoints = append(response.Endpoints, &pb.MonitorEndpoint{
+			Id:              endpoint.ID,
+			Url:             endpoint.URL,
+			IntervalSeconds: endpoint.IntervalSeconds,
+			TimeoutSeconds:  endpoint.TimeoutSeconds,
+			Enabled:         endpoint.Enabled,
+		})
+	}
+	return response, nil
+}
+
+// GetResults returns the most recent stored results of a URL
+func (m *managerService) GetResults(ctx context.Context, req *pb.GetResultsRequest) (*pb.GetResultsResponse, error) {
+	if req.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "url is required")
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > maxResultsLimit {
+		limit = maxResultsLimit
+	}
+
+	url := req.GetUrl()
+	if normalized, err := checker.NormalizeURL(url); err == nil {
+		url = normalized
+	}
+	results, err := m.monitor.GetResults(url, req.GetLabels(), limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &pb.GetResultsResponse{}
+	for _, result := range results {
+		response.Results = append(response.Results, toProtoResult(result))
+	}
+	return response, nil
+}
+
+// StreamResults sends check results as they complete until the client leaves
+func (m *managerService) StreamResults(req *pb.StreamResultsRequest, stream pb.MonitorManager_StreamResultsServer) error {
+	filters := req.GetUrlFilters()
+	if req.GetUrlFilter() != "" {
+		filters = append(filters, req.GetUrlFilter())
+	}
+
+	results, unsubscribe := m.monitor.SubscribeResults()
+	defer unsubscribe()
+	for {
+		select {
+		case event, ok := <-results:
+			if !ok {
+				return nil
+			}
+			if event.Result == nil || !matchesAnyFilter(filters, event.Result.URL) || !checker.MatchLabels(event.Result.Labels, req.GetLabels()) {
+				continue
+			}
+			if err := stream.Send(toProtoResult(*event.Result)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// matchesFilter reports whether url matches a StreamResults filter: a glob
+// when it contains wildcards, otherwise a substring. An empty filter matches all.
+func matchesFilter(filter, url string) bool {
+	if filter == "" {
+		return true
+	}
+	if strings.ContainsAny(filter, "*?[") {
+		matched, _ := path.Match(filter, url)
+		return matched
+	}
+	return strings.Contains(url, filter)
+}
+
+// matchesAnyFilter reports whether url matches one of filters. No filters
+// match everything.
+func matchesAnyFilter(filters []string, url string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if matchesFilter(filter, url) {
+			return true
+		}
+	}
+	return false
+}
+
+// toProtoResult converts a check result to its protobuf message
+func toProtoResult(result checker.CheckResult) *pb.CheckResult {
+	return &pb.CheckResult{
+		Url:            result.URL,
+		StatusCode:     int32(result.StatusCode),
+		ResponseTimeMs: result.ResponseTime.Milliseconds(),
+		IsHealthy:      result.IsHealthy,
+		ErrorMessage:   result.Error,
+		CheckedAt:      timestamppb.New(result.CheckedAt),
+		Labels:         result.Labels,
+	}
+}
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Probe and check statuses
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // an optional dependency is failing
+	StatusFail     = "fail"
+)
+
+// checkTimeout bounds each check of a probe
+const checkTimeout = 5 * time.Second
+
+// Check verifies one dependency of the monitor. A failing required check
+// fails readiness at once and liveness once it has been failing for the
+// grace period; an optional one only degrades both.
+type Check struct {
+	Name     string
+	Required bool
+	Run      func(ctx context.Context) error
+
+	// Every reuses the last outcome for this long, for checks that cost
+	// something to run; zero runs the check on every probe
+	Every time.Duration
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status       string        `json:"status"`
+	Required     bool          `json:"required"`
+	Error        string        `json:"error,omitempty"`
+	Duration     time.Duration `json:"duration"`
+	FailingSince *time.Time    `json:"failingSince,omitempty"`
+	CheckedAt    time.Time     `json:"checkedAt"`
+}
+
+// Report is the answer to a probe
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Prober runs the checks behind the liveness and readiness probes
+type Prober struct {
+	checks []Check
+	grace  time.Duration
+	mutex  sync.Mutex
+	last   map[string]Result
+}
+
+// NewProber creates a prober. grace is how long a required check may fail
+// before liveness fails too, so that a dependency blip takes the monitor out
+// of load balancing but only a lasting failure gets it restarted.
+func NewProber(grace time.Duration, checks ...Check) *Prober {
+	return &Prober{checks: checks, grace: grace, last: make(map[string]Result)}
+}
+
+// Readiness fails while any required check fails
+func (p *Prober) Readiness(ctx context.Context) Report {
+	return p.report(p.run(ctx), func(result Result) bool { return true })
+}
+
+// Liveness fails once a required check has been failing for the grace period
+func (p *Prober) Liveness(ctx context.Context) Report {
+	now := time.Now()
+	return p.report(p.run(ctx), func(result Result) bool {
+		return result.FailingSince != nil && now.Sub(*result.FailingSince) >= p.grace
+	})
+}
+
+// report summarizes results; fatal tells which failing required checks fail
+// the probe rather than degrade it
+func (p *Prober) report(results map[string]Result, fatal func(Result) bool) Report {
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		switch {
+		case result.Status == StatusOK:
+		case result.Required && fatal(result):
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run runs the checks in parallel, reusing recent outcomes of the ones with
+// Every set
+func (p *Prober) run(ctx context.Context) map[string]Result {
+	results := make(map[string]Result, len(p.checks))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range p.checks {
+		p.mutex.Lock()
+		last, ok := p.last[check.Name]
+		p.mutex.Unlock()
+		if ok && check.Every > 0 && time.Since(last.CheckedAt) < check.Every {
+			results[check.Name] = last
+			continue
+		}
+
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := p.runCheck(ctx, check)
+			mutex.Lock()
+			results[check.Name] = result
+			mutex.Unlock()
+		}(check)
+	}
+	wg.Wait()
+	return results
+}
+
+// runCheck runs one check and records since when it has been failing
+func (p *Prober) runCheck(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{Status: StatusOK, Required: check.Required, Duration: time.Since(start), CheckedAt: start}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+		since := start
+		if last, ok := p.last[check.Name]; ok && last.FailingSince != nil {
+			since = *last.FailingSince
+		}
+		result.FailingSince = &since
+	}
+	p.last[check.Name] = result
+	return result
+}
+
+// LivenessHandler serves GET /healthz
+func (p *Prober) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, p.Liveness)
+}
+
+// ReadinessHandler serves GET /readyz
+func (p *Prober) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, p.Readiness)
+}
+
+// serve answers a probe: 200 while ok or degraded, 503 once failed
+func serve(w http.ResponseWriter, r *http.Request, probe func(context.Context) Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	report := probe(r.Context())
+	if report.Status == StatusFail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,316 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/storage"
+)
+
+// Supported export formats
+const (
+	FormatUptimeRobot = "uptimerobot"
+	FormatPingdom     = "pingdom"
+	FormatStatusCake  = "statuscake"
+)
+
+// TriggerImport marks results that were backfilled from another tool
+const TriggerImport = "import"
+
+// Formats lists the supported export formats
+func Formats() []string {
+	return []string{FormatPingdom, FormatStatusCake, FormatUptimeRobot}
+}
+
+// Options describes how to read an export
+type Options struct {
+	Format   string
+	URL      string         // endpoint the history belongs to, unless the export names it
+	Location *time.Location // timezone of timestamps without an offset (UTC when nil)
+}
+
+// Import is the history read from an export
+type Import struct {
+	Results   []checker.CheckResult
+	Incidents []storage.Incident
+	Skipped   int // rows that could not be parsed
+}
+
+// columns maps the fields we read to the header names each tool uses.
+// Headers are matched case-insensitively, ignoring spaces and punctuation.
+var columns = map[string]map[string][]string{
+	FormatUptimeRobot: {
+		"time":     {"datetime", "date", "time"},
+		"event":    {"event", "type"},
+		"reason":   {"reason"},
+		"duration": {"duration", "durationinmins", "durationmins", "durationsec", "durationseconds"},
+		"latency":  {"responsetime", "responsetimems", "value"},
+		"url":      {"monitorurl", "url"},
+	},
+	FormatPingdom: {
+		"time":    {"datetime", "time", "date", "timestamp"},
+		"status":  {"status", "state"},
+		"latency": {"responsetime", "responsetimems", "response"},
+		"reason":  {"statusdesc", "statusdescription", "description", "reason"},
+		"url":     {"url", "hostname", "checkurl"},
+	},
+	FormatStatusCake: {
+		"time":    {"time", "date", "datetime", "timestamp"},
+		"status":  {"status", "statuscode", "state"},
+		"latency": {"responsetime", "performance", "performancems", "loadtime"},
+		"reason":  {"reason", "error"},
+		"url":     {"websiteurl", "url"},
+	},
+}
+
+// timeLayouts are the timestamp formats seen in exports
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"02-01-2006 15:04:05",
+	"2006/01/02 15:04:05",
+}
+
+// Read parses a CSV export. Rows that can't be parsed are counted in Skipped
+// rather than failing the whole import.
+func Read(r io.Reader, opts Options) (Import, error) {
+	aliases, ok := columns[opts.Format]
+	if !ok {
+		return Import{}, fmt.Errorf("unknown format %q (supported: %s)", opts.Format, strings.Join(Formats(), ", "))
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return Import{}, fmt.Errorf("reading header: %w", err)
+	}
+	index := columnIndex(header, aliases)
+	if _, ok := index["time"]; !ok {
+		return Import{}, fmt.Errorf("no timestamp column found in header %v", header)
+	}
+
+	var imported Import
+	var downEvents []storage.Incident
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Import{}, err
+		}
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		at, err := parseTime(field("time"), opts.Location)
+		if err != nil {
+			imported.Skipped++
+			continue
+		}
+		url := field("url")
+		if url == "" {
+			url = opts.URL
+		}
+		if url == "" {
+			return Import{}, fmt.Errorf("the export has no URL column, pass the endpoint URL")
+		}
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+
+		result := checker.CheckResult{URL: url, CheckedAt: at, IsHealthy: true, Trigger: TriggerImport}
+		if latency := field("latency"); latency != "" {
+			if ms, err := strconv.ParseFloat(latency, 64); err == nil && ms >= 0 {
+				result.ResponseTime = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+
+		if event := field("event"); event != "" {
+			// UptimeRobot logs list up/down events with how long each lasted
+			up, ok := parseState(event)
+			if !ok {
+				imported.Skipped++
+				continue
+			}
+			result.IsHealthy = up
+			if !up {
+				incident := storage.Incident{URL: url, StartedAt: at, Cause: field("reason")}
+				if duration, ok := parseDuration(field("duration")); ok {
+					end := at.Add(duration)
+					incident.EndedAt = &end
+				}
+				downEvents = append(downEvents, incident)
+			}
+		} else if status := field("status"); status != "" {
+			up, ok := parseState(status)
+			if !ok {
+				imported.Skipped++
+				continue
+			}
+			result.IsHealthy = up
+			if code, err := strconv.Atoi(status); err == nil {
+				result.StatusCode = code
+			}
+		}
+		if !result.IsHealthy {
+			result.Error = field("reason")
+			if result.Error == "" {
+				result.Error = "down (imported)"
+			}
+		}
+
+		checker.AssessQuality(&result)
+		if result.ResponseTime == 0 && result.Usable() {
+			// Exports without latency shouldn't drag percentiles towards zero
+			result.Quality = checker.QualitySuspect
+			result.QualityIssue = "no latency in imported record"
+		}
+		imported.Results = append(imported.Results, result)
+	}
+
+	sort.Slice(imported.Results, func(i, j int) bool {
+		if imported.Results[i].URL != imported.Results[j].URL {
+			return imported.Results[i].URL < imported.Results[j].URL
+		}
+		return imported.Results[i].CheckedAt.Before(imported.Results[j].CheckedAt)
+	})
+
+	if len(downEvents) > 0 {
+		imported.Incidents = downEvents
+	} else {
+		imported.Incidents = deriveIncidents(imported.Results)
+	}
+	for i := range imported.Incidents {
+		imported.Incidents[i].Source = opts.Format
+	}
+	return imported, nil
+}
+
+// deriveIncidents turns runs of consecutive failed results into incidents
+func deriveIncidents(results []checker.CheckResult) []storage.Incident {
+	var incidents []storage.Incident
+	var open *storage.Incident
+	for _, result := range results {
+		if open != nil && open.URL != result.URL {
+			incidents = append(incidents, *open) // still down at the end of the export
+			open = nil
+		}
+		if !result.IsHealthy && open == nil {
+			open = &storage.Incident{URL: result.URL, StartedAt: result.CheckedAt, Cause: result.Error}
+		} else if result.IsHealthy && open != nil {
+			end := result.CheckedAt
+			open.EndedAt = &end
+			incidents = append(incidents, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		incidents = append(incidents, *open)
+	}
+	return incidents
+}
+
+// columnIndex finds the position of every known field in the header
+func columnIndex(header []string, aliases map[string][]string) map[string]int {
+	index := make(map[string]int)
+	for i, name := range header {
+		key := headerKey(name)
+		for field, names := range aliases {
+			if _, found := index[field]; found {
+				continue
+			}
+			for _, alias := range names {
+				if key == alias {
+					index[field] = i
+				}
+			}
+		}
+	}
+	return index
+}
+
+// headerKey lowercases a header and drops everything but letters and digits,
+// so "Date-Time", "date_time" and "Date/Time" all match
+func headerKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimPrefix(name, "\ufeff")) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", value)
+}
+
+// parseState interprets up/down words and HTTP status codes
+func parseState(value string) (up bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "up", "ok", "success", "started", "resumed":
+		return true, true
+	case "down", "error", "fail", "failed", "failure", "unconfirmed_down":
+		return false, true
+	}
+	if code, err := strconv.Atoi(value); err == nil {
+		return code > 0 && code < 400, true
+	}
+	return false, false
+}
+
+// parseDuration reads durations such as "1h 5m", "00:12:30", "754" (seconds)
+// or "12 mins"
+func parseDuration(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if parts := strings.Split(value, ":"); len(parts) == 3 {
+		var total time.Duration
+		for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+			n, err := strconv.Atoi(parts[i])
+			if err != nil {
+				return 0, false
+			}
+			total += time.Duration(n) * unit
+		}
+		return total, true
+	}
+
+	compact := strings.NewReplacer(" ", "", "hrs", "h", "hr", "h", "mins", "m", "min", "m", "secs", "s", "sec", "s").Replace(strings.ToLower(value))
+	if d, err := time.ParseDuration(compact); err == nil {
+		return d, true
+	}
+	return 0, false
+}
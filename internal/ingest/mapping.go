@@ -0,0 +1,104 @@
+package ingest
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// TriggerRemoteWrite marks results that came from an external prober
+const TriggerRemoteWrite = "remote_write"
+
+// Mapping selects which series become check results. The defaults match
+// blackbox_exporter, whose instance label holds the probed URL.
+type Mapping struct {
+	URLLabel           string // label holding the endpoint URL
+	LatencyMetric      string // latency in seconds
+	AvailabilityMetric string // 1 when the probe succeeded, 0 otherwise
+}
+
+// DefaultMapping returns the blackbox_exporter mapping
+func DefaultMapping() Mapping {
+	return Mapping{
+		URLLabel:           "instance",
+		LatencyMetric:      "probe_duration_seconds",
+		AvailabilityMetric: "probe_success",
+	}
+}
+
+// Results turns the mapped series into check results, one per URL and
+// timestamp. Series that don't match the mapping are ignored.
+func (m Mapping) Results(series []Series) []checker.CheckResult {
+	type key struct {
+		url       string
+		timestamp int64
+	}
+	type point struct {
+		latency, available float64
+		hasLatency         bool
+		hasAvailability    bool
+	}
+
+	points := make(map[key]*point)
+	for _, s := range series {
+		name := s.Name()
+		if name != m.LatencyMetric && name != m.AvailabilityMetric {
+			continue
+		}
+		url := s.Labels[m.URLLabel]
+		if url == "" {
+			continue
+		}
+		if normalized, err := checker.NormalizeURL(url); err == nil {
+			url = normalized
+		}
+
+		for _, sample := range s.Samples {
+			if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+				continue // includes Prometheus staleness markers
+			}
+			k := key{url, sample.Timestamp}
+			p, ok := points[k]
+			if !ok {
+				p = &point{}
+				points[k] = p
+			}
+			if name == m.LatencyMetric {
+				p.latency, p.hasLatency = sample.Value, true
+			} else {
+				p.available, p.hasAvailability = sample.Value, true
+			}
+		}
+	}
+
+	results := make([]checker.CheckResult, 0, len(points))
+	for k, p := range points {
+		result := checker.CheckResult{
+			URL:          k.url,
+			CheckedAt:    time.UnixMilli(k.timestamp).UTC(),
+			ResponseTime: time.Duration(p.latency * float64(time.Second)),
+			IsHealthy:    !p.hasAvailability || p.available > 0,
+			Trigger:      TriggerRemoteWrite,
+		}
+		if !result.IsHealthy {
+			result.Error = "remote probe failed"
+		}
+		checker.AssessQuality(&result)
+		if !p.hasLatency && result.Usable() {
+			// Availability alone says nothing about latency
+			result.Quality = checker.QualitySuspect
+			result.QualityIssue = "no latency reported by remote probe"
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].URL != results[j].URL {
+			return results[i].URL < results[j].URL
+		}
+		return results[i].CheckedAt.Before(results[j].CheckedAt)
+	})
+	return results
+}
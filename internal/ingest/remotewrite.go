@@ -0,0 +1,138 @@
+package ingest
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Sample is one value of a time series
+type Sample struct {
+	Value     float64
+	Timestamp int64 // milliseconds since the epoch
+}
+
+// Series is a labelled time series from a remote-write request
+type Series struct {
+	Labels  map[string]string
+	Samples []Sample
+}
+
+// maxDecodedBytes caps the decompressed size of a remote-write request; the
+// snappy header claims a length that is allocated up front
+const maxDecodedBytes = 32 << 20
+
+// Name returns the metric name of the series
+func (s Series) Name() string {
+	return s.Labels["__name__"]
+}
+
+// DecodeRemoteWrite decodes a snappy-compressed Prometheus remote-write
+// (v1) request. Only the fields needed for ingestion are read; metadata and
+// exemplars are skipped.
+func DecodeRemoteWrite(body []byte) ([]Series, error) {
+	size, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy payload: %w", err)
+	}
+	if size > maxDecodedBytes {
+		return nil, fmt.Errorf("payload decompresses to %d bytes, over the %d byte limit", size, maxDecodedBytes)
+	}
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy payload: %w", err)
+	}
+
+	var series []Series
+	err = eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType { // WriteRequest.timeseries
+			return nil
+		}
+		s, err := decodeSeries(value)
+		if err != nil {
+			return err
+		}
+		series = append(series, s)
+		return nil
+	})
+	return series, err
+}
+
+func decodeSeries(data []byte) (Series, error) {
+	s := Series{Labels: make(map[string]string)}
+	err := eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1: // TimeSeries.labels
+			var name, labelValue string
+			err := eachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ == protowire.BytesType && num == 1 {
+					name = string(value)
+				} else if typ == protowire.BytesType && num == 2 {
+					labelValue = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			s.Labels[name] = labelValue
+		case 2: // TimeSeries.samples
+			var sample Sample
+			err := eachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+				if typ == protowire.Fixed64Type && num == 1 {
+					bits, _ := protowire.ConsumeFixed64(value)
+					sample.Value = math.Float64frombits(bits)
+				} else if typ == protowire.VarintType && num == 2 {
+					timestamp, _ := protowire.ConsumeVarint(value)
+					sample.Timestamp = int64(timestamp)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			s.Samples = append(s.Samples, sample)
+		}
+		return nil
+	})
+	return s, err
+}
+
+// eachField walks the top-level fields of a protobuf message. Scalar values are
+// passed in their raw wire encoding, length-delimited values without the length.
+func eachField(data []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(data)
+			if m < 0 {
+				return fmt.Errorf("invalid protobuf: %w", protowire.ParseError(m))
+			}
+			value, n = v, m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("invalid protobuf: %w", protowire.ParseError(n))
+			}
+			value = data[:n]
+		}
+
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"api-monitor/internal/agents"
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// File is a declarative monitoring configuration, e.g. endpoints.yaml.
+// JSON is accepted too since it is valid YAML.
+type File struct {
+	Endpoints []Endpoint `yaml:"endpoints" json:"endpoints"`
+}
+
+// Endpoint is one monitored endpoint, with the same fields as POST /api/endpoints
+type Endpoint struct {
+	URL         string                       `yaml:"url" json:"url"`
+	Type        string                       `yaml:"type,omitempty" json:"type,omitempty"`
+	Method      string                       `yaml:"method,omitempty" json:"method,omitempty"`
+	Body        string                       `yaml:"body,omitempty" json:"body,omitempty"`
+	ContentType string                       `yaml:"contentType,omitempty" json:"contentType,omitempty"`
+	Head        bool                         `yaml:"head,omitempty" json:"head,omitempty"`
+	Headers     map[string]string            `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Options     map[string]string            `yaml:"options,omitempty" json:"options,omitempty"`
+	Extract     map[string]string            `yaml:"extract,omitempty" json:"extract,omitempty"`
+	Thresholds  map[string]checker.Threshold `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
+	Tags        []string                     `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Cron        string                       `yaml:"cron,omitempty" json:"cron,omitempty"`
+	Timezone    string                       `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	Throughput  bool                         `yaml:"throughput,omitempty" json:"throughput,omitempty"`
+	Sampling    *storage.SamplingPolicy      `yaml:"sampling,omitempty" json:"sampling,omitempty"`
+	Interval    string                       `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout     string                       `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Enabled     *bool                        `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	AIAnalysis  *bool                        `yaml:"aiAnalysis,omitempty" json:"aiAnalysis,omitempty"`
+	EmailRoutes []alerting.EmailRoute        `yaml:"emailRoutes,omitempty" json:"emailRoutes,omitempty"`
+	Recovery    *alerting.RecoveryWebhook    `yaml:"recoveryWebhook,omitempty" json:"recoveryWebhook,omitempty"`
+	Runbook     string                       `yaml:"runbook,omitempty" json:"runbook,omitempty"`
+	Source      string                       `yaml:"source,omitempty" json:"source,omitempty"`
+	DependsOn   []string                     `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+	SLA         *sla.Contract                `yaml:"sla,omitempty" json:"sla,omitempty"`
+	SLO         *sla.Objective               `yaml:"slo,omitempty" json:"slo,omitempty"`
+	Agents      *agents.Placement            `yaml:"agents,omitempty" json:"agents,omitempty"`
+}
+
+// Parse decodes a configuration file, rejecting unknown fields so typos
+// don't silently fall back to defaults
+func Parse(data []byte) (File, error) {
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		if err.Error() == "EOF" {
+			return file, fmt.Errorf("file is empty")
+		}
+		return file, err
+	}
+	return file, nil
+}
+
+// CheckType returns the endpoint's check type, defaulting to HTTP
+func (e Endpoint) CheckType() string {
+	checkType := strings.TrimSpace(e.Type)
+	if checkType == "" {
+		return checker.TypeHTTP
+	}
+	return checkType
+}
+
+// Spec returns the check described by the endpoint
+func (e Endpoint) Spec() checker.CheckSpec {
+	checkType := strings.TrimSpace(e.Type)
+	return checker.CheckSpec{
+		Type:        checkType,
+		URL:         strings.TrimSpace(e.URL),
+		Method:      strings.ToUpper(strings.TrimSpace(e.Method)),
+		Body:        e.Body,
+		ContentType: strings.TrimSpace(e.ContentType),
+		Head:        e.Head,
+		Headers:     e.Headers,
+		Options:     e.Options,
+		Extract:     e.Extract,
+		Thresholds:  e.Thresholds,
+		Source:      strings.TrimSpace(e.Source),
+	}
+}
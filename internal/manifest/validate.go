@@ -0,0 +1,291 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/scheduler"
+)
+
+// Problem severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is something wrong with one endpoint in a configuration file
+type Problem struct {
+	Index    int    `json:"index"` // position in the endpoints list, -1 for the whole file
+	URL      string `json:"url,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String formats the problem for CLI output
+func (p Problem) String() string {
+	if p.Index < 0 {
+		return p.Message
+	}
+	if p.URL == "" {
+		return fmt.Sprintf("endpoints[%d]: %s", p.Index, p.Message)
+	}
+	return fmt.Sprintf("endpoints[%d] %s: %s", p.Index, p.URL, p.Message)
+}
+
+// Report is the outcome of validating a configuration file
+type Report struct {
+	Endpoints int       `json:"endpoints"`
+	Valid     bool      `json:"valid"`
+	Problems  []Problem `json:"problems"`
+}
+
+// Options controls the optional network checks
+type Options struct {
+	// CheckHosts resolves and connects to every host to catch unreachable ones
+	CheckHosts bool
+	Timeout    time.Duration
+
+	// KnownTypes lists the check types the target monitor supports; nil means
+	// every type registered with checker.Register
+	KnownTypes []string
+}
+
+// Validate checks a configuration file for schema errors, duplicate URLs and,
+// optionally, unreachable hosts
+func Validate(ctx context.Context, file File, opts Options) Report {
+	report := Report{Endpoints: len(file.Endpoints), Problems: []Problem{}}
+	if len(file.Endpoints) == 0 {
+		report.add(-1, "", SeverityWarning, "no endpoints defined")
+	}
+
+	knownTypes := opts.KnownTypes
+	if knownTypes == nil {
+		knownTypes = checker.Types()
+	}
+	known := make(map[string]bool)
+	for _, name := range knownTypes {
+		known[name] = true
+	}
+
+	seen := make(map[string]int)
+	var reachable []int
+	canonical := make([]string, len(file.Endpoints))
+	for i, endpoint := range file.Endpoints {
+		spec := endpoint.Spec()
+		if spec.URL == "" {
+			report.add(i, "", SeverityError, "url is required")
+			continue
+		}
+
+		checkType := endpoint.CheckType()
+		if !known[checkType] {
+			report.add(i, spec.URL, SeverityError, fmt.Sprintf("unknown check type %q", checkType))
+			continue
+		}
+
+		key := spec.URL
+		if checkType == checker.TypeHTTP {
+			normalized, err := checker.NormalizeURL(spec.URL)
+			if err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+				continue
+			}
+			key = normalized
+		}
+		canonical[i] = key
+
+		if err := spec.Validate(); err != nil {
+			report.add(i, spec.URL, SeverityError, err.Error())
+		}
+		if _, err := scheduler.ParseCron(endpoint.Cron, endpoint.Timezone); err != nil {
+			report.add(i, spec.URL, SeverityError, err.Error())
+		}
+		if endpoint.Sampling != nil {
+			if err := endpoint.Sampling.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		for _, route := range endpoint.EmailRoutes {
+			if err := route.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		if endpoint.Recovery != nil {
+			if err := endpoint.Recovery.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		if runbook := strings.TrimSpace(endpoint.Runbook); runbook != "" {
+			if err := alerting.ValidateRunbook(runbook); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		if endpoint.SLA != nil {
+			if err := endpoint.SLA.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		if endpoint.SLO != nil {
+			if err := endpoint.SLO.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		if endpoint.Agents != nil {
+			if err := endpoint.Agents.Validate(); err != nil {
+				report.add(i, spec.URL, SeverityError, err.Error())
+			}
+		}
+		for _, field := range [][2]string{{"interval", endpoint.Interval}, {"timeout", endpoint.Timeout}} {
+			if field[1] == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(field[1]); err != nil || d <= 0 {
+				report.add(i, spec.URL, SeverityError, fmt.Sprintf("%s must be a positive duration such as \"30s\"", field[0]))
+			}
+		}
+		if endpoint.Throughput && checkType != checker.TypeHTTP {
+			report.add(i, spec.URL, SeverityError, "throughput is only supported for http checks")
+		}
+		if endpoint.Throughput && endpoint.Head {
+			report.add(i, spec.URL, SeverityError, "throughput checks download the response, they cannot be head checks")
+		}
+
+		if first, ok := seen[checkType+" "+key]; ok {
+			report.add(i, spec.URL, SeverityError, fmt.Sprintf("duplicate of endpoints[%d] (%s)", first, key))
+			continue
+		}
+		seen[checkType+" "+key] = i
+		reachable = append(reachable, i)
+	}
+
+	// Dependencies may be monitored outside this file, so unknown ones only warn
+	defined := make(map[string]bool, len(canonical))
+	for _, key := range canonical {
+		defined[key] = true
+	}
+	for i, endpoint := range file.Endpoints {
+		for _, dep := range endpoint.DependsOn {
+			key := strings.TrimSpace(dep)
+			if normalized, err := checker.NormalizeURL(key); err == nil {
+				key = normalized
+			}
+			switch {
+			case canonical[i] != "" && key == canonical[i]:
+				report.add(i, endpoint.URL, SeverityError, "an endpoint cannot depend on itself")
+			case !defined[key]:
+				report.add(i, endpoint.URL, SeverityWarning, fmt.Sprintf("dependsOn %q is not defined in this file", dep))
+			}
+		}
+	}
+
+	if opts.CheckHosts {
+		report.Problems = append(report.Problems, checkHosts(ctx, file, canonical, reachable, opts.Timeout)...)
+	}
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return report.Problems[i].Index < report.Problems[j].Index
+	})
+
+	report.Valid = true
+	for _, problem := range report.Problems {
+		if problem.Severity == SeverityError {
+			report.Valid = false
+		}
+	}
+	return report
+}
+
+func (r *Report) add(index int, url, severity, message string) {
+	r.Problems = append(r.Problems, Problem{Index: index, URL: url, Severity: severity, Message: message})
+}
+
+// maxHostChecks bounds how many hosts are probed at once
+const maxHostChecks = 20
+
+// checkHosts resolves and dials every distinct host, reporting the ones that
+// can't be reached
+func checkHosts(ctx context.Context, file File, canonical []string, indexes []int, timeout time.Duration) []Problem {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	var (
+		problems []Problem
+		mutex    sync.Mutex
+		wg       sync.WaitGroup
+		slots    = make(chan struct{}, maxHostChecks)
+	)
+	for _, i := range indexes {
+		address, err := dialAddress(canonical[i])
+		if err != nil || address == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			dialCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(dialCtx, "tcp", address)
+			if err != nil {
+				mutex.Lock()
+				problems = append(problems, Problem{
+					Index:    i,
+					URL:      file.Endpoints[i].URL,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("host unreachable: %v", err),
+				})
+				mutex.Unlock()
+				return
+			}
+			conn.Close()
+		}(i, address)
+	}
+	wg.Wait()
+
+	return problems
+}
+
+// dialAddress returns the host:port a check connects to, or "" for check
+// types without a fixed TCP target
+func dialAddress(canonical string) (string, error) {
+	u, err := url.Parse(canonical)
+	if err != nil {
+		return "", err
+	}
+
+	var defaultPort string
+	switch u.Scheme {
+	case "http":
+		defaultPort = "80"
+	case "https":
+		defaultPort = "443"
+	case "grpc", "grpcs":
+		// gRPC targets always name their port
+	default:
+		return "", nil
+	}
+	if u.Hostname() == "" {
+		return "", nil
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	if port == "" {
+		return "", nil
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
@@ -0,0 +1,309 @@
+// Package openapi turns the GET operations of an OpenAPI 3 or Swagger 2
+// specification into URLs that can be monitored
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Operation is a GET operation with every parameter filled in
+type Operation struct {
+	Path        string   `json:"path"` // as written in the spec, e.g. /pets/{petId}
+	URL         string   `json:"url"`
+	OperationID string   `json:"operationId,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Skipped is a GET operation that can't be monitored, with the reason
+type Skipped struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// Spec is what Parse found in a specification
+type Spec struct {
+	Title      string      `json:"title"`
+	Version    string      `json:"version"`
+	BaseURL    string      `json:"baseUrl"`
+	Operations []Operation `json:"operations"`
+	Skipped    []Skipped   `json:"skipped"`
+}
+
+// Options control how operations become URLs
+type Options struct {
+	// BaseURL replaces the servers (OpenAPI 3) or host and basePath
+	// (Swagger 2) of the spec, e.g. to monitor a staging deployment
+	BaseURL string
+
+	// SpecURL is where the spec was downloaded from; relative server URLs
+	// and specs without a host resolve against it
+	SpecURL string
+}
+
+type document struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title   string `yaml:"title"`
+		Version string `yaml:"version"`
+	} `yaml:"info"`
+
+	// OpenAPI 3
+	Servers []struct {
+		URL       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Components struct {
+		Parameters map[string]parameter `yaml:"parameters"`
+	} `yaml:"components"`
+
+	// Swagger 2
+	Host       string               `yaml:"host"`
+	BasePath   string               `yaml:"basePath"`
+	Schemes    []string             `yaml:"schemes"`
+	Parameters map[string]parameter `yaml:"parameters"`
+
+	Paths map[string]pathItem `yaml:"paths"`
+}
+
+type pathItem struct {
+	Parameters []parameter `yaml:"parameters"`
+	Get        *operation  `yaml:"get"`
+}
+
+type operation struct {
+	OperationID string      `yaml:"operationId"`
+	Summary     string      `yaml:"summary"`
+	Tags        []string    `yaml:"tags"`
+	Deprecated  bool        `yaml:"deprecated"`
+	Parameters  []parameter `yaml:"parameters"`
+}
+
+type parameter struct {
+	Ref      string `yaml:"$ref"`
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+
+	// Where example values may be, in order of preference
+	Example  interface{} `yaml:"example"`
+	XExample interface{} `yaml:"x-example"`
+	Examples map[string]struct {
+		Value interface{} `yaml:"value"`
+	} `yaml:"examples"`
+	Schema  *schema       `yaml:"schema"`
+	Default interface{}   `yaml:"default"`
+	Enum    []interface{} `yaml:"enum"`
+}
+
+type schema struct {
+	Example interface{}   `yaml:"example"`
+	Default interface{}   `yaml:"default"`
+	Enum    []interface{} `yaml:"enum"`
+}
+
+// Parse reads a YAML or JSON specification and returns a URL for every GET
+// operation whose required parameters have an example, default or enum
+// value. Deprecated operations and those needing headers or cookies are
+// skipped.
+func Parse(data []byte, opts Options) (Spec, error) {
+	var doc document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return Spec{}, fmt.Errorf("invalid specification: %w", err)
+	}
+	if doc.OpenAPI == "" && doc.Swagger == "" {
+		return Spec{}, fmt.Errorf("not an OpenAPI or Swagger specification (no openapi or swagger version)")
+	}
+
+	base, err := doc.baseURL(opts)
+	if err != nil {
+		return Spec{}, err
+	}
+	spec := Spec{Title: doc.Info.Title, Version: doc.Info.Version, BaseURL: base, Operations: []Operation{}, Skipped: []Skipped{}}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := doc.Paths[path]
+		if item.Get == nil {
+			continue
+		}
+		if item.Get.Deprecated {
+			spec.Skipped = append(spec.Skipped, Skipped{Path: path, Reason: "deprecated"})
+			continue
+		}
+		target, err := doc.fill(path, append(item.Parameters, item.Get.Parameters...))
+		if err != nil {
+			spec.Skipped = append(spec.Skipped, Skipped{Path: path, Reason: err.Error()})
+			continue
+		}
+		spec.Operations = append(spec.Operations, Operation{
+			Path:        path,
+			URL:         base + target,
+			OperationID: item.Get.OperationID,
+			Summary:     item.Get.Summary,
+			Tags:        item.Get.Tags,
+		})
+	}
+	return spec, nil
+}
+
+var serverVariable = regexp.MustCompile(`\{([^}]+)\}`)
+
+// baseURL returns the URL operations' paths are appended to, without a
+// trailing slash
+func (doc document) baseURL(opts Options) (string, error) {
+	var base string
+	switch {
+	case opts.BaseURL != "":
+		base = opts.BaseURL
+	case doc.OpenAPI != "" && len(doc.Servers) > 0:
+		server := doc.Servers[0]
+		base = serverVariable.ReplaceAllStringFunc(server.URL, func(match string) string {
+			return server.Variables[match[1:len(match)-1]].Default
+		})
+	case doc.Swagger != "" && doc.Host != "":
+		scheme := "https"
+		if len(doc.Schemes) > 0 && !contains(doc.Schemes, "https") {
+			scheme = doc.Schemes[0]
+		}
+		base = scheme + "://" + doc.Host + doc.BasePath
+	case doc.Swagger != "":
+		base = doc.BasePath
+	}
+
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", base, err)
+	}
+	if !parsed.IsAbs() {
+		if opts.SpecURL == "" {
+			return "", fmt.Errorf("the specification has no absolute server URL, give a base URL")
+		}
+		specURL, err := url.Parse(opts.SpecURL)
+		if err != nil {
+			return "", err
+		}
+		if parsed.Path == "" {
+			// Without servers the API lives at the root of the spec's host
+			parsed.Path = "/"
+		}
+		parsed = specURL.ResolveReference(parsed)
+	}
+	return strings.TrimSuffix(parsed.String(), "/"), nil
+}
+
+// fill substitutes the path parameters of path and appends required query
+// parameters
+func (doc document) fill(path string, params []parameter) (string, error) {
+	query := url.Values{}
+	for _, param := range params {
+		if param.Ref != "" {
+			resolved, ok := doc.lookup(param.Ref)
+			if !ok {
+				return "", fmt.Errorf("unresolved parameter %s", param.Ref)
+			}
+			param = resolved
+		}
+
+		switch param.In {
+		case "path":
+			value, ok := param.value()
+			if !ok {
+				return "", fmt.Errorf("path parameter %q has no example", param.Name)
+			}
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(value))
+		case "query":
+			if !param.Required {
+				continue
+			}
+			value, ok := param.value()
+			if !ok {
+				return "", fmt.Errorf("required query parameter %q has no example", param.Name)
+			}
+			query.Set(param.Name, value)
+		case "header", "cookie":
+			if param.Required {
+				return "", fmt.Errorf("requires %s %q", param.In, param.Name)
+			}
+		}
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("undeclared path parameter in %s", path)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+// lookup resolves a local parameter reference of either spec version
+func (doc document) lookup(ref string) (parameter, bool) {
+	for prefix, params := range map[string]map[string]parameter{
+		"#/components/parameters/": doc.Components.Parameters,
+		"#/parameters/":            doc.Parameters,
+	} {
+		if name, ok := strings.CutPrefix(ref, prefix); ok {
+			param, exists := params[name]
+			return param, exists
+		}
+	}
+	return parameter{}, false
+}
+
+// value returns the parameter's example value, or failing that its default
+// or first enum value
+func (p parameter) value() (string, bool) {
+	candidates := []interface{}{p.Example, p.XExample}
+	names := make([]string, 0, len(p.Examples))
+	for name := range p.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		candidates = append(candidates, p.Examples[name].Value)
+	}
+	if p.Schema != nil {
+		candidates = append(candidates, p.Schema.Example, p.Schema.Default, first(p.Schema.Enum))
+	}
+	candidates = append(candidates, p.Default, first(p.Enum))
+
+	for _, candidate := range candidates {
+		switch v := candidate.(type) {
+		case nil, map[string]interface{}, []interface{}:
+			continue
+		default:
+			return fmt.Sprint(v), true
+		}
+	}
+	return "", false
+}
+
+func first(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
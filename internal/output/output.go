@@ -0,0 +1,140 @@
+// Package output prints CLI messages and log lines in the configured
+// OUTPUT_STYLE: "emoji" (the default) or "plain", which replaces emoji with
+// ASCII for terminals and log aggregators that can't display them
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Output styles
+const (
+	StyleEmoji = "emoji"
+	StylePlain = "plain"
+)
+
+var plain atomic.Bool
+
+func init() {
+	SetStyle(os.Getenv("OUTPUT_STYLE"))
+}
+
+// SetStyle switches the style; anything but "plain" keeps emoji
+func SetStyle(style string) {
+	plain.Store(strings.EqualFold(strings.TrimSpace(style), StylePlain))
+}
+
+// Plain reports whether emoji are replaced
+func Plain() bool {
+	return plain.Load()
+}
+
+// Status emoji become ASCII tags; other emoji are dropped
+var tags = strings.NewReplacer(
+	"✅", "[OK]",
+	"❌", "[ERROR]",
+	"⚠️", "[WARN]",
+	"⚠", "[WARN]",
+	"🚨", "[ALERT]",
+)
+
+// Text returns s in the configured style
+func Text(s string) string {
+	if !Plain() || isASCII(s) {
+		return s
+	}
+	s = tags.Replace(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	skipSpace := false
+	for _, r := range s {
+		if isEmoji(r) {
+			// Drop the space that separated a decorative emoji from the text
+			skipSpace = true
+			continue
+		}
+		if skipSpace {
+			skipSpace = false
+			if r == ' ' {
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Printf is fmt.Printf in the configured style
+func Printf(format string, args ...interface{}) {
+	fmt.Fprint(os.Stdout, Text(fmt.Sprintf(format, args...)))
+}
+
+// Println is fmt.Println in the configured style
+func Println(args ...interface{}) {
+	fmt.Fprint(os.Stdout, Text(fmt.Sprintln(args...)))
+}
+
+// Print is fmt.Print in the configured style
+func Print(args ...interface{}) {
+	fmt.Fprint(os.Stdout, Text(fmt.Sprint(args...)))
+}
+
+// Fprintf is fmt.Fprintf in the configured style
+func Fprintf(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprint(w, Text(fmt.Sprintf(format, args...)))
+}
+
+// Fprintln is fmt.Fprintln in the configured style
+func Fprintln(w io.Writer, args ...interface{}) {
+	fmt.Fprint(w, Text(fmt.Sprintln(args...)))
+}
+
+// Writer wraps w so everything written to it follows the style, e.g. for
+// log.SetOutput. The log package writes each line in one call.
+func Writer(w io.Writer) io.Writer {
+	return styleWriter{w}
+}
+
+type styleWriter struct {
+	w io.Writer
+}
+
+func (sw styleWriter) Write(p []byte) (int, error) {
+	if !Plain() {
+		return sw.w.Write(p)
+	}
+	if _, err := io.WriteString(sw.w, Text(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmoji reports whether r is an emoji or part of an emoji sequence
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, ...
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // miscellaneous symbols and dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // arrows and stars
+		return true
+	case r == 0x3030, r == 0xFE0F, r == 0x200D: // wavy dash, variation selector, joiner
+		return true
+	}
+	return false
+}
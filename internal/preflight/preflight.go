@@ -0,0 +1,302 @@
+package preflight
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/config"
+	"api-monitor/internal/stats"
+	"api-monitor/internal/storage"
+
+	_ "github.com/lib/pq"
+)
+
+// Check outcomes
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Check is a single line of the readiness report
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Report is the outcome of all startup checks
+type Report struct {
+	Checks []Check `json:"checks"`
+}
+
+// Ready reports whether no check failed
+func (r *Report) Ready() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(name, status, detail string, args ...interface{}) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: fmt.Sprintf(detail, args...)})
+}
+
+// Run validates the configuration and probes its external dependencies
+func Run(ctx context.Context, cfg *config.Config, timeout time.Duration) *Report {
+	report := &Report{}
+
+	validateSettings(report, cfg)
+	checkDatabase(ctx, report, cfg, timeout)
+	checkAI(ctx, report, cfg, timeout)
+	checkSlackWebhook(report, cfg)
+	checkSMTP(ctx, report, cfg, timeout)
+	checkWebhooks(report, cfg)
+	checkPort(report, "web port", cfg.WebPort)
+	checkFiles(report, cfg)
+
+	return report
+}
+
+// validateSettings checks values that don't need any network access
+func validateSettings(report *Report, cfg *config.Config) {
+	for _, loadErr := range cfg.LoadErrors {
+		report.add("configuration", StatusFail, "%s", loadErr)
+	}
+
+	if cfg.CheckInterval <= 0 || cfg.RequestTimeout <= 0 {
+		report.add("intervals", StatusFail, "CHECK_INTERVAL and REQUEST_TIMEOUT must be positive")
+	} else if cfg.RequestTimeout >= cfg.CheckInterval {
+		report.add("intervals", StatusWarn, "REQUEST_TIMEOUT (%v) is not shorter than CHECK_INTERVAL (%v)", cfg.RequestTimeout, cfg.CheckInterval)
+	} else {
+		report.add("intervals", StatusOK, "checking every %v with a %v timeout", cfg.CheckInterval, cfg.RequestTimeout)
+	}
+
+	if cfg.Profile != "" {
+		report.add("profile", StatusOK, "%s deployment profile: %d concurrent checks, %s sampling, raw results kept %d days",
+			cfg.Profile, cfg.MaxConcurrency, cfg.SamplingMode, cfg.RetentionRawDays)
+	}
+	
+	if cfg.MaxConcurrency < 1 {
+		report.add("concurrency", StatusFail, "MAX_CONCURRENCY must be at least 1")
+	}
+
+	if cfg.LatencyMetric != checker.LatencyTotal && cfg.LatencyMetric != checker.LatencyTTFB {
+		report.add("latency metric", StatusFail, "LATENCY_METRIC must be %q or %q, got %q", checker.LatencyTotal, checker.LatencyTTFB, cfg.LatencyMetric)
+	}
+
+	if err := stats.ValidateMode(cfg.StatsMode); err != nil {
+		report.add("statistics", StatusFail, "STATS_MODE: %v", err)
+	} else if cfg.StatsTrimPercent < 0 || cfg.StatsTrimPercent >= 50 {
+		report.add("statistics", StatusFail, "STATS_TRIM_PERCENT must be between 0 and 49, got %v", cfg.StatsTrimPercent)
+	}
+
+	policy := storage.SamplingPolicy{Mode: cfg.SamplingMode, Every: cfg.SamplingEvery}
+	if err := policy.Validate(); err != nil {
+		report.add("sampling", StatusFail, "%v", err)
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		report.add("tls", StatusFail, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if cfg.AuthEnabled && cfg.AuthPassword == "" {
+		report.add("auth", StatusFail, "AUTH_ENABLED is set but AUTH_PASSWORD is empty")
+	} else if !cfg.AuthEnabled {
+		report.add("auth", StatusWarn, "dashboard and API are unauthenticated")
+	} else {
+		report.add("auth", StatusOK, "dashboard login enabled for %s", cfg.AuthUsername)
+	}
+
+	for _, tag := range cfg.PausedTags {
+		if strings.TrimSpace(tag) == "" {
+			report.add("paused tags", StatusFail, "PAUSED_TAGS contains an empty tag")
+		}
+	}
+}
+
+func checkDatabase(ctx context.Context, report *Report, cfg *config.Config, timeout time.Duration) {
+	db, err := sql.Open(cfg.DatabaseDriver, cfg.DatabaseURL)
+	if err != nil {
+		report.add("database", StatusFail, "invalid %s DATABASE_URL: %v", cfg.DatabaseDriver, err)
+		return
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		report.add("database", StatusFail, "not reachable: %v", err)
+		return
+	}
+	report.add("database", StatusOK, "reachable")
+}
+
+func checkAI(ctx context.Context, report *Report, cfg *config.Config, timeout time.Duration) {
+	if !cfg.AIEnabled {
+		report.add("ai", StatusOK, "disabled, rule-based insights will be used")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(cfg.AIBaseURL, "/")+"/v1/models", nil)
+	if err != nil {
+		report.add("ai", StatusFail, "invalid AI_BASE_URL: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.AIAPIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		report.add("ai", StatusWarn, "%s not reachable, insights will fall back to rules: %v", cfg.AIBaseURL, err)
+		return
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		report.add("ai", StatusFail, "%s rejected AI_API_KEY (HTTP %d)", cfg.AIBaseURL, resp.StatusCode)
+	case resp.StatusCode >= 500:
+		report.add("ai", StatusWarn, "%s returned HTTP %d", cfg.AIBaseURL, resp.StatusCode)
+	default:
+		report.add("ai", StatusOK, "%s reachable (model %s)", cfg.AIBaseURL, cfg.AIModel)
+	}
+}
+
+func checkSlackWebhook(report *Report, cfg *config.Config) {
+	if cfg.SlackWebhook == "" {
+		if cfg.AlertingEnabled && cfg.EmailFrom == "" && cfg.PagerDutyRoutingKey == "" && cfg.OpsgenieAPIKey == "" && len(cfg.Webhooks) == 0 {
+			report.add("slack webhook", StatusWarn, "ALERTING_ENABLED is set but no alert channel (Slack, email, PagerDuty, Opsgenie, webhooks) is configured")
+		}
+		return
+	}
+
+	parsed, err := url.Parse(cfg.SlackWebhook)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "hooks.slack.com" || !strings.HasPrefix(parsed.Path, "/services/") {
+		report.add("slack webhook", StatusFail, "SLACK_WEBHOOK must look like https://hooks.slack.com/services/...")
+		return
+	}
+	report.add("slack webhook", StatusOK, "valid format")
+}
+
+func checkSMTP(ctx context.Context, report *Report, cfg *config.Config, timeout time.Duration) {
+	if !cfg.AlertingEnabled || cfg.EmailFrom == "" {
+		return
+	}
+	if _, err := mail.ParseAddress(cfg.EmailFrom); err != nil {
+		report.add("smtp", StatusFail, "EMAIL_FROM %q is not an email address", cfg.EmailFrom)
+		return
+	}
+	if len(cfg.EmailTo) == 0 {
+		report.add("smtp", StatusWarn, "EMAIL_TO is empty, only endpoints with email routes are mailed")
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.EmailSMTPHost, strconv.Itoa(cfg.EmailSMTPPort)))
+	if err != nil {
+		report.add("smtp", StatusFail, "%s:%d not reachable: %v", cfg.EmailSMTPHost, cfg.EmailSMTPPort, err)
+		return
+	}
+	conn.Close()
+	report.add("smtp", StatusOK, "%s:%d reachable", cfg.EmailSMTPHost, cfg.EmailSMTPPort)
+}
+
+// checkWebhooks renders the Slack template and every webhook template with
+// a sample alert
+func checkWebhooks(report *Report, cfg *config.Config) {
+	sample := alerting.Alert{
+		Kind:       alerting.KindHealth,
+		State:      alerting.StateFiring,
+		Severity:   alerting.SeverityCritical,
+		EndpointID: "preflight",
+		URL:        "https://example.com/health",
+		Message:    "endpoint is down: status 503",
+		StartedAt:  time.Now(),
+		At:         time.Now(),
+		Result:     &checker.CheckResult{URL: "https://example.com/health", StatusCode: 503, CheckedAt: time.Now()},
+	}
+	context := func(alert alerting.Alert) alerting.TemplateData {
+		return alerting.TemplateData{
+			Alert:    alert,
+			Endpoint: alerting.TemplateEndpoint{ID: alert.EndpointID, URL: alert.URL, Tags: []string{"preflight"}},
+			Stats:    &alerting.TemplateStats{Checks: 100, Failures: 1, UptimePercent: 99},
+			Runbook:  "https://example.com/runbooks/health",
+		}
+	}
+	if cfg.SlackWebhook != "" && cfg.SlackTemplate != "" {
+		slack := alerting.NewSlackNotifier(cfg.SlackWebhook)
+		if err := slack.SetTemplate(cfg.SlackTemplate, context); err != nil {
+			report.add("slack template", StatusFail, "%v", err)
+		} else if _, err := slack.Render(sample); err != nil {
+			report.add("slack template", StatusFail, "template fails on a sample alert: %v", err)
+		} else {
+			report.add("slack template", StatusOK, "template renders valid JSON")
+		}
+	}
+	for _, webhook := range cfg.Webhooks {
+		name := "webhook " + webhook.Name
+		notifier, err := alerting.NewWebhookNotifier(webhook.Name, webhook.URL, webhook.Secret, webhook.Template)
+		if err != nil {
+			report.add(name, StatusFail, "%v", err)
+			continue
+		}
+		notifier.SetTemplateContext(context)
+		if _, err := notifier.Render(sample); err != nil {
+			report.add(name, StatusFail, "template fails on a sample alert: %v", err)
+			continue
+		}
+		if webhook.Secret == "" {
+			report.add(name, StatusWarn, "requests are unsigned, set WEBHOOK_SECRET_%s", strings.ToUpper(strings.ReplaceAll(webhook.Name, "-", "_")))
+			continue
+		}
+		report.add(name, StatusOK, "template renders valid JSON, requests are signed")
+	}
+}
+
+func checkPort(report *Report, name string, port int) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		report.add(name, StatusFail, "port %d is not available: %v", port, err)
+		return
+	}
+	listener.Close()
+	report.add(name, StatusOK, "port %d is free", port)
+}
+
+func checkFiles(report *Report, cfg *config.Config) {
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		if _, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			report.add("tls certificate", StatusFail, "%v", err)
+		} else {
+			report.add("tls certificate", StatusOK, "loaded %s", cfg.TLSCertFile)
+		}
+	}
+
+	for name, path := range map[string]string{"geoip country db": cfg.GeoIPCountryDB, "geoip asn db": cfg.GeoIPASNDB} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			report.add(name, StatusFail, "%v", err)
+		} else {
+			report.add(name, StatusOK, "found %s", path)
+		}
+	}
+}
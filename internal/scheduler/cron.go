@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser accepts standard 5-field expressions and descriptors like @hourly
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseCron validates a cron expression in the given timezone. It returns a
+// nil schedule when expr is empty.
+func ParseCron(expr, timezone string) (cron.Schedule, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		location = loc
+	}
+
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	// Evaluate the expression in the endpoint's timezone
+	if spec, ok := schedule.(*cron.SpecSchedule); ok {
+		spec.Location = location
+	}
+	return schedule, nil
+}
+
+// NextRun returns when a cron endpoint will next be checked
+func (s *Scheduler) NextRun(id string) (time.Time, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	e, exists := s.endpoints[id]
+	if !exists || e.schedule == nil {
+		return time.Time{}, false
+	}
+	return e.schedule.Next(time.Now()), true
+}
+
+// runCron checks an endpoint at the times given by its cron schedule
+func (s *Scheduler) runCron(e *entry) {
+	for {
+		next := e.schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+			s.execute(e.endpoint, TriggerCron, next)
+		case <-e.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
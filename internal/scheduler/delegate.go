@@ -0,0 +1,42 @@
+package scheduler
+
+import "api-monitor/internal/checker"
+
+// DelegateFunc reports whether an endpoint's scheduled checks currently run
+// elsewhere, e.g. on a remote agent
+type DelegateFunc func(endpoint Endpoint) bool
+
+// SetDelegate makes the scheduler skip the interval and cron checks of the
+// endpoints delegate claims; their results arrive through Record instead.
+// One-off and manual checks still run here.
+func (s *Scheduler) SetDelegate(delegate DelegateFunc) {
+	s.mutex.Lock()
+	s.delegate = delegate
+	s.mutex.Unlock()
+}
+
+// delegated reports whether an endpoint's scheduled checks run elsewhere
+func (s *Scheduler) delegated(endpoint Endpoint) bool {
+	s.mutex.RLock()
+	delegate := s.delegate
+	s.mutex.RUnlock()
+	return delegate != nil && delegate(endpoint)
+}
+
+// Record handles a result checked outside the scheduler, e.g. by an agent,
+// like one of its own: handlers see it and it becomes the latest result.
+// It reports false for endpoints that aren't registered.
+func (s *Scheduler) Record(endpointID string, result checker.CheckResult) bool {
+	s.mutex.RLock()
+	e, exists := s.endpoints[endpointID]
+	s.mutex.RUnlock()
+	if !exists {
+		return false
+	}
+
+	if result.Trigger == "" {
+		result.Trigger = TriggerInterval
+	}
+	s.handle(e.endpoint, &result)
+	return true
+}
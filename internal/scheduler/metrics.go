@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// metricsWindow is how many recent samples lag and duration statistics cover
+const metricsWindow = 512
+
+// Metrics describes how well the scheduler is keeping up with its workload
+type Metrics struct {
+	Endpoints      int   `json:"endpoints"`
+	InFlight       int   `json:"inFlight"`       // checks currently executing
+	PendingOneOffs int   `json:"pendingOneOffs"` // one-off checks waiting for their time
+	ChecksTotal    int64 `json:"checksTotal"`
+	SkippedTotal   int64 `json:"skippedTotal"` // checks skipped while paused
+
+	// Lag is how late checks start relative to when they were due
+	LagAvg time.Duration `json:"lagAvg"`
+	LagP95 time.Duration `json:"lagP95"`
+	LagMax time.Duration `json:"lagMax"`
+
+	// Duration is how long a check takes including result handlers
+	DurationAvg time.Duration `json:"durationAvg"`
+	DurationP95 time.Duration `json:"durationP95"`
+	DurationMax time.Duration `json:"durationMax"`
+
+	// OldestInFlight is how long the longest-running check in progress has
+	// been executing; a check far beyond any timeout is stuck
+	OldestInFlight time.Duration `json:"oldestInFlight"`
+}
+
+// metrics accumulates scheduler self-metrics over a sliding window
+type metrics struct {
+	mutex     sync.Mutex
+	inFlight  int
+	started   map[int64]time.Time // start of each check in progress, by sequence number
+	checks    int64
+	skipped   int64
+	lags      []time.Duration
+	durations []time.Duration
+}
+
+func newMetrics() *metrics {
+	return &metrics{started: make(map[int64]time.Time)}
+}
+
+// begin records a check starting now and returns its sequence number for end
+func (m *metrics) begin(lag time.Duration) int64 {
+	if lag < 0 {
+		lag = 0
+	}
+	m.mutex.Lock()
+	m.inFlight++
+	m.checks++
+	m.started[m.checks] = time.Now()
+	m.lags = appendWindow(m.lags, lag)
+	m.mutex.Unlock()
+	return m.checks
+}
+
+func (m *metrics) end(sequence int64, duration time.Duration) {
+	m.mutex.Lock()
+	m.inFlight--
+	delete(m.started, sequence)
+	m.durations = appendWindow(m.durations, duration)
+	m.mutex.Unlock()
+}
+
+func (m *metrics) recordSkip() {
+	m.mutex.Lock()
+	m.skipped++
+	m.mutex.Unlock()
+}
+
+// Metrics returns a snapshot of the scheduler's self-metrics
+func (s *Scheduler) Metrics() Metrics {
+	s.mutex.RLock()
+	snapshot := Metrics{Endpoints: len(s.endpoints)}
+	for _, scheduled := range s.oneOffs {
+		if scheduled.Status == "pending" {
+			snapshot.PendingOneOffs++
+		}
+	}
+	s.mutex.RUnlock()
+
+	m := s.metrics
+	m.mutex.Lock()
+	snapshot.InFlight = m.inFlight
+	snapshot.ChecksTotal = m.checks
+	snapshot.SkippedTotal = m.skipped
+	snapshot.LagAvg, snapshot.LagP95, snapshot.LagMax = summarize(m.lags)
+	snapshot.DurationAvg, snapshot.DurationP95, snapshot.DurationMax = summarize(m.durations)
+	for _, started := range m.started {
+		snapshot.OldestInFlight = max(snapshot.OldestInFlight, time.Since(started))
+	}
+	m.mutex.Unlock()
+
+	return snapshot
+}
+
+func appendWindow(samples []time.Duration, sample time.Duration) []time.Duration {
+	samples = append(samples, sample)
+	if len(samples) > metricsWindow {
+		samples = samples[len(samples)-metricsWindow:]
+	}
+	return samples
+}
+
+// summarize returns the average, 95th percentile and maximum of the samples
+func summarize(samples []time.Duration) (avg, p95, max time.Duration) {
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, sample := range sorted {
+		total += sample
+	}
+
+	avg = total / time.Duration(len(sorted))
+	p95 = sorted[(len(sorted)*95)/100]
+	max = sorted[len(sorted)-1]
+	return avg, p95, max
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"log"
+	"sort"
+)
+
+// SetPaused pauses or resumes all scheduled checks
+func (s *Scheduler) SetPaused(paused bool) {
+	s.mutex.Lock()
+	s.paused = paused
+	s.mutex.Unlock()
+
+	log.Printf("Monitoring %s fleet-wide", pauseVerb(paused))
+}
+
+// SetTagPaused pauses or resumes scheduled checks of endpoints carrying a tag
+func (s *Scheduler) SetTagPaused(tag string, paused bool) {
+	s.mutex.Lock()
+	if paused {
+		s.pausedTags[tag] = true
+	} else {
+		delete(s.pausedTags, tag)
+	}
+	s.mutex.Unlock()
+
+	log.Printf("Monitoring %s for tag %q", pauseVerb(paused), tag)
+}
+
+// Paused reports whether monitoring is paused fleet-wide
+func (s *Scheduler) Paused() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.paused
+}
+
+// PausedTags returns the currently paused tags in sorted order
+func (s *Scheduler) PausedTags() []string {
+	s.mutex.RLock()
+	tags := make([]string, 0, len(s.pausedTags))
+	for tag := range s.pausedTags {
+		tags = append(tags, tag)
+	}
+	s.mutex.RUnlock()
+
+	sort.Strings(tags)
+	return tags
+}
+
+// IsPaused reports whether an endpoint's scheduled checks are currently
+// skipped, because it is disabled or paused fleet-wide or by tag
+func (s *Scheduler) IsPaused(endpoint Endpoint) bool {
+	if endpoint.Disabled {
+		return true
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.paused {
+		return true
+	}
+	for _, tag := range endpoint.Tags {
+		if s.pausedTags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+func pauseVerb(paused bool) string {
+	if paused {
+		return "paused"
+	}
+	return "resumed"
+}
@@ -0,0 +1,535 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"api-monitor/internal/agents"
+	"api-monitor/internal/alerting"
+	"api-monitor/internal/checker"
+	"api-monitor/internal/sla"
+	"api-monitor/internal/storage"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Triggers recorded on check results
+const (
+	TriggerInterval = "interval"
+	TriggerOneOff   = "one-off"
+	TriggerManual   = "manual"
+	TriggerCron     = "cron"
+)
+
+// Endpoint is a monitored endpoint as seen by the scheduler
+type Endpoint struct {
+	ID         string        `json:"id"`
+	URL        string        `json:"url"`
+	Interval   time.Duration `json:"interval"`
+	Throughput bool          `json:"throughput,omitempty"`
+	Tags       []string      `json:"tags,omitempty"`
+
+	// Request overrides for write-path endpoints (a plain GET when empty)
+	Method      string `json:"method,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+
+	// Head checks availability with HEAD, falling back to GET
+	Head bool `json:"head,omitempty"`
+
+	// Type selects a registered custom checker instead of HTTP, with its Options
+	Type    string            `json:"type,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+
+	// Metrics extracted from JSON responses and their bounds
+	Extract    map[string]string            `json:"extract,omitempty"`
+	Thresholds map[string]checker.Threshold `json:"thresholds,omitempty"`
+
+	// Sampling controls how many results are persisted (nil uses the global default)
+	Sampling *storage.SamplingPolicy `json:"sampling,omitempty"`
+
+	// Cron replaces Interval when set, e.g. "*/5 9-17 * * MON-FRI".
+	// Timezone is an IANA name used to evaluate it (UTC when empty).
+	Cron     string `json:"cron,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+
+	// Timeout overrides the checker's timeout when positive
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Source is the local IP or interface checks leave from (any when empty)
+	Source string `json:"source,omitempty"`
+
+	// DependsOn lists the IDs of endpoints this one needs to work, for the
+	// service map and root cause highlighting
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// SLA is the availability contract customer statements are rendered for
+	SLA *sla.Contract `json:"sla,omitempty"`
+
+	// SLO is the internal availability objective whose error budget burn
+	// rate alerts watch
+	SLO *sla.Objective `json:"slo,omitempty"`
+
+	// Agents is how the endpoint is assigned to remote agents, round-robin
+	// when unset
+	Agents *agents.Placement `json:"agents,omitempty"`
+
+	// Disabled endpoints stay registered but are not checked on schedule
+	Disabled bool `json:"disabled,omitempty"`
+
+	// AIExcluded keeps the endpoint out of AI prompts to save tokens
+	AIExcluded bool `json:"aiExcluded,omitempty"`
+
+	// EmailRoutes picks who is mailed about this endpoint's alerts
+	// (EMAIL_TO when empty)
+	EmailRoutes []alerting.EmailRoute `json:"emailRoutes,omitempty"`
+
+	// RecoveryWebhook receives a summary of each outage once the endpoint
+	// recovers
+	RecoveryWebhook *alerting.RecoveryWebhook `json:"recoveryWebhook,omitempty"`
+
+	// Runbook links the instructions for handling the endpoint's alerts,
+	// available to message templates
+	Runbook string `json:"runbook,omitempty"`
+
+	// Managed endpoints are defined by a declarative endpoints file and
+	// follow its changes, including removal
+	Managed bool `json:"managed,omitempty"`
+}
+
+// Realtime reports whether the endpoint is checked more than once a second.
+// Its stored results are coalesced, see storage.Coalescer.
+func (e Endpoint) Realtime() bool {
+	return e.Cron == "" && e.Interval > 0 && e.Interval < time.Second
+}
+
+// StaleAt returns when an endpoint last checked at last has gone intervals
+// scheduled checks without a result, e.g. because its worker crashed. It is
+// false for endpoints without a usable schedule.
+func (e Endpoint) StaleAt(last time.Time, intervals int) (time.Time, bool) {
+	if intervals < 1 || last.IsZero() {
+		return time.Time{}, false
+	}
+	if e.Cron == "" {
+		if e.Interval <= 0 {
+			return time.Time{}, false
+		}
+		return last.Add(time.Duration(intervals) * e.Interval), true
+	}
+	schedule, err := ParseCron(e.Cron, e.Timezone)
+	if err != nil || schedule == nil {
+		return time.Time{}, false
+	}
+	due := last
+	for i := 0; i < intervals; i++ {
+		if due = schedule.Next(due); due.IsZero() {
+			return time.Time{}, false
+		}
+	}
+	return due, true
+}
+
+// Spec returns the request the checker should send for this endpoint
+func (e Endpoint) Spec() checker.CheckSpec {
+	return checker.CheckSpec{
+		Type:        e.Type,
+		URL:         e.URL,
+		Method:      e.Method,
+		Body:        e.Body,
+		ContentType: e.ContentType,
+		Head:        e.Head,
+		Headers:     e.Headers,
+		Options:     e.Options,
+		Extract:     e.Extract,
+		Thresholds:  e.Thresholds,
+		Timeout:     e.Timeout,
+		Source:      e.Source,
+	}
+}
+
+// CheckFunc performs a single check of an endpoint
+type CheckFunc func(endpoint Endpoint) checker.CheckResult
+
+// ResultHandler receives every completed check, regular or one-off. Handlers
+// run in registration order and may enrich the result before later handlers see it.
+type ResultHandler func(endpoint Endpoint, result *checker.CheckResult)
+
+// ScheduledCheck is a single extra check requested for a specific time
+type ScheduledCheck struct {
+	ID         string               `json:"id"`
+	EndpointID string               `json:"endpointId"`
+	At         time.Time            `json:"at"`
+	Status     string               `json:"status"` // "pending", "done", "skipped" or "cancelled"
+	Result     *checker.CheckResult `json:"result,omitempty"`
+	timer      *time.Timer
+	finishedAt time.Time // when it stopped being pending
+}
+
+// oneOffRetention is how long finished one-off checks stay listed
+const oneOffRetention = 24 * time.Hour
+
+// Scheduler runs periodic checks for each endpoint plus any one-off checks
+type Scheduler struct {
+	check     CheckFunc
+	handlers  []ResultHandler
+	endpoints map[string]*entry
+	oneOffs   map[string]*ScheduledCheck
+	latest    map[string]checker.CheckResult
+	mutex     sync.RWMutex
+	nextID    int
+
+	// Paused checks are skipped entirely, either fleet-wide or per tag
+	paused     bool
+	pausedTags map[string]bool
+
+	// delegate claims endpoints whose scheduled checks run elsewhere
+	delegate DelegateFunc
+
+	metrics *metrics
+
+	// running counts checks in progress; none start once stopped
+	running sync.WaitGroup
+	stopped bool
+}
+
+type entry struct {
+	endpoint Endpoint
+	schedule cron.Schedule // nil for interval-based endpoints
+	stop     chan struct{}
+}
+
+// New creates a scheduler that uses check to perform checks
+func New(check CheckFunc) *Scheduler {
+	return &Scheduler{
+		check:     check,
+		endpoints: make(map[string]*entry),
+		oneOffs:   make(map[string]*ScheduledCheck),
+		latest:     make(map[string]checker.CheckResult),
+		pausedTags: make(map[string]bool),
+		metrics:    newMetrics(),
+	}
+}
+
+// EndpointID derives a stable identifier from an endpoint URL
+func EndpointID(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:6])
+}
+
+// OnResult registers a handler for completed checks. Handlers must be
+// registered before endpoints are added.
+func (s *Scheduler) OnResult(handler ResultHandler) {
+	s.handlers = append(s.handlers, handler)
+}
+
+// Add starts monitoring an endpoint. The first check runs immediately.
+func (s *Scheduler) Add(endpoint Endpoint) error {
+	e, err := newEntry(endpoint)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.endpoints[e.endpoint.ID]; exists {
+		return fmt.Errorf("endpoint %s already scheduled", e.endpoint.ID)
+	}
+	s.start(e)
+	return nil
+}
+
+// Replace swaps the definition of a monitored endpoint, or starts monitoring
+// it, and runs its first check immediately like Add. Unlike Remove and Add,
+// the endpoint's latest result and pending one-off checks are kept; the
+// one-offs run with the new definition.
+func (s *Scheduler) Replace(endpoint Endpoint) error {
+	e, err := newEntry(endpoint)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if previous, exists := s.endpoints[e.endpoint.ID]; exists {
+		close(previous.stop)
+	}
+	s.start(e)
+	return nil
+}
+
+// newEntry validates an endpoint's schedule and prepares its entry
+func newEntry(endpoint Endpoint) (*entry, error) {
+	if endpoint.ID == "" {
+		endpoint.ID = EndpointID(endpoint.URL)
+	}
+	schedule, err := ParseCron(endpoint.Cron, endpoint.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil && endpoint.Interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	return &entry{endpoint: endpoint, schedule: schedule, stop: make(chan struct{})}, nil
+}
+
+// start registers an entry and runs its checks until it is stopped. The
+// caller holds the lock.
+func (s *Scheduler) start(e *entry) {
+	s.endpoints[e.endpoint.ID] = e
+	if e.schedule != nil {
+		go s.runCron(e)
+	} else {
+		go s.run(e)
+	}
+}
+
+// Remove stops monitoring an endpoint and cancels its pending one-off checks
+func (s *Scheduler) Remove(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, exists := s.endpoints[id]
+	if !exists {
+		return false
+	}
+	close(e.stop)
+	delete(s.endpoints, id)
+	delete(s.latest, id)
+
+	now := time.Now()
+	for _, scheduled := range s.oneOffs {
+		if scheduled.EndpointID == id && scheduled.Status == "pending" {
+			scheduled.timer.Stop()
+			scheduled.Status = "cancelled"
+			scheduled.finishedAt = now
+		}
+	}
+	return true
+}
+
+// Get returns an endpoint by ID
+func (s *Scheduler) Get(id string) (Endpoint, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	e, exists := s.endpoints[id]
+	if !exists {
+		return Endpoint{}, false
+	}
+	return e.endpoint, true
+}
+
+// List returns all scheduled endpoints ordered by URL
+func (s *Scheduler) List() []Endpoint {
+	s.mutex.RLock()
+	endpoints := make([]Endpoint, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		endpoints = append(endpoints, e.endpoint)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+	return endpoints
+}
+
+// Latest returns the most recent result for an endpoint
+func (s *Scheduler) Latest(id string) (checker.CheckResult, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result, exists := s.latest[id]
+	return result, exists
+}
+
+// ScheduleAt queues a single extra check of an endpoint at the given time
+func (s *Scheduler) ScheduleAt(endpointID string, at time.Time) (ScheduledCheck, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.endpoints[endpointID]; !exists {
+		return ScheduledCheck{}, fmt.Errorf("endpoint %s not found", endpointID)
+	}
+
+	s.pruneOneOffs(time.Now())
+	s.nextID++
+	scheduled := &ScheduledCheck{
+		ID:         fmt.Sprintf("sched_%d", s.nextID),
+		EndpointID: endpointID,
+		At:         at,
+		Status:     "pending",
+	}
+	scheduled.timer = time.AfterFunc(time.Until(at), func() { s.runOneOff(scheduled) })
+	s.oneOffs[scheduled.ID] = scheduled
+
+	log.Printf("Scheduled one-off check %s for endpoint %s at %s", scheduled.ID, endpointID, at.Format(time.RFC3339))
+	return *scheduled, nil
+}
+
+// pruneOneOffs forgets one-off checks that finished more than
+// oneOffRetention ago. The caller holds the lock.
+func (s *Scheduler) pruneOneOffs(now time.Time) {
+	for id, scheduled := range s.oneOffs {
+		if scheduled.Status != "pending" && now.Sub(scheduled.finishedAt) > oneOffRetention {
+			delete(s.oneOffs, id)
+		}
+	}
+}
+
+// ScheduledChecks returns the one-off checks for an endpoint, soonest first;
+// finished ones are kept for a day
+func (s *Scheduler) ScheduledChecks(endpointID string) []ScheduledCheck {
+	s.mutex.RLock()
+	var checks []ScheduledCheck
+	for _, scheduled := range s.oneOffs {
+		if scheduled.EndpointID == endpointID {
+			checks = append(checks, *scheduled)
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].At.Before(checks[j].At) })
+	return checks
+}
+
+// Stop halts all periodic and pending one-off checks
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.stopped = true
+	for id, e := range s.endpoints {
+		close(e.stop)
+		delete(s.endpoints, id)
+	}
+	now := time.Now()
+	for _, scheduled := range s.oneOffs {
+		if scheduled.Status == "pending" {
+			scheduled.timer.Stop()
+			scheduled.Status = "cancelled"
+			scheduled.finishedAt = now
+		}
+	}
+}
+
+// Shutdown stops the scheduler and waits until the checks in progress have
+// finished and their results were handled, or ctx is done
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run performs periodic checks for one endpoint until it is removed
+func (s *Scheduler) run(e *entry) {
+	s.execute(e.endpoint, TriggerInterval, time.Now())
+
+	ticker := time.NewTicker(e.endpoint.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case due := <-ticker.C:
+			s.execute(e.endpoint, TriggerInterval, due)
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// runOneOff performs a scheduled one-off check
+func (s *Scheduler) runOneOff(scheduled *ScheduledCheck) {
+	s.mutex.RLock()
+	e, exists := s.endpoints[scheduled.EndpointID]
+	s.mutex.RUnlock()
+	if !exists {
+		return
+	}
+
+	result, ok := s.execute(e.endpoint, TriggerOneOff, scheduled.At)
+
+	s.mutex.Lock()
+	if ok {
+		scheduled.Status = "done"
+		scheduled.Result = &result
+	} else {
+		scheduled.Status = "skipped"
+	}
+	scheduled.finishedAt = time.Now()
+	s.pruneOneOffs(scheduled.finishedAt)
+	s.mutex.Unlock()
+}
+
+// execute runs a check that was due at the given time, remembers it as the
+// latest result and notifies handlers. Scheduled checks of paused endpoints,
+// and every check once the scheduler is stopped, are skipped and reported as
+// not ok.
+func (s *Scheduler) execute(endpoint Endpoint, trigger string, due time.Time) (checker.CheckResult, bool) {
+	if trigger != TriggerManual && s.IsPaused(endpoint) {
+		s.metrics.recordSkip()
+		return checker.CheckResult{}, false
+	}
+	if (trigger == TriggerInterval || trigger == TriggerCron) && s.delegated(endpoint) {
+		return checker.CheckResult{}, false
+	}
+	s.mutex.RLock()
+	if s.stopped {
+		s.mutex.RUnlock()
+		return checker.CheckResult{}, false
+	}
+	s.running.Add(1)
+	s.mutex.RUnlock()
+	defer s.running.Done()
+
+	start := time.Now()
+	sequence := s.metrics.begin(start.Sub(due))
+	defer func() { s.metrics.end(sequence, time.Since(start)) }()
+
+	result := s.check(endpoint)
+	result.Trigger = trigger
+	s.handle(endpoint, &result)
+	return result, true
+}
+
+// handle notifies handlers of a completed check and remembers it as the
+// endpoint's latest result
+func (s *Scheduler) handle(endpoint Endpoint, result *checker.CheckResult) {
+	if result.Quality == "" {
+		// Custom checkers may not assess their own timings
+		checker.AssessQuality(result)
+	}
+
+	for _, handler := range s.handlers {
+		handler(endpoint, result)
+	}
+
+	s.mutex.Lock()
+	if _, exists := s.endpoints[endpoint.ID]; exists {
+		s.latest[endpoint.ID] = *result
+	}
+	s.mutex.Unlock()
+}
+
+// CheckNow runs an immediate check of an endpoint outside its schedule
+func (s *Scheduler) CheckNow(endpoint Endpoint) checker.CheckResult {
+	result, _ := s.execute(endpoint, TriggerManual, time.Now())
+	return result
+}
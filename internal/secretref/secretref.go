@@ -0,0 +1,232 @@
+// Package secretref resolves references to secrets kept in HashiCorp Vault
+// or AWS Secrets Manager, so credentials don't have to be copied into the
+// monitor's configuration. A reference is "vault:<path>#<key>" or
+// "aws-sm:<name>[#<key>]".
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Reference prefixes
+const (
+	PrefixVault = "vault:"
+	PrefixAWS   = "aws-sm:"
+)
+
+// fetchTimeout bounds one request to a secret backend
+const fetchTimeout = 10 * time.Second
+
+// IsReference reports whether value names a secret instead of holding one
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, PrefixVault) || strings.HasPrefix(value, PrefixAWS)
+}
+
+// Backend fetches secrets by the part of a reference after its prefix
+type Backend interface {
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// Resolver resolves references through the configured backends. Values are
+// cached for the TTL and then fetched again, so rotated secrets are picked
+// up; when a refresh fails the previous value is kept.
+type Resolver struct {
+	backends map[string]Backend // by prefix
+	ttl      time.Duration
+
+	cache map[string]cachedSecret
+	mutex sync.Mutex
+}
+
+type cachedSecret struct {
+	value   string
+	fetched time.Time
+}
+
+// Options configures the backends; a backend without its address or region
+// rejects references to it
+type Options struct {
+	TTL time.Duration
+
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+
+	AWSRegion   string
+	AWSEndpoint string // Secrets Manager endpoint override, e.g. for LocalStack
+}
+
+// NewResolver creates a resolver with the Vault and AWS backends
+func NewResolver(opts Options) *Resolver {
+	client := &http.Client{Timeout: fetchTimeout}
+	r := &Resolver{
+		backends: make(map[string]Backend),
+		ttl:      opts.TTL,
+		cache:    make(map[string]cachedSecret),
+	}
+	if opts.VaultAddr != "" {
+		r.backends[PrefixVault] = &vaultBackend{
+			addr:      strings.TrimRight(opts.VaultAddr, "/"),
+			token:     opts.VaultToken,
+			namespace: opts.VaultNamespace,
+			client:    client,
+		}
+	}
+	if opts.AWSRegion != "" {
+		r.backends[PrefixAWS] = newAWSBackend(opts.AWSRegion, opts.AWSEndpoint, client)
+	}
+	return r
+}
+
+// SetBackend replaces the backend of a prefix, e.g. with a test double
+func (r *Resolver) SetBackend(prefix string, backend Backend) {
+	r.mutex.Lock()
+	r.backends[prefix] = backend
+	r.mutex.Unlock()
+}
+
+// Resolve returns the secret a reference names, or value unchanged when it
+// isn't a reference
+func (r *Resolver) Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+
+	r.mutex.Lock()
+	cached, ok := r.cache[value]
+	prefix := PrefixVault
+	if strings.HasPrefix(value, PrefixAWS) {
+		prefix = PrefixAWS
+	}
+	backend := r.backends[prefix]
+	r.mutex.Unlock()
+	if ok && (r.ttl <= 0 || time.Since(cached.fetched) < r.ttl) {
+		return cached.value, nil
+	}
+	if backend == nil {
+		return "", fmt.Errorf("%s references need %s to be configured", strings.TrimSuffix(prefix, ":"), backendSetting(prefix))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	secret, err := backend.Fetch(ctx, strings.TrimPrefix(value, prefix))
+	if err != nil {
+		if ok {
+			log.Printf("⚠️ Failed to refresh secret %s, keeping the cached value: %v", value, err)
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("resolving %s: %w", value, err)
+	}
+
+	r.mutex.Lock()
+	r.cache[value] = cachedSecret{value: secret, fetched: time.Now()}
+	r.mutex.Unlock()
+	return secret, nil
+}
+
+// Forget drops a cached secret so the next use fetches it again, e.g. after
+// it was rejected because it has been rotated
+func (r *Resolver) Forget(value string) {
+	r.mutex.Lock()
+	delete(r.cache, value)
+	r.mutex.Unlock()
+}
+
+func backendSetting(prefix string) string {
+	if prefix == PrefixAWS {
+		return "AWS_REGION"
+	}
+	return "VAULT_ADDR"
+}
+
+// splitKey separates "name#key" into its parts
+func splitKey(name string) (string, string) {
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// pickKey returns one field of a secret holding several. Without a key the
+// secret must have exactly one field.
+func pickKey(fields map[string]interface{}, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d fields, name one with #key", len(fields))
+		}
+		for name := range fields {
+			key = name
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("field %q is empty", key)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data), nil
+	}
+}
+
+// headerReference splits a header value into the text before a reference
+// and the reference, e.g. "Bearer " and "vault:secret/data/api#token"
+func headerReference(value string) (string, string, bool) {
+	if IsReference(value) {
+		return "", value, true
+	}
+	if i := strings.LastIndex(value, " "); i >= 0 && IsReference(value[i+1:]) {
+		return value[:i+1], value[i+1:], true
+	}
+	return "", "", false
+}
+
+// Middleware resolves references in request headers just before HTTP checks
+// are sent, so endpoints can be configured with e.g.
+// {"Authorization": "Bearer vault:secret/data/payments#token"}. The stored
+// endpoint keeps the reference. A check answered with 401 or 403 drops the
+// cached secrets it used, so a rotated secret is fetched on the next check.
+func (r *Resolver) Middleware() checker.Middleware {
+	return checker.Middleware{
+		Name: "secrets",
+		Before: func(req *http.Request) error {
+			for name, values := range req.Header {
+				for i, value := range values {
+					prefix, reference, ok := headerReference(value)
+					if !ok {
+						continue
+					}
+					secret, err := r.Resolve(reference)
+					if err != nil {
+						return fmt.Errorf("header %s: %w", name, err)
+					}
+					values[i] = prefix + secret
+				}
+			}
+			return nil
+		},
+		After: func(spec checker.CheckSpec, result *checker.CheckResult) {
+			if result.StatusCode != http.StatusUnauthorized && result.StatusCode != http.StatusForbidden {
+				return
+			}
+			for _, value := range spec.Headers {
+				if _, reference, ok := headerReference(value); ok {
+					r.Forget(reference)
+				}
+			}
+		},
+	}
+}
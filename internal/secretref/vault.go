@@ -0,0 +1,61 @@
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// vaultBackend reads secrets over Vault's HTTP API with a token. Both KV
+// engines work: "vault:secret/data/payments#api_key" for KV v2 and
+// "vault:kv/payments#api_key" for KV v1.
+type vaultBackend struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func (b *vaultBackend) Fetch(ctx context.Context, name string) (string, error) {
+	path, key := splitKey(name)
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("vault reference needs a path")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.addr+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	fields := secret.Data
+	// KV v2 nests the fields under data.data next to the version metadata
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	return pickKey(fields, key)
+}
@@ -0,0 +1,162 @@
+// Package signing signs stored check results so uptime records can later be
+// shown to be unmodified
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Algorithms
+const (
+	HMACSHA256 = "hmac-sha256"
+	Ed25519    = "ed25519"
+)
+
+// Verification failures
+var (
+	ErrUnsigned     = errors.New("unsigned")
+	ErrUnknownKey   = errors.New("signed with another key")
+	ErrInvalid      = errors.New("signature does not match")
+	ErrCannotVerify = errors.New("key cannot verify")
+)
+
+// Key signs and verifies payloads. HMAC keys are shared secrets; ed25519
+// keys sign with the private key and verify with the public key alone, so
+// third parties can check records without being able to forge them.
+type Key struct {
+	algorithm string
+	id        string // identifies the key in signatures so rotations are detected
+	secret    []byte
+	private   ed25519.PrivateKey
+	public    ed25519.PublicKey
+}
+
+// ParseKey reads a signing key: "ed25519:<base64 private key or seed>", or
+// any other value as an HMAC-SHA256 secret
+func ParseKey(value string) (*Key, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("empty key")
+	}
+	encoded, ok := strings.CutPrefix(value, Ed25519+":")
+	if !ok {
+		sum := sha256.Sum256([]byte(value))
+		return &Key{algorithm: HMACSHA256, id: hex.EncodeToString(sum[:4]), secret: []byte(value)}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("ed25519 key is not valid base64: %w", err)
+	}
+	var private ed25519.PrivateKey
+	switch len(raw) {
+	case ed25519.SeedSize:
+		private = ed25519.NewKeyFromSeed(raw)
+	case ed25519.PrivateKeySize:
+		private = ed25519.PrivateKey(raw)
+	default:
+		return nil, fmt.Errorf("ed25519 private key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+	public := private.Public().(ed25519.PublicKey)
+	return &Key{algorithm: Ed25519, id: publicKeyID(public), private: private, public: public}, nil
+}
+
+// ParsePublicKey reads an "ed25519:<base64 public key>" verification key
+func ParsePublicKey(value string) (*Key, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(value), Ed25519+":")
+	if !ok {
+		return nil, fmt.Errorf("public key must start with %q", Ed25519+":")
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("public key is not valid base64: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("ed25519 public key must be %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return &Key{algorithm: Ed25519, id: publicKeyID(raw), public: ed25519.PublicKey(raw)}, nil
+}
+
+// GenerateEd25519 returns a new private key and its public key in the
+// formats ParseKey and ParsePublicKey read
+func GenerateEd25519() (private, public string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return Ed25519 + ":" + base64.StdEncoding.EncodeToString(priv.Seed()),
+		Ed25519 + ":" + base64.StdEncoding.EncodeToString(pub), nil
+}
+
+func publicKeyID(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:4])
+}
+
+// Algorithm returns the key's algorithm
+func (k *Key) Algorithm() string {
+	return k.algorithm
+}
+
+// ID returns the short key fingerprint recorded in signatures
+func (k *Key) ID() string {
+	return k.id
+}
+
+// Sign returns "<algorithm>:<key id>:<base64 signature>" of payload
+func (k *Key) Sign(payload []byte) (string, error) {
+	var signature []byte
+	switch {
+	case k.secret != nil:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(payload)
+		signature = mac.Sum(nil)
+	case k.private != nil:
+		signature = ed25519.Sign(k.private, payload)
+	default:
+		return "", ErrCannotVerify
+	}
+	return k.algorithm + ":" + k.id + ":" + base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify checks a signature made by Sign
+func (k *Key) Verify(payload []byte, signature string) error {
+	if signature == "" {
+		return ErrUnsigned
+	}
+	parts := strings.SplitN(signature, ":", 3)
+	if len(parts) != 3 {
+		return ErrInvalid
+	}
+	if parts[0] != k.algorithm || parts[1] != k.id {
+		return ErrUnknownKey
+	}
+	raw, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalid
+	}
+
+	switch {
+	case k.secret != nil:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(payload)
+		if !hmac.Equal(raw, mac.Sum(nil)) {
+			return ErrInvalid
+		}
+	case k.public != nil:
+		if !ed25519.Verify(k.public, payload, raw) {
+			return ErrInvalid
+		}
+	default:
+		return ErrCannotVerify
+	}
+	return nil
+}
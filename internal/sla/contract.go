@@ -0,0 +1,317 @@
+package sla
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"api-monitor/internal/storage"
+)
+
+// Billing periods of a contract
+const (
+	PeriodMonthly   = "monthly"
+	PeriodQuarterly = "quarterly"
+	PeriodYearly    = "yearly"
+)
+
+// Statement outcomes
+const (
+	StatusMet      = "met"
+	StatusBreached = "breached"
+	StatusNoData   = "no_data"
+)
+
+// Contract is the availability an endpoint's operator commits to towards a
+// customer, measured per billing period
+type Contract struct {
+	Customer string  `json:"customer,omitempty" yaml:"customer,omitempty"`
+	Target   float64 `json:"target" yaml:"target"` // committed availability in percent, e.g. 99.9
+
+	// Period is the billing period (monthly when empty) and Timezone the IANA
+	// timezone its boundaries fall in (UTC when empty)
+	Period   string `json:"period,omitempty" yaml:"period,omitempty"`
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"`
+
+	// Exclusions are the kinds of time left out of the measurement, e.g.
+	// ["maintenance"]; nothing is excluded when empty
+	Exclusions []string `json:"exclusions,omitempty" yaml:"exclusions,omitempty"`
+
+	// Credits are the service credits owed when the target is missed
+	Credits []CreditTier `json:"credits,omitempty" yaml:"credits,omitempty"`
+}
+
+// CreditTier owes Percent of the period's fee when availability falls below Below
+type CreditTier struct {
+	Below   float64 `json:"below" yaml:"below"`
+	Percent float64 `json:"percent" yaml:"percent"`
+}
+
+// Validate checks the contract's target, period, timezone, exclusions and credits
+func (c Contract) Validate() error {
+	if c.Target <= 0 || c.Target > 100 {
+		return fmt.Errorf("sla target must be a percentage above 0 and at most 100")
+	}
+	switch c.Period {
+	case "", PeriodMonthly, PeriodQuarterly, PeriodYearly:
+	default:
+		return fmt.Errorf("sla period must be %q, %q or %q", PeriodMonthly, PeriodQuarterly, PeriodYearly)
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("sla timezone: %v", err)
+	}
+	for _, kind := range c.Exclusions {
+		if kind != ExcludeMaintenance && kind != ExcludeGap {
+			return fmt.Errorf("sla exclusions must be %q or %q", ExcludeMaintenance, ExcludeGap)
+		}
+	}
+	for _, tier := range c.Credits {
+		if tier.Below <= 0 || tier.Below > 100 || tier.Percent < 0 || tier.Percent > 100 {
+			return fmt.Errorf("sla credits need a 'below' availability and a credit 'percent' between 0 and 100")
+		}
+	}
+	return nil
+}
+
+// Excludes reports whether the contract leaves time of the given kind out
+func (c Contract) Excludes(kind string) bool {
+	for _, excluded := range c.Exclusions {
+		if excluded == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the timezone of the period boundaries
+func (c Contract) location() *time.Location {
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// PeriodAt returns the billing period containing t
+func (c Contract) PeriodAt(t time.Time) (from, to time.Time) {
+	t = t.In(c.location())
+	year, month := t.Year(), t.Month()
+	switch c.Period {
+	case PeriodYearly:
+		from = time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(1, 0, 0)
+	case PeriodQuarterly:
+		from = time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 3, 0)
+	default:
+		from = time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 1, 0)
+	}
+}
+
+// PeriodLabel names the billing period starting at from, e.g. "2026-09",
+// "2026-Q3" or "2026"
+func (c Contract) PeriodLabel(from time.Time) string {
+	from = from.In(c.location())
+	switch c.Period {
+	case PeriodYearly:
+		return strconv.Itoa(from.Year())
+	case PeriodQuarterly:
+		return fmt.Sprintf("%d-Q%d", from.Year(), (from.Month()-1)/3+1)
+	default:
+		return from.Format("2006-01")
+	}
+}
+
+// ParsePeriod returns the billing period named by label: "current",
+// "previous" or a label as returned by PeriodLabel
+func (c Contract) ParsePeriod(label string, now time.Time) (from, to time.Time, err error) {
+	switch label {
+	case "", "current":
+		from, to = c.PeriodAt(now)
+		return from, to, nil
+	case "previous":
+		current, _ := c.PeriodAt(now)
+		from, to = c.PeriodAt(current.Add(-time.Nanosecond))
+		return from, to, nil
+	}
+
+	location := c.location()
+	var start time.Time
+	switch c.Period {
+	case PeriodYearly:
+		year, convErr := strconv.Atoi(label)
+		if convErr != nil {
+			return from, to, fmt.Errorf("period must be a year such as \"2026\"")
+		}
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, location)
+	case PeriodQuarterly:
+		var year, quarter int
+		if _, scanErr := fmt.Sscanf(label, "%d-Q%d", &year, &quarter); scanErr != nil || quarter < 1 || quarter > 4 {
+			return from, to, fmt.Errorf("period must be a quarter such as \"2026-Q3\"")
+		}
+		start = time.Date(year, time.Month(quarter-1)*3+1, 1, 0, 0, 0, 0, location)
+	default:
+		parsed, parseErr := time.ParseInLocation("2006-01", label, location)
+		if parseErr != nil {
+			return from, to, fmt.Errorf("period must be a month such as \"2026-09\"")
+		}
+		start = parsed
+	}
+	from, to = c.PeriodAt(start)
+	return from, to, nil
+}
+
+// Credit returns the service credit owed at an availability: the largest
+// tier it falls below
+func (c Contract) Credit(availability float64) float64 {
+	credit := 0.0
+	for _, tier := range c.Credits {
+		if availability < tier.Below && tier.Percent > credit {
+			credit = tier.Percent
+		}
+	}
+	return credit
+}
+
+// Statement is the customer-facing compliance statement of one endpoint's
+// contract for one billing period
+type Statement struct {
+	EndpointID string    `json:"endpointId,omitempty"`
+	URL        string    `json:"url"`
+	Customer   string    `json:"customer,omitempty"`
+	Period     string    `json:"period"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+
+	// Final is set once the period has ended; until then the figures cover
+	// the period so far
+	Final       bool      `json:"final"`
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	Target       float64  `json:"target"`
+	Availability *float64 `json:"availability"` // nil without results in the period
+	Status       string   `json:"status"`
+
+	// AllowedDowntime is the downtime the target permits over the measured
+	// time; CreditPercent the service credit owed at the achieved availability
+	Measured        time.Duration `json:"measured"`
+	Downtime        time.Duration `json:"downtime"`
+	AllowedDowntime time.Duration `json:"allowedDowntime"`
+	Outages         int           `json:"outages"`
+	CreditPercent   float64       `json:"creditPercent"`
+
+	Excluded   time.Duration `json:"excluded"`
+	Exclusions []Exclusion   `json:"exclusions"`
+
+	// RolledUp is set when retention rolled up the raw results of the start
+	// of the period, which the figures above then leave out
+	RolledUp *RolledUp `json:"rolledUp,omitempty"`
+}
+
+// BuildStatement renders the statement of a contract for the billing period
+// [from, to) from stored results, measured up to now for a running period.
+// Maintenance windows and monitoring gaps are only excluded when the
+// contract says so.
+func BuildStatement(store storage.Store, target Target, contract Contract, from, to, now time.Time, maintenance []storage.MaintenanceWindow) (Statement, error) {
+	statement := Statement{
+		URL:         target.URL,
+		Customer:    contract.Customer,
+		Period:      contract.PeriodLabel(from),
+		From:        from,
+		To:          to,
+		Final:       !now.Before(to),
+		GeneratedAt: now,
+		Target:      contract.Target,
+		Status:      StatusNoData,
+		Exclusions:  []Exclusion{},
+	}
+	end := to
+	if now.Before(end) {
+		end = now
+	}
+	if !from.Before(end) {
+		return statement, nil
+	}
+
+	if !contract.Excludes(ExcludeMaintenance) {
+		maintenance = nil
+	}
+	if !contract.Excludes(ExcludeGap) {
+		target.MinGap = 0
+	}
+	report, err := BuildRange(store, target, from, end, maintenance)
+	if err != nil {
+		return statement, err
+	}
+
+	statement.Measured = report.Observed
+	statement.Downtime = report.Downtime
+	statement.AllowedDowntime = time.Duration(float64(report.Observed) * (100 - contract.Target) / 100)
+	statement.Outages = report.Outages
+	statement.Excluded = report.Excluded
+	statement.Exclusions = report.Exclusions
+	statement.RolledUp = report.RolledUp
+	if report.UptimePercent != nil {
+		statement.Availability = report.UptimePercent
+		statement.Status = StatusMet
+		if *report.UptimePercent < contract.Target {
+			statement.Status = StatusBreached
+		}
+		statement.CreditPercent = contract.Credit(*report.UptimePercent)
+	}
+	return statement, nil
+}
+
+// WriteText renders the statement as a plain-text document for customers
+func (s Statement) WriteText(w io.Writer) {
+	const layout = "2006-01-02 15:04 MST"
+	fmt.Fprintln(w, "SLA Compliance Statement")
+	fmt.Fprintln(w, strings.Repeat("=", 24))
+	if s.Customer != "" {
+		fmt.Fprintf(w, "Customer:         %s\n", s.Customer)
+	}
+	fmt.Fprintf(w, "Service:          %s\n", s.URL)
+	fmt.Fprintf(w, "Billing period:   %s (%s to %s)\n", s.Period, s.From.Format(layout), s.To.Format(layout))
+	fmt.Fprintf(w, "Commitment:       %s%% availability\n", formatPercent(s.Target))
+
+	if s.Availability == nil {
+		fmt.Fprintln(w, "Achieved:         no measurements in this period")
+	} else {
+		fmt.Fprintf(w, "Achieved:         %s%% (%s)\n", formatPercent(*s.Availability), strings.ToUpper(s.Status))
+		fmt.Fprintf(w, "Downtime:         %v of %v allowed over %v measured\n",
+			s.Downtime.Round(time.Second), s.AllowedDowntime.Round(time.Second), s.Measured.Round(time.Second))
+		fmt.Fprintf(w, "Outages:          %d\n", s.Outages)
+		fmt.Fprintf(w, "Service credit:   %s%%\n", formatPercent(s.CreditPercent))
+	}
+
+	if len(s.Exclusions) > 0 {
+		fmt.Fprintf(w, "Excluded time:    %v\n", s.Excluded.Round(time.Second))
+		for _, exclusion := range s.Exclusions {
+			fmt.Fprintf(w, "  - %s %s to %s: %s\n", exclusion.Kind,
+				exclusion.From.In(s.From.Location()).Format(layout), exclusion.To.In(s.From.Location()).Format(layout), exclusion.Reason)
+		}
+	}
+
+	if s.RolledUp != nil {
+		fmt.Fprintf(w, "Rolled up:        results before %s are only kept as daily totals (%d checks, %d failed)\n",
+			s.RolledUp.To.In(s.From.Location()).Format(layout), s.RolledUp.Checks, s.RolledUp.FailedChecks)
+		fmt.Fprintln(w, "                  and are not part of the achieved availability")
+	}
+
+	fmt.Fprintln(w)
+	if s.Final {
+		fmt.Fprintf(w, "Generated %s. The billing period has ended and these figures are final.\n", s.GeneratedAt.Format(time.RFC3339))
+	} else {
+		fmt.Fprintf(w, "Generated %s. The billing period is in progress; figures cover it so far.\n", s.GeneratedAt.Format(time.RFC3339))
+	}
+}
+
+// formatPercent prints a percentage with up to three decimals, rounded down
+// so a statement never shows more availability than was achieved
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(math.Floor(value*1000)/1000, 'f', -1, 64)
+}
@@ -0,0 +1,81 @@
+package sla
+
+import (
+	"fmt"
+	"time"
+
+	"api-monitor/internal/storage"
+)
+
+// Objective is an internal availability target (SLO) over a rolling window.
+// Its error budget is the downtime the target permits, e.g. 43m12s per 30
+// days at 99.9%.
+type Objective struct {
+	Target float64 `json:"target" yaml:"target"`                     // availability in percent, e.g. 99.9
+	Window string  `json:"window,omitempty" yaml:"window,omitempty"` // a standard window, 30d when empty
+}
+
+// Validate checks the objective's target and window
+func (o Objective) Validate() error {
+	if o.Target <= 0 || o.Target >= 100 {
+		return fmt.Errorf("slo target must be a percentage above 0 and below 100")
+	}
+	if o.Window != "" {
+		if _, err := LookupWindow(o.Window); err != nil {
+			return fmt.Errorf("slo window: %v", err)
+		}
+	}
+	return nil
+}
+
+// Period returns the rolling window the budget is spent over
+func (o Objective) Period() Window {
+	window, err := LookupWindow(o.Window)
+	if err != nil {
+		window, _ = LookupWindow("30d")
+	}
+	return window
+}
+
+// Budget returns the fraction of time the objective allows to fail
+func (o Objective) Budget() float64 {
+	return (100 - o.Target) / 100
+}
+
+// BurnRate returns how fast a report's downtime spends the budget: 1 spends
+// exactly the budget over the objective's window, 14.4 spends 2% of a 30 day
+// budget in an hour. ok is false without observed time.
+func (o Objective) BurnRate(report Report) (rate float64, ok bool) {
+	if report.Observed <= 0 {
+		return 0, false
+	}
+	return float64(report.Downtime) / float64(report.Observed) / o.Budget(), true
+}
+
+// BurnRates reports the burn rate over each window ending at now, excluding
+// maintenance and monitoring gaps like Build. Windows without results are
+// left out. Health changes are read once for the longest window.
+func BurnRates(store storage.Store, target Target, objective Objective, windows []time.Duration, now time.Time, maintenance []storage.MaintenanceWindow) (map[time.Duration]float64, error) {
+	longest := time.Duration(0)
+	for _, window := range windows {
+		if window > longest {
+			longest = window
+		}
+	}
+	changes, err := store.GetHealthChanges(target.URL, now.Add(-longest), now)
+	if err != nil {
+		return nil, err
+	}
+	exclusions, err := targetExclusions(store, target, now.Add(-longest), now, maintenance)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[time.Duration]float64, len(windows))
+	for _, window := range windows {
+		if rate, ok := objective.BurnRate(Compute(changes, now.Add(-window), now, exclusions)); ok {
+			rates[window] = rate
+		}
+	}
+	return rates, nil
+}
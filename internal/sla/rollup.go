@@ -0,0 +1,55 @@
+package sla
+
+import (
+	"time"
+
+	"api-monitor/internal/storage"
+)
+
+// RolledUp summarizes the part of a report period whose raw results
+// retention has rolled up into daily aggregates. Uptime can't be weighted by
+// time from aggregates, so availability there is by check count over whole
+// days.
+type RolledUp struct {
+	From                time.Time `json:"from"`
+	To                  time.Time `json:"to"` // the time-weighted figures start here
+	Checks              int       `json:"checks"`
+	FailedChecks        int       `json:"failedChecks"`
+	AvailabilityPercent *float64  `json:"availabilityPercent"`
+}
+
+// rollups returns the daily rollups of url that overlap [from, to)
+func rollups(store storage.Store, url string, from, to time.Time) ([]storage.Rollup, error) {
+	return store.GetRollups(url, from.Add(-storage.RollupDaily), to, storage.RollupDaily)
+}
+
+// summarizeRollups returns the part of [from, to) covered by daily rollups,
+// nil when none overlap it
+func summarizeRollups(daily []storage.Rollup, from, to time.Time) *RolledUp {
+	var summary *RolledUp
+	for _, rollup := range daily {
+		end := rollup.Start.Add(storage.RollupDaily)
+		if !end.After(from) || !rollup.Start.Before(to) {
+			continue
+		}
+		if summary == nil {
+			summary = &RolledUp{From: from}
+		}
+		summary.Checks += rollup.Checks
+		summary.FailedChecks += rollup.FailedChecks
+		if end.After(summary.To) {
+			summary.To = end
+		}
+	}
+	if summary == nil {
+		return nil
+	}
+	if summary.To.After(to) {
+		summary.To = to
+	}
+	if summary.Checks > 0 {
+		availability := float64(summary.Checks-summary.FailedChecks) / float64(summary.Checks) * 100
+		summary.AvailabilityPercent = &availability
+	}
+	return summary
+}
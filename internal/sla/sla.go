@@ -0,0 +1,311 @@
+package sla
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"api-monitor/internal/storage"
+)
+
+// Window is a named reporting period ending now
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Windows are the standard reporting periods
+var Windows = []Window{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"90d", 90 * 24 * time.Hour},
+}
+
+// LongestWindow returns the longest standard window, which raw retention
+// has to cover for time-weighted reports
+func LongestWindow() Window {
+	longest := Windows[0]
+	for _, window := range Windows {
+		if window.Duration > longest.Duration {
+			longest = window
+		}
+	}
+	return longest
+}
+
+// LookupWindow returns the standard window with the given name
+func LookupWindow(name string) (Window, error) {
+	for _, window := range Windows {
+		if window.Name == name {
+			return window, nil
+		}
+	}
+	return Window{}, fmt.Errorf("unknown window %q (use 24h, 7d, 30d or 90d)", name)
+}
+
+// Exclusion kinds
+const (
+	ExcludeMaintenance = "maintenance" // a planned maintenance window
+	ExcludeGap         = "gap"         // no results were stored, e.g. the monitor was down
+)
+
+// Exclusion is a period left out of a report, itemized with its reason so
+// the figures can be audited
+type Exclusion struct {
+	Kind   string    `json:"kind"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Reason string    `json:"reason"`
+
+	// Maintenance windows also name who recorded them and when, which shows
+	// windows added after the fact
+	MaintenanceID int64      `json:"maintenanceId,omitempty"`
+	CreatedBy     string     `json:"createdBy,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+
+	// Duration is the part of the report period the exclusion covers and
+	// Downtime the failing time it removed from the figures. Overlapping
+	// exclusions each count the shared time.
+	Duration time.Duration `json:"duration"`
+	Downtime time.Duration `json:"downtime"`
+}
+
+// Report summarizes an endpoint's availability over a period. Uptime is
+// weighted by time rather than by check count, so it stays correct under
+// sampling and coalescing. An outage is a run of failed checks.
+type Report struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Checks       int       `json:"checks"`
+	FailedChecks int       `json:"failedChecks"`
+
+	// Observed is the part of the period covered by results, minus
+	// exclusions; uptime is nil without any
+	Observed      time.Duration `json:"observed"`
+	Downtime      time.Duration `json:"downtime"`
+	UptimePercent *float64      `json:"uptimePercent"`
+
+	Outages       int  `json:"outages"`
+	OngoingOutage bool `json:"ongoingOutage,omitempty"`
+
+	// Mean time to recovery over resolved outages and mean time between
+	// failures (healthy time per outage); zero without outages
+	MTTR time.Duration `json:"mttr,omitempty"`
+	MTBF time.Duration `json:"mtbf,omitempty"`
+
+	// Excluded is the observed time removed by Exclusions
+	Excluded   time.Duration `json:"excluded"`
+	Exclusions []Exclusion   `json:"exclusions"`
+
+	// RolledUp is set when the period reaches back past raw retention: the
+	// figures above then only cover the time from RolledUp.To on
+	RolledUp *RolledUp `json:"rolledUp,omitempty"`
+}
+
+// Compute builds the report of [from, to) from the endpoint's health
+// changes, as returned by storage.Store.GetHealthChanges for at least that
+// range, leaving out the excluded periods. Outages entirely within
+// exclusions aren't counted. Checks and FailedChecks are left to the caller.
+func Compute(changes []storage.HealthChange, from, to time.Time, exclusions []Exclusion) Report {
+	report := Report{From: from, To: to, Exclusions: []Exclusion{}}
+	for _, exclusion := range exclusions {
+		exclusion.From, exclusion.To = clip(exclusion.From, exclusion.To, from, to)
+		if exclusion.From.Before(exclusion.To) {
+			exclusion.Duration = exclusion.To.Sub(exclusion.From)
+			report.Exclusions = append(report.Exclusions, exclusion)
+		}
+	}
+	sort.Slice(report.Exclusions, func(i, j int) bool { return report.Exclusions[i].From.Before(report.Exclusions[j].From) })
+	excluded := merge(report.Exclusions)
+
+	var uptime, resolved time.Duration
+	var resolvedCount int
+	for i, change := range changes {
+		end := to
+		if i+1 < len(changes) {
+			end = changes[i+1].At
+		}
+		start, end := clip(change.At, end, from, to)
+		if !start.Before(end) {
+			continue
+		}
+
+		span := end.Sub(start)
+		removed := overlap(start, end, excluded)
+		report.Excluded += removed
+		span -= removed
+		report.Observed += span
+		if change.Healthy {
+			uptime += span
+			continue
+		}
+		for j := range report.Exclusions {
+			report.Exclusions[j].Downtime += overlap(start, end, report.Exclusions[j:j+1])
+		}
+		if span <= 0 {
+			continue
+		}
+		report.Downtime += span
+		report.Outages++
+		if i+1 < len(changes) && !changes[i+1].At.After(to) {
+			resolved += span
+			resolvedCount++
+		} else {
+			report.OngoingOutage = true
+		}
+	}
+
+	if report.Observed > 0 {
+		percent := float64(uptime) / float64(report.Observed) * 100
+		report.UptimePercent = &percent
+	}
+	if resolvedCount > 0 {
+		report.MTTR = resolved / time.Duration(resolvedCount)
+	}
+	if report.Outages > 0 {
+		report.MTBF = uptime / time.Duration(report.Outages)
+	}
+	return report
+}
+
+// clip limits [start, end) to [from, to)
+func clip(start, end, from, to time.Time) (time.Time, time.Time) {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return start, end
+}
+
+// merge returns the union of exclusions sorted by start as disjoint periods
+func merge(exclusions []Exclusion) []Exclusion {
+	var merged []Exclusion
+	for _, exclusion := range exclusions {
+		if n := len(merged); n > 0 && !exclusion.From.After(merged[n-1].To) {
+			if exclusion.To.After(merged[n-1].To) {
+				merged[n-1].To = exclusion.To
+			}
+			continue
+		}
+		merged = append(merged, Exclusion{From: exclusion.From, To: exclusion.To})
+	}
+	return merged
+}
+
+// overlap returns how much of [start, end) disjoint periods cover
+func overlap(start, end time.Time, periods []Exclusion) time.Duration {
+	var total time.Duration
+	for _, period := range periods {
+		from, to := clip(start, end, period.From, period.To)
+		if from.Before(to) {
+			total += to.Sub(from)
+		}
+	}
+	return total
+}
+
+// Target is an endpoint to report on
+type Target struct {
+	URL  string
+	Tags []string
+
+	// MinGap is the shortest period without results that is excluded as a
+	// monitoring gap; zero keeps gaps in the figures, e.g. for sampled
+	// endpoints whose results are sparse by design
+	MinGap time.Duration
+}
+
+// Build reports the given windows ending at now for an endpoint, excluding
+// the maintenance windows that cover it and its monitoring gaps. Health
+// changes, gaps and rollups are read once for the longest window.
+func Build(store storage.Store, target Target, windows []Window, now time.Time, maintenance []storage.MaintenanceWindow) (map[string]Report, error) {
+	longest := time.Duration(0)
+	for _, window := range windows {
+		if window.Duration > longest {
+			longest = window.Duration
+		}
+	}
+	changes, err := store.GetHealthChanges(target.URL, now.Add(-longest), now)
+	if err != nil {
+		return nil, err
+	}
+	exclusions, err := targetExclusions(store, target, now.Add(-longest), now, maintenance)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := rollups(store, target.URL, now.Add(-longest), now)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make(map[string]Report, len(windows))
+	for _, window := range windows {
+		from := now.Add(-window.Duration)
+		report := Compute(changes, from, now, exclusions)
+		if report.Checks, report.FailedChecks, err = store.CountChecks(target.URL, from, now); err != nil {
+			return nil, err
+		}
+		report.RolledUp = summarizeRollups(daily, from, now)
+		reports[window.Name] = report
+	}
+	return reports, nil
+}
+
+// BuildRange reports [from, to) for an endpoint like Build does a window
+func BuildRange(store storage.Store, target Target, from, to time.Time, maintenance []storage.MaintenanceWindow) (Report, error) {
+	changes, err := store.GetHealthChanges(target.URL, from, to)
+	if err != nil {
+		return Report{}, err
+	}
+	exclusions, err := targetExclusions(store, target, from, to, maintenance)
+	if err != nil {
+		return Report{}, err
+	}
+	daily, err := rollups(store, target.URL, from, to)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Compute(changes, from, to, exclusions)
+	report.RolledUp = summarizeRollups(daily, from, to)
+	report.Checks, report.FailedChecks, err = store.CountChecks(target.URL, from, to)
+	return report, err
+}
+
+// targetExclusions lists the maintenance windows covering an endpoint and
+// its monitoring gaps in [from, to)
+func targetExclusions(store storage.Store, target Target, from, to time.Time, maintenance []storage.MaintenanceWindow) ([]Exclusion, error) {
+	var exclusions []Exclusion
+	for _, window := range maintenance {
+		if !window.Covers(target.URL, target.Tags) {
+			continue
+		}
+		createdAt := window.CreatedAt
+		exclusions = append(exclusions, Exclusion{
+			Kind:          ExcludeMaintenance,
+			From:          window.StartsAt,
+			To:            window.EndsAt,
+			Reason:        window.Reason,
+			MaintenanceID: window.ID,
+			CreatedBy:     window.CreatedBy,
+			CreatedAt:     &createdAt,
+		})
+	}
+	if target.MinGap > 0 {
+		gaps, err := store.GetMonitoringGaps(target.URL, from, to, target.MinGap)
+		if err != nil {
+			return nil, err
+		}
+		for _, gap := range gaps {
+			exclusions = append(exclusions, Exclusion{
+				Kind:   ExcludeGap,
+				From:   gap.From,
+				To:     gap.To,
+				Reason: fmt.Sprintf("no results for %v", gap.To.Sub(gap.From).Round(time.Second)),
+			})
+		}
+	}
+	return exclusions, nil
+}
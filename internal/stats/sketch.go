@@ -0,0 +1,141 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// sketchAccuracy is the relative error of quantiles returned by a Sketch
+const sketchAccuracy = 0.01
+
+// sketchMinValue is the smallest latency tracked separately; anything faster
+// is counted in the zero bucket
+const sketchMinValue = float64(time.Microsecond)
+
+var sketchGamma = (1 + sketchAccuracy) / (1 - sketchAccuracy)
+var sketchLogGamma = math.Log(sketchGamma)
+
+// Sketch is a mergeable latency histogram with logarithmic buckets. Any
+// quantile it returns is within 1% of the true value, and sketches for
+// separate windows can be merged to answer queries over arbitrary ranges
+// without the raw samples.
+type Sketch struct {
+	Bins  map[int]uint64 `json:"bins"`
+	Zero  uint64         `json:"zero"`
+	Count uint64         `json:"count"`
+	Min   time.Duration  `json:"min"`
+	Max   time.Duration  `json:"max"`
+	Sum   time.Duration  `json:"sum"`
+}
+
+// NewSketch creates an empty sketch
+func NewSketch() *Sketch {
+	return &Sketch{Bins: make(map[int]uint64)}
+}
+
+// Add records one latency sample
+func (s *Sketch) Add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if s.Count == 0 || d < s.Min {
+		s.Min = d
+	}
+	if d > s.Max {
+		s.Max = d
+	}
+	s.Count++
+	s.Sum += d
+
+	if float64(d) < sketchMinValue {
+		s.Zero++
+		return
+	}
+	s.Bins[sketchIndex(float64(d))]++
+}
+
+// Merge adds all samples of other into s
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.Count == 0 {
+		return
+	}
+	if s.Bins == nil {
+		s.Bins = make(map[int]uint64)
+	}
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.Zero += other.Zero
+	for index, count := range other.Bins {
+		s.Bins[index] += count
+	}
+}
+
+// Quantile returns the latency at quantile q (0 to 1)
+func (s *Sketch) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return s.Min
+	}
+	if q >= 1 {
+		return s.Max
+	}
+
+	rank := uint64(q * float64(s.Count-1))
+	if rank < s.Zero {
+		return s.Min
+	}
+
+	indexes := make([]int, 0, len(s.Bins))
+	for index := range s.Bins {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	seen := s.Zero
+	for _, index := range indexes {
+		seen += s.Bins[index]
+		if seen > rank {
+			return s.clamp(sketchValue(index))
+		}
+	}
+	return s.Max
+}
+
+// Mean returns the exact average of all samples
+func (s *Sketch) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// clamp keeps bucket midpoints inside the observed range
+func (s *Sketch) clamp(v float64) time.Duration {
+	d := time.Duration(v)
+	if d < s.Min {
+		return s.Min
+	}
+	if d > s.Max {
+		return s.Max
+	}
+	return d
+}
+
+func sketchIndex(v float64) int {
+	return int(math.Ceil(math.Log(v) / sketchLogGamma))
+}
+
+// sketchValue is the representative value of a bucket, within the relative
+// accuracy of every value that maps to it
+func sketchValue(index int) float64 {
+	return 2 * math.Pow(sketchGamma, float64(index)) / (sketchGamma + 1)
+}
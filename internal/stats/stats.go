@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Modes for the central latency value shown in summaries
+const (
+	ModeMean        = "mean"         // plain average, sensitive to timeouts
+	ModeTrimmedMean = "trimmed_mean" // average after dropping the slowest and fastest samples
+	ModeMedian      = "median"
+)
+
+// Summary describes a set of latency samples
+type Summary struct {
+	Mode    string        `json:"mode"`
+	Central time.Duration `json:"central"` // value selected by Mode
+	Mean    time.Duration `json:"mean"`
+	Median  time.Duration `json:"median"`
+	P95     time.Duration `json:"p95"`
+	Min     time.Duration `json:"min"`
+	Max     time.Duration `json:"max"`
+	Count   int           `json:"count"`
+	Trimmed int           `json:"trimmed"` // samples dropped from each end for the trimmed mean
+}
+
+// ValidateMode reports whether mode is a supported statistics mode
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeMean, ModeTrimmedMean, ModeMedian:
+		return nil
+	}
+	return fmt.Errorf("statistics mode must be %q, %q or %q, got %q", ModeMean, ModeTrimmedMean, ModeMedian, mode)
+}
+
+// Summarize computes latency statistics. trimPercent is the share of samples
+// dropped from each end for the trimmed mean, e.g. 5 drops the fastest and
+// slowest 5%. Unknown modes fall back to the mean.
+func Summarize(samples []time.Duration, mode string, trimPercent float64) Summary {
+	summary := Summary{Mode: mode, Count: len(samples)}
+	if len(samples) == 0 {
+		return summary
+	}
+
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	summary.Min = sorted[0]
+	summary.Max = sorted[len(sorted)-1]
+	summary.Mean = mean(sorted)
+	summary.Median = percentile(sorted, 50)
+	summary.P95 = percentile(sorted, 95)
+
+	switch mode {
+	case ModeMedian:
+		summary.Central = summary.Median
+	case ModeTrimmedMean:
+		if trimPercent > 0 && trimPercent < 50 {
+			summary.Trimmed = int(float64(len(sorted)) * trimPercent / 100)
+		}
+		summary.Central = mean(sorted[summary.Trimmed : len(sorted)-summary.Trimmed])
+	default:
+		summary.Mode = ModeMean
+		summary.Central = summary.Mean
+	}
+
+	return summary
+}
+
+// Label names the central value for human-readable output
+func (s Summary) Label() string {
+	switch s.Mode {
+	case ModeMedian:
+		return "median"
+	case ModeTrimmedMean:
+		return "trimmed mean"
+	}
+	return "average"
+}
+
+func mean(sorted []time.Duration) time.Duration {
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return total / time.Duration(len(sorted))
+}
+
+// percentile uses the nearest-rank method on sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "time"
+
+// SaveAlertState creates or replaces the alerting state of an endpoint. The
+// state is opaque JSON owned by the alerting package.
+func (s *sqlStore) SaveAlertState(endpointID string, state []byte) error {
+	_, err := s.exec(`
+	INSERT INTO alert_states (endpoint_id, state, updated_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (endpoint_id) DO UPDATE SET state = EXCLUDED.state, updated_at = EXCLUDED.updated_at
+	`, endpointID, state, s.ts(time.Now()))
+	return err
+}
+
+// LoadAlertStates returns the alerting state of every endpoint
+func (s *sqlStore) LoadAlertStates() (map[string][]byte, error) {
+	rows, err := s.query(`SELECT endpoint_id, state FROM alert_states`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string][]byte)
+	for rows.Next() {
+		var id string
+		var state []byte
+		if err := rows.Scan(&id, &state); err != nil {
+			return nil, err
+		}
+		states[id] = state
+	}
+	return states, rows.Err()
+}
+
+// DeleteAlertState removes the alerting state of an endpoint
+func (s *sqlStore) DeleteAlertState(endpointID string) error {
+	_, err := s.exec(`DELETE FROM alert_states WHERE endpoint_id = $1`, endpointID)
+	return err
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AnalysisRecord is one scheduled AI analysis of a group of endpoints
+type AnalysisRecord struct {
+	Scope       string          `json:"scope"` // tag, or "*" for the whole fleet
+	Insights    json.RawMessage `json:"insights"`
+	Endpoints   int             `json:"endpoints"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// SaveAnalysis stores the result of an analysis run
+func (s *sqlStore) SaveAnalysis(record AnalysisRecord) error {
+	_, err := s.exec(`
+	INSERT INTO ai_analyses (scope, insights, endpoint_count, generated_at)
+	VALUES ($1, $2, $3, $4)
+	`, record.Scope, []byte(record.Insights), record.Endpoints, s.ts(record.GeneratedAt))
+	return err
+}
+
+// LatestAnalyses returns the most recent analysis of every scope
+func (s *sqlStore) LatestAnalyses() ([]AnalysisRecord, error) {
+	rows, err := s.query(`
+	SELECT a.scope, a.insights, a.endpoint_count, a.generated_at
+	FROM ai_analyses a
+	JOIN (SELECT scope, MAX(generated_at) AS latest FROM ai_analyses GROUP BY scope) l
+		ON a.scope = l.scope AND a.generated_at = l.latest
+	ORDER BY a.scope
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []AnalysisRecord
+	for rows.Next() {
+		var record AnalysisRecord
+		var insights []byte
+		if err := rows.Scan(&record.Scope, &insights, &record.Endpoints, &record.GeneratedAt); err != nil {
+			return nil, err
+		}
+		record.Insights = insights
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
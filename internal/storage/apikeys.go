@@ -0,0 +1,79 @@
+package storage
+
+import "time"
+
+// APIKey is a credential for the web API. Only the key's hash is stored;
+// the key itself is shown once when it is created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	Hash       string     `json:"-"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// SaveAPIKey stores a new API key
+func (s *sqlStore) SaveAPIKey(key APIKey) error {
+	_, err := s.exec(`
+	INSERT INTO api_keys (id, name, scope, key_hash, created_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`, key.ID, key.Name, key.Scope, key.Hash, nullString(key.CreatedBy), s.ts(key.CreatedAt))
+	return err
+}
+
+// GetAPIKey returns the API key with the given hash
+func (s *sqlStore) GetAPIKey(hash string) (APIKey, bool, error) {
+	keys, err := s.queryAPIKeys(`WHERE key_hash = $1`, hash)
+	if err != nil || len(keys) == 0 {
+		return APIKey{}, false, err
+	}
+	return keys[0], true, nil
+}
+
+// ListAPIKeys returns every API key, oldest first
+func (s *sqlStore) ListAPIKeys() ([]APIKey, error) {
+	return s.queryAPIKeys(`ORDER BY created_at, id`)
+}
+
+func (s *sqlStore) queryAPIKeys(clause string, args ...interface{}) ([]APIKey, error) {
+	rows, err := s.query(`
+	SELECT id, name, scope, key_hash, created_by, created_at, last_used_at
+	FROM api_keys
+	`+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		var createdBy *string
+		if err := rows.Scan(&key.ID, &key.Name, &key.Scope, &key.Hash, &createdBy, &key.CreatedAt, &key.LastUsedAt); err != nil {
+			return nil, err
+		}
+		if createdBy != nil {
+			key.CreatedBy = *createdBy
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// TouchAPIKey records when an API key was last used
+func (s *sqlStore) TouchAPIKey(id string, at time.Time) error {
+	_, err := s.exec(`UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, s.ts(at))
+	return err
+}
+
+// DeleteAPIKey revokes an API key
+func (s *sqlStore) DeleteAPIKey(id string) (bool, error) {
+	res, err := s.exec(`DELETE FROM api_keys WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry records who performed an administrative action and what it did
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	Action     string          `json:"action"` // e.g. "results.delete"
+	Actor      string          `json:"actor,omitempty"`
+	RemoteAddr string          `json:"remoteAddr,omitempty"`
+	Details    json.RawMessage `json:"details,omitempty"`
+	At         time.Time       `json:"at"`
+}
+
+// SaveAudit appends an entry to the audit log
+func (s *sqlStore) SaveAudit(entry AuditEntry) error {
+	var details *string
+	if len(entry.Details) > 0 {
+		details = nullString(string(entry.Details))
+	}
+	_, err := s.exec(`
+	INSERT INTO audit_log (action, actor, remote_addr, details, at)
+	VALUES ($1, $2, $3, $4, $5)
+	`, entry.Action, nullString(entry.Actor), nullString(entry.RemoteAddr), details, s.ts(entry.At))
+	return err
+}
+
+// GetAuditLog returns the most recent audit entries, newest first
+func (s *sqlStore) GetAuditLog(limit int) ([]AuditEntry, error) {
+	rows, err := s.query(`
+	SELECT id, action, actor, remote_addr, details, at
+	FROM audit_log
+	ORDER BY at DESC, id DESC
+	LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var actor, remoteAddr, details *string
+		if err := rows.Scan(&entry.ID, &entry.Action, &actor, &remoteAddr, &details, &entry.At); err != nil {
+			return nil, err
+		}
+		if actor != nil {
+			entry.Actor = *actor
+		}
+		if remoteAddr != nil {
+			entry.RemoteAddr = *remoteAddr
+		}
+		if details != nil {
+			entry.Details = json.RawMessage(*details)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
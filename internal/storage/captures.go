@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Capture is the full request and response of one check made while a debug
+// capture of its endpoint was running
+type Capture struct {
+	ID         int64            `json:"id"`
+	EndpointID string           `json:"endpointId"`
+	URL        string           `json:"url"`
+	CheckedAt  time.Time        `json:"checkedAt"`
+	StatusCode int              `json:"statusCode,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Exchange   checker.Exchange `json:"exchange"`
+}
+
+// SaveCapture stores a captured exchange
+func (s *sqlStore) SaveCapture(capture Capture) error {
+	exchange, err := json.Marshal(capture.Exchange)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(`
+	INSERT INTO check_captures (endpoint_id, url, checked_at, status_code, error, exchange)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`, capture.EndpointID, capture.URL, s.ts(capture.CheckedAt), capture.StatusCode, nullString(capture.Error), string(exchange))
+	return err
+}
+
+// GetCaptures returns up to limit captures of an endpoint made within
+// [from, to], newest first
+func (s *sqlStore) GetCaptures(endpointID string, from, to time.Time, limit int) ([]Capture, error) {
+	rows, err := s.query(`
+	SELECT id, endpoint_id, url, checked_at, status_code, error, exchange
+	FROM check_captures
+	WHERE endpoint_id = $1 AND checked_at >= $2 AND checked_at <= $3
+	ORDER BY checked_at DESC, id DESC
+	LIMIT $4
+	`, endpointID, s.ts(from), s.ts(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	captures := []Capture{}
+	for rows.Next() {
+		var capture Capture
+		var statusCode *int
+		var captureErr *string
+		var exchange []byte
+		if err := rows.Scan(&capture.ID, &capture.EndpointID, &capture.URL, &capture.CheckedAt, &statusCode, &captureErr, &exchange); err != nil {
+			return nil, err
+		}
+		if statusCode != nil {
+			capture.StatusCode = *statusCode
+		}
+		if captureErr != nil {
+			capture.Error = *captureErr
+		}
+		if err := json.Unmarshal(exchange, &capture.Exchange); err != nil {
+			return nil, err
+		}
+		captures = append(captures, capture)
+	}
+	return captures, rows.Err()
+}
+
+// DeleteCaptures removes the captures made before a time, of one endpoint
+// or of all when endpointID is empty
+func (s *sqlStore) DeleteCaptures(endpointID string, before time.Time) (int64, error) {
+	res, err := s.exec(`
+	DELETE FROM check_captures
+	WHERE ($1 = '' OR endpoint_id = $1) AND checked_at < $2
+	`, endpointID, s.ts(before))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
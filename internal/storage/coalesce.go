@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// CoalesceWindow is how much time one stored aggregate of a sub-second
+// endpoint covers
+const CoalesceWindow = time.Second
+
+// Metadata keys of coalesced results
+const (
+	MetaSamples       = "samples"        // checks folded into the result
+	MetaFailedSamples = "failed_samples" // of which failed
+	MetaLatencyMinMS  = "latency_min_ms"
+	MetaLatencyMaxMS  = "latency_max_ms"
+)
+
+// Coalescer folds the results of sub-second checks into one result per
+// CoalesceWindow, so latency-critical endpoints can be checked tightly
+// without storing every sample
+type Coalescer struct {
+	windows map[string]*coalesceWindow
+	mutex   sync.Mutex
+}
+
+type coalesceWindow struct {
+	start   time.Time
+	samples []checker.CheckResult
+}
+
+// NewCoalescer creates a coalescer
+func NewCoalescer() *Coalescer {
+	return &Coalescer{windows: make(map[string]*coalesceWindow)}
+}
+
+// Add records a result. Once a result falls into a later window, the
+// aggregate of the previous window is returned for storage.
+func (c *Coalescer) Add(result checker.CheckResult) (checker.CheckResult, bool) {
+	start := result.CheckedAt.Truncate(CoalesceWindow)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	window, exists := c.windows[result.URL]
+	if !exists {
+		c.windows[result.URL] = &coalesceWindow{start: start, samples: []checker.CheckResult{result}}
+		return checker.CheckResult{}, false
+	}
+	if !start.After(window.start) {
+		window.samples = append(window.samples, result)
+		return checker.CheckResult{}, false
+	}
+
+	aggregate := coalesce(window.start, window.samples)
+	c.windows[result.URL] = &coalesceWindow{start: start, samples: []checker.CheckResult{result}}
+	return aggregate, true
+}
+
+// Drain returns the aggregates of every open window and forgets them, so
+// nothing is lost on shutdown
+func (c *Coalescer) Drain() []checker.CheckResult {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	aggregates := make([]checker.CheckResult, 0, len(c.windows))
+	for url, window := range c.windows {
+		aggregates = append(aggregates, coalesce(window.start, window.samples))
+		delete(c.windows, url)
+	}
+	return aggregates
+}
+
+// Forget drops the open window of a URL, e.g. when its endpoint is removed
+func (c *Coalescer) Forget(url string) {
+	c.mutex.Lock()
+	delete(c.windows, url)
+	c.mutex.Unlock()
+}
+
+// coalesce builds one result from a window's samples. Identity fields come
+// from the last failure, or the last sample when all succeeded; timings and
+// extracted metrics are averaged.
+func coalesce(start time.Time, samples []checker.CheckResult) checker.CheckResult {
+	base := samples[len(samples)-1]
+	failed := 0
+	for _, sample := range samples {
+		if !sample.IsHealthy {
+			failed++
+			base = sample
+		}
+	}
+
+	aggregate := base
+	aggregate.CheckedAt = start
+	aggregate.IsHealthy = failed == 0
+	aggregate.Metadata = make(map[string]interface{}, len(base.Metadata)+4)
+	for key, value := range base.Metadata {
+		aggregate.Metadata[key] = value
+	}
+
+	var total, ttfb time.Duration
+	minLatency, maxLatency := samples[0].ResponseTime, samples[0].ResponseTime
+	metrics := map[string]float64{}
+	metricCounts := map[string]int{}
+	for _, sample := range samples {
+		total += sample.ResponseTime
+		ttfb += sample.TTFB
+		if sample.ResponseTime < minLatency {
+			minLatency = sample.ResponseTime
+		}
+		if sample.ResponseTime > maxLatency {
+			maxLatency = sample.ResponseTime
+		}
+		for name, value := range sample.Metrics {
+			metrics[name] += value
+			metricCounts[name]++
+		}
+	}
+	n := time.Duration(len(samples))
+	aggregate.ResponseTime = total / n
+	aggregate.TTFB = ttfb / n
+
+	aggregate.Metrics = nil
+	if len(metrics) > 0 {
+		aggregate.Metrics = make(map[string]float64, len(metrics))
+		for name, sum := range metrics {
+			aggregate.Metrics[name] = sum / float64(metricCounts[name])
+		}
+	}
+
+	aggregate.Metadata[MetaSamples] = len(samples)
+	aggregate.Metadata[MetaFailedSamples] = failed
+	aggregate.Metadata[MetaLatencyMinMS] = minLatency.Milliseconds()
+	aggregate.Metadata[MetaLatencyMaxMS] = maxLatency.Milliseconds()
+	return aggregate
+}
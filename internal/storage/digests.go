@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DigestRecord is one weekly AI digest of the fleet
+type DigestRecord struct {
+	ID          int64           `json:"id"`
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Digest      json.RawMessage `json:"digest"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// SaveDigest stores a digest and returns its ID
+func (s *sqlStore) SaveDigest(record DigestRecord) (int64, error) {
+	err := s.queryRow(`
+	INSERT INTO ai_digests (period_start, period_end, digest, generated_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id
+	`, s.ts(record.From), s.ts(record.To), []byte(record.Digest), s.ts(record.GeneratedAt)).Scan(&record.ID)
+	return record.ID, err
+}
+
+// ListDigests returns the most recent digests, newest first
+func (s *sqlStore) ListDigests(limit int) ([]DigestRecord, error) {
+	rows, err := s.query(`
+	SELECT id, period_start, period_end, digest, generated_at
+	FROM ai_digests
+	ORDER BY generated_at DESC, id DESC
+	LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []DigestRecord{}
+	for rows.Next() {
+		var record DigestRecord
+		var digest []byte
+		if err := rows.Scan(&record.ID, &record.From, &record.To, &digest, &record.GeneratedAt); err != nil {
+			return nil, err
+		}
+		record.Digest = digest
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
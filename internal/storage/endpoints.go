@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// OwnerGRPC marks endpoints defined through the gRPC server. The web server
+// and the gRPC server share the endpoints table but each only loads and
+// deletes its own definitions.
+const OwnerGRPC = "grpc"
+
+// EndpointRecord is a persisted endpoint definition. Config holds the
+// endpoint's JSON encoding so storage stays independent of the scheduler.
+type EndpointRecord struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Config    json.RawMessage `json:"config"`
+	Owner     string          `json:"owner,omitempty"` // empty for the web server, see OwnerGRPC
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// SaveEndpoint creates or replaces an endpoint definition
+func (s *sqlStore) SaveEndpoint(record EndpointRecord) error {
+	_, err := s.exec(`
+	INSERT INTO endpoints (id, url, config, owner, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $5)
+	ON CONFLICT (id) DO UPDATE SET url = EXCLUDED.url, config = EXCLUDED.config, owner = EXCLUDED.owner, updated_at = EXCLUDED.updated_at
+	`, record.ID, record.URL, []byte(record.Config), record.Owner, s.ts(time.Now()))
+	return err
+}
+
+// GetEndpoint returns one endpoint definition
+func (s *sqlStore) GetEndpoint(id string) (EndpointRecord, bool, error) {
+	var record EndpointRecord
+	var config []byte
+	err := s.queryRow(`SELECT id, url, config, owner, created_at, updated_at FROM endpoints WHERE id = $1`, id).
+		Scan(&record.ID, &record.URL, &config, &record.Owner, &record.CreatedAt, &record.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return EndpointRecord{}, false, nil
+	}
+	if err != nil {
+		return EndpointRecord{}, false, err
+	}
+	record.Config = config
+	return record, true, nil
+}
+
+// ListEndpoints returns every endpoint definition ordered by URL
+func (s *sqlStore) ListEndpoints() ([]EndpointRecord, error) {
+	rows, err := s.query(`SELECT id, url, config, owner, created_at, updated_at FROM endpoints ORDER BY url`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []EndpointRecord
+	for rows.Next() {
+		var record EndpointRecord
+		var config []byte
+		if err := rows.Scan(&record.ID, &record.URL, &config, &record.Owner, &record.CreatedAt, &record.UpdatedAt); err != nil {
+			return nil, err
+		}
+		record.Config = config
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// DeleteEndpoint removes an endpoint definition. Its check history is kept.
+func (s *sqlStore) DeleteEndpoint(id string) (bool, error) {
+	res, err := s.exec(`DELETE FROM endpoints WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// LatencyBucket aggregates the results of one time bucket. Percentiles use
+// the nearest-rank method over every result in the bucket, failed ones
+// included; results with suspect timings are left out altogether.
+type LatencyBucket struct {
+	Start     time.Time     `json:"start"`
+	Checks    int           `json:"checks"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"errorRate"` // failed share of checks, 0 to 1
+	Avg       time.Duration `json:"avg"`
+	P50       time.Duration `json:"p50"`
+	P95       time.Duration `json:"p95"`
+	P99       time.Duration `json:"p99"`
+}
+
+// GetLatencyBuckets aggregates the results of url in [from, to) into buckets
+// of the given size, aligned to multiples of it since the Unix epoch. The
+// aggregation runs in the database, so only one row per bucket is read.
+// Buckets without results are omitted.
+func (s *sqlStore) GetLatencyBuckets(url string, from, to time.Time, bucket time.Duration) ([]LatencyBucket, error) {
+	seconds := int64(bucket / time.Second)
+	if seconds < 1 {
+		return nil, fmt.Errorf("bucket must be at least 1s")
+	}
+
+	// Neither database shares a percentile function with the other, so
+	// ranks are computed with window functions
+	rows, err := s.query(`
+		WITH ranked AS (
+			SELECT bucket, response_time_ms, is_healthy,
+				ROW_NUMBER() OVER (PARTITION BY bucket ORDER BY response_time_ms) AS rn,
+				COUNT(*) OVER (PARTITION BY bucket) AS n
+			FROM (
+				SELECT `+s.epochBucket("checked_at")+` AS bucket, response_time_ms, is_healthy
+				FROM check_results
+				WHERE url = $1 AND checked_at >= $2 AND checked_at < $3
+					AND (data_quality IS NULL OR data_quality <> 'suspect')
+			) bucketed
+		)
+		SELECT bucket, COUNT(*), SUM(CASE WHEN is_healthy THEN 0 ELSE 1 END), AVG(response_time_ms),
+			MAX(CASE WHEN rn = (n * 50 + 99) / 100 THEN response_time_ms END),
+			MAX(CASE WHEN rn = (n * 95 + 99) / 100 THEN response_time_ms END),
+			MAX(CASE WHEN rn = (n * 99 + 99) / 100 THEN response_time_ms END)
+		FROM ranked
+		GROUP BY bucket
+		ORDER BY bucket`, url, s.ts(from), s.ts(to), seconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []LatencyBucket{}
+	for rows.Next() {
+		var index int64
+		var avg float64
+		var p50, p95, p99 int64
+		var b LatencyBucket
+		if err := rows.Scan(&index, &b.Checks, &b.Errors, &avg, &p50, &p95, &p99); err != nil {
+			return nil, err
+		}
+		b.Start = time.Unix(index*seconds, 0).UTC()
+		if b.Checks > 0 {
+			b.ErrorRate = float64(b.Errors) / float64(b.Checks)
+		}
+		b.Avg = time.Duration(avg * float64(time.Millisecond))
+		b.P50 = time.Duration(p50) * time.Millisecond
+		b.P95 = time.Duration(p95) * time.Millisecond
+		b.P99 = time.Duration(p99) * time.Millisecond
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+// epochBucket returns the SQL for the bucket index of a timestamp column,
+// with the bucket size in seconds as parameter $4
+func (s *sqlStore) epochBucket(column string) string {
+	if s.driver == DriverSQLite {
+		return "CAST(strftime('%s', " + column + ") AS INTEGER) / $4"
+	}
+	return "CAST(FLOOR(EXTRACT(EPOCH FROM " + column + ") / $4) AS BIGINT)"
+}
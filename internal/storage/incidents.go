@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Incident is a period during which an endpoint was down
+type Incident struct {
+	ID        int64      `json:"id"`
+	URL       string     `json:"url"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"` // nil while ongoing
+	Cause     string     `json:"cause,omitempty"`
+	Source    string     `json:"source,omitempty"` // e.g. the tool an incident was imported from
+}
+
+// SaveIncident records an outage the monitor observed itself and returns
+// its ID
+func (s *sqlStore) SaveIncident(incident Incident) (int64, error) {
+	err := s.queryRow(`
+	INSERT INTO incidents (url, started_at, ended_at, cause, source)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id
+	`, incident.URL, s.ts(incident.StartedAt), s.tsPtr(incident.EndedAt), nullString(incident.Cause), nullString(incident.Source)).Scan(&incident.ID)
+	return incident.ID, err
+}
+
+// GetIncidents returns the incidents of a URL that overlap [from, to], oldest first
+func (s *sqlStore) GetIncidents(url string, from, to time.Time) ([]Incident, error) {
+	rows, err := s.query(`
+	SELECT id, url, started_at, ended_at, cause, source
+	FROM incidents
+	WHERE url = $1 AND started_at <= $3 AND (ended_at IS NULL OR ended_at >= $2)
+	ORDER BY started_at
+	`, url, s.ts(from), s.ts(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []Incident
+	for rows.Next() {
+		var incident Incident
+		var endedAt *time.Time
+		var cause, source *string
+		if err := rows.Scan(&incident.ID, &incident.URL, &incident.StartedAt, &endedAt, &cause, &source); err != nil {
+			return nil, err
+		}
+		incident.EndedAt = endedAt
+		if cause != nil {
+			incident.Cause = *cause
+		}
+		if source != nil {
+			incident.Source = *source
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// GetIncident returns one incident
+func (s *sqlStore) GetIncident(id int64) (Incident, bool, error) {
+	var incident Incident
+	var endedAt *time.Time
+	var cause, source *string
+	err := s.queryRow(`SELECT id, url, started_at, ended_at, cause, source FROM incidents WHERE id = $1`, id).
+		Scan(&incident.ID, &incident.URL, &incident.StartedAt, &endedAt, &cause, &source)
+	if err == sql.ErrNoRows {
+		return Incident{}, false, nil
+	}
+	if err != nil {
+		return Incident{}, false, err
+	}
+	incident.EndedAt = endedAt
+	if cause != nil {
+		incident.Cause = *cause
+	}
+	if source != nil {
+		incident.Source = *source
+	}
+	return incident, true, nil
+}
+
+// SavePostmortem stores the postmortem written about an incident,
+// replacing an earlier one
+func (s *sqlStore) SavePostmortem(incidentID int64, postmortem []byte) error {
+	_, err := s.exec(`UPDATE incidents SET postmortem = $2 WHERE id = $1`, incidentID, postmortem)
+	return err
+}
+
+// GetPostmortem returns the postmortem of an incident, false while none
+// has been written
+func (s *sqlStore) GetPostmortem(incidentID int64) ([]byte, bool, error) {
+	var postmortem []byte
+	err := s.queryRow(`SELECT postmortem FROM incidents WHERE id = $1`, incidentID).Scan(&postmortem)
+	if err == sql.ErrNoRows || (err == nil && postmortem == nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return postmortem, true, nil
+}
+
+// ReplaceImported stores backfilled history for url, first removing results
+// and incidents previously imported from source over the same period so an
+// export can be imported again without duplicates
+func (s *sqlStore) ReplaceImported(url, source string, results []checker.CheckResult, incidents []Incident) error {
+	if len(results) == 0 && len(incidents) == 0 {
+		return nil
+	}
+
+	var from, to time.Time
+	for i, result := range results {
+		if i == 0 || result.CheckedAt.Before(from) {
+			from = result.CheckedAt
+		}
+		if i == 0 || result.CheckedAt.After(to) {
+			to = result.CheckedAt
+		}
+	}
+
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(results) > 0 {
+		if _, err := tx.Exec(`DELETE FROM check_results WHERE url = $1 AND check_trigger = $2 AND checked_at BETWEEN $3 AND $4`,
+			url, results[0].Trigger, s.ts(from), s.ts(to)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM incidents WHERE url = $1 AND source = $2`, url, source); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if err := s.insertResult(tx, result, false); err != nil {
+			return err
+		}
+	}
+	for _, incident := range incidents {
+		_, err := tx.Exec(`
+		INSERT INTO incidents (url, started_at, ended_at, cause, source)
+		VALUES ($1, $2, $3, $4, $5)
+		`, incident.URL, s.ts(incident.StartedAt), s.tsPtr(incident.EndedAt), nullString(incident.Cause), nullString(source))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
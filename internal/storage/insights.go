@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// InsightRecord is an insight that has been asked about, with the results it
+// was generated from so follow-ups keep their context across restarts
+type InsightRecord struct {
+	ID        string          `json:"id"`
+	Insight   json.RawMessage `json:"insight"`
+	Snapshot  json.RawMessage `json:"snapshot"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// FollowUpRecord is one question about an insight and its answer
+type FollowUpRecord struct {
+	InsightID string    `json:"insightId"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	Tier      string    `json:"tier,omitempty"`
+	AskedAt   time.Time `json:"askedAt"`
+}
+
+// SaveInsight stores an insight unless it is already stored
+func (s *sqlStore) SaveInsight(record InsightRecord) error {
+	_, err := s.exec(`
+	INSERT INTO insights (id, insight, snapshot, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (id) DO NOTHING
+	`, record.ID, []byte(record.Insight), []byte(record.Snapshot), s.ts(record.CreatedAt))
+	return err
+}
+
+// GetInsight returns a stored insight
+func (s *sqlStore) GetInsight(id string) (InsightRecord, bool, error) {
+	var record InsightRecord
+	var insight, snapshot []byte
+	err := s.queryRow(`SELECT id, insight, snapshot, created_at FROM insights WHERE id = $1`, id).
+		Scan(&record.ID, &insight, &snapshot, &record.CreatedAt)
+	if err == sql.ErrNoRows {
+		return InsightRecord{}, false, nil
+	}
+	if err != nil {
+		return InsightRecord{}, false, err
+	}
+	record.Insight = insight
+	record.Snapshot = snapshot
+	return record, true, nil
+}
+
+// SaveFollowUp appends a question and answer to an insight's thread
+func (s *sqlStore) SaveFollowUp(record FollowUpRecord) error {
+	_, err := s.exec(`
+	INSERT INTO insight_followups (insight_id, question, answer, tier, asked_at)
+	VALUES ($1, $2, $3, $4, $5)
+	`, record.InsightID, record.Question, record.Answer, nullString(record.Tier), s.ts(record.AskedAt))
+	return err
+}
+
+// GetFollowUps returns an insight's thread, oldest first
+func (s *sqlStore) GetFollowUps(insightID string) ([]FollowUpRecord, error) {
+	rows, err := s.query(`
+	SELECT insight_id, question, answer, tier, asked_at
+	FROM insight_followups
+	WHERE insight_id = $1
+	ORDER BY asked_at, id
+	`, insightID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []FollowUpRecord
+	for rows.Next() {
+		var record FollowUpRecord
+		var tier sql.NullString
+		if err := rows.Scan(&record.InsightID, &record.Question, &record.Answer, &tier, &record.AskedAt); err != nil {
+			return nil, err
+		}
+		record.Tier = tier.String
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
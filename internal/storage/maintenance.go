@@ -0,0 +1,89 @@
+package storage
+
+import "time"
+
+// MaintenanceWindow is a planned period excluded from SLA reports. It
+// applies to one URL, to endpoints carrying Tag, or to every endpoint when
+// both are empty.
+type MaintenanceWindow struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url,omitempty"`
+	Tag       string    `json:"tag,omitempty"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Reason    string    `json:"reason"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Covers reports whether the window applies to an endpoint
+func (m MaintenanceWindow) Covers(url string, tags []string) bool {
+	if m.URL != "" {
+		return m.URL == url
+	}
+	if m.Tag == "" {
+		return true
+	}
+	for _, tag := range tags {
+		if tag == m.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveMaintenance stores a new maintenance window and returns it with its ID
+func (s *sqlStore) SaveMaintenance(window MaintenanceWindow) (MaintenanceWindow, error) {
+	err := s.queryRow(`
+	INSERT INTO maintenance_windows (url, tag, starts_at, ends_at, reason, created_by, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id
+	`, nullString(window.URL), nullString(window.Tag), s.ts(window.StartsAt), s.ts(window.EndsAt),
+		window.Reason, nullString(window.CreatedBy), s.ts(window.CreatedAt)).Scan(&window.ID)
+	return window, err
+}
+
+// GetMaintenance returns the maintenance windows overlapping [from, to),
+// earliest first
+func (s *sqlStore) GetMaintenance(from, to time.Time) ([]MaintenanceWindow, error) {
+	rows, err := s.query(`
+	SELECT id, url, tag, starts_at, ends_at, reason, created_by, created_at
+	FROM maintenance_windows
+	WHERE starts_at < $2 AND ends_at > $1
+	ORDER BY starts_at, id
+	`, s.ts(from), s.ts(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []MaintenanceWindow{}
+	for rows.Next() {
+		var window MaintenanceWindow
+		var url, tag, createdBy *string
+		if err := rows.Scan(&window.ID, &url, &tag, &window.StartsAt, &window.EndsAt, &window.Reason, &createdBy, &window.CreatedAt); err != nil {
+			return nil, err
+		}
+		if url != nil {
+			window.URL = *url
+		}
+		if tag != nil {
+			window.Tag = *tag
+		}
+		if createdBy != nil {
+			window.CreatedBy = *createdBy
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+// DeleteMaintenance removes a maintenance window
+func (s *sqlStore) DeleteMaintenance(id int64) (bool, error) {
+	res, err := s.exec(`DELETE FROM maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"api-monitor/internal/checker"
+)
+
+// Result fields without a column of their own are stored in the metadata
+// document next to CheckResult.Metadata, so adding one needs no migration
+const (
+	metaTLSIssuer    = "tls_issuer"
+	metaServerHeader = "server_header"
+	metaDNSAnswers   = "dns_answers"
+)
+
+// metadataDocument builds the metadata column of a result, nil when empty
+func metadataDocument(result checker.CheckResult) (*string, error) {
+	doc := make(map[string]interface{}, len(result.Metadata)+3)
+	for key, value := range result.Metadata {
+		doc[key] = value
+	}
+	if result.TLSIssuer != "" {
+		doc[metaTLSIssuer] = result.TLSIssuer
+	}
+	if result.ServerHeader != "" {
+		doc[metaServerHeader] = result.ServerHeader
+	}
+	if len(result.DNSAnswers) > 0 {
+		doc[metaDNSAnswers] = result.DNSAnswers
+	}
+	if len(doc) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return nullString(string(data)), nil
+}
+
+// applyMetadata restores a stored metadata document onto result
+func applyMetadata(data string, result *checker.CheckResult) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return
+	}
+	for key, raw := range doc {
+		switch key {
+		case metaTLSIssuer:
+			json.Unmarshal(raw, &result.TLSIssuer)
+		case metaServerHeader:
+			json.Unmarshal(raw, &result.ServerHeader)
+		case metaDNSAnswers:
+			json.Unmarshal(raw, &result.DNSAnswers)
+		default:
+			var value interface{}
+			if json.Unmarshal(raw, &value) == nil {
+				result.SetMetadata(key, value)
+			}
+		}
+	}
+}
+
+// labelValues converts labels for jsonConditions
+func labelValues(labels map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(labels))
+	for name, value := range labels {
+		values[name] = value
+	}
+	return values
+}
+
+// metadataValues converts metadata filters for jsonConditions. Values that
+// read as numbers or booleans match those JSON types, everything else
+// matches strings.
+func metadataValues(filters map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(filters))
+	for key, value := range filters {
+		if value == "true" || value == "false" {
+			values[key] = value == "true"
+		} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+			values[key] = f
+		} else {
+			values[key] = value
+		}
+	}
+	return values
+}
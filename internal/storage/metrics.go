@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// MetricPoint is one extracted metric value
+type MetricPoint struct {
+	Name      string    `json:"name"`
+	Value     float64   `json:"value"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// SaveMetrics stores the metrics extracted by a check as time-series points
+func (s *sqlStore) SaveMetrics(result checker.CheckResult) error {
+	if len(result.Metrics) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for name, value := range result.Metrics {
+		_, err := tx.Exec(`INSERT INTO check_metrics (url, name, value, checked_at) VALUES ($1, $2, $3, $4)`,
+			result.URL, name, value, s.ts(result.CheckedAt))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMetrics returns points for url between from and to, oldest first. An
+// empty name returns every metric of the endpoint.
+func (s *sqlStore) GetMetrics(url, name string, from, to time.Time, limit int) ([]MetricPoint, error) {
+	rows, err := s.query(`
+		SELECT name, value, checked_at FROM (
+			SELECT name, value, checked_at FROM check_metrics
+			WHERE url = $1 AND ($2 = '' OR name = $2) AND checked_at >= $3 AND checked_at < $4
+			ORDER BY checked_at DESC
+			LIMIT $5
+		) recent ORDER BY checked_at`, url, name, s.ts(from), s.ts(to), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []MetricPoint{}
+	for rows.Next() {
+		var point MetricPoint
+		if err := rows.Scan(&point.Name, &point.Value, &point.CheckedAt); err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -2,6 +2,10 @@ package storage
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"api-monitor/internal/checker"
@@ -10,7 +14,7 @@ import (
 
 // PostgresStore handles database operations
 type PostgresStore struct {
-	db *sql.DB
+	sqlStore
 }
 
 // NewPostgresStore creates a new PostgreSQL storage
@@ -22,13 +26,15 @@ func NewPostgresStore(connectionString string) (*PostgresStore, error) {
 
 	// Test connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	store := &PostgresStore{db: db}
+	store := &PostgresStore{sqlStore{db: db, driver: DriverPostgres}}
 	
 	// Create tables if they don't exist
 	if err := store.createTables(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -50,17 +56,186 @@ func (s *PostgresStore) createTables() error {
 
 	CREATE INDEX IF NOT EXISTS idx_check_results_url ON check_results(url);
 	CREATE INDEX IF NOT EXISTS idx_check_results_checked_at ON check_results(checked_at);
+	CREATE INDEX IF NOT EXISTS idx_check_results_url_checked_at ON check_results(url, checked_at);
+
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS remote_ip VARCHAR(45);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS asn BIGINT;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS as_org VARCHAR(255);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS bytes_downloaded BIGINT;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS throughput_mbps DOUBLE PRECISION;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS ttfb_ms INTEGER;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS check_trigger VARCHAR(20);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS data_quality VARCHAR(20);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS quality_issue TEXT;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS dns_ms INTEGER;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS connect_ms INTEGER;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS tls_ms INTEGER;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS download_ms INTEGER;
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS labels JSONB;
+	CREATE INDEX IF NOT EXISTS idx_check_results_labels ON check_results USING GIN (labels);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS metadata JSONB;
+	CREATE INDEX IF NOT EXISTS idx_check_results_metadata ON check_results USING GIN (metadata jsonb_path_ops);
+	ALTER TABLE check_results ADD COLUMN IF NOT EXISTS signature TEXT;
+
+	CREATE TABLE IF NOT EXISTS latency_sketches (
+		url VARCHAR(500) NOT NULL,
+		window_start TIMESTAMP NOT NULL,
+		window_seconds INTEGER NOT NULL,
+		sketch JSONB NOT NULL,
+		PRIMARY KEY (url, window_start, window_seconds)
+	);
+	CREATE INDEX IF NOT EXISTS idx_latency_sketches_window ON latency_sketches(window_start);
+
+	CREATE TABLE IF NOT EXISTS check_rollups (
+		url VARCHAR(500) NOT NULL,
+		bucket_start TIMESTAMP NOT NULL,
+		bucket_seconds INTEGER NOT NULL,
+		checks INTEGER NOT NULL,
+		failed_checks INTEGER NOT NULL,
+		latency_sum_ms BIGINT NOT NULL,
+		latency_min_ms INTEGER NOT NULL,
+		latency_max_ms INTEGER NOT NULL,
+		PRIMARY KEY (url, bucket_start, bucket_seconds)
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_rollups_bucket ON check_rollups(bucket_seconds, bucket_start);
+
+	CREATE TABLE IF NOT EXISTS check_metrics (
+		id SERIAL PRIMARY KEY,
+		url VARCHAR(500) NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		checked_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_metrics_series ON check_metrics(url, name, checked_at);
+
+	CREATE TABLE IF NOT EXISTS incidents (
+		id SERIAL PRIMARY KEY,
+		url VARCHAR(500) NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		cause TEXT,
+		source VARCHAR(50)
+	);
+	CREATE INDEX IF NOT EXISTS idx_incidents_url ON incidents(url, started_at);
+	ALTER TABLE incidents ADD COLUMN IF NOT EXISTS postmortem JSONB;
+
+	CREATE TABLE IF NOT EXISTS endpoints (
+		id VARCHAR(64) PRIMARY KEY,
+		url VARCHAR(500) NOT NULL,
+		config JSONB NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+	ALTER TABLE endpoints ADD COLUMN IF NOT EXISTS owner VARCHAR(20) NOT NULL DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS ai_analyses (
+		id SERIAL PRIMARY KEY,
+		scope VARCHAR(100) NOT NULL,
+		insights JSONB NOT NULL,
+		endpoint_count INTEGER NOT NULL,
+		generated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_analyses_scope ON ai_analyses(scope, generated_at);
+
+	CREATE TABLE IF NOT EXISTS ai_digests (
+		id SERIAL PRIMARY KEY,
+		period_start TIMESTAMP NOT NULL,
+		period_end TIMESTAMP NOT NULL,
+		digest JSONB NOT NULL,
+		generated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_digests_generated ON ai_digests(generated_at);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id SERIAL PRIMARY KEY,
+		action VARCHAR(100) NOT NULL,
+		actor VARCHAR(255),
+		remote_addr VARCHAR(64),
+		details JSONB,
+		at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+
+	CREATE TABLE IF NOT EXISTS maintenance_windows (
+		id SERIAL PRIMARY KEY,
+		url VARCHAR(500),
+		tag VARCHAR(100),
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		reason TEXT NOT NULL,
+		created_by VARCHAR(255),
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_maintenance_windows_starts_at ON maintenance_windows(starts_at);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(16) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		scope VARCHAR(16) NOT NULL,
+		key_hash VARCHAR(64) NOT NULL UNIQUE,
+		created_by VARCHAR(255),
+		created_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS alert_states (
+		endpoint_id VARCHAR(64) PRIMARY KEY,
+		state JSONB NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS check_captures (
+		id SERIAL PRIMARY KEY,
+		endpoint_id VARCHAR(64) NOT NULL,
+		url VARCHAR(500) NOT NULL,
+		checked_at TIMESTAMP NOT NULL,
+		status_code INTEGER,
+		error TEXT,
+		exchange JSONB NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_captures_endpoint ON check_captures(endpoint_id, checked_at);
+
+	CREATE TABLE IF NOT EXISTS insights (
+		id VARCHAR(32) PRIMARY KEY,
+		insight JSONB NOT NULL,
+		snapshot JSONB NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS insight_followups (
+		id SERIAL PRIMARY KEY,
+		insight_id VARCHAR(32) NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+		question TEXT NOT NULL,
+		answer TEXT NOT NULL,
+		tier VARCHAR(100),
+		asked_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_insight_followups_insight ON insight_followups(insight_id, asked_at);
 	`
 	
-	_, err := s.db.Exec(query)
+	_, err := s.exec(query)
 	return err
 }
 
 // SaveResult saves a check result to the database
-func (s *PostgresStore) SaveResult(result checker.CheckResult) error {
+func (s *sqlStore) SaveResult(result checker.CheckResult) error {
+	return s.insertResult(s.db, result, false)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertResult writes a check result using db or a transaction, signing it
+// when signing is on or, with keepSignature, storing result.Signature as is
+func (s *sqlStore) insertResult(db execer, result checker.CheckResult, keepSignature bool) error {
 	query := `
-	INSERT INTO check_results (url, status_code, response_time_ms, is_healthy, error_message, checked_at)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	INSERT INTO check_results (url, status_code, response_time_ms, is_healthy, error_message, checked_at,
+		remote_ip, country, asn, as_org, bytes_downloaded, throughput_mbps, ttfb_ms, check_trigger,
+		data_quality, quality_issue, dns_ms, connect_ms, tls_ms, download_ms, labels, metadata, signature)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 	`
 	
 	responseTimeMs := int(result.ResponseTime.Milliseconds())
@@ -68,29 +243,90 @@ func (s *PostgresStore) SaveResult(result checker.CheckResult) error {
 	if result.Error != "" {
 		errorMessage = &result.Error
 	}
-	
-	_, err := s.db.Exec(query, 
-		result.URL, 
-		result.StatusCode, 
-		responseTimeMs, 
-		result.IsHealthy, 
-		errorMessage, 
-		result.CheckedAt,
+	var labels *string
+	if len(result.Labels) > 0 {
+		data, err := json.Marshal(result.Labels)
+		if err != nil {
+			return err
+		}
+		labels = nullString(string(data))
+	}
+	metadata, err := metadataDocument(result)
+	if err != nil {
+		return err
+	}
+	var signature *string
+	if keepSignature {
+		signature = nullString(result.Signature)
+	} else if s.signer != nil {
+		// PostgreSQL keeps microseconds, so sign the time as it will be stored
+		result.CheckedAt = result.CheckedAt.Truncate(time.Microsecond)
+		payload := signaturePayload(result.URL, s.ts(result.CheckedAt), result.StatusCode, responseTimeMs, result.IsHealthy, result.Error, result.Trigger)
+		value, err := s.signer.Sign(payload)
+		if err != nil {
+			return err
+		}
+		signature = &value
+	}
+
+	_, err = db.Exec(query,
+		result.URL,
+		result.StatusCode,
+		responseTimeMs,
+		result.IsHealthy,
+		errorMessage,
+		s.ts(result.CheckedAt),
+		nullString(result.RemoteIP),
+		nullString(result.Country),
+		result.ASN,
+		nullString(result.ASOrg),
+		result.BytesDownloaded,
+		result.ThroughputMBps,
+		int(result.TTFB.Milliseconds()),
+		nullString(result.Trigger),
+		nullString(result.Quality),
+		nullString(result.QualityIssue),
+		int(result.DNSLookup.Milliseconds()),
+		int(result.TCPConnect.Milliseconds()),
+		int(result.TLSHandshake.Milliseconds()),
+		int(result.BodyDownload.Milliseconds()),
+		labels,
+		metadata,
+		signature,
 	)
 	
 	return err
 }
 
 // SaveResults saves multiple check results
-func (s *PostgresStore) SaveResults(results []checker.CheckResult) error {
-	tx, err := s.db.Begin()
+func (s *sqlStore) SaveResults(results []checker.CheckResult) error {
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, result := range results {
+		if err := s.insertResult(tx, result, false); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RestoreResults saves results read back from storage, e.g. from an archive,
+// with the signatures they were stored with rather than signing them again,
+// so tampering with them in between still fails verification
+func (s *sqlStore) RestoreResults(results []checker.CheckResult) error {
+	tx, err := s.db.BeginTx(s.context(), nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
 	for _, result := range results {
-		if err := s.SaveResult(result); err != nil {
+		if err := s.insertResult(tx, result, true); err != nil {
 			return err
 		}
 	}
@@ -99,16 +335,45 @@ func (s *PostgresStore) SaveResults(results []checker.CheckResult) error {
 }
 
 // GetRecentResults gets recent results for a URL
-func (s *PostgresStore) GetRecentResults(url string, limit int) ([]checker.CheckResult, error) {
+func (s *sqlStore) GetRecentResults(url string, limit int) ([]checker.CheckResult, error) {
+	return s.QueryResults(ResultQuery{URL: url, Limit: limit})
+}
+
+// QueryResults returns the most recent results matching query, newest first
+func (s *sqlStore) QueryResults(q ResultQuery) ([]checker.CheckResult, error) {
+	var conditions []string
+	var args []interface{}
+	if q.URL != "" {
+		args = append(args, q.URL)
+		conditions = append(conditions, fmt.Sprintf("url = $%d", len(args)))
+	}
+	if !q.From.IsZero() {
+		args = append(args, s.ts(q.From))
+		conditions = append(conditions, fmt.Sprintf("checked_at >= $%d", len(args)))
+	}
+	if !q.To.IsZero() {
+		args = append(args, s.ts(q.To))
+		conditions = append(conditions, fmt.Sprintf("checked_at < $%d", len(args)))
+	}
+	if q.Failed {
+		conditions = append(conditions, "NOT is_healthy")
+	}
+	conditions, args = s.jsonConditions("labels", labelValues(q.Labels), conditions, args)
+	conditions, args = s.jsonConditions("metadata", metadataValues(q.Metadata), conditions, args)
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, q.Limit)
+
 	query := `
-	SELECT url, status_code, response_time_ms, is_healthy, error_message, checked_at
+	SELECT ` + resultColumns + `
 	FROM check_results 
-	WHERE url = $1 
+	` + where + `
 	ORDER BY checked_at DESC 
-	LIMIT $2
-	`
+	LIMIT $` + fmt.Sprint(len(args))
 	
-	rows, err := s.db.Query(query, url, limit)
+	rows, err := s.query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -116,34 +381,220 @@ func (s *PostgresStore) GetRecentResults(url string, limit int) ([]checker.Check
 
 	var results []checker.CheckResult
 	for rows.Next() {
-		var result checker.CheckResult
-		var responseTimeMs int
-		var errorMessage sql.NullString
-		
-		err := rows.Scan(
-			&result.URL,
-			&result.StatusCode,
-			&responseTimeMs,
-			&result.IsHealthy,
-			&errorMessage,
-			&result.CheckedAt,
-		)
+		result, err := scanResult(rows)
 		if err != nil {
 			return nil, err
 		}
-		
-		result.ResponseTime = time.Duration(responseTimeMs) * time.Millisecond
-		if errorMessage.Valid {
-			result.Error = errorMessage.String
-		}
-		
 		results = append(results, result)
 	}
 	
 	return results, rows.Err()
 }
 
+// EachResult passes every stored result of url to fn, oldest first, without
+// loading them all at once. An error from fn stops the iteration.
+func (s *sqlStore) EachResult(url string, fn func(checker.CheckResult) error) error {
+	rows, err := s.query(`SELECT `+resultColumns+` FROM check_results WHERE url = $1 ORDER BY checked_at`, url)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result, err := scanResult(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// resultColumns are the check_results columns scanResult reads
+const resultColumns = `url, status_code, response_time_ms, is_healthy, error_message, checked_at,
+		remote_ip, country, COALESCE(asn, 0), as_org,
+		COALESCE(bytes_downloaded, 0), COALESCE(throughput_mbps, 0), COALESCE(ttfb_ms, 0),
+		check_trigger, data_quality, quality_issue,
+		COALESCE(dns_ms, 0), COALESCE(connect_ms, 0), COALESCE(tls_ms, 0), COALESCE(download_ms, 0),
+		labels, metadata, signature`
+
+// scanResult reads a row of resultColumns
+func scanResult(rows *sql.Rows) (checker.CheckResult, error) {
+	var result checker.CheckResult
+	var responseTimeMs, ttfbMs, dnsMs, connectMs, tlsMs, downloadMs int
+	var errorMessage sql.NullString
+	var remoteIP, country, asOrg, trigger, quality, qualityIssue, labels, metadata, signature sql.NullString
+	
+	err := rows.Scan(
+		&result.URL,
+		&result.StatusCode,
+		&responseTimeMs,
+		&result.IsHealthy,
+		&errorMessage,
+		&result.CheckedAt,
+		&remoteIP,
+		&country,
+		&result.ASN,
+		&asOrg,
+		&result.BytesDownloaded,
+		&result.ThroughputMBps,
+		&ttfbMs,
+		&trigger,
+		&quality,
+		&qualityIssue,
+		&dnsMs,
+		&connectMs,
+		&tlsMs,
+		&downloadMs,
+		&labels,
+		&metadata,
+		&signature,
+	)
+	if err != nil {
+		return result, err
+	}
+	if labels.Valid {
+		json.Unmarshal([]byte(labels.String), &result.Labels)
+	}
+	if metadata.Valid {
+		applyMetadata(metadata.String, &result)
+	}
+	
+	result.ResponseTime = time.Duration(responseTimeMs) * time.Millisecond
+	result.TTFB = time.Duration(ttfbMs) * time.Millisecond
+	result.DNSLookup = time.Duration(dnsMs) * time.Millisecond
+	result.TCPConnect = time.Duration(connectMs) * time.Millisecond
+	result.TLSHandshake = time.Duration(tlsMs) * time.Millisecond
+	result.BodyDownload = time.Duration(downloadMs) * time.Millisecond
+	if errorMessage.Valid {
+		result.Error = errorMessage.String
+	}
+	result.RemoteIP = remoteIP.String
+	result.Country = country.String
+	result.ASOrg = asOrg.String
+	result.Trigger = trigger.String
+	result.Quality = quality.String
+	result.QualityIssue = qualityIssue.String
+	result.Signature = signature.String
+	return result, nil
+}
+
+// ListURLs returns every URL that has stored results carrying labels
+func (s *sqlStore) ListURLs(labels map[string]string) ([]string, error) {
+	conditions, args := s.jsonConditions("labels", labelValues(labels), nil, nil)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := s.query(`SELECT DISTINCT url FROM check_results`+where+` ORDER BY url`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// MergeResults moves the history of the source URLs onto target, rollups
+// included, and returns the number of results moved. Rollups of a bucket
+// target already has are added to it.
+func (s *sqlStore) MergeResults(target string, sources []string) (int64, error) {
+	if len(sources) == 0 {
+		return 0, nil
+	}
+	placeholders := make([]string, len(sources))
+	args := []interface{}{target}
+	for i, source := range sources {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, source)
+	}
+	in := `url IN (` + strings.Join(placeholders, ", ") + `)`
+
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE check_results SET url = $1 WHERE `+in, args...)
+	if err != nil {
+		return 0, err
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO check_rollups (url, bucket_start, bucket_seconds, checks, failed_checks, latency_sum_ms, latency_min_ms, latency_max_ms)
+		SELECT CAST($1 AS TEXT), bucket_start, bucket_seconds, SUM(checks), SUM(failed_checks),
+			SUM(latency_sum_ms), MIN(latency_min_ms), MAX(latency_max_ms)
+		FROM check_rollups
+		WHERE `+in+`
+		GROUP BY bucket_start, bucket_seconds
+		`+mergeRollup, args...); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM check_rollups WHERE url <> $1 AND `+in, args...); err != nil {
+		return 0, err
+	}
+	return moved, tx.Commit()
+}
+
+// jsonConditions appends a condition per required key of a JSON column.
+// PostgreSQL uses JSONB containment, which the GIN indexes serve.
+func (s *sqlStore) jsonConditions(column string, values map[string]interface{}, conditions []string, args []interface{}) ([]string, []interface{}) {
+	if len(values) == 0 {
+		return conditions, args
+	}
+	if s.driver != DriverSQLite {
+		data, _ := json.Marshal(values)
+		args = append(args, string(data))
+		return append(conditions, fmt.Sprintf("%s @> $%d::jsonb", column, len(args))), args
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := values[name]
+		if b, ok := value.(bool); ok {
+			// json_extract returns JSON booleans as 1 and 0
+			value = 0
+			if b {
+				value = 1
+			}
+		}
+		args = append(args, `$."`+name+`"`, value)
+		conditions = append(conditions, fmt.Sprintf("json_extract(%s, $%d) = $%d", column, len(args)-1, len(args)))
+	}
+	return conditions, args
+}
+
+// nullString converts an empty string to SQL NULL
+func nullString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// Ping checks that the database answers, within the store's context
+func (s *sqlStore) Ping() error {
+	return s.db.PingContext(s.context())
+}
+
 // Close closes the database connection
-func (s *PostgresStore) Close() error {
+func (s *sqlStore) Close() error {
 	return s.db.Close()
 }
\ No newline at end of file
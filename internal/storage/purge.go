@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PurgeSummary counts the rows removed by DeleteResults
+type PurgeSummary struct {
+	Results   int64 `json:"results"`
+	Metrics   int64 `json:"metrics"`
+	Sketches  int64 `json:"sketches"`
+	Rollups   int64 `json:"rollups"`
+	Incidents int64 `json:"incidents"`
+}
+
+// DeleteResults removes the stored history of url from before, or all of it
+// when before is zero: results, extracted metrics, latency sketches, hourly
+// and daily rollups ending by before and incidents. Everything is removed
+// in one transaction.
+func (s *sqlStore) DeleteResults(url string, before time.Time) (PurgeSummary, error) {
+	var summary PurgeSummary
+	if before.IsZero() {
+		before = time.Now().Add(time.Hour)
+	}
+
+	// Rollup buckets only go once they end by before, so a purge never drops
+	// aggregates of results it keeps
+	err := s.purge(url, []purgeStep{
+		{`DELETE FROM check_results WHERE url = $1 AND checked_at < $2`, []interface{}{s.ts(before)}, &summary.Results},
+		{`DELETE FROM check_metrics WHERE url = $1 AND checked_at < $2`, []interface{}{s.ts(before)}, &summary.Metrics},
+		{`DELETE FROM latency_sketches WHERE url = $1 AND window_start < $2`, []interface{}{s.ts(before)}, &summary.Sketches},
+		{`DELETE FROM check_rollups WHERE url = $1 AND bucket_seconds = 3600 AND bucket_start <= $2`, []interface{}{s.ts(before.Add(-RollupHourly))}, &summary.Rollups},
+		{`DELETE FROM check_rollups WHERE url = $1 AND bucket_seconds = 86400 AND bucket_start <= $2`, []interface{}{s.ts(before.Add(-RollupDaily))}, &summary.Rollups},
+		{`DELETE FROM incidents WHERE url = $1 AND started_at < $2`, []interface{}{s.ts(before)}, &summary.Incidents},
+	})
+	return summary, err
+}
+
+// HasHistory reports whether url has any result, extracted metric, latency
+// sketch, rollup or incident stored from from through to
+func (s *sqlStore) HasHistory(url string, from, to time.Time) (bool, error) {
+	var found int
+	err := s.queryRow(`
+		SELECT 1 FROM check_results WHERE url = $1 AND checked_at >= $2 AND checked_at <= $3
+		UNION ALL SELECT 1 FROM check_metrics WHERE url = $1 AND checked_at >= $2 AND checked_at <= $3
+		UNION ALL SELECT 1 FROM latency_sketches WHERE url = $1 AND window_start >= $2 AND window_start <= $3
+		UNION ALL SELECT 1 FROM check_rollups WHERE url = $1 AND bucket_start >= $2 AND bucket_start <= $3
+		UNION ALL SELECT 1 FROM incidents WHERE url = $1 AND started_at >= $2 AND started_at <= $3
+		LIMIT 1`, url, s.ts(from), s.ts(to)).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteHistoryBetween removes everything HasHistory finds for url from
+// from through to, in one transaction, e.g. to undo a failed restore
+func (s *sqlStore) DeleteHistoryBetween(url string, from, to time.Time) (PurgeSummary, error) {
+	var summary PurgeSummary
+	between := []interface{}{s.ts(from), s.ts(to)}
+	err := s.purge(url, []purgeStep{
+		{`DELETE FROM check_results WHERE url = $1 AND checked_at >= $2 AND checked_at <= $3`, between, &summary.Results},
+		{`DELETE FROM check_metrics WHERE url = $1 AND checked_at >= $2 AND checked_at <= $3`, between, &summary.Metrics},
+		{`DELETE FROM latency_sketches WHERE url = $1 AND window_start >= $2 AND window_start <= $3`, between, &summary.Sketches},
+		{`DELETE FROM check_rollups WHERE url = $1 AND bucket_start >= $2 AND bucket_start <= $3`, between, &summary.Rollups},
+		{`DELETE FROM incidents WHERE url = $1 AND started_at >= $2 AND started_at <= $3`, between, &summary.Incidents},
+	})
+	return summary, err
+}
+
+// purgeStep is one statement of a purge, run with the URL and args and
+// adding the rows it removed to count
+type purgeStep struct {
+	query string
+	args  []interface{}
+	count *int64
+}
+
+// purge runs the steps for url in one transaction
+func (s *sqlStore) purge(url string, steps []purgeStep) error {
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, step := range steps {
+		res, err := tx.Exec(step.query, append([]interface{}{url}, step.args...)...)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		*step.count += n
+	}
+	return tx.Commit()
+}
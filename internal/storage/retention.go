@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Rollup resolutions
+const (
+	RollupHourly = time.Hour
+	RollupDaily  = 24 * time.Hour
+)
+
+// mergeRollup adds rollup rows to the ones already stored for a bucket
+const mergeRollup = `ON CONFLICT (url, bucket_start, bucket_seconds) DO UPDATE SET
+	checks = check_rollups.checks + EXCLUDED.checks,
+	failed_checks = check_rollups.failed_checks + EXCLUDED.failed_checks,
+	latency_sum_ms = check_rollups.latency_sum_ms + EXCLUDED.latency_sum_ms,
+	latency_min_ms = CASE WHEN EXCLUDED.latency_min_ms < check_rollups.latency_min_ms
+		THEN EXCLUDED.latency_min_ms ELSE check_rollups.latency_min_ms END,
+	latency_max_ms = CASE WHEN EXCLUDED.latency_max_ms > check_rollups.latency_max_ms
+		THEN EXCLUDED.latency_max_ms ELSE check_rollups.latency_max_ms END`
+
+// retentionLockKey names the PostgreSQL advisory lock that keeps replicas
+// from rolling up the same results twice
+const retentionLockKey = 7262656
+
+// RetentionPolicy says how long data is kept. Raw results older than Raw
+// are rolled up into hourly and daily aggregates and deleted; the
+// aggregates are kept for Hourly and Daily. Zero keeps data forever.
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// RetentionSummary counts what one retention run changed
+type RetentionSummary struct {
+	RolledUp      int64 `json:"rolledUp"` // raw results aggregated and deleted
+	Metrics       int64 `json:"metrics"`
+	Sketches      int64 `json:"sketches"`
+	ExpiredHourly int64 `json:"expiredHourly"`
+	ExpiredDaily  int64 `json:"expiredDaily"`
+	Skipped       bool  `json:"skipped,omitempty"` // another replica was already running
+}
+
+// Rollup aggregates an endpoint's results over an hour or a day
+type Rollup struct {
+	URL          string        `json:"url"`
+	Start        time.Time     `json:"start"`
+	Resolution   time.Duration `json:"resolution"`
+	Checks       int           `json:"checks"`
+	FailedChecks int           `json:"failedChecks"`
+	AvgLatency   time.Duration `json:"avgLatency"`
+	MinLatency   time.Duration `json:"minLatency"`
+	MaxLatency   time.Duration `json:"maxLatency"`
+}
+
+// ApplyRetention rolls up and deletes the raw results that expired under
+// policy, one day per transaction so a first run over a large history
+// doesn't hold a long lock, then deletes expired aggregates. Extracted
+// metrics expire with raw results and latency sketches with hourly rollups.
+func (s *sqlStore) ApplyRetention(policy RetentionPolicy, now time.Time) (RetentionSummary, error) {
+	var summary RetentionSummary
+	now = now.UTC()
+
+	if policy.Raw > 0 {
+		// Whole days only, so every rolled up bucket is complete
+		cutoff := now.Add(-policy.Raw).Truncate(RollupDaily)
+		var oldest time.Time
+		err := s.queryRow(`
+			SELECT checked_at FROM check_results
+			WHERE checked_at < $1
+			ORDER BY checked_at
+			LIMIT 1`, s.ts(cutoff)).Scan(&oldest)
+		if err != nil && err != sql.ErrNoRows {
+			return summary, err
+		}
+		if err == nil {
+			for day := oldest.UTC().Truncate(RollupDaily); day.Before(cutoff); day = day.Add(RollupDaily) {
+				skipped, err := s.rollupDay(day, &summary)
+				if err != nil {
+					return summary, err
+				}
+				if skipped {
+					summary.Skipped = true
+					return summary, nil
+				}
+			}
+		}
+	}
+
+	for _, step := range []struct {
+		keep  time.Duration
+		query string
+		count *int64
+	}{
+		{policy.Hourly, `DELETE FROM check_rollups WHERE bucket_seconds = 3600 AND bucket_start < $1`, &summary.ExpiredHourly},
+		{policy.Hourly, `DELETE FROM latency_sketches WHERE window_start < $1`, &summary.Sketches},
+		{policy.Daily, `DELETE FROM check_rollups WHERE bucket_seconds = 86400 AND bucket_start < $1`, &summary.ExpiredDaily},
+	} {
+		if step.keep <= 0 {
+			continue
+		}
+		res, err := s.exec(step.query, s.ts(now.Add(-step.keep)))
+		if err != nil {
+			return summary, err
+		}
+		if *step.count, err = res.RowsAffected(); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// rollupDay aggregates the raw results of the day starting at day into
+// hourly and daily rollups and deletes them. It reports whether another
+// replica holds the retention lock.
+func (s *sqlStore) rollupDay(day time.Time, summary *RetentionSummary) (bool, error) {
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// SQLite's single writer already serializes runs
+	if s.driver != DriverSQLite {
+		var locked bool
+		if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, retentionLockKey).Scan(&locked); err != nil {
+			return false, err
+		}
+		if !locked {
+			return true, nil
+		}
+	}
+
+	from, to := s.ts(day), s.ts(day.Add(RollupDaily))
+	for _, resolution := range []time.Duration{RollupHourly, RollupDaily} {
+		seconds := int64(resolution / time.Second)
+		_, err := tx.Exec(`
+			INSERT INTO check_rollups (url, bucket_start, bucket_seconds, checks, failed_checks, latency_sum_ms, latency_min_ms, latency_max_ms)
+			SELECT url, `+s.epochTime("bucket * CAST($3 AS BIGINT)")+`, CAST($3 AS INTEGER), COUNT(*),
+				SUM(CASE WHEN is_healthy THEN 0 ELSE 1 END), SUM(response_time_ms), MIN(response_time_ms), MAX(response_time_ms)
+			FROM (
+				SELECT url, `+s.epochBucket("checked_at")+` AS bucket, response_time_ms, is_healthy
+				FROM check_results
+				WHERE checked_at >= $1 AND checked_at < $2
+			) bucketed
+			GROUP BY url, bucket
+			`+mergeRollup,
+			from, to, seconds, seconds)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, step := range []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM check_results WHERE checked_at >= $1 AND checked_at < $2`, &summary.RolledUp},
+		{`DELETE FROM check_metrics WHERE checked_at >= $1 AND checked_at < $2`, &summary.Metrics},
+	} {
+		res, err := tx.Exec(step.query, from, to)
+		if err != nil {
+			return false, err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		*step.count += count
+	}
+	return false, tx.Commit()
+}
+
+// GetRollups returns the rollups of url at the given resolution with
+// buckets starting in [from, to), oldest first
+func (s *sqlStore) GetRollups(url string, from, to time.Time, resolution time.Duration) ([]Rollup, error) {
+	rows, err := s.query(`
+		SELECT bucket_start, checks, failed_checks, latency_sum_ms, latency_min_ms, latency_max_ms
+		FROM check_rollups
+		WHERE url = $1 AND bucket_seconds = $2 AND bucket_start >= $3 AND bucket_start < $4
+		ORDER BY bucket_start`, url, int64(resolution/time.Second), s.ts(from), s.ts(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []Rollup{}
+	for rows.Next() {
+		rollup := Rollup{URL: url, Resolution: resolution}
+		var sum, min, max int64
+		if err := rows.Scan(&rollup.Start, &rollup.Checks, &rollup.FailedChecks, &sum, &min, &max); err != nil {
+			return nil, err
+		}
+		rollup.Start = rollup.Start.UTC()
+		if rollup.Checks > 0 {
+			rollup.AvgLatency = time.Duration(sum/int64(rollup.Checks)) * time.Millisecond
+		}
+		rollup.MinLatency = time.Duration(min) * time.Millisecond
+		rollup.MaxLatency = time.Duration(max) * time.Millisecond
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
+
+// RollupRecord is a stored rollup as kept, with the latency sum rather than
+// the average, so it can be saved again without losing precision
+type RollupRecord struct {
+	Start        time.Time     `json:"start"`
+	Resolution   time.Duration `json:"resolution"`
+	Checks       int           `json:"checks"`
+	FailedChecks int           `json:"failedChecks"`
+	LatencySumMs int64         `json:"latencySumMs"`
+	LatencyMinMs int64         `json:"latencyMinMs"`
+	LatencyMaxMs int64         `json:"latencyMaxMs"`
+}
+
+// GetRollupRecords returns every rollup of url at any resolution, oldest first
+func (s *sqlStore) GetRollupRecords(url string) ([]RollupRecord, error) {
+	rows, err := s.query(`
+		SELECT bucket_start, bucket_seconds, checks, failed_checks, latency_sum_ms, latency_min_ms, latency_max_ms
+		FROM check_rollups
+		WHERE url = $1
+		ORDER BY bucket_start, bucket_seconds`, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []RollupRecord{}
+	for rows.Next() {
+		var record RollupRecord
+		var seconds int64
+		if err := rows.Scan(&record.Start, &seconds, &record.Checks, &record.FailedChecks,
+			&record.LatencySumMs, &record.LatencyMinMs, &record.LatencyMaxMs); err != nil {
+			return nil, err
+		}
+		record.Start = record.Start.UTC()
+		record.Resolution = time.Duration(seconds) * time.Second
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// SaveRollupRecords stores rollups of url, adding them to any already stored
+// for the same buckets
+func (s *sqlStore) SaveRollupRecords(url string, records []RollupRecord) error {
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, record := range records {
+		_, err := tx.Exec(`
+			INSERT INTO check_rollups (url, bucket_start, bucket_seconds, checks, failed_checks, latency_sum_ms, latency_min_ms, latency_max_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`+mergeRollup,
+			url, s.ts(record.Start), int64(record.Resolution/time.Second), record.Checks, record.FailedChecks,
+			record.LatencySumMs, record.LatencyMinMs, record.LatencyMaxMs)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// epochTime returns the SQL for the timestamp of an expression in Unix
+// seconds, in the format each database stores timestamps in
+func (s *sqlStore) epochTime(expr string) string {
+	if s.driver == DriverSQLite {
+		return "strftime('%Y-%m-%d %H:%M:%S+00:00', " + expr + ", 'unixepoch')"
+	}
+	return "(to_timestamp(" + expr + ") AT TIME ZONE 'UTC')"
+}
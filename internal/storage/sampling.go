@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+
+	"api-monitor/internal/checker"
+)
+
+// Sampling modes. Failures are always stored regardless of mode.
+const (
+	SampleAll      = "all"       // store every result
+	SampleEveryN   = "every_n"   // store every Nth success
+	SampleOnChange = "on_change" // store only when health or status code changes
+)
+
+// SamplingPolicy controls how many results of a frequently checked endpoint are persisted
+type SamplingPolicy struct {
+	Mode  string `json:"mode"`
+	Every int    `json:"every,omitempty"` // N for SampleEveryN
+}
+
+// Validate checks that the policy is usable
+func (p SamplingPolicy) Validate() error {
+	switch p.Mode {
+	case "", SampleAll, SampleOnChange:
+		return nil
+	case SampleEveryN:
+		if p.Every < 1 {
+			return fmt.Errorf("sampling 'every' must be at least 1")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sampling mode %q", p.Mode)
+	}
+}
+
+// Sampler decides which results to persist, tracking per-endpoint state
+type Sampler struct {
+	state map[string]*sampleState
+	mutex sync.Mutex
+}
+
+type sampleState struct {
+	successes   int
+	lastHealthy bool
+	lastStatus  int
+	seen        bool
+}
+
+// NewSampler creates a sampler
+func NewSampler() *Sampler {
+	return &Sampler{state: make(map[string]*sampleState)}
+}
+
+// ShouldStore reports whether a result should be written to storage under the given policy.
+// Failures and the first success after a failure are always kept so incidents retain full fidelity.
+func (s *Sampler) ShouldStore(policy SamplingPolicy, result checker.CheckResult) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st, exists := s.state[result.URL]
+	if !exists {
+		st = &sampleState{}
+		s.state[result.URL] = st
+	}
+
+	changed := !st.seen || st.lastHealthy != result.IsHealthy || st.lastStatus != result.StatusCode
+	st.seen = true
+	st.lastHealthy = result.IsHealthy
+	st.lastStatus = result.StatusCode
+
+	if !result.IsHealthy {
+		st.successes = 0
+		return true
+	}
+
+	switch policy.Mode {
+	case SampleEveryN:
+		st.successes++
+		if changed {
+			st.successes = 0
+			return true
+		}
+		if st.successes >= policy.Every {
+			st.successes = 0
+			return true
+		}
+		return false
+	case SampleOnChange:
+		return changed
+	default:
+		return true
+	}
+}
+
+// Forget drops sampling state for a URL, e.g. when its endpoint is removed
+func (s *Sampler) Forget(url string) {
+	s.mutex.Lock()
+	delete(s.state, url)
+	s.mutex.Unlock()
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"api-monitor/internal/signing"
+)
+
+// maxVerifyFailures caps the failures itemized in a VerifyReport
+const maxVerifyFailures = 100
+
+// signatureTimeFormat renders checked_at as its stored wall-clock time, which
+// reads back the same from both drivers
+const signatureTimeFormat = "2006-01-02T15:04:05.999999"
+
+// VerifyReport counts the stored results checked against a key
+type VerifyReport struct {
+	Checked  int `json:"checked"`
+	Valid    int `json:"valid"`
+	Unsigned int `json:"unsigned"`
+	OtherKey int `json:"otherKey"` // signed with a key other than the one verified against
+	Invalid  int `json:"invalid"`
+
+	// Failures itemizes invalid and other-key results, the first
+	// maxVerifyFailures in time order
+	Failures []VerifyFailure `json:"failures"`
+}
+
+// VerifyFailure is a stored result whose signature didn't verify
+type VerifyFailure struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Reason    string    `json:"reason"`
+}
+
+// SetSigner makes the store sign every result it saves from now on; nil
+// turns signing off
+func (s *sqlStore) SetSigner(key *signing.Key) {
+	s.signer = key
+}
+
+// signaturePayload is the canonical form of the signed fields of a result:
+// what was checked, when, and what it returned. Timings other than the
+// response time, labels and metadata aren't covered.
+func signaturePayload(url string, checkedAt time.Time, statusCode, responseTimeMs int, healthy bool, errorMessage, trigger string) []byte {
+	data, _ := json.Marshal([]interface{}{
+		"v1", url, checkedAt.Format(signatureTimeFormat), statusCode, responseTimeMs, healthy, errorMessage, trigger,
+	})
+	return data
+}
+
+// VerifyResults checks the signatures of the results of url (every URL when
+// empty) checked in [from, to) against key
+func (s *sqlStore) VerifyResults(key *signing.Key, url string, from, to time.Time) (VerifyReport, error) {
+	report := VerifyReport{Failures: []VerifyFailure{}}
+	rows, err := s.query(`
+		SELECT id, url, checked_at, COALESCE(status_code, 0), response_time_ms, is_healthy,
+			COALESCE(error_message, ''), COALESCE(check_trigger, ''), signature
+		FROM check_results
+		WHERE ($1 = '' OR url = $1) AND checked_at >= $2 AND checked_at < $3
+		ORDER BY checked_at, id`, url, s.ts(from), s.ts(to))
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var resultURL, errorMessage, trigger string
+		var checkedAt time.Time
+		var statusCode, responseTimeMs int
+		var healthy bool
+		var signature sql.NullString
+		if err := rows.Scan(&id, &resultURL, &checkedAt, &statusCode, &responseTimeMs, &healthy, &errorMessage, &trigger, &signature); err != nil {
+			return report, err
+		}
+
+		report.Checked++
+		err := key.Verify(signaturePayload(resultURL, checkedAt, statusCode, responseTimeMs, healthy, errorMessage, trigger), signature.String)
+		switch {
+		case err == nil:
+			report.Valid++
+			continue
+		case errors.Is(err, signing.ErrUnsigned):
+			report.Unsigned++
+			continue
+		case errors.Is(err, signing.ErrUnknownKey):
+			report.OtherKey++
+		default:
+			report.Invalid++
+		}
+		if len(report.Failures) < maxVerifyFailures {
+			report.Failures = append(report.Failures, VerifyFailure{ID: id, URL: resultURL, CheckedAt: checkedAt, Reason: err.Error()})
+		}
+	}
+	return report, rows.Err()
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/stats"
+)
+
+// sketchKey identifies one endpoint's sketch for one window
+type sketchKey struct {
+	url   string
+	start time.Time
+}
+
+// SketchRecorder accumulates latency sketches per endpoint per time window
+// in memory until the window closes and is flushed to the database
+type SketchRecorder struct {
+	window   time.Duration
+	sketches map[sketchKey]*stats.Sketch
+	mutex    sync.Mutex
+}
+
+// NewSketchRecorder creates a recorder with the given window size
+func NewSketchRecorder(window time.Duration) *SketchRecorder {
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	return &SketchRecorder{
+		window:   window,
+		sketches: make(map[sketchKey]*stats.Sketch),
+	}
+}
+
+// Window returns the recorder's window size
+func (r *SketchRecorder) Window() time.Duration {
+	return r.window
+}
+
+// Add records the latency of a check result. Results flagged as suspect are ignored.
+func (r *SketchRecorder) Add(result checker.CheckResult) {
+	if !result.Usable() {
+		return
+	}
+	key := sketchKey{url: result.URL, start: result.CheckedAt.UTC().Truncate(r.window)}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sketch, ok := r.sketches[key]
+	if !ok {
+		sketch = stats.NewSketch()
+		r.sketches[key] = sketch
+	}
+	sketch.Add(result.ResponseTime)
+}
+
+// Flush saves every window that closed before now and drops it from memory.
+// Windows that fail to save are kept for the next attempt.
+func (r *SketchRecorder) Flush(store Store, now time.Time) error {
+	r.mutex.Lock()
+	closed := make(map[sketchKey]*stats.Sketch)
+	for key, sketch := range r.sketches {
+		if !key.start.Add(r.window).After(now) {
+			closed[key] = sketch
+			delete(r.sketches, key)
+		}
+	}
+	r.mutex.Unlock()
+
+	var firstErr error
+	for key, sketch := range closed {
+		if err := store.SaveSketch(key.url, key.start, r.window, sketch); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			r.mutex.Lock()
+			if pending, ok := r.sketches[key]; ok {
+				sketch.Merge(pending)
+			}
+			r.sketches[key] = sketch
+			r.mutex.Unlock()
+		}
+	}
+	return firstErr
+}
+
+// Pending merges the unflushed sketches for url with windows starting in [from, to)
+func (r *SketchRecorder) Pending(url string, from, to time.Time) *stats.Sketch {
+	merged := stats.NewSketch()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for key, sketch := range r.sketches {
+		if key.url == url && !key.start.Before(from.Truncate(r.window)) && key.start.Before(to) {
+			merged.Merge(sketch)
+		}
+	}
+	return merged
+}
+
+// SaveSketch stores a window's sketch, merging with any sketch another
+// replica already saved for the same window
+func (s *sqlStore) SaveSketch(url string, windowStart time.Time, window time.Duration, sketch *stats.Sketch) error {
+	tx, err := s.db.BeginTx(s.context(), nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	seconds := int(window.Seconds())
+	var existing []byte
+	err = tx.QueryRow(`
+		SELECT sketch FROM latency_sketches
+		WHERE url = $1 AND window_start = $2 AND window_seconds = $3`+s.forUpdate(), url, s.ts(windowStart), seconds).Scan(&existing)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	merged := stats.NewSketch()
+	if existing != nil {
+		if err := json.Unmarshal(existing, merged); err != nil {
+			return err
+		}
+	}
+	merged.Merge(sketch)
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO latency_sketches (url, window_start, window_seconds, sketch)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (url, window_start, window_seconds) DO UPDATE SET sketch = EXCLUDED.sketch`,
+		url, s.ts(windowStart), seconds, data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SketchWindow is one stored latency sketch
+type SketchWindow struct {
+	Start  time.Time     `json:"start"`
+	Window time.Duration `json:"window"`
+	Sketch *stats.Sketch `json:"sketch"`
+}
+
+// EachSketch passes every stored sketch of url to fn, oldest first. An error
+// from fn stops the iteration.
+func (s *sqlStore) EachSketch(url string, fn func(SketchWindow) error) error {
+	rows, err := s.query(`
+		SELECT window_start, window_seconds, sketch FROM latency_sketches
+		WHERE url = $1 ORDER BY window_start`, url)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var window SketchWindow
+		var seconds int
+		var data []byte
+		if err := rows.Scan(&window.Start, &seconds, &data); err != nil {
+			return err
+		}
+		window.Start = window.Start.UTC()
+		window.Window = time.Duration(seconds) * time.Second
+		window.Sketch = stats.NewSketch()
+		if err := json.Unmarshal(data, window.Sketch); err != nil {
+			return err
+		}
+		if err := fn(window); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// LoadSketch merges all stored sketches for url with windows starting in [from, to)
+func (s *sqlStore) LoadSketch(url string, from, to time.Time) (*stats.Sketch, error) {
+	rows, err := s.query(`
+		SELECT sketch FROM latency_sketches
+		WHERE url = $1 AND window_start >= $2 AND window_start < $3`, url, s.ts(from), s.ts(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	merged := stats.NewSketch()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		window := stats.NewSketch()
+		if err := json.Unmarshal(data, window); err != nil {
+			return nil, err
+		}
+		merged.Merge(window)
+	}
+
+	return merged, rows.Err()
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// HealthChange is a point where an endpoint's stored results changed health
+type HealthChange struct {
+	At      time.Time `json:"at"`
+	Healthy bool      `json:"healthy"`
+}
+
+// GetHealthChanges returns the health changes of url in [from, to), oldest
+// first. The health of the last result before from, if any, is reported as a
+// change at from so the whole range is covered.
+func (s *sqlStore) GetHealthChanges(url string, from, to time.Time) ([]HealthChange, error) {
+	changes := []HealthChange{}
+
+	var before bool
+	err := s.queryRow(`
+		SELECT is_healthy FROM check_results
+		WHERE url = $1 AND checked_at < $2
+		ORDER BY checked_at DESC
+		LIMIT 1`, url, s.ts(from)).Scan(&before)
+	if err == nil {
+		changes = append(changes, HealthChange{At: from, Healthy: before})
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Only rows whose health differs from the previous row are returned, so
+	// long healthy stretches cost the database a scan but not the network
+	rows, err := s.query(`
+		SELECT checked_at, is_healthy FROM (
+			SELECT checked_at, is_healthy, LAG(is_healthy) OVER (ORDER BY checked_at) AS previous
+			FROM check_results
+			WHERE url = $1 AND checked_at >= $2 AND checked_at < $3
+		) ordered
+		WHERE previous IS NULL OR previous <> is_healthy
+		ORDER BY checked_at`, url, s.ts(from), s.ts(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change HealthChange
+		if err := rows.Scan(&change.At, &change.Healthy); err != nil {
+			return nil, err
+		}
+		if n := len(changes); n > 0 && changes[n-1].Healthy == change.Healthy {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// CountChecks returns how many results url has in [from, to) and how many of them failed
+func (s *sqlStore) CountChecks(url string, from, to time.Time) (int, int, error) {
+	var checks, failed int
+	err := s.queryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN is_healthy THEN 0 ELSE 1 END), 0)
+		FROM check_results
+		WHERE url = $1 AND checked_at >= $2 AND checked_at < $3`, url, s.ts(from), s.ts(to)).Scan(&checks, &failed)
+	return checks, failed, err
+}
+
+// Gap is a period in which an endpoint produced no stored results
+type Gap struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GetMonitoringGaps returns the periods within [from, to) longer than minGap
+// without results of url, including a gap running up to to. Gaps are
+// measured from the last result before from, so an endpoint added during the
+// range doesn't start with one.
+func (s *sqlStore) GetMonitoringGaps(url string, from, to time.Time, minGap time.Duration) ([]Gap, error) {
+	gaps := []Gap{}
+	add := func(start, end time.Time) {
+		if start.Before(from) {
+			start = from
+		}
+		if end.Sub(start) > minGap {
+			gaps = append(gaps, Gap{From: start, To: end})
+		}
+	}
+
+	var before, first, last time.Time
+	err := s.queryRow(`SELECT checked_at FROM check_results WHERE url = $1 AND checked_at < $2 ORDER BY checked_at DESC LIMIT 1`,
+		url, s.ts(from)).Scan(&before)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	err = s.queryRow(`SELECT checked_at FROM check_results WHERE url = $1 AND checked_at >= $2 AND checked_at < $3 ORDER BY checked_at LIMIT 1`,
+		url, s.ts(from), s.ts(to)).Scan(&first)
+	if err == sql.ErrNoRows {
+		if !before.IsZero() {
+			add(before, to)
+		}
+		return gaps, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = s.queryRow(`SELECT checked_at FROM check_results WHERE url = $1 AND checked_at >= $2 AND checked_at < $3 ORDER BY checked_at DESC LIMIT 1`,
+		url, s.ts(from), s.ts(to)).Scan(&last)
+	if err != nil {
+		return nil, err
+	}
+
+	if !before.IsZero() {
+		add(before, first)
+	}
+
+	// The gap length comes back in seconds because SQLite returns computed
+	// timestamps as text
+	rows, err := s.query(`
+		SELECT checked_at, seconds FROM (
+			SELECT checked_at, `+s.secondsBetween("LAG(checked_at) OVER (ORDER BY checked_at)", "checked_at")+` AS seconds
+			FROM check_results
+			WHERE url = $1 AND checked_at >= $2 AND checked_at < $3
+		) spaced
+		WHERE seconds > $4
+		ORDER BY checked_at`, url, s.ts(from), s.ts(to), minGap.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var end time.Time
+		var seconds float64
+		if err := rows.Scan(&end, &seconds); err != nil {
+			return nil, err
+		}
+		start := end.Add(-time.Duration(seconds * float64(time.Second))).Round(time.Millisecond)
+		add(start, end)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	add(last, to)
+	return gaps, nil
+}
+
+// secondsBetween returns the SQL for the seconds from one timestamp expression to another
+func (s *sqlStore) secondsBetween(start, end string) string {
+	if s.driver == DriverSQLite {
+		return "(julianday(" + end + ") - julianday(" + start + ")) * 86400"
+	}
+	return "EXTRACT(EPOCH FROM " + end + " - " + start + ")"
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore keeps the monitor's data in a single SQLite file so it can run
+// without a database server
+type SQLiteStore struct {
+	sqlStore
+}
+
+// NewSQLiteStore opens or creates the SQLite database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	// One connection serializes writers instead of failing with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	store := &SQLiteStore{sqlStore{db: db, driver: DriverSQLite}}
+	if err := store.createTables(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// sqliteDSN turns a file path into a DSN with a busy timeout, WAL journaling
+// and a sortable time format. DSNs that already carry options are used as is.
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path
+	}
+	if !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	return path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+}
+
+// createTables creates the same tables as PostgresStore in SQLite's dialect
+func (s *SQLiteStore) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS check_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		status_code INTEGER,
+		response_time_ms INTEGER NOT NULL,
+		is_healthy BOOLEAN NOT NULL,
+		error_message TEXT,
+		checked_at TIMESTAMP NOT NULL,
+		remote_ip TEXT,
+		country TEXT,
+		asn INTEGER,
+		as_org TEXT,
+		bytes_downloaded INTEGER,
+		throughput_mbps REAL,
+		ttfb_ms INTEGER,
+		check_trigger TEXT,
+		data_quality TEXT,
+		quality_issue TEXT,
+		dns_ms INTEGER,
+		connect_ms INTEGER,
+		tls_ms INTEGER,
+		download_ms INTEGER,
+		labels TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_results_url ON check_results(url);
+	CREATE INDEX IF NOT EXISTS idx_check_results_checked_at ON check_results(checked_at);
+	CREATE INDEX IF NOT EXISTS idx_check_results_url_checked_at ON check_results(url, checked_at);
+
+	CREATE TABLE IF NOT EXISTS latency_sketches (
+		url TEXT NOT NULL,
+		window_start TIMESTAMP NOT NULL,
+		window_seconds INTEGER NOT NULL,
+		sketch BLOB NOT NULL,
+		PRIMARY KEY (url, window_start, window_seconds)
+	);
+	CREATE INDEX IF NOT EXISTS idx_latency_sketches_window ON latency_sketches(window_start);
+
+	CREATE TABLE IF NOT EXISTS check_rollups (
+		url TEXT NOT NULL,
+		bucket_start TIMESTAMP NOT NULL,
+		bucket_seconds INTEGER NOT NULL,
+		checks INTEGER NOT NULL,
+		failed_checks INTEGER NOT NULL,
+		latency_sum_ms INTEGER NOT NULL,
+		latency_min_ms INTEGER NOT NULL,
+		latency_max_ms INTEGER NOT NULL,
+		PRIMARY KEY (url, bucket_start, bucket_seconds)
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_rollups_bucket ON check_rollups(bucket_seconds, bucket_start);
+
+	CREATE TABLE IF NOT EXISTS check_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value REAL NOT NULL,
+		checked_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_metrics_series ON check_metrics(url, name, checked_at);
+
+	CREATE TABLE IF NOT EXISTS incidents (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		started_at TIMESTAMP NOT NULL,
+		ended_at TIMESTAMP,
+		cause TEXT,
+		source TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_incidents_url ON incidents(url, started_at);
+
+	CREATE TABLE IF NOT EXISTS endpoints (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		config BLOB NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS ai_analyses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		scope TEXT NOT NULL,
+		insights BLOB NOT NULL,
+		endpoint_count INTEGER NOT NULL,
+		generated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_analyses_scope ON ai_analyses(scope, generated_at);
+
+	CREATE TABLE IF NOT EXISTS ai_digests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		period_start TIMESTAMP NOT NULL,
+		period_end TIMESTAMP NOT NULL,
+		digest BLOB NOT NULL,
+		generated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_ai_digests_generated ON ai_digests(generated_at);
+
+	CREATE TABLE IF NOT EXISTS insights (
+		id TEXT PRIMARY KEY,
+		insight BLOB NOT NULL,
+		snapshot BLOB NOT NULL,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS insight_followups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		insight_id TEXT NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+		question TEXT NOT NULL,
+		answer TEXT NOT NULL,
+		tier TEXT,
+		asked_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_insight_followups_insight ON insight_followups(insight_id, asked_at);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		action TEXT NOT NULL,
+		actor TEXT,
+		remote_addr TEXT,
+		details TEXT,
+		at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+
+	CREATE TABLE IF NOT EXISTS maintenance_windows (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT,
+		tag TEXT,
+		starts_at TIMESTAMP NOT NULL,
+		ends_at TIMESTAMP NOT NULL,
+		reason TEXT NOT NULL,
+		created_by TEXT,
+		created_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_maintenance_windows_starts_at ON maintenance_windows(starts_at);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		created_by TEXT,
+		created_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS alert_states (
+		endpoint_id TEXT PRIMARY KEY,
+		state BLOB NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS check_captures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint_id TEXT NOT NULL,
+		url TEXT NOT NULL,
+		checked_at TIMESTAMP NOT NULL,
+		status_code INTEGER,
+		error TEXT,
+		exchange TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_check_captures_endpoint ON check_captures(endpoint_id, checked_at);
+	`
+
+	if _, err := s.exec(query); err != nil {
+		return err
+	}
+	if err := s.addColumns("check_results", map[string]string{"labels": "TEXT", "metadata": "TEXT", "signature": "TEXT"}); err != nil {
+		return err
+	}
+	if err := s.addColumns("incidents", map[string]string{"postmortem": "TEXT"}); err != nil {
+		return err
+	}
+	return s.addColumns("endpoints", map[string]string{"owner": "TEXT NOT NULL DEFAULT ''"})
+}
+
+// addColumns adds columns that databases created by older versions lack.
+// SQLite has no ADD COLUMN IF NOT EXISTS.
+func (s *SQLiteStore) addColumns(table string, columns map[string]string) error {
+	rows, err := s.query(`SELECT name FROM pragma_table_info($1)`, table)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for name, definition := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := s.exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + name + ` ` + definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
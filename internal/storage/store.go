@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"api-monitor/internal/checker"
+	"api-monitor/internal/signing"
+	"api-monitor/internal/stats"
+)
+
+// Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Store persists check results and everything derived from them
+type Store interface {
+	SaveResult(result checker.CheckResult) error
+	SaveResults(results []checker.CheckResult) error
+	RestoreResults(results []checker.CheckResult) error
+	GetRecentResults(url string, limit int) ([]checker.CheckResult, error)
+	QueryResults(query ResultQuery) ([]checker.CheckResult, error)
+	EachResult(url string, fn func(checker.CheckResult) error) error
+	ListURLs(labels map[string]string) ([]string, error)
+	MergeResults(target string, sources []string) (int64, error)
+	DeleteResults(url string, before time.Time) (PurgeSummary, error)
+	HasHistory(url string, from, to time.Time) (bool, error)
+	DeleteHistoryBetween(url string, from, to time.Time) (PurgeSummary, error)
+	GetHealthChanges(url string, from, to time.Time) ([]HealthChange, error)
+	CountChecks(url string, from, to time.Time) (int, int, error)
+	GetMonitoringGaps(url string, from, to time.Time, minGap time.Duration) ([]Gap, error)
+	GetLatencyBuckets(url string, from, to time.Time, bucket time.Duration) ([]LatencyBucket, error)
+	ApplyRetention(policy RetentionPolicy, now time.Time) (RetentionSummary, error)
+	GetRollups(url string, from, to time.Time, resolution time.Duration) ([]Rollup, error)
+	GetRollupRecords(url string) ([]RollupRecord, error)
+	SaveRollupRecords(url string, records []RollupRecord) error
+	SetSigner(key *signing.Key)
+
+	// WithContext returns the store running its queries within ctx
+	WithContext(ctx context.Context) Store
+	VerifyResults(key *signing.Key, url string, from, to time.Time) (VerifyReport, error)
+
+	SaveSketch(url string, windowStart time.Time, window time.Duration, sketch *stats.Sketch) error
+	LoadSketch(url string, from, to time.Time) (*stats.Sketch, error)
+	EachSketch(url string, fn func(SketchWindow) error) error
+
+	SaveMetrics(result checker.CheckResult) error
+	GetMetrics(url, name string, from, to time.Time, limit int) ([]MetricPoint, error)
+
+	GetIncidents(url string, from, to time.Time) ([]Incident, error)
+	SaveIncident(incident Incident) (int64, error)
+	GetIncident(id int64) (Incident, bool, error)
+	SavePostmortem(incidentID int64, postmortem []byte) error
+	GetPostmortem(incidentID int64) ([]byte, bool, error)
+	ReplaceImported(url, source string, results []checker.CheckResult, incidents []Incident) error
+
+	SaveEndpoint(record EndpointRecord) error
+	GetEndpoint(id string) (EndpointRecord, bool, error)
+	ListEndpoints() ([]EndpointRecord, error)
+	DeleteEndpoint(id string) (bool, error)
+
+	SaveAnalysis(record AnalysisRecord) error
+	LatestAnalyses() ([]AnalysisRecord, error)
+	SaveDigest(record DigestRecord) (int64, error)
+	ListDigests(limit int) ([]DigestRecord, error)
+
+	SaveInsight(record InsightRecord) error
+	GetInsight(id string) (InsightRecord, bool, error)
+	SaveFollowUp(record FollowUpRecord) error
+	GetFollowUps(insightID string) ([]FollowUpRecord, error)
+
+	SaveAlertState(endpointID string, state []byte) error
+	LoadAlertStates() (map[string][]byte, error)
+	DeleteAlertState(endpointID string) error
+
+	SaveCapture(capture Capture) error
+	GetCaptures(endpointID string, from, to time.Time, limit int) ([]Capture, error)
+	DeleteCaptures(endpointID string, before time.Time) (int64, error)
+
+	SaveMaintenance(window MaintenanceWindow) (MaintenanceWindow, error)
+	GetMaintenance(from, to time.Time) ([]MaintenanceWindow, error)
+	DeleteMaintenance(id int64) (bool, error)
+
+	SaveAudit(entry AuditEntry) error
+	GetAuditLog(limit int) ([]AuditEntry, error)
+
+	SaveAPIKey(key APIKey) error
+	GetAPIKey(hash string) (APIKey, bool, error)
+	ListAPIKeys() ([]APIKey, error)
+	TouchAPIKey(id string, at time.Time) error
+	DeleteAPIKey(id string) (bool, error)
+
+	Ping() error
+	Close() error
+}
+
+// ResultQuery selects stored results. Empty fields match everything.
+type ResultQuery struct {
+	URL      string
+	Labels   map[string]string // results must carry all of these labels
+	Metadata map[string]string // and these metadata values, e.g. {"error_class": "timeout"}
+	From     time.Time         // results checked in [From, To); zero leaves a side open
+	To       time.Time
+	Failed   bool // only unhealthy results
+	Limit    int
+}
+
+// Open connects to the database of the given driver. For postgres dsn is a
+// connection string, for sqlite a file path.
+func Open(driver, dsn string) (Store, error) {
+	switch driver {
+	case DriverPostgres, "":
+		store, err := NewPostgresStore(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case DriverSQLite:
+		store, err := NewSQLiteStore(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q (use %s or %s)", driver, DriverPostgres, DriverSQLite)
+	}
+}
+
+// sqlStore implements Store on database/sql. Queries stick to the SQL shared
+// by PostgreSQL and SQLite; the few that differ check driver.
+type sqlStore struct {
+	db     *sql.DB
+	driver string
+	signer *signing.Key    // signs saved results when set
+	ctx    context.Context // of the queries, see WithContext
+}
+
+// ts prepares a time for a query. SQLite stores timestamps as text, so they
+// are written in UTC to keep comparisons and ordering correct.
+func (s *sqlStore) ts(t time.Time) time.Time {
+	if s.driver == DriverSQLite {
+		return t.UTC()
+	}
+	return t
+}
+
+// tsPtr is ts for nullable times
+func (s *sqlStore) tsPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := s.ts(*t)
+	return &v
+}
+
+// forUpdate locks the selected rows until the transaction ends. SQLite has no
+// row locks; its single writer already serializes transactions.
+func (s *sqlStore) forUpdate() string {
+	if s.driver == DriverSQLite {
+		return ""
+	}
+	return "\n\t\tFOR UPDATE"
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("api-monitor/internal/storage")
+
+// WithContext returns the store running its queries within ctx, so they
+// show up as spans of the trace ctx belongs to, e.g. an API request's
+func (s *sqlStore) WithContext(ctx context.Context) Store {
+	scoped := *s
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// context returns the context queries run in
+func (s *sqlStore) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// startSpan starts the span of a statement. Statements outside a trace, like
+// saving results, get none so they don't each start a trace of their own.
+func (s *sqlStore) startSpan(statement string) (context.Context, trace.Span) {
+	ctx := s.context()
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	operation := "query"
+	if fields := strings.Fields(statement); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	system := "postgresql"
+	if s.driver == DriverSQLite {
+		system = "sqlite"
+	}
+	return tracer.Start(ctx, "db "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", system),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", strings.Join(strings.Fields(statement), " ")),
+	))
+}
+
+// endSpan ends a statement's span, marking it failed on errors
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *sqlStore) query(statement string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := s.startSpan(statement)
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (s *sqlStore) queryRow(statement string, args ...interface{}) *sql.Row {
+	ctx, span := s.startSpan(statement)
+	row := s.db.QueryRowContext(ctx, statement, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+func (s *sqlStore) exec(statement string, args ...interface{}) (sql.Result, error) {
+	ctx, span := s.startSpan(statement)
+	result, err := s.db.ExecContext(ctx, statement, args...)
+	endSpan(span, err)
+	return result, err
+}
@@ -0,0 +1,54 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"api-monitor/internal/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Setup holds the TLS configuration shared by the web and gRPC servers
+type Setup struct {
+	Config *tls.Config
+
+	// ChallengeHandler answers ACME HTTP-01 challenges and redirects
+	// everything else to HTTPS. It is nil when certificates come from files.
+	ChallengeHandler http.Handler
+}
+
+// New builds the TLS setup from configuration. It returns nil when TLS is disabled.
+func New(cfg *config.Config) (*Setup, error) {
+	if !cfg.TLSEnabled() {
+		return nil, nil
+	}
+
+	// Automatic certificates via Let's Encrypt take precedence over static files
+	if len(cfg.TLSDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSDomains...),
+			Cache:      autocert.DirCache(cfg.TLSCacheDir),
+			Email:      cfg.TLSACMEEmail,
+		}
+
+		return &Setup{
+			Config:           manager.TLSConfig(),
+			ChallengeHandler: manager.HTTPHandler(nil),
+		}, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	return &Setup{
+		Config: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
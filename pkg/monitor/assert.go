@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Assertion inspects a completed check and returns an error describing why
+// the result is not acceptable, or nil. Assertions add requirements on top of
+// the default rule that only 2xx responses are healthy.
+type Assertion func(result Result) error
+
+// StatusIn requires the response status code to be one of codes
+func StatusIn(codes ...int) Assertion {
+	return func(result Result) error {
+		for _, code := range codes {
+			if result.StatusCode == code {
+				return nil
+			}
+		}
+		return fmt.Errorf("status %d not in %v", result.StatusCode, codes)
+	}
+}
+
+// MaxLatency requires the full response, including the body, within limit
+func MaxLatency(limit time.Duration) Assertion {
+	return func(result Result) error {
+		if result.ResponseTime > limit {
+			return fmt.Errorf("response time %v exceeds %v", result.ResponseTime, limit)
+		}
+		return nil
+	}
+}
+
+// MaxTTFB requires the first response byte within limit
+func MaxTTFB(limit time.Duration) Assertion {
+	return func(result Result) error {
+		if result.TTFB > limit {
+			return fmt.Errorf("time to first byte %v exceeds %v", result.TTFB, limit)
+		}
+		return nil
+	}
+}
+
+// ServerHeader requires the Server response header to contain substr
+func ServerHeader(substr string) Assertion {
+	return func(result Result) error {
+		if !strings.Contains(result.ServerHeader, substr) {
+			return fmt.Errorf("server header %q does not contain %q", result.ServerHeader, substr)
+		}
+		return nil
+	}
+}
+
+// MinThroughput requires a throughput check to reach at least mbps MB/s
+func MinThroughput(mbps float64) Assertion {
+	return func(result Result) error {
+		if result.ThroughputMBps < mbps {
+			return fmt.Errorf("throughput %.2f MB/s below %.2f MB/s", result.ThroughputMBps, mbps)
+		}
+		return nil
+	}
+}
+
+// evaluate applies assertions to a result that completed without an error
+func evaluate(result Result, assertions []Assertion) Result {
+	if result.Error != "" {
+		return result
+	}
+
+	var failures []string
+	for _, assertion := range assertions {
+		if err := assertion(result); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		result.IsHealthy = false
+		result.Error = "assertion failed: " + strings.Join(failures, "; ")
+	}
+
+	return result
+}
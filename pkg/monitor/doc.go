@@ -0,0 +1,19 @@
+// Package monitor exposes the api-monitor health-check engine for use inside
+// other Go programs, without running the scheduler, database or web server.
+//
+// A minimal check:
+//
+//	m := monitor.New(5 * time.Second)
+//	result := m.Check(monitor.Request{URL: "https://api.example.com/health"},
+//		monitor.StatusIn(200),
+//		monitor.MaxLatency(500*time.Millisecond),
+//	)
+//	if !result.IsHealthy {
+//		log.Printf("health check failed: %s", result.Error)
+//	}
+//
+// Results are the same type the monitor stores and serves from its API, so
+// they can be forwarded to a running monitor unchanged. The exported API of
+// this package follows semantic versioning; everything under internal/ may
+// change without notice.
+package monitor
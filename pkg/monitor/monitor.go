@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"api-monitor/internal/checker"
+)
+
+// Result is the outcome of a single check
+type Result = checker.CheckResult
+
+// Request describes the HTTP request a check sends. Only URL is required;
+// Method defaults to GET.
+type Request = checker.CheckSpec
+
+// Latency metrics accepted by Result.Latency
+const (
+	LatencyTotal = checker.LatencyTotal
+	LatencyTTFB  = checker.LatencyTTFB
+)
+
+// Checker is implemented by custom check types
+type Checker = checker.Checker
+
+// Factory creates a custom Checker for a check timeout
+type Factory = checker.Factory
+
+// Middleware hooks into checks: Before may modify each HTTP request, After
+// observes or adjusts each result. See Monitor.Use.
+type Middleware = checker.Middleware
+
+// RedactSecrets masks passwords and query parameter values in result errors
+func RedactSecrets() Middleware {
+	return checker.RedactSecrets()
+}
+
+// Register adds a custom check type, e.g. Register("kafka", newKafkaChecker).
+// Endpoints whose Type is name are then scheduled, stored and alerted on like
+// HTTP endpoints. Call it from an init function; it panics on duplicate names.
+func Register(name string, factory Factory) {
+	checker.Register(name, factory)
+}
+
+// Monitor runs health checks in-process
+type Monitor struct {
+	http    *checker.HTTPChecker
+	timeout time.Duration
+
+	custom      map[string]checker.Checker // created on first use
+	customMutex sync.Mutex
+	middleware  []Middleware
+}
+
+// New creates a Monitor whose checks time out after timeout
+func New(timeout time.Duration) *Monitor {
+	return &Monitor{
+		http:    checker.NewHTTPChecker(timeout),
+		timeout: timeout,
+		custom:  make(map[string]checker.Checker),
+	}
+}
+
+// SetMaxPayloadBytes caps how much a throughput check downloads (100MB by default)
+func (m *Monitor) SetMaxPayloadBytes(n int64) {
+	m.http.SetMaxPayloadBytes(n)
+}
+
+// SetMaxConcurrency limits how many HTTP requests are in flight at once
+// (10 by default)
+func (m *Monitor) SetMaxConcurrency(n int) {
+	m.http.SetMaxConcurrency(n)
+}
+
+// Use adds middleware to every check, e.g. to add headers or observe results.
+// Call it before the first check.
+func (m *Monitor) Use(middleware ...Middleware) {
+	m.middleware = append(m.middleware, middleware...)
+	m.http.Use(middleware...)
+}
+
+// Check sends req and evaluates the assertions against the result. A failed
+// assertion marks the result unhealthy and is reported in Result.Error.
+func (m *Monitor) Check(req Request, assertions ...Assertion) Result {
+	if req.Type != "" && req.Type != checker.TypeHTTP {
+		custom, err := m.customChecker(req.Type)
+		if err != nil {
+			return Result{URL: req.URL, CheckedAt: time.Now(), Error: err.Error()}
+		}
+		return evaluate(custom.CheckWith(req), assertions)
+	}
+	return evaluate(m.http.CheckWith(req), assertions)
+}
+
+// customChecker returns the checker for a registered type, creating it once
+func (m *Monitor) customChecker(name string) (checker.Checker, error) {
+	m.customMutex.Lock()
+	defer m.customMutex.Unlock()
+
+	if c, ok := m.custom[name]; ok {
+		return c, nil
+	}
+	factory, ok := checker.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown check type %q", name)
+	}
+	c, err := factory(m.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("check type %q unavailable: %w", name, err)
+	}
+	c = checker.Wrap(c, m.middleware...)
+	m.custom[name] = c
+	return c, nil
+}
+
+// CheckThroughput is like Check but downloads the whole response body (up to
+// the payload cap) and records the transfer rate
+func (m *Monitor) CheckThroughput(req Request, assertions ...Assertion) Result {
+	return evaluate(m.http.CheckThroughputWith(req), assertions)
+}
+
+// CheckAll checks the requests concurrently, at most SetMaxConcurrency at a
+// time, and returns results in the same order as reqs
+func (m *Monitor) CheckAll(reqs []Request, assertions ...Assertion) []Result {
+	results := make([]Result, len(reqs))
+	jobs := make(chan int)
+
+	workers := m.http.MaxConcurrency()
+	if workers > len(reqs) {
+		workers = len(reqs)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = m.Check(reqs[i], assertions...)
+			}
+		}()
+	}
+	for i := range reqs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,293 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: monitor.proto
+
+package monitor
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MonitorManager_AddEndpoint_FullMethodName    = "/monitor.MonitorManager/AddEndpoint"
+	MonitorManager_RemoveEndpoint_FullMethodName = "/monitor.MonitorManager/RemoveEndpoint"
+	MonitorManager_ListEndpoints_FullMethodName  = "/monitor.MonitorManager/ListEndpoints"
+	MonitorManager_GetResults_FullMethodName     = "/monitor.MonitorManager/GetResults"
+	MonitorManager_StreamResults_FullMethodName  = "/monitor.MonitorManager/StreamResults"
+)
+
+// MonitorManagerClient is the client API for MonitorManager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Service for managing monitoring configuration.
+// The google.api.http options map each RPC onto the REST gateway.
+type MonitorManagerClient interface {
+	// Add a new endpoint to monitor
+	AddEndpoint(ctx context.Context, in *AddEndpointRequest, opts ...grpc.CallOption) (*AddEndpointResponse, error)
+	// Stop monitoring an endpoint and delete its stored definition
+	RemoveEndpoint(ctx context.Context, in *RemoveEndpointRequest, opts ...grpc.CallOption) (*RemoveEndpointResponse, error)
+	// List all monitored endpoints
+	ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (*ListEndpointsResponse, error)
+	// Get historical results for an endpoint
+	GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (*GetResultsResponse, error)
+	// Stream real-time check results
+	StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CheckResult], error)
+}
+
+type monitorManagerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMonitorManagerClient(cc grpc.ClientConnInterface) MonitorManagerClient {
+	return &monitorManagerClient{cc}
+}
+
+func (c *monitorManagerClient) AddEndpoint(ctx context.Context, in *AddEndpointRequest, opts ...grpc.CallOption) (*AddEndpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddEndpointResponse)
+	err := c.cc.Invoke(ctx, MonitorManager_AddEndpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorManagerClient) RemoveEndpoint(ctx context.Context, in *RemoveEndpointRequest, opts ...grpc.CallOption) (*RemoveEndpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveEndpointResponse)
+	err := c.cc.Invoke(ctx, MonitorManager_RemoveEndpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorManagerClient) ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (*ListEndpointsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEndpointsResponse)
+	err := c.cc.Invoke(ctx, MonitorManager_ListEndpoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorManagerClient) GetResults(ctx context.Context, in *GetResultsRequest, opts ...grpc.CallOption) (*GetResultsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResultsResponse)
+	err := c.cc.Invoke(ctx, MonitorManager_GetResults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorManagerClient) StreamResults(ctx context.Context, in *StreamResultsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CheckResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonitorManager_ServiceDesc.Streams[0], MonitorManager_StreamResults_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamResultsRequest, CheckResult]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonitorManager_StreamResultsClient = grpc.ServerStreamingClient[CheckResult]
+
+// MonitorManagerServer is the server API for MonitorManager service.
+// All implementations must embed UnimplementedMonitorManagerServer
+// for forward compatibility.
+//
+// Service for managing monitoring configuration.
+// The google.api.http options map each RPC onto the REST gateway.
+type MonitorManagerServer interface {
+	// Add a new endpoint to monitor
+	AddEndpoint(context.Context, *AddEndpointRequest) (*AddEndpointResponse, error)
+	// Stop monitoring an endpoint and delete its stored definition
+	RemoveEndpoint(context.Context, *RemoveEndpointRequest) (*RemoveEndpointResponse, error)
+	// List all monitored endpoints
+	ListEndpoints(context.Context, *ListEndpointsRequest) (*ListEndpointsResponse, error)
+	// Get historical results for an endpoint
+	GetResults(context.Context, *GetResultsRequest) (*GetResultsResponse, error)
+	// Stream real-time check results
+	StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[CheckResult]) error
+	mustEmbedUnimplementedMonitorManagerServer()
+}
+
+// UnimplementedMonitorManagerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMonitorManagerServer struct{}
+
+func (UnimplementedMonitorManagerServer) AddEndpoint(context.Context, *AddEndpointRequest) (*AddEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddEndpoint not implemented")
+}
+func (UnimplementedMonitorManagerServer) RemoveEndpoint(context.Context, *RemoveEndpointRequest) (*RemoveEndpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveEndpoint not implemented")
+}
+func (UnimplementedMonitorManagerServer) ListEndpoints(context.Context, *ListEndpointsRequest) (*ListEndpointsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEndpoints not implemented")
+}
+func (UnimplementedMonitorManagerServer) GetResults(context.Context, *GetResultsRequest) (*GetResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResults not implemented")
+}
+func (UnimplementedMonitorManagerServer) StreamResults(*StreamResultsRequest, grpc.ServerStreamingServer[CheckResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamResults not implemented")
+}
+func (UnimplementedMonitorManagerServer) mustEmbedUnimplementedMonitorManagerServer() {}
+func (UnimplementedMonitorManagerServer) testEmbeddedByValue()                        {}
+
+// UnsafeMonitorManagerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonitorManagerServer will
+// result in compilation errors.
+type UnsafeMonitorManagerServer interface {
+	mustEmbedUnimplementedMonitorManagerServer()
+}
+
+func RegisterMonitorManagerServer(s grpc.ServiceRegistrar, srv MonitorManagerServer) {
+	// If the following call pancis, it indicates UnimplementedMonitorManagerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MonitorManager_ServiceDesc, srv)
+}
+
+func _MonitorManager_AddEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorManagerServer).AddEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorManager_AddEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorManagerServer).AddEndpoint(ctx, req.(*AddEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorManager_RemoveEndpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveEndpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorManagerServer).RemoveEndpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorManager_RemoveEndpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorManagerServer).RemoveEndpoint(ctx, req.(*RemoveEndpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorManager_ListEndpoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEndpointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorManagerServer).ListEndpoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorManager_ListEndpoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorManagerServer).ListEndpoints(ctx, req.(*ListEndpointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorManager_GetResults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorManagerServer).GetResults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorManager_GetResults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorManagerServer).GetResults(ctx, req.(*GetResultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorManager_StreamResults_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamResultsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonitorManagerServer).StreamResults(m, &grpc.GenericServerStream[StreamResultsRequest, CheckResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonitorManager_StreamResultsServer = grpc.ServerStreamingServer[CheckResult]
+
+// MonitorManager_ServiceDesc is the grpc.ServiceDesc for MonitorManager service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MonitorManager_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "monitor.MonitorManager",
+	HandlerType: (*MonitorManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddEndpoint",
+			Handler:    _MonitorManager_AddEndpoint_Handler,
+		},
+		{
+			MethodName: "RemoveEndpoint",
+			Handler:    _MonitorManager_RemoveEndpoint_Handler,
+		},
+		{
+			MethodName: "ListEndpoints",
+			Handler:    _MonitorManager_ListEndpoints_Handler,
+		},
+		{
+			MethodName: "GetResults",
+			Handler:    _MonitorManager_GetResults_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamResults",
+			Handler:       _MonitorManager_StreamResults_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "monitor.proto",
+}
@@ -0,0 +1,43 @@
+#!/bin/bash
+
+# Generate Go stubs, the grpc-gateway REST proxy and the OpenAPI spec from proto/monitor.proto
+set -e
+
+cd "$(dirname "$0")/.."
+
+PROTOC_ZIP=protoc-21.12-linux-x86_64.zip
+PROTOC_DIR=.protoc
+GOBIN_DIR="$(go env GOPATH)/bin"
+
+# Unpack the bundled protoc once
+if [ ! -x "$PROTOC_DIR/bin/protoc" ]; then
+    echo "📦 Unpacking $PROTOC_ZIP..."
+    mkdir -p "$PROTOC_DIR"
+    unzip -q -o "$PROTOC_ZIP" -d "$PROTOC_DIR"
+fi
+
+export PATH="$GOBIN_DIR:$PATH"
+
+# Install pinned code generator plugins that are not already available
+install_plugin() {
+    if ! command -v "$1" > /dev/null; then
+        echo "🔧 Installing $1..."
+        go install "$2"
+    fi
+}
+install_plugin protoc-gen-go google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.6
+install_plugin protoc-gen-go-grpc google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
+install_plugin protoc-gen-grpc-gateway github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway@v2.27.1
+install_plugin protoc-gen-openapiv2 github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-openapiv2@v2.27.1
+
+echo "⚙️ Generating code from proto/monitor.proto..."
+mkdir -p proto/openapi
+"$PROTOC_DIR/bin/protoc" \
+    -I proto -I "$PROTOC_DIR/include" \
+    --go_out=. --go_opt=module=api-monitor \
+    --go-grpc_out=. --go-grpc_opt=module=api-monitor \
+    --grpc-gateway_out=. --grpc-gateway_opt=module=api-monitor \
+    --openapiv2_out=proto/openapi \
+    proto/monitor.proto
+
+echo "✅ Generated proto/monitor and proto/openapi/monitor.swagger.json"